@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+)
+
+// configWatchRetryDelay is how long runConfigWatcher waits before watching
+// again after the watcher died, e.g. because the file was deleted and is
+// being replaced. A var so tests can shorten it.
+var configWatchRetryDelay = 5 * time.Second
+
+// runConfigWatcher follows config.watch: while it is true, file-system events
+// on the config file are debounced by config.watch_debounce and turned into a
+// request on trigger — the same channel SIGHUP feeds — so watched edits go
+// through startReloadHandler exactly like a manual reload (validation,
+// last-known-good retention, stream restarts, /healthz reporting).
+//
+// KoanfConfig.Watch already reloads on every event; that reload only keeps
+// the koanf snapshot current. Rejected edits are not reported here: the
+// debounced request makes startReloadHandler re-run the reload and record
+// the failure.
+//
+// updates carries hot-reloaded configs, so watching can be switched on and
+// off, and the debounce changed, without restarting the daemon. The koanf
+// file watcher stops for good when the file is removed; runConfigWatcher
+// re-arms it after configWatchRetryDelay and requests a reload in case the
+// file was replaced in the meantime.
+func runConfigWatcher(
+	ctx context.Context,
+	logger *slog.Logger,
+	koanfCfg *config.KoanfConfig,
+	cfg *config.Config,
+	updates <-chan *config.Config,
+	trigger chan<- struct{},
+) {
+	var (
+		stop   context.CancelFunc
+		ended  chan struct{}
+		retry  <-chan time.Time
+		timer  *time.Timer
+		fire   <-chan time.Time
+		events = make(chan struct{}, 1)
+	)
+	notify := func() {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	}
+	start := func() {
+		wctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		stop, ended = cancel, done
+		go func() {
+			defer close(done)
+			err := koanfCfg.Watch(wctx, func(event string, err error) {
+				if event == "watch error" {
+					// The underlying watcher has exited; end this session
+					// so the loop below can re-arm it.
+					logger.Warn("config file watch interrupted", "error", err)
+					cancel()
+					return
+				}
+				notify()
+			})
+			if err != nil {
+				logger.Warn("failed to watch config file", "error", err)
+			}
+		}()
+	}
+	halt := func() {
+		if stop == nil {
+			return
+		}
+		stop()
+		<-ended
+		stop, ended = nil, nil
+	}
+	defer halt()
+
+	for {
+		switch {
+		case cfg.ConfigFile.Watch && stop == nil && retry == nil:
+			start()
+			logger.Info("watching config file for changes", "debounce", cfg.ConfigFile.WatchDebounce)
+		case !cfg.ConfigFile.Watch && (stop != nil || retry != nil):
+			halt()
+			retry, fire = nil, nil
+			logger.Info("config file watch disabled")
+		}
+
+		select {
+		case <-events:
+			if timer == nil {
+				timer = time.NewTimer(cfg.ConfigFile.WatchDebounce)
+			} else {
+				timer.Stop()
+				timer.Reset(cfg.ConfigFile.WatchDebounce)
+			}
+			fire = timer.C
+		case <-fire:
+			fire = nil
+			logger.Info("config file changed, requesting reload")
+			select {
+			case trigger <- struct{}{}:
+			default: // a reload is already pending
+			}
+		case <-ended:
+			stop, ended = nil, nil
+			retry = time.After(configWatchRetryDelay)
+		case <-retry:
+			retry = nil
+			if cfg.ConfigFile.Watch {
+				notify()
+			}
+		case newCfg := <-updates:
+			cfg = newCfg
+		case <-ctx.Done():
+			return
+		}
+	}
+}
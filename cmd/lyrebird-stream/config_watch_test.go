@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+// startTestConfigWatcher runs runConfigWatcher on a fresh config file and
+// returns the file path, the update channel and the reload trigger.
+func startTestConfigWatcher(t *testing.T, watch bool) (string, chan *config.Config, chan struct{}, *config.KoanfConfig) {
+	t.Helper()
+	cfgPath := t.TempDir() + "/config.yaml"
+	writeTestConfig(t, cfgPath, minimalConfig())
+	koanfCfg, cfg, err := loadConfigurationKoanf(cfgPath)
+	if err != nil || koanfCfg == nil {
+		t.Fatalf("loadConfigurationKoanf: err=%v", err)
+	}
+	cfg.ConfigFile.Watch = watch
+	cfg.ConfigFile.WatchDebounce = 200 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan *config.Config, 1)
+	trigger := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		runConfigWatcher(ctx, slog.New(slog.DiscardHandler), koanfCfg, cfg, updates, trigger)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	// Let the fsnotify watch arm before the test starts writing.
+	time.Sleep(100 * time.Millisecond)
+	return cfgPath, updates, trigger, koanfCfg
+}
+
+func expectTrigger(t *testing.T, trigger <-chan struct{}, want bool) {
+	t.Helper()
+	select {
+	case <-trigger:
+		if !want {
+			t.Fatal("unexpected reload request")
+		}
+	case <-time.After(time.Second):
+		if want {
+			t.Fatal("no reload request after config file change")
+		}
+	}
+}
+
+// TestRunConfigWatcherDebounces verifies that a burst of writes produces a
+// single reload request.
+func TestRunConfigWatcherDebounces(t *testing.T) {
+	cfgPath, _, trigger, _ := startTestConfigWatcher(t, true)
+
+	for i := 0; i < 5; i++ {
+		writeTestConfig(t, cfgPath, minimalConfig()+"monitor:\n  interval: 7s\n")
+		time.Sleep(20 * time.Millisecond)
+	}
+	expectTrigger(t, trigger, true)
+	expectTrigger(t, trigger, false)
+}
+
+// TestRunConfigWatcherToggle verifies that config.watch is honoured and can
+// be enabled by a hot reload.
+func TestRunConfigWatcherToggle(t *testing.T) {
+	cfgPath, updates, trigger, _ := startTestConfigWatcher(t, false)
+
+	writeTestConfig(t, cfgPath, minimalConfig()+"monitor:\n  interval: 7s\n")
+	expectTrigger(t, trigger, false)
+
+	enabled := config.DefaultConfig()
+	enabled.ConfigFile.Watch = true
+	enabled.ConfigFile.WatchDebounce = 50 * time.Millisecond
+	updates <- enabled
+	time.Sleep(100 * time.Millisecond)
+
+	writeTestConfig(t, cfgPath, minimalConfig()+"monitor:\n  interval: 8s\n")
+	expectTrigger(t, trigger, true)
+}
+
+// TestRunConfigWatcherRearmsAfterRemoval verifies that replacing the file by
+// delete-and-recreate, which stops the koanf watcher, still reloads.
+func TestRunConfigWatcherRearmsAfterRemoval(t *testing.T) {
+	orig := configWatchRetryDelay
+	configWatchRetryDelay = 100 * time.Millisecond
+	t.Cleanup(func() { configWatchRetryDelay = orig })
+
+	cfgPath, _, trigger, _ := startTestConfigWatcher(t, true)
+	if err := os.Remove(cfgPath); err != nil {
+		t.Fatalf("remove config: %v", err)
+	}
+	writeTestConfig(t, cfgPath, minimalConfig()+"monitor:\n  interval: 9s\n")
+	expectTrigger(t, trigger, true)
+
+	// The re-armed watcher sees later edits too.
+	time.Sleep(100 * time.Millisecond)
+	writeTestConfig(t, cfgPath, minimalConfig()+"monitor:\n  interval: 10s\n")
+	expectTrigger(t, trigger, true)
+}
+
+// TestConfigWatchInvalidEditKeepsLastKnownGood runs the watcher into the real
+// reload handler: an invalid edit is reported as a failed reload and the
+// previous configuration stays current.
+func TestConfigWatchInvalidEditKeepsLastKnownGood(t *testing.T) {
+	cfgPath, _, trigger, koanfCfg := startTestConfigWatcher(t, true)
+	initial, err := koanfCfg.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broadcast := newConfigBroadcast(initial)
+	tracker := &reloadTracker{}
+	var mu sync.RWMutex
+	go startReloadHandler(ctx, slog.New(slog.DiscardHandler), trigger, koanfCfg,
+		supervisor.New(supervisor.Config{}), &mu, map[string]bool{}, map[string]string{},
+		func(*config.Config) int { return 0 }, broadcast, tracker)
+
+	writeTestConfig(t, cfgPath, "default:\n  codec: mp9\n")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for tracker.LastReload() == nil && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	ri := tracker.LastReload()
+	if ri == nil || ri.Success {
+		t.Fatalf("LastReload() = %+v, want a failed reload", ri)
+	}
+	if broadcast.Current() != initial {
+		t.Error("an invalid edit must not replace the current config")
+	}
+	if got, _ := koanfCfg.Load(); got == nil || got.Default.Codec != initial.Default.Codec {
+		t.Errorf("koanf config after invalid edit = %+v, want last-known-good", got)
+	}
+}
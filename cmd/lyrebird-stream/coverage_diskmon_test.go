@@ -39,7 +39,7 @@ func TestRunSegmentRetention(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		runSegmentRetention(ctx, logger, cfg)
+		runSegmentRetention(ctx, logger, &config.Config{Stream: cfg}, nil)
 		close(done)
 	}()
 
@@ -79,7 +79,7 @@ func TestRunDiskSpaceMonitor(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		runDiskSpaceMonitor(ctx, logger, cfg)
+		runDiskSpaceMonitor(ctx, logger, cfg, nil)
 		close(done)
 	}()
 
@@ -115,7 +115,7 @@ func TestRunDiskSpaceMonitorHighThreshold(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		runDiskSpaceMonitor(ctx, logger, cfg)
+		runDiskSpaceMonitor(ctx, logger, cfg, nil)
 		close(done)
 	}()
 
@@ -149,7 +149,7 @@ func TestRunDiskSpaceMonitorEmptyRecordDir(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		runDiskSpaceMonitor(ctx, logger, cfg)
+		runDiskSpaceMonitor(ctx, logger, cfg, nil)
 		close(done)
 	}()
 
@@ -3,6 +3,7 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
 	"log/slog"
 	"os"
@@ -14,11 +15,13 @@ import (
 
 // loadConfigurationKoanf loads configuration using koanf with support for:
 //   - YAML configuration file
+//   - Include directory snippets (conf.d/*.yaml next to the file)
 //   - Environment variable overrides (LYREBIRD_*)
 //   - Hot-reload via SIGHUP
 //
-// Returns both the KoanfConfig (for reload) and the loaded Config.
-func loadConfigurationKoanf(path string) (*config.KoanfConfig, *config.Config, error) {
+// Returns both the KoanfConfig (for reload) and the loaded Config. opts are
+// appended to the loader's options (e.g. config.WithRedactor).
+func loadConfigurationKoanf(path string, opts ...config.Option) (*config.KoanfConfig, *config.Config, error) {
 	// Check if file exists
 	fileExists := true
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -30,18 +33,20 @@ func loadConfigurationKoanf(path string) (*config.KoanfConfig, *config.Config, e
 
 	if fileExists {
 		// Load with file + env vars
-		kc, err = config.NewKoanfConfig(
+		kc, err = config.NewKoanfConfig(append([]config.Option{
 			config.WithYAMLFile(path),
+			config.WithIncludeDir(config.DefaultIncludeDir(path)),
 			config.WithEnvPrefix("LYREBIRD"),
-		)
+		}, opts...)...)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to create koanf config: %w", err)
 		}
 	} else {
 		// No config file — load with env vars only
-		kc, err = config.NewKoanfConfig(
+		kc, err = config.NewKoanfConfig(append([]config.Option{
+			config.WithIncludeDir(config.DefaultIncludeDir(path)),
 			config.WithEnvPrefix("LYREBIRD"),
-		)
+		}, opts...)...)
 		if err != nil {
 			// If no file and env vars fail, return default config
 			return nil, config.DefaultConfig(), nil
@@ -95,17 +100,75 @@ func parseSlogLevel(level string) slog.Level {
 // M-2 fix: Now accepts the full stream config to include LocalRecordDir,
 // SegmentDuration, SegmentFormat, and StopTimeout in the hash, ensuring that
 // changes to these fields trigger a stream restart on SIGHUP.
+//
+// devCfg should come from GetDeviceConfig, which already resolves the
+// per-device overrides (recording, mode, restart policy) against streamCfg;
+// streamCfg only fills fields devCfg leaves unset and supplies StopTimeout.
+// The restart policy is hashed because the manager's backoff is built once.
+// Retention, stall thresholds and the schedule are deliberately NOT hashed:
+// the retention, stall-detector and scheduler loops apply them live, and
+// restarting FFmpeg for them would cut a gap into the recording for nothing.
 func deviceConfigHash(devCfg config.DeviceConfig, rtspURL string, streamCfg config.StreamConfig) string {
-	return fmt.Sprintf("%d/%d/%s/%s/%d/%s/%s/%d/%s/%v",
+	return fmt.Sprintf("%d/%d/%s/%s/%d/%s/%s/%d/%s/%s/%s/%s/%s/%v/%s/%s/%v/%v/%d/%s/%s/%+v",
 		devCfg.SampleRate,
 		devCfg.Channels,
 		devCfg.Bitrate,
 		devCfg.Codec,
 		devCfg.ThreadQueue,
 		rtspURL,
-		streamCfg.LocalRecordDir,
-		streamCfg.SegmentDuration,
-		streamCfg.SegmentFormat,
+		cmp.Or(devCfg.LocalRecordDir, streamCfg.LocalRecordDir),
+		cmp.Or(devCfg.SegmentDuration, streamCfg.SegmentDuration),
+		cmp.Or(devCfg.SegmentFormat, streamCfg.SegmentFormat),
+		cmp.Or(devCfg.SegmentName, streamCfg.SegmentName),
+		cmp.Or(devCfg.SegmentTimezone, streamCfg.SegmentTimezone),
+		cmp.Or(devCfg.SegmentLayout, streamCfg.SegmentLayout),
+		cmp.Or(devCfg.SegmentAlign, streamCfg.SegmentAlign),
 		streamCfg.StopTimeout,
+		devCfg.Mode,
+		devCfg.Recorder,
+		cmp.Or(devCfg.InitialRestartDelay, streamCfg.InitialRestartDelay),
+		cmp.Or(devCfg.MaxRestartDelay, streamCfg.MaxRestartDelay),
+		cmp.Or(devCfg.MaxRestartAttempts, streamCfg.MaxRestartAttempts),
+		config.FilterChain(devCfg.Filters),
+		strings.Join(devCfg.Opus.Settings().Args(), " "),
+		devCfg.Source,
 	)
 }
+
+// logUnknownConfigKeys warns about config keys that no setting reads. With
+// config.strict enabled such a config never loads, so this fires only in the
+// default, lenient mode.
+func logUnknownConfigKeys(logger *slog.Logger, kc *config.KoanfConfig) {
+	if kc == nil {
+		return
+	}
+	for _, u := range kc.UnknownKeys() {
+		logger.Warn("ignoring unknown config key",
+			"file", u.File, "line", u.Line, "key", u.Key, "suggestion", u.Suggestion)
+	}
+}
+
+// migrateConfigOnDisk upgrades the config file to the current schema version,
+// backing up the original first. The loaders migrate in memory as well, so a
+// failure here (read-only /etc, full disk) only means the file keeps its old
+// version and is migrated again on every start; it is logged, not fatal.
+func migrateConfigOnDisk(logger *slog.Logger, path string) {
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	res, err := config.MigrateConfigFile(path, config.GetBackupDir(path))
+	if err != nil {
+		logger.Warn("config schema migration not written; old config is migrated in memory",
+			"path", path, "error", err)
+		return
+	}
+	if res.Migrated() {
+		logger.Info("migrated config to current schema version",
+			"event", "config_migrated",
+			"path", path,
+			"from_version", res.From,
+			"to_version", res.To,
+			"steps", res.Steps,
+			"backup", res.BackupPath)
+	}
+}
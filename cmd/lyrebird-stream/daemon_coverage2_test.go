@@ -55,7 +55,7 @@ func TestRegisterNewDevicesAbsentAsound(t *testing.T) {
 	hashes := make(map[string]string)
 	cards := make(map[string]int)
 
-	count := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", sup, &mu, registered, hashes, cards)
+	count := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, registered, hashes, cards)
 	if count != 0 {
 		t.Errorf("registerNewDevices() = %d, want 0 when /proc/asound absent", count)
 	}
@@ -85,7 +85,7 @@ func TestRegisterNewDevicesAlreadyRegisteredPath(t *testing.T) {
 	hashes := make(map[string]string)
 	cards := make(map[string]int)
 
-	count := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", sup, &mu, registered, hashes, cards)
+	count := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, registered, hashes, cards)
 	// Either 0 (DetectDevices error) or 0 (all devices already registered).
 	if count != 0 {
 		t.Errorf("registerNewDevices() = %d, want 0", count)
@@ -63,7 +63,7 @@ func TestStartHealthEndpointContextDone(t *testing.T) {
 	cfg.Monitor.HealthAddr = addr
 
 	// Should return quickly (ctx already done, port is in use → healthReady never fires).
-	startHealthEndpoint(ctx, logger, cfg, sup)
+	startHealthEndpoint(ctx, logger, cfg, sup, nil, nil, nil, nil, nil, nil)
 	// No assertions needed — reaching here means ctx.Done() path was executed.
 }
 
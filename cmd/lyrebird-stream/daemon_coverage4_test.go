@@ -109,7 +109,7 @@ func TestRunDiskSpaceMonitorStatfsError(t *testing.T) {
 	var logBuf bytes.Buffer
 	logger := slog.New(slog.NewTextHandler(&logBuf, nil))
 
-	runDiskSpaceMonitor(ctx, logger, cfg)
+	runDiskSpaceMonitor(ctx, logger, cfg, nil)
 
 	if !bytes.Contains(logBuf.Bytes(), []byte("disk space check failed")) {
 		t.Errorf("expected 'disk space check failed' warning, got: %s", logBuf.String())
@@ -147,7 +147,7 @@ func TestStartHealthEndpointListenErrorWarning(t *testing.T) {
 		cancel()
 	}()
 
-	startHealthEndpoint(ctx, logger, cfg, sup)
+	startHealthEndpoint(ctx, logger, cfg, sup, nil, nil, nil, nil, nil, nil)
 
 	// Give the internal goroutine a brief moment to complete its logger.Warn call.
 	time.Sleep(50 * time.Millisecond)
@@ -183,7 +183,7 @@ func TestStartHealthEndpointTimeoutWarning(t *testing.T) {
 	// This call blocks ~2 seconds until the time.After case fires.
 	// The internal goroutine logs "health endpoint error" immediately (fast),
 	// but the select waits for time.After(2s) since ctx is not cancelled.
-	startHealthEndpoint(ctx, logger, cfg, sup)
+	startHealthEndpoint(ctx, logger, cfg, sup, nil, nil, nil, nil, nil, nil)
 
 	if !sb.Contains("health endpoint did not start within 2s") {
 		t.Errorf("expected '2s timeout' log, got: %s", sb.String())
@@ -212,7 +212,7 @@ func TestStartHealthEndpointNoLogger(t *testing.T) {
 		cancel()
 	}()
 
-	startHealthEndpoint(ctx, logger, cfg, sup)
+	startHealthEndpoint(ctx, logger, cfg, sup, nil, nil, nil, nil, nil, nil)
 	// Reaching here without panic or race means the goroutine ran correctly.
 }
 
@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+)
+
+// deviceStreamName returns the name a detected device is registered under
+// and whether its config entry says to ignore it.
+//
+// A device selected by a devices.<name>.match: block takes the entry's name,
+// which keeps two identical microphones (same sanitized ALSA name) apart and
+// survives the card being renamed. Otherwise it is dev.StableName() as
+// before. Registration and removal must both use this so they agree on the
+// registry key.
+func deviceStreamName(cfg *config.Config, dev *audio.Device) (name string, ignored bool) {
+	return cfg.ResolveDevice(deviceIdentity(dev))
+}
+
+// deviceIdentity converts a detected device into its config match identity.
+func deviceIdentity(dev *audio.Device) config.DeviceIdentity {
+	return config.DeviceIdentity{
+		Name:     dev.StableName(),
+		CardName: dev.Name,
+		USBID:    dev.USBID,
+		Serial:   dev.Serial,
+		ByID:     dev.DeviceID,
+		Port:     dev.PortPath,
+	}
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+// TestRegisterNewDevicesMatchRules verifies that two identical microphones
+// are told apart by port match rules, and that ignore: true skips a device.
+func TestRegisterNewDevicesMatchRules(t *testing.T) {
+	origDetect := detectAudioDevices
+	t.Cleanup(func() { detectAudioDevices = origDetect })
+
+	devices := []*audio.Device{
+		{Name: "Device", CardNumber: 1, USBID: "0d8c:0014", PortPath: "1-1.2"},
+		{Name: "Device", CardNumber: 2, USBID: "0d8c:0014", PortPath: "1-1.3"},
+		{Name: "Webcam", CardNumber: 3, USBID: "046d:0825", PortPath: "1-1.4"},
+	}
+	detectAudioDevices = func(string) ([]*audio.Device, error) { return devices, nil }
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	cfg := config.DefaultConfig()
+	cfg.Stream.USBStabilizationDelay = 0
+	cfg.Devices = map[string]config.DeviceConfig{
+		"north_mic": {Match: config.DeviceMatch{Port: "1-1.2"}},
+		"south_mic": {Match: config.DeviceMatch{Port: "1-1.3"}},
+		"webcam":    {Match: config.DeviceMatch{USBID: "046d:*"}, Ignore: true},
+	}
+	flags := daemonFlags{LockDir: t.TempDir()}
+	sup := supervisor.New(supervisor.Config{})
+
+	var mu sync.RWMutex
+	services := make(map[string]bool)
+	hashes := make(map[string]string)
+	cards := make(map[string]int)
+
+	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards); n != 2 {
+		t.Fatalf("registered %d, want 2 (%v)", n, keysOf(services))
+	}
+	if !services["north_mic"] || !services["south_mic"] || services["webcam"] {
+		t.Fatalf("services = %v, want north_mic and south_mic only", keysOf(services))
+	}
+	if cards["north_mic"] != 1 || cards["south_mic"] != 2 {
+		t.Errorf("cards = %v", cards)
+	}
+
+	// A re-poll must not restart either stream.
+	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards); n != 0 {
+		t.Fatalf("re-poll registered %d, want 0", n)
+	}
+
+	// Both still present under their matched names: nothing is removed.
+	if removed := removeVanishedDevices(logger, cfg, time.Now(), newVanishedDevices(), sup,
+		&mu, services, hashes, cards); len(removed) != 0 {
+		t.Errorf("removeVanishedDevices removed %v, want none", removed)
+	}
+}
+
+// TestRegisterNewDevicesDuplicateName verifies that two devices resolving to
+// the same stream name register once instead of restarting each other.
+func TestRegisterNewDevicesDuplicateName(t *testing.T) {
+	origDetect := detectAudioDevices
+	t.Cleanup(func() { detectAudioDevices = origDetect })
+	detectAudioDevices = func(string) ([]*audio.Device, error) {
+		return []*audio.Device{
+			{Name: "Device", CardNumber: 1, USBID: "0d8c:0014"},
+			{Name: "Device", CardNumber: 2, USBID: "0d8c:0014"},
+		}, nil
+	}
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	cfg := config.DefaultConfig()
+	cfg.Stream.USBStabilizationDelay = 0
+	flags := daemonFlags{LockDir: t.TempDir()}
+	sup := supervisor.New(supervisor.Config{})
+
+	var mu sync.RWMutex
+	services := make(map[string]bool)
+	hashes := make(map[string]string)
+	cards := make(map[string]int)
+
+	for poll := 0; poll < 2; poll++ {
+		registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards)
+	}
+	if got := sup.ServiceCount(); got != 1 {
+		t.Fatalf("ServiceCount = %d, want 1", got)
+	}
+	if cards["Device"] != 1 {
+		t.Errorf("Device streams card %d, want 1 (first detected)", cards["Device"])
+	}
+}
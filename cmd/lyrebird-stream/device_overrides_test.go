@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+)
+
+// overridesTestConfig returns a config where bird_box keeps 90 days of audio
+// in the shared directory and test_mic records to its own directory.
+func overridesTestConfig(dir, testDir string) *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Stream.LocalRecordDir = dir
+	cfg.Stream.SegmentMaxAge = 7 * 24 * time.Hour
+	cfg.Devices["bird_box"] = config.DeviceConfig{SegmentMaxAge: 90 * 24 * time.Hour}
+	cfg.Devices["test_mic"] = config.DeviceConfig{LocalRecordDir: testDir, SegmentMaxAge: 24 * time.Hour}
+	cfg.Devices["plain"] = config.DeviceConfig{Bitrate: "64k"}
+	return cfg
+}
+
+func TestRetentionPolicies(t *testing.T) {
+	cfg := overridesTestConfig("/rec", "/rec-test")
+	got := retentionPolicies(cfg)
+	want := []retentionPolicy{
+		{Dir: "/rec", Exclude: []string{"bird_box"}, MaxAge: 7 * 24 * time.Hour},
+		{Dir: "/rec", Device: "bird_box", MaxAge: 90 * 24 * time.Hour},
+		{Dir: "/rec-test", Device: "test_mic", MaxAge: 24 * time.Hour},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("retentionPolicies() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := retentionPolicies(config.DefaultConfig()); len(got) != 0 {
+		t.Errorf("retentionPolicies(default) = %+v, want none", got)
+	}
+}
+
+func TestCleanupSegmentsPerDevice(t *testing.T) {
+	dir := t.TempDir()
+	logger := slog.New(slog.DiscardHandler)
+	cfg := overridesTestConfig(dir, filepath.Join(t.TempDir(), "test"))
+
+	age := 10 * 24 * time.Hour
+	files := map[string]bool{ // name -> should survive
+		"bird_box_20260101_000000.ogg":   true,  // 90-day policy
+		"plain_20260101_000000.ogg":      false, // shared 7-day policy
+		"bird_box_2_20260101_000000.ogg": false, // another device, not bird_box
+		"notes.txt":                      false, // unparseable names stay under the shared policy
+	}
+	mtime := time.Now().Add(-age)
+	for name := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("audio"), 0600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("chtimes %s: %v", name, err)
+		}
+	}
+
+	for _, p := range retentionPolicies(cfg) {
+		cleanupSegmentFiles(logger, p)
+	}
+
+	for name, keep := range files {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != keep {
+			t.Errorf("%s exists = %v, want %v", name, exists, keep)
+		}
+	}
+}
+
+func TestDeviceConfigHashOverrides(t *testing.T) {
+	cfg := overridesTestConfig("/rec", "/rec-test")
+	url := "rtsp://localhost:8554/bird_box"
+	base := deviceConfigHash(cfg.GetDeviceConfig("bird_box"), url, cfg.Stream)
+
+	changed := map[string]func(d *config.DeviceConfig){
+		"mode":                  func(d *config.DeviceConfig) { d.Mode = config.DeviceModeStreamOnly },
+		"local_record_dir":      func(d *config.DeviceConfig) { d.LocalRecordDir = "/other" },
+		"segment_format":        func(d *config.DeviceConfig) { d.SegmentFormat = "flac" },
+		"segment_duration":      func(d *config.DeviceConfig) { d.SegmentDuration = 60 },
+		"segment_name":          func(d *config.DeviceConfig) { d.SegmentName = "{stream}_{iso}" },
+		"segment_timezone":      func(d *config.DeviceConfig) { d.SegmentTimezone = config.SegmentTimezoneUTC },
+		"segment_layout":        func(d *config.DeviceConfig) { d.SegmentLayout = config.SegmentLayoutDaily },
+		"segment_align":         func(d *config.DeviceConfig) { d.SegmentAlign = config.SegmentAlignClock },
+		"max_restart_attempts":  func(d *config.DeviceConfig) { d.MaxRestartAttempts = 999 },
+		"initial_restart_delay": func(d *config.DeviceConfig) { d.InitialRestartDelay = 3 * time.Second },
+		"max_restart_delay":     func(d *config.DeviceConfig) { d.MaxRestartDelay = time.Hour },
+	}
+	for name, mutate := range changed {
+		d := cfg.Devices["bird_box"]
+		mutate(&d)
+		cfg.Devices["bird_box"] = d
+		if deviceConfigHash(cfg.GetDeviceConfig("bird_box"), url, cfg.Stream) == base {
+			t.Errorf("hash unchanged after overriding %s", name)
+		}
+		cfg = overridesTestConfig("/rec", "/rec-test")
+	}
+
+	// Retention and stall thresholds are applied live, without a restart.
+	d := cfg.Devices["bird_box"]
+	d.SegmentMaxAge = time.Hour
+	d.MaxStallChecks = 10
+	cfg.Devices["bird_box"] = d
+	if deviceConfigHash(cfg.GetDeviceConfig("bird_box"), url, cfg.Stream) != base {
+		t.Error("retention or stall override should not restart the stream")
+	}
+}
+
+func TestRecordingDirs(t *testing.T) {
+	cfg := overridesTestConfig("/rec", "/rec-test")
+	if got := recordingDirs(cfg); !reflect.DeepEqual(got, []string{"/rec", "/rec-test"}) {
+		t.Errorf("recordingDirs() = %v", got)
+	}
+	if got := healthRecordDir(cfg); got != "/rec" {
+		t.Errorf("healthRecordDir() = %q, want the shared directory", got)
+	}
+	if got := recordingDirs(config.DefaultConfig()); !reflect.DeepEqual(got, []string{"/"}) {
+		t.Errorf("recordingDirs(default) = %v, want [/]", got)
+	}
+}
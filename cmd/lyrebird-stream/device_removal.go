@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+// vanishedDevices records when each registered device was first seen
+// missing, so a stream is only torn down once its device has been gone for
+// the configured grace period (stream.device_removal_grace). A flaky
+// connector that drops out for a moment then keeps its running stream.
+type vanishedDevices struct {
+	mu    sync.Mutex
+	since map[string]time.Time
+}
+
+func newVanishedDevices() *vanishedDevices {
+	return &vanishedDevices{since: make(map[string]time.Time)}
+}
+
+// markMissing records name as missing at now unless it is already marked,
+// and returns when it was first seen missing.
+func (v *vanishedDevices) markMissing(name string, now time.Time) time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if t, ok := v.since[name]; ok {
+		return t
+	}
+	v.since[name] = now
+	return now
+}
+
+// clear forgets name, reporting whether it had been marked missing.
+func (v *vanishedDevices) clear(name string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	_, ok := v.since[name]
+	delete(v.since, name)
+	return ok
+}
+
+// removeVanishedDevices diffs the detected devices against
+// registeredServices and stops the streams whose device has been missing for
+// at least stream.device_removal_grace. It returns the names of the streams
+// it removed. Devices are named with deviceStreamName, so a device whose
+// entry is now ignore: true counts as missing.
+//
+// Without this, an unplugged microphone leaves its stream registered:
+// FFmpeg fails against the vanished hw:<card>,0 until backoff is exhausted
+// and failed-stream recovery finally clears it, hours later. Removed streams
+// are re-registered by the normal scan when the device returns.
+//
+// A detection error removes nothing — an unreadable /proc/asound is not
+// evidence that every device is gone.
+func removeVanishedDevices(
+	logger *slog.Logger,
+	cfg *config.Config,
+	now time.Time,
+	vanished *vanishedDevices,
+	sup *supervisor.Supervisor,
+	registeredMu *sync.RWMutex,
+	registeredServices map[string]bool,
+	registeredConfigHashes map[string]string,
+	registeredCardNumbers map[string]int,
+) []string {
+	devices, err := detectAudioDevices("/proc/asound")
+	if err != nil {
+		logger.Warn("failed to detect audio devices for removal check", "error", err)
+		return nil
+	}
+	present := make(map[string]bool, len(devices))
+	for _, dev := range devices {
+		if name, ignored := deviceStreamName(cfg, dev); !ignored {
+			present[name] = true
+		}
+	}
+	// Inputs defined by the config are not detected; they last as long as
+	// their config entry.
+	for name := range configuredInputs(cfg) {
+		present[name] = true
+	}
+	grace := cfg.Stream.DeviceRemovalGrace
+
+	registeredMu.RLock()
+	var missing []string
+	for name := range registeredServices {
+		if present[name] {
+			continue
+		}
+		missing = append(missing, name)
+	}
+	// A recorder whose publisher is between failed-stream recovery and
+	// re-registration still goes with its device.
+	for name := range registeredConfigHashes {
+		if dev, ok := recorderDevice(name); ok && !registeredServices[dev] && !present[dev] {
+			missing = append(missing, dev)
+		}
+	}
+	registeredMu.RUnlock()
+
+	for name := range present {
+		if vanished.clear(name) {
+			logger.Info("device returned within removal grace period, keeping stream", "device", name)
+		}
+	}
+
+	var removed []string
+	for _, name := range missing {
+		since := vanished.markMissing(name, now)
+		if gone := now.Sub(since); gone < grace {
+			logger.Debug("device missing, within removal grace period",
+				"device", name, "missing_for", gone, "grace", grace)
+			continue
+		}
+		if unregisterRemovedDevice(logger, sup, name, now.Sub(since),
+			registeredMu, registeredServices, registeredConfigHashes, registeredCardNumbers) {
+			vanished.clear(name)
+			removed = append(removed, name)
+		}
+	}
+	return removed
+}
+
+// removeCardStreams stops and unregisters every stream registered on ALSA
+// card number card, returning the names of the streams it removed. It is the
+// immediate path for a kernel "remove" uevent.
+//
+// Streams are matched on registeredCardNumbers, but only names still present
+// in registeredServices are considered: entries in the card map may be stale
+// (see runDaemon), and a stale entry must not tear down a device that has
+// since moved to that card number under another name.
+func removeCardStreams(
+	logger *slog.Logger,
+	sup *supervisor.Supervisor,
+	card int,
+	registeredMu *sync.RWMutex,
+	registeredServices map[string]bool,
+	registeredConfigHashes map[string]string,
+	registeredCardNumbers map[string]int,
+) []string {
+	names := cardStreamNames(card, registeredMu, registeredServices, registeredCardNumbers)
+	removed := make([]string, 0, len(names))
+	for _, name := range names {
+		if unregisterRemovedDevice(logger, sup, name, 0,
+			registeredMu, registeredServices, registeredConfigHashes, registeredCardNumbers) {
+			removed = append(removed, name)
+		}
+	}
+	return removed
+}
+
+// cardStreamNames returns the registered streams pinned to ALSA card card.
+func cardStreamNames(
+	card int,
+	registeredMu *sync.RWMutex,
+	registeredServices map[string]bool,
+	registeredCardNumbers map[string]int,
+) []string {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	var names []string
+	for name := range registeredServices {
+		if c, ok := registeredCardNumbers[name]; ok && c == card {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// unregisterRemovedDevice gracefully stops the stream and recorder for a
+// device that is gone, clears its registration and records a device_removed
+// event. It returns false (leaving the registration for the next scan to
+// retry) if the supervisor cannot remove the service.
+func unregisterRemovedDevice(
+	logger *slog.Logger,
+	sup *supervisor.Supervisor,
+	name string,
+	missingFor time.Duration,
+	registeredMu *sync.RWMutex,
+	registeredServices map[string]bool,
+	registeredConfigHashes map[string]string,
+	registeredCardNumbers map[string]int,
+) bool {
+	registeredMu.RLock()
+	publishing := registeredServices[name]
+	registeredMu.RUnlock()
+	if publishing {
+		if err := sup.Remove(name); err != nil {
+			logger.Warn("failed to remove stream for unplugged device; will retry next scan",
+				"device", name, "error", err)
+			return false
+		}
+	}
+	if !stopRecorder(logger, sup, name, registeredMu, registeredConfigHashes) {
+		return false
+	}
+	registeredMu.Lock()
+	card, hadCard := registeredCardNumbers[name]
+	delete(registeredServices, name)
+	delete(registeredConfigHashes, name)
+	delete(registeredCardNumbers, name)
+	registeredMu.Unlock()
+
+	attrs := []any{"event", "device_removed", "device", name, "missing_for", missingFor}
+	if hadCard {
+		attrs = append(attrs, "card", card)
+	}
+	logger.Info("device removed, stream stopped", attrs...)
+	return true
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+// removalFixture registers mockServices for names with the supervisor and
+// the registration maps, and makes detection report present.
+type removalFixture struct {
+	sup      *supervisor.Supervisor
+	mu       sync.RWMutex
+	services map[string]bool
+	hashes   map[string]string
+	cards    map[string]int
+	present  []*audio.Device
+	detErr   error
+}
+
+func newRemovalFixture(t *testing.T, names ...string) *removalFixture {
+	t.Helper()
+	f := &removalFixture{
+		sup:      supervisor.New(supervisor.Config{ShutdownTimeout: time.Second}),
+		services: make(map[string]bool),
+		hashes:   make(map[string]string),
+		cards:    make(map[string]int),
+	}
+	for i, name := range names {
+		if err := f.sup.Add(&mockService{name: name}); err != nil {
+			t.Fatalf("Add(%s): %v", name, err)
+		}
+		f.services[name] = true
+		f.hashes[name] = "h"
+		f.cards[name] = i + 1
+	}
+	orig := detectAudioDevices
+	t.Cleanup(func() { detectAudioDevices = orig })
+	detectAudioDevices = func(string) ([]*audio.Device, error) { return f.present, f.detErr }
+	return f
+}
+
+func (f *removalFixture) remove(logger *slog.Logger, grace time.Duration, now time.Time, v *vanishedDevices) []string {
+	cfg := &config.Config{Stream: config.StreamConfig{DeviceRemovalGrace: grace}}
+	return removeVanishedDevices(logger, cfg, now, v, f.sup, &f.mu, f.services, f.hashes, f.cards)
+}
+
+func TestRemoveVanishedDevices_Immediate(t *testing.T) {
+	var logBuf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logBuf, nil))
+	f := newRemovalFixture(t, "mic_a", "mic_b")
+	f.present = []*audio.Device{{Name: "mic_b", CardNumber: 2}}
+
+	removed := f.remove(logger, 0, time.Now(), newVanishedDevices())
+	if len(removed) != 1 || removed[0] != "mic_a" {
+		t.Fatalf("removed = %v, want [mic_a]", removed)
+	}
+	if f.services["mic_a"] || !f.services["mic_b"] {
+		t.Errorf("services = %v, want only mic_b", f.services)
+	}
+	if _, ok := f.cards["mic_a"]; ok {
+		t.Error("card entry for removed device not cleared")
+	}
+	if f.sup.ServiceCount() != 1 {
+		t.Errorf("ServiceCount() = %d, want 1", f.sup.ServiceCount())
+	}
+	if !bytes.Contains(logBuf.Bytes(), []byte("event=device_removed")) {
+		t.Errorf("missing device_removed event:\n%s", logBuf.String())
+	}
+}
+
+func TestRemoveVanishedDevices_GracePeriod(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	f := newRemovalFixture(t, "mic_a")
+	v := newVanishedDevices()
+	const grace = 30 * time.Second
+	t0 := time.Now()
+
+	if removed := f.remove(logger, grace, t0, v); len(removed) != 0 {
+		t.Fatalf("removed %v on first miss, want none within grace", removed)
+	}
+	if removed := f.remove(logger, grace, t0.Add(grace/2), v); len(removed) != 0 {
+		t.Fatalf("removed %v within grace", removed)
+	}
+	if removed := f.remove(logger, grace, t0.Add(grace), v); len(removed) != 1 {
+		t.Fatalf("removed %v after grace, want [mic_a]", removed)
+	}
+	if f.services["mic_a"] {
+		t.Error("mic_a still registered after grace expired")
+	}
+}
+
+// TestRemoveVanishedDevices_ReturnWithinGrace verifies that a flaky
+// connector which reconnects inside the grace period keeps its stream and
+// starts a fresh grace period on the next drop.
+func TestRemoveVanishedDevices_ReturnWithinGrace(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	f := newRemovalFixture(t, "mic_a")
+	v := newVanishedDevices()
+	const grace = 30 * time.Second
+	t0 := time.Now()
+
+	f.remove(logger, grace, t0, v)
+	f.present = []*audio.Device{{Name: "mic_a", CardNumber: 1}}
+	f.remove(logger, grace, t0.Add(10*time.Second), v)
+	f.present = nil
+
+	if removed := f.remove(logger, grace, t0.Add(grace), v); len(removed) != 0 {
+		t.Errorf("removed %v; the return should have restarted the grace period", removed)
+	}
+	if !f.services["mic_a"] {
+		t.Error("mic_a unregistered despite returning within grace")
+	}
+}
+
+func TestRemoveVanishedDevices_DetectionErrorRemovesNothing(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	f := newRemovalFixture(t, "mic_a")
+	f.detErr = errors.New("permission denied")
+
+	if removed := f.remove(logger, 0, time.Now(), newVanishedDevices()); len(removed) != 0 {
+		t.Errorf("removed %v on detection error", removed)
+	}
+	if !f.services["mic_a"] {
+		t.Error("mic_a unregistered on detection error")
+	}
+}
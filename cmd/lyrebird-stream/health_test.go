@@ -43,7 +43,7 @@ func TestStartHealthEndpointListens(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Monitor.HealthAddr = addr
 
-	startHealthEndpoint(ctx, logger, cfg, sup)
+	startHealthEndpoint(ctx, logger, cfg, sup, nil, nil, nil, nil, nil, nil)
 
 	// Wait for the endpoint to be ready (startHealthEndpoint blocks until ready).
 	client := &http.Client{Timeout: 3 * time.Second}
@@ -85,7 +85,7 @@ func TestStartHealthEndpointDefaultAddr(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Monitor.HealthAddr = "" // Use default
 
-	startHealthEndpoint(ctx, logger, cfg, sup)
+	startHealthEndpoint(ctx, logger, cfg, sup, nil, nil, nil, nil, nil, nil)
 
 	client := &http.Client{Timeout: 3 * time.Second}
 	var resp2 *http.Response
@@ -114,7 +114,7 @@ func TestStartHealthEndpointContextCancellation(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Monitor.HealthAddr = addr
 
-	startHealthEndpoint(ctx, logger, cfg, sup)
+	startHealthEndpoint(ctx, logger, cfg, sup, nil, nil, nil, nil, nil, nil)
 
 	// Verify it's up.
 	client := &http.Client{Timeout: 2 * time.Second}
@@ -156,7 +156,7 @@ func TestRegisterNewDevicesNoALSA(t *testing.T) {
 	// registerNewDevices calls audio.DetectDevices("/proc/asound").
 	// In CI, /proc/asound exists but has no USB audio devices (or may not exist at all).
 	// Either way, the function should return 0 gracefully.
-	n := registerNewDevices(ctx, logger, cfg, flags, "/nonexistent/ffmpeg", sup, &mu, services, hashes, cards)
+	n := registerNewDevices(ctx, logger, cfg, flags, "/nonexistent/ffmpeg", nil, sup, &mu, services, hashes, cards)
 	if n != 0 {
 		t.Errorf("expected 0 registered devices with no USB audio, got %d", n)
 	}
@@ -183,7 +183,7 @@ func TestRegisterNewDevicesContextCancellationDuringStabilization(t *testing.T)
 	cards := make(map[string]int)
 
 	// With cancelled context and no real devices, should return 0 without blocking.
-	n := registerNewDevices(ctx, logger, cfg, flags, "/nonexistent/ffmpeg", sup, &mu, services, hashes, cards)
+	n := registerNewDevices(ctx, logger, cfg, flags, "/nonexistent/ffmpeg", nil, sup, &mu, services, hashes, cards)
 	if n != 0 {
 		t.Errorf("expected 0 registered devices, got %d", n)
 	}
@@ -227,7 +227,7 @@ stream:
 
 	done := make(chan struct{})
 	go func() {
-		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices)
+		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices, nil, nil)
 		close(done)
 	}()
 
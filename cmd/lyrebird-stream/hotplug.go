@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/udev"
+)
+
+// openUeventSource opens the kernel uevent socket. Tests replace it with a
+// fake source.
+var openUeventSource = udev.OpenUeventSource
+
+// hotplugSettleDelay coalesces the burst of uevents one USB microphone
+// produces (card, controlC, pcmC…D…, and a change event when the card is
+// fully registered) into a single device scan.
+var hotplugSettleDelay = 500 * time.Millisecond
+
+// hotplugReconcileInterval is how often the hotplug listener rescans devices
+// without a uevent. The stall detector and failed-stream recovery remove a
+// stream and leave it to the next scan to register it again; uevents only
+// report hardware changes, so without this scan such a stream would stay
+// down. It matches startDevicePoller's interval.
+var hotplugReconcileInterval = 10 * time.Second
+
+// watchDevices keeps the registered streams in step with the plugged-in
+// hardware until ctx is cancelled.
+//
+// It prefers kernel uevents (startHotplugListener): a plug-in is picked up
+// within hotplugSettleDelay instead of up to 10 seconds later, and an unplug
+// tears the stream down at once instead of letting FFmpeg fail into backoff.
+// If the netlink socket cannot be opened (restricted sandbox, non-Linux
+// kernel configuration) or fails later, it falls back to startDevicePoller.
+func watchDevices(
+	ctx context.Context,
+	logger *slog.Logger,
+	koanfCfg *config.KoanfConfig,
+	fallbackCfg *config.Config,
+	registerDevices func(cfg *config.Config) int,
+	removeCard func(card int) []string,
+) {
+	src, err := openUeventSource()
+	if err != nil {
+		logger.Warn("uevent hotplug unavailable, falling back to polling", "error", err)
+		startDevicePoller(ctx, logger, koanfCfg, fallbackCfg, registerDevices)
+		return
+	}
+
+	logger.Info("listening for USB audio hotplug events")
+	err = startHotplugListener(ctx, logger, src, koanfCfg, fallbackCfg, registerDevices, removeCard)
+	if ctx.Err() != nil {
+		return
+	}
+	logger.Warn("uevent hotplug listener stopped, falling back to polling", "error", err)
+	startDevicePoller(ctx, logger, koanfCfg, fallbackCfg, registerDevices)
+}
+
+// startHotplugListener consumes uevents from src until ctx is cancelled (it
+// then closes src and returns ctx.Err()) or src fails (returns that error).
+//
+// Sound card add/change events schedule a device scan after
+// hotplugSettleDelay; remove events call removeCard immediately. A kernel
+// buffer overflow means events were lost, so it also schedules a scan. A
+// scan also runs every hotplugReconcileInterval to re-register streams the
+// monitors removed.
+//
+// Scans run in their own goroutine, one at a time: registerDevices waits
+// USBStabilizationDelay for each new device, and a remove event must not
+// queue behind that. A scan requested while one runs is run once it ends.
+// Before returning, the listener waits for a running scan to finish, so a
+// fallback poller never overlaps it.
+func startHotplugListener(
+	ctx context.Context,
+	logger *slog.Logger,
+	src udev.UeventSource,
+	koanfCfg *config.KoanfConfig,
+	fallbackCfg *config.Config,
+	registerDevices func(cfg *config.Config) int,
+	removeCard func(card int) []string,
+) error {
+	events := make(chan udev.Uevent)
+	overflow := make(chan struct{}, 1)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			ev, err := src.Receive()
+			if errors.Is(err, udev.ErrUeventOverflow) {
+				select {
+				case overflow <- struct{}{}:
+				default:
+				}
+				continue
+			}
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	defer func() { _ = src.Close() }()
+
+	// settle is armed by the first add/change event and re-armed by each
+	// following one, so a scan runs once the burst has gone quiet.
+	settle := time.NewTimer(time.Hour)
+	settle.Stop()
+	defer settle.Stop()
+
+	reconcile := time.NewTicker(hotplugReconcileInterval)
+	defer reconcile.Stop()
+
+	scanDone := make(chan struct{}, 1)
+	var scanning, rescan bool
+	startScan := func() {
+		if scanning {
+			rescan = true
+			return
+		}
+		scanning = true
+		go func() {
+			scanDevices(logger, koanfCfg, fallbackCfg, registerDevices)
+			scanDone <- struct{}{}
+		}()
+	}
+	defer func() {
+		if scanning {
+			<-scanDone
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case err := <-recvErr:
+			return err
+
+		case <-overflow:
+			logger.Warn("uevent buffer overflowed, rescanning devices")
+			settle.Reset(hotplugSettleDelay)
+
+		case ev := <-events:
+			card, ok := ev.SoundCard()
+			if !ok {
+				continue
+			}
+			switch ev.Action {
+			case "add", "change":
+				logger.Debug("sound card hotplug event", "action", ev.Action, "card", card)
+				settle.Reset(hotplugSettleDelay)
+			case "remove":
+				removed := removeCard(card)
+				logger.Info("sound card removed", "card", card, "streams_stopped", removed)
+			}
+
+		case <-settle.C:
+			startScan()
+
+		case <-reconcile.C:
+			startScan()
+
+		case <-scanDone:
+			scanning = false
+			if rescan {
+				rescan = false
+				startScan()
+			}
+		}
+	}
+}
+
+// scanDevices registers the streams of the devices present now that are not
+// registered yet.
+func scanDevices(logger *slog.Logger, koanfCfg *config.KoanfConfig, fallbackCfg *config.Config, registerDevices func(cfg *config.Config) int) {
+	if cfg, ok := loadScanConfig(logger, koanfCfg, fallbackCfg); ok {
+		if n := registerDevices(cfg); n > 0 {
+			logger.Info("discovered new devices", "count", n)
+		}
+	}
+}
+
+// loadScanConfig returns the config a device scan should use: the live
+// koanf config when available, else fallbackCfg. ok is false (and the scan
+// should be skipped) when the koanf load fails.
+func loadScanConfig(logger *slog.Logger, koanfCfg *config.KoanfConfig, fallbackCfg *config.Config) (*config.Config, bool) {
+	// C-3 guard: koanfCfg can be nil when NewKoanfConfig failed.
+	if koanfCfg == nil {
+		return fallbackCfg, true
+	}
+	cfg, err := koanfCfg.Load()
+	if err != nil {
+		logger.Warn("failed to load config for device scan", "error", err)
+		return nil, false
+	}
+	return cfg, true
+}
@@ -0,0 +1,378 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/udev"
+)
+
+// fakeUeventSource delivers events pushed by the test. Close makes Receive
+// return io.EOF; fail makes it return an arbitrary error.
+type fakeUeventSource struct {
+	events chan udev.Uevent
+	errs   chan error
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newFakeUeventSource() *fakeUeventSource {
+	return &fakeUeventSource{
+		events: make(chan udev.Uevent, 16),
+		errs:   make(chan error, 1),
+		closed: make(chan struct{}),
+	}
+}
+
+func (f *fakeUeventSource) Receive() (udev.Uevent, error) {
+	select {
+	case ev := <-f.events:
+		return ev, nil
+	case err := <-f.errs:
+		return udev.Uevent{}, err
+	case <-f.closed:
+		return udev.Uevent{}, io.EOF
+	}
+}
+
+func (f *fakeUeventSource) Close() error {
+	f.once.Do(func() { close(f.closed) })
+	return nil
+}
+
+func soundCardEvent(action string, card string) udev.Uevent {
+	return udev.Uevent{
+		Action:    action,
+		Subsystem: "sound",
+		DevPath:   "/devices/pci0000:00/usb1/1-1/1-1:1.0/sound/" + card,
+	}
+}
+
+func withSettleDelay(t *testing.T, d time.Duration) {
+	t.Helper()
+	orig := hotplugSettleDelay
+	hotplugSettleDelay = d
+	t.Cleanup(func() { hotplugSettleDelay = orig })
+}
+
+// TestHotplugListener_AddBurstScansOnce verifies that the burst of events a
+// single plug-in produces triggers one scan, after the settle delay.
+func TestHotplugListener_AddBurstScansOnce(t *testing.T) {
+	withSettleDelay(t, 50*time.Millisecond)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	src := newFakeUeventSource()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scans := make(chan *config.Config, 10)
+	registerDevices := func(c *config.Config) int {
+		scans <- c
+		return 1
+	}
+	removeCard := func(int) []string {
+		t.Error("removeCard called for an add event")
+		return nil
+	}
+
+	fallback := config.DefaultConfig()
+	done := make(chan error, 1)
+	go func() {
+		done <- startHotplugListener(ctx, logger, src, nil, fallback, registerDevices, removeCard)
+	}()
+
+	src.events <- soundCardEvent("add", "card1")
+	src.events <- udev.Uevent{Action: "add", Subsystem: "sound", DevPath: "/devices/x/sound/card1/pcmC1D0c"}
+	src.events <- udev.Uevent{Action: "add", Subsystem: "usb", DevPath: "/devices/x/1-1"}
+	src.events <- soundCardEvent("change", "card1")
+
+	select {
+	case got := <-scans:
+		if got != fallback {
+			t.Error("scan did not use the fallback config")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no device scan after add events")
+	}
+	select {
+	case <-scans:
+		t.Error("burst of events triggered more than one scan")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("startHotplugListener() = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not exit on cancel")
+	}
+	select {
+	case <-src.closed:
+	default:
+		t.Error("source not closed on shutdown")
+	}
+}
+
+func TestHotplugListener_RemoveTearsDownImmediately(t *testing.T) {
+	withSettleDelay(t, time.Hour)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	src := newFakeUeventSource()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	removed := make(chan int, 1)
+	removeCard := func(card int) []string {
+		removed <- card
+		return []string{"usb_mic"}
+	}
+	go func() {
+		_ = startHotplugListener(ctx, logger, src, nil, config.DefaultConfig(),
+			func(*config.Config) int { return 0 }, removeCard)
+	}()
+
+	src.events <- soundCardEvent("remove", "card3")
+	select {
+	case card := <-removed:
+		if card != 3 {
+			t.Errorf("removeCard(%d), want 3", card)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("removeCard not called for a remove event")
+	}
+}
+
+// TestHotplugListener_RemoveDuringScan verifies that a remove event is
+// handled while a scan is still waiting out USB stabilization.
+func TestHotplugListener_RemoveDuringScan(t *testing.T) {
+	withSettleDelay(t, 10*time.Millisecond)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	src := newFakeUeventSource()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	scanStarted := make(chan struct{}, 1)
+	release := make(chan struct{})
+	registerDevices := func(*config.Config) int {
+		scanStarted <- struct{}{}
+		<-release
+		return 1
+	}
+	removed := make(chan int, 1)
+	removeCard := func(card int) []string {
+		removed <- card
+		return nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- startHotplugListener(ctx, logger, src, nil, config.DefaultConfig(), registerDevices, removeCard)
+	}()
+
+	src.events <- soundCardEvent("add", "card1")
+	select {
+	case <-scanStarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no device scan after an add event")
+	}
+
+	src.events <- soundCardEvent("remove", "card2")
+	select {
+	case card := <-removed:
+		if card != 2 {
+			t.Errorf("removeCard(%d), want 2", card)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("remove event queued behind a running scan")
+	}
+
+	close(release)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not exit on cancel")
+	}
+}
+
+func TestHotplugListener_OverflowRescans(t *testing.T) {
+	withSettleDelay(t, 10*time.Millisecond)
+	var logBuf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logBuf, nil))
+	src := newFakeUeventSource()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	scanned := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- startHotplugListener(ctx, logger, src, nil, config.DefaultConfig(),
+			func(*config.Config) int {
+				select {
+				case scanned <- struct{}{}:
+				default:
+				}
+				return 0
+			}, func(int) []string { return nil })
+	}()
+
+	src.errs <- udev.ErrUeventOverflow
+	select {
+	case <-scanned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no rescan after overflow")
+	}
+	cancel()
+	<-done
+}
+
+func TestHotplugListener_SourceErrorReturned(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	src := newFakeUeventSource()
+	boom := errors.New("socket gone")
+	src.errs <- boom
+
+	err := startHotplugListener(context.Background(), logger, src, nil, config.DefaultConfig(),
+		func(*config.Config) int { return 0 }, func(int) []string { return nil })
+	if !errors.Is(err, boom) {
+		t.Errorf("startHotplugListener() = %v, want %v", err, boom)
+	}
+}
+
+// TestHotplugListener_ReregistersRemovedStream verifies that a stream the
+// stall detector or failed-stream recovery removed comes back while the
+// listener is active, although no uevent arrives.
+func TestHotplugListener_ReregistersRemovedStream(t *testing.T) {
+	orig := hotplugReconcileInterval
+	hotplugReconcileInterval = 20 * time.Millisecond
+	t.Cleanup(func() { hotplugReconcileInterval = orig })
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sup := supervisor.New(supervisor.Config{ShutdownTimeout: time.Second})
+	var mu sync.RWMutex
+	services := map[string]bool{}
+	registerDevices := func(*config.Config) int {
+		mu.Lock()
+		defer mu.Unlock()
+		if services["usb_mic"] {
+			return 0
+		}
+		if err := sup.Add(&mockService{name: "usb_mic"}); err != nil {
+			t.Errorf("Add: %v", err)
+			return 0
+		}
+		services["usb_mic"] = true
+		return 1
+	}
+	registered := func() bool {
+		mu.RLock()
+		defer mu.RUnlock()
+		return services["usb_mic"]
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = startHotplugListener(ctx, logger, newFakeUeventSource(), nil, config.DefaultConfig(),
+			registerDevices, func(int) []string { return nil })
+	}()
+
+	waitRegistered := func(step string) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !registered(); time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("usb_mic not registered %s", step)
+			}
+		}
+	}
+	waitRegistered("by the first scan")
+
+	// Remove the stream the way the stall detector does.
+	if err := sup.Remove("usb_mic"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	mu.Lock()
+	delete(services, "usb_mic")
+	mu.Unlock()
+
+	waitRegistered("again after its removal")
+	if sup.ServiceCount() != 1 {
+		t.Errorf("ServiceCount() = %d, want 1", sup.ServiceCount())
+	}
+}
+
+// TestWatchDevices_FallsBackToPolling verifies that an unavailable netlink
+// socket leaves the daemon on the poller rather than without hotplug at all.
+func TestWatchDevices_FallsBackToPolling(t *testing.T) {
+	orig := openUeventSource
+	t.Cleanup(func() { openUeventSource = orig })
+	openUeventSource = func() (udev.UeventSource, error) {
+		return nil, errors.New("netlink: permission denied")
+	}
+
+	var logBuf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logBuf, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watchDevices(ctx, logger, nil, config.DefaultConfig(),
+			func(*config.Config) int { return 0 }, func(int) []string { return nil })
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchDevices did not exit on cancel")
+	}
+	if !bytes.Contains(logBuf.Bytes(), []byte("falling back to polling")) {
+		t.Errorf("missing fallback log:\n%s", logBuf.String())
+	}
+}
+
+func TestRemoveCardStreams(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sup := supervisor.New(supervisor.Config{ShutdownTimeout: time.Second})
+	for _, name := range []string{"mic_a", "mic_b", "mic_c"} {
+		if err := sup.Add(&mockService{name: name}); err != nil {
+			t.Fatalf("Add(%s): %v", name, err)
+		}
+	}
+
+	var mu sync.RWMutex
+	services := map[string]bool{"mic_a": true, "mic_b": true, "mic_c": true}
+	hashes := map[string]string{"mic_a": "h", "mic_b": "h", "mic_c": "h"}
+	// "stale" is a leftover card entry for an unregistered name.
+	cards := map[string]int{"mic_a": 1, "mic_b": 2, "mic_c": 1, "stale": 2}
+
+	removed := removeCardStreams(logger, sup, 1, &mu, services, hashes, cards)
+	if len(removed) != 2 {
+		t.Fatalf("removed = %v, want mic_a and mic_c", removed)
+	}
+	if services["mic_a"] || services["mic_c"] || !services["mic_b"] {
+		t.Errorf("services = %v, want only mic_b", services)
+	}
+	if _, ok := hashes["mic_a"]; ok {
+		t.Error("hash for removed stream not cleared")
+	}
+	if _, ok := cards["mic_c"]; ok {
+		t.Error("card number for removed stream not cleared")
+	}
+	if sup.ServiceCount() != 1 {
+		t.Errorf("ServiceCount() = %d, want 1", sup.ServiceCount())
+	}
+
+	if removed := removeCardStreams(logger, sup, 2, &mu, services, hashes, cards); len(removed) != 1 || removed[0] != "mic_b" {
+		t.Errorf("removed = %v, want [mic_b] (stale entry ignored)", removed)
+	}
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/stream"
+)
+
+// deviceInput is where a stream's FFmpeg reads from.
+type deviceInput struct {
+	format  string               // FFmpeg input format ("" = alsa)
+	device  string               // FFmpeg input: hw:<card>,0, a pulse source or a JACK client name
+	ports   []string             // jack: ports to connect to FFmpeg's inputs
+	network *stream.NetworkInput // static source: the URL pulled instead of a device
+	test    *stream.TestSignal   // test source: the signal generated instead of a device
+	card    int                  // ALSA card number, or -1 for an input defined by the config
+	id      string               // device identifier for the capture metadata ("" = stream name)
+}
+
+// alsaInput returns the input of a detected USB card. Its metadata ID is
+// the USB ID, plus the serial number and port when known, so recordings name
+// the physical device rather than a config key.
+func alsaInput(dev *audio.Device) deviceInput {
+	id := "alsa:" + dev.Name
+	if dev.USBID != "" {
+		id = "usb:" + dev.USBID
+		if dev.Serial != "" {
+			id += "/" + dev.Serial
+		}
+		if dev.PortPath != "" {
+			id += "@" + dev.PortPath
+		}
+	}
+	return deviceInput{device: fmt.Sprintf("hw:%d,0", dev.CardNumber), card: dev.CardNumber, id: id}
+}
+
+// configuredInputs returns the streams whose input comes from the config
+// rather than from device detection, keyed by stream name: device entries
+// with a source: block (PipeWire, PulseAudio, JACK), static_sources:
+// (network pulls) and test_sources: (generated signals). Entries marked
+// ignore: true are left out.
+//
+// These streams have no card to detect. They are registered by
+// registerNewDevices alongside detected cards, last as long as their config
+// entry, and a card resolving to one of their names is not streamed.
+func configuredInputs(cfg *config.Config) map[string]deviceInput {
+	inputs := make(map[string]deviceInput)
+	for _, name := range cfg.Sources() {
+		src := cfg.Devices[name].Source
+		format, device := src.Input(name)
+		inputs[name] = deviceInput{format: format, device: device, ports: src.Ports, card: -1, id: format + ":" + device}
+	}
+	for _, name := range cfg.StaticSourceNames() {
+		src := cfg.StaticSources[name]
+		inputs[name] = deviceInput{
+			network: &stream.NetworkInput{
+				URL:               src.URL,
+				RTSPTransport:     src.RTSPTransport,
+				Timeout:           src.Timeout,
+				ReconnectDelayMax: src.ReconnectDelayMax,
+				Copy:              src.Copy,
+			},
+			card: -1,
+			id:   src.RedactedURL(),
+		}
+	}
+	for _, name := range cfg.TestSourceNames() {
+		src := cfg.TestSources[name]
+		inputs[name] = deviceInput{
+			test: &stream.TestSignal{
+				Kind:         src.Signal,
+				Frequency:    src.Frequency,
+				EndFrequency: src.EndFrequency,
+				Period:       src.Period,
+				LevelDB:      src.LevelDB,
+				Ident:        src.Ident,
+			},
+			card: -1,
+			id:   "test:" + cmp.Or(src.Signal, stream.TestSignalTone),
+		}
+	}
+	return inputs
+}
+
+// streamConfigHash is deviceConfigHash for stream name in cfg, extended with
+// the static or test source settings when the stream has one, with the
+// timecode settings and with the renditions, so a changed URL, pull option,
+// signal, metadata setting or rendition restarts the stream on reload. The
+// renditions are left out of inputConfigHash, which the recorder also uses:
+// they only change what is published.
+func streamConfigHash(cfg *config.Config, name string) string {
+	h := inputConfigHash(cfg, name, cfg.MediaMTX.RTSPURL+"/"+name)
+	if devCfg := cfg.GetDeviceConfig(name); devCfg.PublishesRTSP() && len(devCfg.Renditions) > 0 {
+		h += fmt.Sprintf("/%+v", devCfg.ResolvedRenditions())
+	}
+	return h
+}
+
+// inputConfigHash is streamConfigHash for a stream published to rtspURL.
+func inputConfigHash(cfg *config.Config, name, rtspURL string) string {
+	h := deviceConfigHash(cfg.GetDeviceConfig(name), rtspURL, cfg.Stream)
+	h += fmt.Sprintf("/%+v", cfg.Timecode)
+	if src, ok := cfg.StaticSources[name]; ok {
+		h += fmt.Sprintf("/%+v", src)
+	}
+	if src, ok := cfg.TestSources[name]; ok {
+		h += fmt.Sprintf("/%+v", src)
+	}
+	return h
+}
+
+// streamTimecode returns the capture metadata settings for a stream with
+// input in, or nil when timecode is disabled.
+func streamTimecode(cfg *config.Config, in deviceInput) *stream.Timecode {
+	if !cfg.Timecode.Enabled {
+		return nil
+	}
+	return &stream.Timecode{
+		HostID:    cfg.Timecode.Host(),
+		DeviceID:  in.id,
+		CheckSync: cfg.Timecode.TimeSource != config.TimeSourceSystem,
+	}
+}
@@ -25,13 +25,17 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
+	"maps"
+	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"sync"
 	"syscall"
 	"time"
@@ -91,7 +95,13 @@ func main() {
 func runDaemon(flags daemonFlags) int {
 	// Initialize structured logger
 	slogLevel := parseSlogLevel(flags.LogLevel)
-	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slogLevel}))
+	// The redactor learns the config's secret values when it is loaded (and
+	// on every reload) and scrubs them from all log output.
+	redactor := config.NewRedactor()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level:       slogLevel,
+		ReplaceAttr: redactor.ReplaceAttr,
+	}))
 	slog.SetDefault(logger)
 	logger.Info("starting lyrebird-stream", "version", Version, "commit", Commit, "built", BuildTime)
 
@@ -109,13 +119,17 @@ func runDaemon(flags daemonFlags) int {
 		}
 	}
 
+	// Upgrade a config written by an older release before loading it.
+	migrateConfigOnDisk(logger, flags.ConfigPath)
+
 	// Load configuration using koanf (supports env vars and hot-reload)
-	koanfCfg, cfg, err := loadConfigurationKoanf(flags.ConfigPath)
+	koanfCfg, cfg, err := loadConfigurationKoanf(flags.ConfigPath, config.WithRedactor(redactor))
 	if err != nil {
 		logger.Error("failed to load configuration", "error", err)
 		return 1
 	}
 	logger.Info("loaded configuration", "path", flags.ConfigPath)
+	logUnknownConfigKeys(logger, koanfCfg)
 
 	// Create supervisor
 	var supLogger *slog.Logger
@@ -173,27 +187,74 @@ func runDaemon(flags daemonFlags) int {
 		registeredCardNumbers  = make(map[string]int)
 	)
 
-	// registerDevices detects USB audio devices and registers new ones with the supervisor.
+	// cfgBroadcast carries hot-reloaded configs to the background subsystems
+	// (see startReloadHandler); Current() is always the live config.
+	cfgBroadcast := newConfigBroadcast(cfg)
+
+	// Dependency-aware backoff: every stream waits on one shared upstream
+	// signal (the managed MediaMTX, or a probe of an external one) instead of
+	// burning restart attempts while the RTSP server is down.
+	upstream, err := setupUpstream(logger, cfg, flags, sup, cfgBroadcast)
+	if err != nil {
+		logger.Error("failed to set up upstream health check", "error", err)
+		cancel()
+		return 1
+	}
+
+	// registerDevices reconciles the registered streams with the detected USB
+	// audio devices: streams whose device is gone (past the removal grace
+	// period) are stopped, and new or returning devices are registered.
+	vanished := newVanishedDevices()
 	registerDevices := func(cfg *config.Config) int {
-		return registerNewDevices(ctx, logger, cfg, flags, ffmpegPath, sup,
+		removeVanishedDevices(logger, cfg, time.Now(), vanished, sup,
+			&registeredMu, registeredServices, registeredConfigHashes, registeredCardNumbers)
+		return registerNewDevices(ctx, logger, cfg, flags, ffmpegPath, upstream, sup,
 			&registeredMu, registeredServices, registeredConfigHashes, registeredCardNumbers)
 	}
 
 	// Initial device registration
-	registerDevices(cfg)
-
-	if sup.ServiceCount() == 0 {
+	// The count comes from registration, not sup.ServiceCount(): the
+	// supervisor also runs the upstream monitor / managed MediaMTX.
+	if n := registerDevices(cfg); n == 0 {
 		logger.Info("no USB audio devices found, waiting for devices")
 	} else {
-		logger.Info("detected USB audio devices", "count", sup.ServiceCount())
+		logger.Info("detected USB audio devices", "count", n)
+	}
+
+	// removeCard handles a kernel "remove" uevent. Without a grace period the
+	// card's streams stop at once; with one, the devices are marked missing
+	// and a scan after the grace period decides.
+	removeCard := func(card int) []string {
+		scanCfg, ok := loadScanConfig(logger, koanfCfg, cfg)
+		if !ok {
+			scanCfg = cfg
+		}
+		grace := scanCfg.Stream.DeviceRemovalGrace
+		if grace <= 0 {
+			return removeCardStreams(logger, sup, card,
+				&registeredMu, registeredServices, registeredConfigHashes, registeredCardNumbers)
+		}
+		now := time.Now()
+		for _, name := range cardStreamNames(card, &registeredMu, registeredServices, registeredCardNumbers) {
+			vanished.markMissing(name, now)
+		}
+		time.AfterFunc(grace, func() {
+			if ctx.Err() != nil {
+				return
+			}
+			if c, ok := loadScanConfig(logger, koanfCfg, cfg); ok {
+				registerDevices(c)
+			}
+		})
+		return nil
 	}
 
 	// Start background goroutines. Each long-lived loop is wrapped in
 	// runSupervised so a panic in one background subsystem is recovered, logged,
 	// and the loop restarted — rather than crashing the whole daemon and dropping
 	// every audio stream. See runSupervised for the rationale.
-	go runSupervised(ctx, logger, "device-poller", func() {
-		startDevicePoller(ctx, logger, koanfCfg, cfg, registerDevices)
+	go runSupervised(ctx, logger, "device-watcher", func() {
+		watchDevices(ctx, logger, koanfCfg, cfg, registerDevices, removeCard)
 	})
 
 	// Bridge os.Signal channel to struct{} channel for testability
@@ -208,9 +269,23 @@ func runDaemon(flags daemonFlags) int {
 			}
 		}
 	}()
+	// Hot reload: the reload handler publishes each successfully reloaded
+	// config on cfgBroadcast; every background subsystem below subscribes
+	// and reconfigures itself. reloads records the outcome for /healthz.
+	// Subsystems restarted by runSupervised start from cfgBroadcast.Current()
+	// rather than the startup config.
+	reloads := &reloadTracker{}
+	if koanfCfg != nil {
+		// config.watch: file edits feed reloadBridge like SIGHUP does.
+		watchUpdates := cfgBroadcast.Subscribe()
+		go runSupervised(ctx, logger, "config-watcher", func() {
+			runConfigWatcher(ctx, logger, koanfCfg, cfgBroadcast.Current(), watchUpdates, reloadBridge)
+		})
+	}
 	go runSupervised(ctx, logger, "reload-handler", func() {
 		startReloadHandler(ctx, logger, reloadBridge, koanfCfg, sup,
-			&registeredMu, registeredServices, registeredConfigHashes, registerDevices)
+			&registeredMu, registeredServices, registeredConfigHashes, registerDevices,
+			cfgBroadcast, reloads)
 	})
 
 	// GAP-5/A-5: Start systemd watchdog heartbeat, gated on supervisor liveness.
@@ -232,39 +307,46 @@ func runDaemon(flags daemonFlags) int {
 	})
 
 	// Start health check HTTP server
-	startHealthEndpoint(ctx, logger, cfg, sup)
+	schedules := newScheduleTracker()
+	renditions := &renditionHealth{}
+	startHealthEndpoint(ctx, logger, cfg, sup, &registeredMu, registeredConfigHashes, cfgBroadcast.Subscribe(), reloads, schedules, renditions)
+
+	// Operating windows: stop streams outside their device's schedule and
+	// start them when a window opens. Reads the live config on every check.
+	go runSupervised(ctx, logger, "scheduler", func() {
+		runScheduler(ctx, logger, schedules, cfgBroadcast.Current, registerDevices, sup,
+			&registeredMu, registeredServices, registeredConfigHashes, registeredCardNumbers)
+	})
 
 	// P-3 fix: Periodic recovery for permanently failed streams.
+	recoveryUpdates := cfgBroadcast.Subscribe()
 	go runSupervised(ctx, logger, "failed-stream-recovery", func() {
-		startFailedStreamRecovery(ctx, logger, cfg.Monitor.Interval, sup,
-			&registeredMu, registeredServices, registeredConfigHashes)
+		startFailedStreamRecovery(ctx, logger, cfgBroadcast.Current().Monitor.Interval, sup,
+			&registeredMu, registeredServices, registeredConfigHashes, recoveryUpdates)
 	})
 
-	// P-1/P-2 fix: Stream health check loop using MediaMTX API.
-	if cfg.Monitor.Enabled {
-		go runSupervised(ctx, logger, "stall-detector", func() {
-			startStallDetector(ctx, logger, cfg, sup,
-				&registeredMu, registeredServices, registeredConfigHashes)
-		})
-	}
+	// P-1/P-2 fix: Stream health check loop using MediaMTX API. It always
+	// runs so a reload can enable it; it idles while monitor.enabled is false.
+	stallUpdates := cfgBroadcast.Subscribe()
+	go runSupervised(ctx, logger, "stall-detector", func() {
+		startStallDetector(ctx, logger, cfgBroadcast.Current(), sup,
+			&registeredMu, registeredServices, registeredConfigHashes, stallUpdates, renditions)
+	})
 
-	// GAP-1c: Segment retention goroutine.
-	if cfg.Stream.LocalRecordDir != "" &&
-		(cfg.Stream.SegmentMaxAge > 0 || cfg.Stream.SegmentMaxTotalBytes > 0) {
-		go runSupervised(ctx, logger, "segment-retention", func() {
-			runSegmentRetention(ctx, logger, cfg.Stream)
-		})
-	}
+	// GAP-1c: Segment retention goroutine. Idles until retention is configured.
+	retentionUpdates := cfgBroadcast.Subscribe()
+	go runSupervised(ctx, logger, "segment-retention", func() {
+		runSegmentRetention(ctx, logger, cfgBroadcast.Current(), retentionUpdates)
+	})
 
-	// GAP-1d: Disk space monitoring goroutine.
-	if cfg.Monitor.DiskLowThresholdMB > 0 {
-		go runSupervised(ctx, logger, "disk-space-monitor", func() {
-			runDiskSpaceMonitor(ctx, logger, cfg)
-		})
-	}
+	// GAP-1d: Disk space monitoring goroutine. Idles while the threshold is 0.
+	diskUpdates := cfgBroadcast.Subscribe()
+	go runSupervised(ctx, logger, "disk-space-monitor", func() {
+		runDiskSpaceMonitor(ctx, logger, cfgBroadcast.Current(), diskUpdates)
+	})
 
 	// Run supervisor (blocks until shutdown)
-	logger.Info("starting supervisor", "streams", sup.ServiceCount())
+	logger.Info("starting supervisor", "services", sup.ServiceCount())
 	if err := sup.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
 		logger.Error("supervisor error", "error", err)
 	}
@@ -287,6 +369,7 @@ func registerNewDevices(
 	cfg *config.Config,
 	flags daemonFlags,
 	ffmpegPath string,
+	upstream stream.Upstream,
 	sup *supervisor.Supervisor,
 	registeredMu *sync.RWMutex,
 	registeredServices map[string]bool,
@@ -300,13 +383,35 @@ func registerNewDevices(
 	}
 
 	registered := 0
+	inputs := configuredInputs(cfg)
+	claimed := make(map[string]int, len(devices))
 	for _, dev := range devices {
-		// StableName, not SanitizeDeviceName: the sanitizer's fallback for an
-		// unusable raw name is timestamped (unstable), and this name keys the
-		// stream registry across polls. An unstable key would re-register the
-		// same physical device as a new stream on every 10s poll — unbounded
-		// growth of managers, lock files and failing FFmpeg processes.
-		devName := dev.StableName()
+		// The match: entry name or StableName, not SanitizeDeviceName: the
+		// sanitizer's fallback for an unusable raw name is timestamped
+		// (unstable), and this name keys the stream registry across polls. An
+		// unstable key would re-register the same physical device as a new
+		// stream on every 10s poll — unbounded growth of managers, lock files
+		// and failing FFmpeg processes.
+		devName, ignored := deviceStreamName(cfg, dev)
+		if ignored {
+			logger.Debug("device ignored by config", "device", devName, "card", dev.CardNumber)
+			continue
+		}
+		// The name belongs to a stream whose input the config defines (a
+		// sound server holding this card, or a network pull); the loop
+		// below starts it.
+		if _, ok := inputs[devName]; ok {
+			logger.Debug("device name taken by a configured input, not streaming the card", "device", devName, "card", dev.CardNumber)
+			continue
+		}
+		// Two devices resolving to one name would otherwise look like a single
+		// device hopping between cards and restart each other on every poll.
+		if card, dup := claimed[devName]; dup {
+			logger.Warn("multiple devices resolve to the same stream name; add a devices.<name>.match block to tell them apart",
+				"device", devName, "card", dev.CardNumber, "streaming_card", card)
+			continue
+		}
+		claimed[devName] = dev.CardNumber
 
 		registeredMu.RLock()
 		alreadyRegistered := registeredServices[devName]
@@ -340,6 +445,13 @@ func registerNewDevices(
 			// Fall through to registration below with the new card number.
 		}
 
+		// Outside its operating window: the scheduler registers the device
+		// again (through registerDevices) when the window opens.
+		if !scheduleAllows(cfg.GetDeviceConfig(devName), time.Now()) {
+			logger.Debug("device outside its operating window, not starting", "device", devName)
+			continue
+		}
+
 		// P-7 fix: Wait for USB device to finish Linux initialization
 		if cfg.Stream.USBStabilizationDelay > 0 {
 			logger.Debug("waiting for USB device to stabilize", "device", devName, "delay", cfg.Stream.USBStabilizationDelay)
@@ -350,97 +462,284 @@ func registerNewDevices(
 			}
 		}
 
-		devCfg := cfg.GetDeviceConfig(devName)
-		streamName := devName
-		rtspURL := fmt.Sprintf("%s/%s", cfg.MediaMTX.RTSPURL, streamName)
-		alsaDevice := fmt.Sprintf("hw:%d,0", dev.CardNumber)
-
-		mgrCfg := &stream.ManagerConfig{
-			DeviceName:      devName,
-			ALSADevice:      alsaDevice,
-			StreamName:      streamName,
-			SampleRate:      devCfg.SampleRate,
-			Channels:        devCfg.Channels,
-			Bitrate:         devCfg.Bitrate,
-			Codec:           devCfg.Codec,
-			ThreadQueue:     devCfg.ThreadQueue,
-			RTSPURL:         rtspURL,
-			LockDir:         flags.LockDir,
-			LogDir:          flags.LogDir,
-			FFmpegPath:      ffmpegPath,
-			StopTimeout:     cfg.Stream.StopTimeout,
-			LocalRecordDir:  cfg.Stream.LocalRecordDir,
-			SegmentDuration: cfg.Stream.SegmentDuration,
-			SegmentFormat:   cfg.Stream.SegmentFormat,
-			Backoff: stream.NewBackoff(
-				cfg.Stream.InitialRestartDelay,
-				cfg.Stream.MaxRestartDelay,
-				cfg.Stream.MaxRestartAttempts,
-			),
-			Logger: logger.With("component", "manager", "device", devName),
+		if startDeviceStream(logger, cfg, devName, alsaInput(dev),
+			flags, ffmpegPath, upstream, sup, registeredMu, registeredServices, registeredConfigHashes, registeredCardNumbers) {
+			registered++
 		}
+	}
 
-		mgr, err := stream.NewManager(mgrCfg)
-		if err != nil {
-			logger.Warn("failed to create manager", "device", devName, "error", err)
+	// Sound-server sources and static (network) sources have no card to
+	// detect, so they are started from the config alone. A server or source
+	// that is not up yet is left to the manager's restart backoff, like a
+	// failing card.
+	for _, devName := range slices.Sorted(maps.Keys(inputs)) {
+		registeredMu.RLock()
+		alreadyRegistered := registeredServices[devName]
+		registeredMu.RUnlock()
+		if alreadyRegistered {
 			continue
 		}
-
-		svc := &streamService{
-			name:    devName,
-			manager: mgr,
-			logger:  logger,
+		if !scheduleAllows(cfg.GetDeviceConfig(devName), time.Now()) {
+			logger.Debug("device outside its operating window, not starting", "device", devName)
+			continue
+		}
+		if startDeviceStream(logger, cfg, devName, inputs[devName],
+			flags, ffmpegPath, upstream, sup, registeredMu, registeredServices, registeredConfigHashes, registeredCardNumbers) {
+			registered++
 		}
+	}
 
-		if err := sup.Add(svc); err != nil {
-			logger.Warn("failed to add service", "device", devName, "error", err)
-			// stream.NewManager eagerly opens a rotating log-file fd, so an
-			// abandoned manager must be closed or the fd leaks. sup.Add fails on
-			// a duplicate name, which can happen when the device poller and the
-			// SIGHUP reload handler race to register the same new device.
-			if closeErr := mgr.Close(); closeErr != nil {
-				logger.Warn("failed to close abandoned manager", "device", devName, "error", closeErr)
-			}
-			continue
+	return registered
+}
+
+// startDeviceStream creates the stream manager for device devName reading
+// from in, adds it to the supervisor and records the registration. It
+// reports whether the stream was registered.
+func startDeviceStream(
+	logger *slog.Logger,
+	cfg *config.Config,
+	devName string,
+	in deviceInput,
+	flags daemonFlags,
+	ffmpegPath string,
+	upstream stream.Upstream,
+	sup *supervisor.Supervisor,
+	registeredMu *sync.RWMutex,
+	registeredServices map[string]bool,
+	registeredConfigHashes map[string]string,
+	registeredCardNumbers map[string]int,
+) bool {
+	devCfg := cfg.GetDeviceConfig(devName)
+	rtspURL := fmt.Sprintf("%s/%s", cfg.MediaMTX.RTSPURL, devName)
+
+	// The recorder is brought in line first: switching back to the tee
+	// recorder must free the card before the publisher reopens it as hw:.
+	syncRecorder(logger, cfg, devName, in, flags, ffmpegPath, sup, registeredMu, registeredConfigHashes)
+	if devCfg.RecordsSeparately() {
+		in = sharedInput(in)
+	}
+
+	mgrCfg := managerConfig(logger, cfg, devCfg, devName, in, flags, ffmpegPath)
+	mgrCfg.RTSPURL = rtspURL
+	mgrCfg.Renditions = streamRenditions(cfg, devCfg, devName)
+	mgrCfg.RecordOnly = !devCfg.PublishesRTSP()
+	mgrCfg.Upstream = upstream
+	mgrCfg.UpstreamResumeJitter = upstreamResumeJitter
+	if devCfg.RecordsLocally() && !devCfg.RecordsSeparately() {
+		mgrCfg.LocalRecordDir = devCfg.LocalRecordDir
+	}
+	if !addStreamService(logger, sup, devName, mgrCfg) {
+		return false
+	}
+
+	registeredMu.Lock()
+	registeredServices[devName] = true
+	registeredConfigHashes[devName] = streamConfigHash(cfg, devName)
+	if in.card >= 0 {
+		registeredCardNumbers[devName] = in.card
+	}
+	registeredMu.Unlock()
+	attrs := []any{"alsa_device", in.device}
+	switch {
+	case in.network != nil:
+		attrs = []any{"source", "network", "url", cfg.StaticSources[devName].RedactedURL()}
+	case in.test != nil:
+		attrs = []any{"source", "test", "signal", cmp.Or(in.test.Kind, stream.TestSignalTone)}
+	case in.format != "":
+		attrs = []any{"source", devCfg.Source.Type, "input", in.device}
+	}
+	if len(mgrCfg.Renditions) > 0 {
+		paths := make([]string, len(mgrCfg.Renditions))
+		for i, r := range mgrCfg.Renditions {
+			paths[i] = r.StreamName
 		}
+		attrs = append(attrs, "renditions", paths)
+	}
+	logger.Info("registered stream", append(attrs, "rtsp_url", rtspURL,
+		"mode", cmp.Or(devCfg.Mode, config.DeviceModeBoth))...)
+	return true
+}
+
+// managerConfig returns the stream manager settings shared by a device's
+// publisher and recorder; the caller fills in the outputs.
+func managerConfig(
+	logger *slog.Logger,
+	cfg *config.Config,
+	devCfg config.DeviceConfig,
+	devName string,
+	in deviceInput,
+	flags daemonFlags,
+	ffmpegPath string,
+) *stream.ManagerConfig {
+	return &stream.ManagerConfig{
+		DeviceName:         devName,
+		ALSADevice:         in.device,
+		InputFormat:        in.format,
+		InputPorts:         in.ports,
+		Network:            in.network,
+		TestSignal:         in.test,
+		Timecode:           streamTimecode(cfg, in),
+		StreamName:         devName,
+		SampleRate:         devCfg.SampleRate,
+		Channels:           devCfg.Channels,
+		Bitrate:            devCfg.Bitrate,
+		Codec:              devCfg.Codec,
+		ThreadQueue:        devCfg.ThreadQueue,
+		Opus:               devCfg.Opus.Settings(),
+		AudioFilter:        config.FilterChain(devCfg.Filters),
+		LockDir:            flags.LockDir,
+		LogDir:             flags.LogDir,
+		FFmpegPath:         ffmpegPath,
+		StopTimeout:        cfg.Stream.StopTimeout,
+		SegmentDuration:    devCfg.SegmentDuration,
+		SegmentFormat:      devCfg.SegmentFormat,
+		SegmentNaming:      cfg.SegmentNaming(devCfg),
+		SegmentAtClockTime: devCfg.SegmentAlign == config.SegmentAlignClock,
+		// Per-device restart policy (defaults to stream:).
+		Backoff: stream.NewBackoff(
+			devCfg.InitialRestartDelay,
+			devCfg.MaxRestartDelay,
+			devCfg.MaxRestartAttempts,
+		),
+		Logger: logger.With("component", "manager", "device", devName),
+	}
+}
 
-		registeredMu.Lock()
-		registeredServices[devName] = true
-		registeredConfigHashes[devName] = deviceConfigHash(devCfg, rtspURL, cfg.Stream)
-		registeredCardNumbers[devName] = dev.CardNumber
-		registeredMu.Unlock()
-		registered++
-		logger.Info("registered stream", "alsa_device", alsaDevice, "rtsp_url", rtspURL)
+// addStreamService creates the manager for mgrCfg and adds it to the
+// supervisor as service name, reporting whether it was added.
+func addStreamService(logger *slog.Logger, sup *supervisor.Supervisor, name string, mgrCfg *stream.ManagerConfig) bool {
+	mgr, err := stream.NewManager(mgrCfg)
+	if err != nil {
+		logger.Warn("failed to create manager", "device", name, "error", err)
+		return false
 	}
 
-	return registered
+	svc := &streamService{
+		name:    name,
+		manager: mgr,
+		logger:  logger,
+	}
+
+	if err := sup.Add(svc); err != nil {
+		logger.Warn("failed to add service", "device", name, "error", err)
+		// stream.NewManager eagerly opens a rotating log-file fd, so an
+		// abandoned manager must be closed or the fd leaks. sup.Add fails on
+		// a duplicate name, which can happen when the device poller and the
+		// SIGHUP reload handler race to register the same new device.
+		if closeErr := mgr.Close(); closeErr != nil {
+			logger.Warn("failed to close abandoned manager", "device", name, "error", closeErr)
+		}
+		return false
+	}
+	return true
 }
 
 // startHealthEndpoint starts the health check HTTP server.
-func startHealthEndpoint(ctx context.Context, logger *slog.Logger, cfg *config.Config, sup *supervisor.Supervisor) {
-	healthAddr := cfg.Monitor.HealthAddr
-	if healthAddr == "" {
-		healthAddr = "127.0.0.1:9998"
-	}
+//
+// A config arriving on updates (may be nil) is applied by a background
+// goroutine: the disk-space inputs are refreshed, and a changed
+// monitor.health_addr rebinds the listener. If the new address cannot be
+// bound the old one is restored, so a typo in a reload never leaves the
+// daemon without a health endpoint. reloads (may be nil) adds the last
+// reload outcome to /healthz, schedules (may be nil) the operating-window
+// state of scheduled devices, and renditions (may be nil) an entry per
+// rendition. /healthz lists the streams in registeredConfigHashes; with a
+// nil registeredMu it lists every supervised service.
+func startHealthEndpoint(
+	ctx context.Context,
+	logger *slog.Logger,
+	cfg *config.Config,
+	sup *supervisor.Supervisor,
+	registeredMu *sync.RWMutex,
+	registeredConfigHashes map[string]string,
+	updates <-chan *config.Config,
+	reloads health.ReloadInfoProvider,
+	schedules health.ScheduleInfoProvider,
+	renditions *renditionHealth,
+) {
 	sysInfoProvider := &daemonSystemInfoProvider{
-		recordDir:        cfg.Stream.LocalRecordDir,
+		recordDir:        healthRecordDir(cfg),
 		diskLowThreshold: uint64(cfg.Monitor.DiskLowThresholdMB) * 1024 * 1024, //#nosec G115
 	}
-	healthHandler := health.NewHandler(&supervisorStatusProvider{sup: sup}).
+	healthHandler := health.NewHandler(&supervisorStatusProvider{
+		sup:          sup,
+		renditions:   renditions,
+		registeredMu: registeredMu,
+		streams:      registeredConfigHashes,
+	}).
 		WithSystemInfo(sysInfoProvider)
+	if reloads != nil {
+		healthHandler = healthHandler.WithReloadInfo(reloads)
+	}
+	if schedules != nil {
+		healthHandler = healthHandler.WithScheduleInfo(schedules)
+	}
+
+	addr := healthAddr(cfg)
+	stop, _ := serveHealth(ctx, logger, addr, healthHandler)
+	if updates == nil {
+		return
+	}
+
+	go runSupervised(ctx, logger, "health-endpoint", func() {
+		for {
+			select {
+			case newCfg := <-updates:
+				sysInfoProvider.update(healthRecordDir(newCfg),
+					uint64(newCfg.Monitor.DiskLowThresholdMB)*1024*1024) //#nosec G115 -- validated non-negative
+				newAddr := healthAddr(newCfg)
+				if newAddr == addr {
+					continue
+				}
+				logger.Info("health endpoint address changed, rebinding", "old", addr, "new", newAddr)
+				stop()
+				if newStop, ok := serveHealth(ctx, logger, newAddr, healthHandler); ok {
+					stop, addr = newStop, newAddr
+					continue
+				}
+				logger.Warn("keeping previous health endpoint address", "addr", addr)
+				stop, _ = serveHealth(ctx, logger, addr, healthHandler)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// healthAddr returns the configured health endpoint address or its default.
+func healthAddr(cfg *config.Config) string {
+	if cfg.Monitor.HealthAddr == "" {
+		return "127.0.0.1:9998"
+	}
+	return cfg.Monitor.HealthAddr
+}
+
+// serveHealth serves handler on addr until ctx is cancelled or the returned
+// stop function is called; stop waits for the listener to close. ok reports
+// whether the listener came up within 2 seconds.
+func serveHealth(ctx context.Context, logger *slog.Logger, addr string, handler http.Handler) (stop func(), ok bool) {
+	srvCtx, cancel := context.WithCancel(ctx)
 	healthReady := make(chan struct{})
+	done := make(chan struct{})
 	go func() {
-		if err := health.ListenAndServeReady(ctx, healthAddr, healthHandler, healthReady); err != nil {
-			logger.Warn("health endpoint error", "error", err)
+		defer close(done)
+		if err := health.ListenAndServeReady(srvCtx, addr, handler, healthReady); err != nil {
+			logger.Warn("health endpoint error", "addr", addr, "error", err)
 		}
 	}()
+	stop = func() {
+		cancel()
+		<-done
+	}
 	select {
 	case <-healthReady:
-		logger.Info("health endpoint listening", "addr", healthAddr)
+		logger.Info("health endpoint listening", "addr", addr)
+		return stop, true
 	case <-time.After(2 * time.Second):
 		logger.Warn("health endpoint did not start within 2s, continuing without health monitoring")
 	case <-ctx.Done():
 	}
+	return stop, false
 }
 
 func printUsage() {
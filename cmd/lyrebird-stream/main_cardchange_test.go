@@ -49,7 +49,7 @@ func TestRegisterNewDevicesCardNumberChange(t *testing.T) {
 	devName := audio.SanitizeDeviceName("usb_mic")
 
 	// First poll: registers usb_mic on card 1.
-	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", sup, &mu, services, hashes, cards); n != 1 {
+	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards); n != 1 {
 		t.Fatalf("first registration: got %d newly registered, want 1", n)
 	}
 	if got := cards[devName]; got != 1 {
@@ -63,7 +63,7 @@ func TestRegisterNewDevicesCardNumberChange(t *testing.T) {
 	}
 
 	// Second poll, SAME card: no-op (already registered, card unchanged).
-	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", sup, &mu, services, hashes, cards); n != 0 {
+	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards); n != 0 {
 		t.Fatalf("re-poll with unchanged card: got %d, want 0 (no re-registration)", n)
 	}
 	if sup.ServiceCount() != 1 {
@@ -72,7 +72,7 @@ func TestRegisterNewDevicesCardNumberChange(t *testing.T) {
 
 	// Device re-enumerates to card 2.
 	card = 2
-	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", sup, &mu, services, hashes, cards); n != 1 {
+	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards); n != 1 {
 		t.Fatalf("after card change: got %d newly registered, want 1 (stale stream restarted on new card)", n)
 	}
 	if got := cards[devName]; got != 2 {
@@ -68,7 +68,7 @@ func TestRegisterRemoveCyclesNoResourceLeak(t *testing.T) {
 
 	runOneCycle := func(cycle int) {
 		t.Helper()
-		if n := registerNewDevices(ctx, logger, cfg, flags, scriptPath, sup,
+		if n := registerNewDevices(ctx, logger, cfg, flags, scriptPath, nil, sup,
 			&mu, services, hashes, cards); n != 1 {
 			t.Fatalf("cycle %d: registered %d devices, want 1", cycle, n)
 		}
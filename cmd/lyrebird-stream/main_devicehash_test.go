@@ -80,6 +80,22 @@ func TestDeviceConfigHash(t *testing.T) {
 		}
 	})
 
+	t.Run("different filters produce different hash", func(t *testing.T) {
+		changed := base
+		changed.Filters = []config.FilterConfig{{Type: config.FilterGain, GainDB: 6}}
+		if deviceConfigHash(base, url, config.StreamConfig{}) == deviceConfigHash(changed, url, config.StreamConfig{}) {
+			t.Error("different filters should produce different hashes")
+		}
+	})
+
+	t.Run("different opus tuning produces different hash", func(t *testing.T) {
+		changed := base
+		changed.Opus = config.OpusConfig{Preset: "low"}
+		if deviceConfigHash(base, url, config.StreamConfig{}) == deviceConfigHash(changed, url, config.StreamConfig{}) {
+			t.Error("different opus settings should produce different hashes")
+		}
+	})
+
 	// M-2 fix: verify stream config fields affect the hash
 	t.Run("different local_record_dir produces different hash", func(t *testing.T) {
 		sc1 := config.StreamConfig{LocalRecordDir: ""}
@@ -53,7 +53,7 @@ func TestRegisterNewDevicesUnsanitizableNameStableIdentity(t *testing.T) {
 	hashes := make(map[string]string)
 	cards := make(map[string]int)
 
-	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", sup, &mu, services, hashes, cards); n != 1 {
+	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards); n != 1 {
 		t.Fatalf("first registration: got %d newly registered, want 1", n)
 	}
 
@@ -66,7 +66,7 @@ func TestRegisterNewDevicesUnsanitizableNameStableIdentity(t *testing.T) {
 	}
 
 	// A later poll of the SAME device must be a no-op, not a fresh registration.
-	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", sup, &mu, services, hashes, cards); n != 0 {
+	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards); n != 0 {
 		t.Fatalf("re-poll: got %d newly registered, want 0 (identity must be stable across polls)", n)
 	}
 	if got := sup.ServiceCount(); got != 1 {
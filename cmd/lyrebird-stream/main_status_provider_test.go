@@ -2,10 +2,18 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
 	"github.com/tomtom215/lyrebirdaudio-go/internal/health"
 	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
 )
@@ -123,6 +131,63 @@ func TestSupervisorStatusProvider_FailedService(t *testing.T) {
 	}
 }
 
+// TestSupervisorStatusProvider_DaemonServicesNotCounted verifies that the
+// always-supervised upstream monitor is not reported as a stream, so
+// /healthz fails with no streams and is idle when every device is outside
+// its operating window.
+func TestSupervisorStatusProvider_DaemonServicesNotCounted(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sup := supervisor.New(supervisor.Config{ShutdownTimeout: time.Second})
+	if _, err := setupUpstream(logger, config.DefaultConfig(), daemonFlags{LockDir: t.TempDir()}, sup, nil); err != nil {
+		t.Fatalf("setupUpstream() error = %v", err)
+	}
+	if sup.ServiceCount() != 1 {
+		t.Fatalf("ServiceCount() = %d, want the upstream monitor", sup.ServiceCount())
+	}
+
+	var mu sync.RWMutex
+	hashes := map[string]string{}
+	provider := &supervisorStatusProvider{sup: sup, registeredMu: &mu, streams: hashes}
+
+	healthz := func(h *health.Handler) (int, string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		var resp health.Response
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode /healthz: %v", err)
+		}
+		return rec.Code, resp.Status
+	}
+
+	if code, status := healthz(health.NewHandler(provider)); code != http.StatusServiceUnavailable || status != "unhealthy" {
+		t.Errorf("no streams: /healthz = %d %q, want 503 unhealthy", code, status)
+	}
+
+	schedules := newScheduleTracker()
+	schedules.replace(map[string]health.ScheduleInfo{"bird_box": {Device: "bird_box"}})
+	if code, status := healthz(health.NewHandler(provider).WithScheduleInfo(schedules)); code != http.StatusOK || status != "idle" {
+		t.Errorf("devices off schedule: /healthz = %d %q, want 200 idle", code, status)
+	}
+
+	for _, name := range []string{"bird_box", recorderServiceName("bird_box")} {
+		if err := sup.Add(&mockService{name: name}); err != nil {
+			t.Fatalf("Add(%s): %v", name, err)
+		}
+		mu.Lock()
+		hashes[name] = "hash"
+		mu.Unlock()
+	}
+	var names []string
+	for _, svc := range provider.Services(context.Background()) {
+		names = append(names, svc.Name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"bird_box", "bird_box.recorder"}) {
+		t.Errorf("Services() = %v, want the stream and its recorder only", names)
+	}
+}
+
 // TestSupervisorStatusProvider_ImplementsInterface verifies that
 // supervisorStatusProvider satisfies health.StatusProvider at compile time.
 func TestSupervisorStatusProvider_ImplementsInterface(t *testing.T) {
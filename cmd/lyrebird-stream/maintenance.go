@@ -9,11 +9,14 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"sort"
 	"syscall"
 	"time"
 
 	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/segment"
 )
 
 // sdNotify sends a state notification to systemd via NOTIFY_SOCKET.
@@ -95,12 +98,23 @@ func startWatchdog(ctx context.Context, logger *slog.Logger, healthy func(contex
 //  1. Files older than SegmentMaxAge are deleted first.
 //  2. If total remaining size exceeds SegmentMaxTotalBytes, oldest files are
 //     deleted until total size is within budget.
-func runSegmentRetention(ctx context.Context, logger *slog.Logger, streamCfg config.StreamConfig) {
+//
+// Devices that override the recording directory or retention limits get
+// their own policy over their own segments (see retentionPolicies).
+//
+// A config arriving on updates (may be nil) replaces the retention settings;
+// when they changed, a pass runs immediately so a tightened limit takes
+// effect without waiting for the next hourly tick. Passes are skipped while
+// retention is not configured (no local_record_dir, or no limit set).
+func runSegmentRetention(ctx context.Context, logger *slog.Logger, cfg *config.Config, updates <-chan *config.Config) {
 	// Run cleanup once at startup, then every hour.
 	const cleanupInterval = 1 * time.Hour
 
+	policies := retentionPolicies(cfg)
 	doCleanup := func() {
-		cleanupSegments(logger, streamCfg)
+		for _, p := range policies {
+			cleanupSegmentFiles(logger, p)
+		}
 	}
 
 	doCleanup() // initial pass
@@ -110,6 +124,22 @@ func runSegmentRetention(ctx context.Context, logger *slog.Logger, streamCfg con
 
 	for {
 		select {
+		case newCfg := <-updates:
+			old := policies
+			policies = retentionPolicies(newCfg)
+			if !reflect.DeepEqual(old, policies) {
+				for _, p := range policies {
+					logger.Info("segment retention reconfigured",
+						"dir", p.Dir,
+						"device", p.Device,
+						"max_age", p.MaxAge,
+						"max_total_bytes", p.MaxTotalBytes)
+				}
+				if len(policies) == 0 {
+					logger.Info("segment retention reconfigured", "enabled", false)
+				}
+				doCleanup()
+			}
 		case <-ticker.C:
 			doCleanup()
 		case <-ctx.Done():
@@ -118,6 +148,99 @@ func runSegmentRetention(ctx context.Context, logger *slog.Logger, streamCfg con
 	}
 }
 
+// retentionPolicy is the retention applied to the segments in one recording
+// directory: either one device's segments (Device set) or every segment not
+// claimed by a device policy for the same directory.
+type retentionPolicy struct {
+	Dir           string
+	Device        string                    // Only this device's segments ("" = all but Exclude)
+	Naming        segment.Naming            // How Device's segments are named
+	Exclude       []string                  // Devices in Dir with their own policy (Device == "")
+	Namings       map[string]segment.Naming // Naming of each Exclude device not using the default
+	MaxAge        time.Duration
+	MaxTotalBytes int64
+}
+
+// retentionPolicies derives the retention passes for cfg. The shared policy
+// uses the settings unconfigured devices get (stream:, overridden by
+// default:). A device whose own entry changes the directory or a limit gets
+// a separate policy over its segments, and is excluded from the shared one,
+// so a critical mic can keep months of audio in the same directory where a
+// test mic keeps a day. Policies without any limit are dropped.
+func retentionPolicies(cfg *config.Config) []retentionPolicy {
+	base := cfg.GetDeviceConfig("")
+	shared := retentionPolicy{
+		Dir:           base.LocalRecordDir,
+		MaxAge:        base.SegmentMaxAge,
+		MaxTotalBytes: base.SegmentMaxTotalBytes,
+	}
+
+	names := make([]string, 0, len(cfg.Devices))
+	for name := range cfg.Devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var policies []retentionPolicy
+	for _, name := range names {
+		d := cfg.GetDeviceConfig(name)
+		if !d.RecordsLocally() {
+			continue // leftovers of a stream_only device fall under the shared policy
+		}
+		if d.LocalRecordDir == shared.Dir && d.SegmentMaxAge == shared.MaxAge &&
+			d.SegmentMaxTotalBytes == shared.MaxTotalBytes {
+			continue
+		}
+		naming := cfg.SegmentNaming(d)
+		if d.LocalRecordDir == shared.Dir {
+			shared.Exclude = append(shared.Exclude, name)
+			if naming != (segment.Naming{}) {
+				if shared.Namings == nil {
+					shared.Namings = make(map[string]segment.Naming)
+				}
+				shared.Namings[name] = naming
+			}
+		}
+		policies = append(policies, retentionPolicy{
+			Dir:           d.LocalRecordDir,
+			Device:        name,
+			Naming:        naming,
+			MaxAge:        d.SegmentMaxAge,
+			MaxTotalBytes: d.SegmentMaxTotalBytes,
+		})
+	}
+	policies = append([]retentionPolicy{shared}, policies...)
+
+	enabled := policies[:0]
+	for _, p := range policies {
+		if p.Dir != "" && (p.MaxAge > 0 || p.MaxTotalBytes > 0) {
+			enabled = append(enabled, p)
+		}
+	}
+	return enabled
+}
+
+// matcher returns whether a segment file name (without directory) in p.Dir
+// is subject to p. A device's segments are recognized by its naming
+// template; files no device claims fall under the shared policy.
+func (p retentionPolicy) matcher() func(name string) bool {
+	if p.Device != "" {
+		return p.Naming.Matcher(p.Device).MatchString
+	}
+	excluded := make([]*regexp.Regexp, len(p.Exclude))
+	for i, dev := range p.Exclude {
+		excluded[i] = p.Namings[dev].Matcher(dev)
+	}
+	return func(name string) bool {
+		for _, re := range excluded {
+			if re.MatchString(name) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
 // segmentMtimeSanityFloor is the earliest modification time considered a REAL
 // wall-clock timestamp on a recording segment. Field stations (Raspberry Pi)
 // have no RTC: after a power loss the clock starts at or near the Unix epoch,
@@ -130,18 +253,29 @@ func runSegmentRetention(ctx context.Context, logger *slog.Logger, streamCfg con
 // subject to the size budget, which needs no trustworthy clock).
 var segmentMtimeSanityFloor = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
 
-// cleanupSegments performs one pass of segment file cleanup.
+// cleanupSegments performs one pass of segment file cleanup over every file
+// in streamCfg.LocalRecordDir.
 func cleanupSegments(logger *slog.Logger, streamCfg config.StreamConfig) {
-	dir := streamCfg.LocalRecordDir
+	cleanupSegmentFiles(logger, retentionPolicy{
+		Dir:           streamCfg.LocalRecordDir,
+		MaxAge:        streamCfg.SegmentMaxAge,
+		MaxTotalBytes: streamCfg.SegmentMaxTotalBytes,
+	})
+}
+
+// cleanupSegmentFiles performs one pass of segment file cleanup for p.
+func cleanupSegmentFiles(logger *slog.Logger, p retentionPolicy) {
+	dir := p.Dir
 	if dir == "" {
 		return
 	}
 
-	entries, err := os.ReadDir(dir)
+	paths, err := segment.List(dir)
 	if err != nil {
 		logger.Warn("segment retention: failed to read recording directory", "dir", dir, "error", err)
 		return
 	}
+	defer removeEmptyDayDirs(logger, dir)
 
 	now := time.Now()
 	type segFile struct {
@@ -150,17 +284,18 @@ func cleanupSegments(logger *slog.Logger, streamCfg config.StreamConfig) {
 		size    int64
 	}
 
+	matches := p.matcher()
 	var files []segFile
-	for _, e := range entries {
-		if e.IsDir() {
+	for _, path := range paths {
+		if !matches(filepath.Base(path)) {
 			continue
 		}
-		info, err := e.Info()
+		info, err := os.Stat(path)
 		if err != nil {
 			continue
 		}
 		files = append(files, segFile{
-			path:    filepath.Join(dir, e.Name()),
+			path:    path,
 			modTime: info.ModTime(),
 			size:    info.Size(),
 		})
@@ -170,8 +305,8 @@ func cleanupSegments(logger *slog.Logger, streamCfg config.StreamConfig) {
 	// sanity floor were written while the clock was unsynced (no-RTC boot);
 	// their real age is unknowable, so they are kept here and left to the
 	// size-budget step below.
-	if streamCfg.SegmentMaxAge > 0 {
-		cutoff := now.Add(-streamCfg.SegmentMaxAge)
+	if p.MaxAge > 0 {
+		cutoff := now.Add(-p.MaxAge)
 		bogus := 0
 		remaining := files[:0]
 		for _, f := range files {
@@ -198,7 +333,7 @@ func cleanupSegments(logger *slog.Logger, streamCfg config.StreamConfig) {
 	}
 
 	// Step 2: Delete oldest files until total size is within SegmentMaxTotalBytes.
-	if streamCfg.SegmentMaxTotalBytes > 0 {
+	if p.MaxTotalBytes > 0 {
 		// Sort ascending by mod time (oldest first).
 		sort.Slice(files, func(i, j int) bool {
 			return files[i].modTime.Before(files[j].modTime)
@@ -210,7 +345,7 @@ func cleanupSegments(logger *slog.Logger, streamCfg config.StreamConfig) {
 		}
 
 		for _, f := range files {
-			if totalBytes <= streamCfg.SegmentMaxTotalBytes {
+			if totalBytes <= p.MaxTotalBytes {
 				break
 			}
 			if err := os.Remove(f.path); err != nil {
@@ -221,42 +356,80 @@ func cleanupSegments(logger *slog.Logger, streamCfg config.StreamConfig) {
 				"path", f.path,
 				"freed_bytes", f.size,
 				"total_bytes_before", totalBytes,
-				"budget_bytes", streamCfg.SegmentMaxTotalBytes,
+				"budget_bytes", p.MaxTotalBytes,
 			)
 			totalBytes -= f.size
 		}
 	}
 }
 
+// removeEmptyDayDirs removes the per-day subdirectories of dir that
+// retention has emptied. Directories for the last two days are kept: the
+// recorder creates the next day's ahead of midnight, in a time zone this
+// cannot tell, and FFmpeg cannot recreate one.
+func removeEmptyDayDirs(logger *slog.Logger, dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	keepFrom := time.Now().AddDate(0, 0, -2).Format(segment.DayDirFormat)
+	for _, e := range entries {
+		// Day names sort chronologically, so a string comparison suffices.
+		if !e.IsDir() || !segment.IsDayDir(e.Name()) || e.Name() >= keepFrom {
+			continue
+		}
+		// os.Remove only removes an empty directory.
+		if err := os.Remove(filepath.Join(dir, e.Name())); err == nil {
+			logger.Info("segment retention: removed empty day directory", "dir", filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
+// recordingDirs returns the distinct local recording directories in use,
+// including per-device overrides, or "/" when nothing records locally.
+func recordingDirs(cfg *config.Config) []string {
+	seen := map[string]bool{}
+	var dirs []string
+	add := func(d config.DeviceConfig) {
+		if d.RecordsLocally() && !seen[d.LocalRecordDir] {
+			seen[d.LocalRecordDir] = true
+			dirs = append(dirs, d.LocalRecordDir)
+		}
+	}
+	add(cfg.GetDeviceConfig(""))
+	for name := range cfg.Devices {
+		add(cfg.GetDeviceConfig(name))
+	}
+	if len(dirs) == 0 {
+		return []string{"/"}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// healthRecordDir is the recording directory whose disk space /healthz
+// reports: the shared stream.local_record_dir, or else the first per-device
+// one. The disk-space monitor logs every directory (recordingDirs).
+func healthRecordDir(cfg *config.Config) string {
+	if base := cfg.GetDeviceConfig(""); base.LocalRecordDir != "" {
+		return base.LocalRecordDir
+	}
+	return recordingDirs(cfg)[0]
+}
+
 // runDiskSpaceMonitor is a goroutine that warns when free disk space drops
-// below the configured threshold (GAP-1d / A-4).
-func runDiskSpaceMonitor(ctx context.Context, logger *slog.Logger, cfg *config.Config) {
+// below the configured threshold (GAP-1d / A-4). A config arriving on updates
+// (may be nil) replaces the directory and threshold; a threshold of 0
+// disables the check until it is set again.
+func runDiskSpaceMonitor(ctx context.Context, logger *slog.Logger, cfg *config.Config, updates <-chan *config.Config) {
 	const checkInterval = 5 * time.Minute
 
 	checkDisk := func() {
-		dir := cfg.Stream.LocalRecordDir
-		if dir == "" {
-			dir = "/"
-		}
-
-		var stat syscall.Statfs_t
-		if err := syscall.Statfs(dir, &stat); err != nil {
-			logger.Warn("disk space check failed", "dir", dir, "error", err)
+		if cfg.Monitor.DiskLowThresholdMB <= 0 {
 			return
 		}
-
-		freeBytes := stat.Bavail * uint64(stat.Bsize)                          //#nosec G115
-		totalBytes := stat.Blocks * uint64(stat.Bsize)                         //#nosec G115
-		thresholdBytes := uint64(cfg.Monitor.DiskLowThresholdMB) * 1024 * 1024 //#nosec G115 -- DiskLowThresholdMB > 0 is checked before goroutine start
-
-		if freeBytes < thresholdBytes {
-			logger.Warn("LOW DISK SPACE WARNING",
-				"dir", dir,
-				"free_bytes", freeBytes,
-				"free_gb", fmt.Sprintf("%.2f", float64(freeBytes)/1e9),
-				"total_gb", fmt.Sprintf("%.2f", float64(totalBytes)/1e9),
-				"threshold_mb", cfg.Monitor.DiskLowThresholdMB,
-			)
+		for _, dir := range recordingDirs(cfg) {
+			checkDiskSpace(logger, dir, cfg.Monitor.DiskLowThresholdMB)
 		}
 	}
 
@@ -266,6 +439,9 @@ func runDiskSpaceMonitor(ctx context.Context, logger *slog.Logger, cfg *config.C
 	defer ticker.Stop()
 	for {
 		select {
+		case newCfg := <-updates:
+			cfg = newCfg
+			checkDisk()
 		case <-ticker.C:
 			checkDisk()
 		case <-ctx.Done():
@@ -273,3 +449,27 @@ func runDiskSpaceMonitor(ctx context.Context, logger *slog.Logger, cfg *config.C
 		}
 	}
 }
+
+// checkDiskSpace logs a warning when the filesystem holding dir has less
+// than thresholdMB free.
+func checkDiskSpace(logger *slog.Logger, dir string, thresholdMB int64) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		logger.Warn("disk space check failed", "dir", dir, "error", err)
+		return
+	}
+
+	freeBytes := stat.Bavail * uint64(stat.Bsize)       //#nosec G115
+	totalBytes := stat.Blocks * uint64(stat.Bsize)      //#nosec G115
+	thresholdBytes := uint64(thresholdMB) * 1024 * 1024 //#nosec G115 -- callers only pass thresholdMB > 0
+
+	if freeBytes < thresholdBytes {
+		logger.Warn("LOW DISK SPACE WARNING",
+			"dir", dir,
+			"free_bytes", freeBytes,
+			"free_gb", fmt.Sprintf("%.2f", float64(freeBytes)/1e9),
+			"total_gb", fmt.Sprintf("%.2f", float64(totalBytes)/1e9),
+			"threshold_mb", thresholdMB,
+		)
+	}
+}
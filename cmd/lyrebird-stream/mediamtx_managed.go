@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/mediamtx"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/stream"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+// managedMediaMTXConfigName is the generated mediamtx.yml written into the
+// lock (runtime) directory in managed mode. The runtime directory is always
+// writable under the systemd sandbox, unlike /etc/mediamtx.
+const managedMediaMTXConfigName = "mediamtx.yml"
+
+// managedMediaMTXServiceName is the supervisor name of the managed MediaMTX.
+// Stream names are letters, digits and underscores, so the hyphen keeps it,
+// like the upstream monitor's, from colliding with a device named mediamtx.
+const managedMediaMTXServiceName = "mediamtx-server"
+
+// startManagedMediaMTX generates a mediamtx.yml from lyrebird's config and
+// registers a supervised MediaMTX service with sup. The returned service is
+// the stream.Upstream every stream manager waits on, so stream restarts are
+// held while MediaMTX is down instead of exhausting MaxRestartAttempts.
+//
+// The server's stdout/stderr go to a rotating mediamtx.log in LogDir (or are
+// discarded when LogDir is empty), matching how FFmpeg output is handled.
+func startManagedMediaMTX(
+	logger *slog.Logger,
+	cfg *config.Config,
+	flags daemonFlags,
+	sup *supervisor.Supervisor,
+) (*supervisor.MediaMTXService, error) {
+	data, err := mediamtx.ManagedConfig(cfg.MediaMTX.APIURL, cfg.MediaMTX.RTSPURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mediamtx config: %w", err)
+	}
+	configPath := filepath.Join(flags.LockDir, managedMediaMTXConfigName)
+	// #nosec G306 -- 0640: readable by the daemon's group only; may carry API addresses
+	if err := os.WriteFile(configPath, data, 0640); err != nil {
+		return nil, fmt.Errorf("failed to write mediamtx config: %w", err)
+	}
+
+	var output io.Writer
+	if flags.LogDir != "" {
+		w, err := stream.LogWriter(flags.LogDir, "mediamtx",
+			stream.WithMaxSize(stream.DefaultMaxLogSize),
+			stream.WithMaxFiles(stream.DefaultMaxLogFiles),
+			stream.WithCompression(true),
+			stream.WithRotateLogger(logger))
+		if err != nil {
+			logger.Warn("failed to open mediamtx log, output will be discarded", "error", err)
+		} else {
+			output = w
+		}
+	}
+
+	svc, err := supervisor.NewMediaMTXService(supervisor.MediaMTXConfig{
+		Name:         managedMediaMTXServiceName,
+		BinaryPath:   cfg.MediaMTX.BinaryPath,
+		ConfigPath:   configPath,
+		Pinger:       mediamtx.NewClient(cfg.MediaMTX.APIURL),
+		ReadyTimeout: cfg.MediaMTX.ReadyTimeout,
+		StopTimeout:  cfg.Stream.StopTimeout,
+		Output:       output,
+		Logger:       logger.With("component", "mediamtx"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := sup.Add(svc); err != nil {
+		return nil, fmt.Errorf("failed to register mediamtx service: %w", err)
+	}
+	logger.Info("managed MediaMTX enabled", "binary", cfg.MediaMTX.BinaryPath, "config", configPath)
+	return svc, nil
+}
@@ -5,11 +5,14 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/health"
 	"github.com/tomtom215/lyrebirdaudio-go/internal/mediamtx"
 	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
 )
@@ -19,50 +22,56 @@ import (
 // hung MediaMTX API must not be allowed to stall recovery itself.
 const kickRecoveryTimeout = 3 * time.Second
 
-// kickStalledPathReaders tries to force-disconnect every RTSP reader session
-// attached to the given path name. It is called as a soft cleanup step right
-// before the supervisor hard-removes a stalled publisher.
+// kickStalledPathReaders tries to force-disconnect every reader session
+// attached to the given path name, across every protocol MediaMTX serves
+// (RTSP, WebRTC, RTMP, SRT). It is called as a soft cleanup step right before
+// the supervisor hard-removes a stalled publisher.
 //
 // The function is deliberately best-effort:
 //
 //   - It uses its own short-timeout context (kickRecoveryTimeout) so a wedged
 //     MediaMTX API can never stall the stall detector itself.
-//   - It filters sessions to state=="read" so the publisher (the "publish"
+//   - It filters sessions to readers so the publisher (the "publish"
 //     session) is never accidentally kicked — the caller already handles that.
+//   - HLS readers are skipped: MediaMTX exposes one muxer per path with no
+//     kick endpoint, and the muxer goes away with the publisher anyway.
 //   - ErrSessionNotFound is swallowed silently: the session raced us and is
 //     already gone, which is exactly what we wanted.
 //   - Any other per-session error is logged at debug level and iteration
 //     continues; one bad session does not prevent kicking the rest.
-//   - An overall list-sessions failure is logged and the function returns
-//     without kicking anything; the caller proceeds to the hard restart.
+//   - A list failure is logged; sessions from protocols that did list are
+//     still kicked, and the caller proceeds to the hard restart regardless.
 func kickStalledPathReaders(ctx context.Context, logger *slog.Logger, client *mediamtx.Client, pathName string) {
 	kickCtx, cancel := context.WithTimeout(ctx, kickRecoveryTimeout)
 	defer cancel()
 
-	sessions, err := client.ListRTSPSessions(kickCtx)
+	sessions, err := client.ListSessions(kickCtx)
 	if err != nil {
 		logger.Debug("stall recovery: list sessions failed", "stream", pathName, "error", err)
-		return
+		if len(sessions) == 0 {
+			return
+		}
 	}
 
 	var kicked, failed int
-	for _, s := range sessions {
-		if s.Path != pathName || s.State != "read" {
+	for i := range sessions {
+		s := &sessions[i]
+		if s.Path != pathName || !s.IsReader() || !s.Kickable() {
 			continue
 		}
-		if err := client.KickRTSPSession(kickCtx, s.ID); err != nil {
+		if err := client.KickSession(kickCtx, *s); err != nil {
 			if errors.Is(err, mediamtx.ErrSessionNotFound) {
 				// Session disappeared between list and kick — fine.
 				continue
 			}
 			failed++
 			logger.Debug("stall recovery: kick session failed",
-				"stream", pathName, "session_id", s.ID, "remote", s.RemoteAddr, "error", err)
+				"stream", pathName, "protocol", s.Protocol, "session_id", s.ID, "remote", s.RemoteAddr, "error", err)
 			continue
 		}
 		kicked++
 		logger.Info("stall recovery: kicked reader session",
-			"stream", pathName, "session_id", s.ID, "remote", s.RemoteAddr)
+			"stream", pathName, "protocol", s.Protocol, "session_id", s.ID, "remote", s.RemoteAddr)
 	}
 	if kicked > 0 || failed > 0 {
 		logger.Info("stall recovery: session cleanup complete",
@@ -72,6 +81,7 @@ func kickStalledPathReaders(ctx context.Context, logger *slog.Logger, client *me
 
 // startDevicePoller starts the device polling goroutine that periodically scans
 // for newly plugged-in USB devices and registers them with the supervisor.
+// It is the fallback for when uevent hotplug is unavailable (see watchDevices).
 //
 // M-4 fix: the poll runs unconditionally on every tick, not only when the
 // service count is zero. This is the correct hotplug support.
@@ -89,17 +99,9 @@ func startDevicePoller(
 	for {
 		select {
 		case <-ticker.C:
-			var pollCfg *config.Config
-			if koanfCfg != nil {
-				// C-3 guard: koanfCfg can be nil when NewKoanfConfig failed.
-				var loadErr error
-				pollCfg, loadErr = koanfCfg.Load()
-				if loadErr != nil {
-					logger.Warn("failed to load config for device scan", "error", loadErr)
-					continue
-				}
-			} else {
-				pollCfg = fallbackCfg
+			pollCfg, ok := loadScanConfig(logger, koanfCfg, fallbackCfg)
+			if !ok {
+				continue
 			}
 			n := registerDevices(pollCfg)
 			if n > 0 {
@@ -113,6 +115,13 @@ func startDevicePoller(
 
 // startReloadHandler starts the SIGHUP reload goroutine that reloads
 // configuration and restarts streams whose config has changed (M-6 fix).
+//
+// A successful reload is published on broadcast so the background subsystems
+// (stall detector, failed-stream recovery, retention, disk monitor, health
+// endpoint) pick up their new settings, and its outcome — changed keys,
+// restarted streams, keys that need a daemon restart, or the error that kept
+// the previous config in force — is logged as a config_reload event and
+// recorded in tracker for /healthz. broadcast and tracker may be nil.
 func startReloadHandler(
 	ctx context.Context,
 	logger *slog.Logger,
@@ -123,11 +132,18 @@ func startReloadHandler(
 	registeredServices map[string]bool,
 	registeredConfigHashes map[string]string,
 	registerDevices func(cfg *config.Config) int,
+	broadcast *configBroadcast,
+	tracker *reloadTracker,
 ) {
+	fail := func(msg string, err error) {
+		logger.Warn(msg, "event", "config_reload", "success", false, "error", err)
+		tracker.record(health.ReloadInfo{Success: false, Error: fmt.Sprintf("%s: %v", msg, err)})
+	}
+
 	for {
 		select {
 		case <-reloadCh:
-			logger.Info("received SIGHUP, reloading configuration")
+			logger.Info("reload requested, reloading configuration")
 
 			// C-3 guard: koanfCfg can be nil when loadConfigurationKoanf
 			// fell back to defaults and returned a nil KoanfConfig.
@@ -137,14 +153,15 @@ func startReloadHandler(
 			}
 
 			if err := koanfCfg.Reload(); err != nil {
-				logger.Warn("failed to reload configuration", "error", err)
+				fail("failed to reload configuration", err)
 				continue
 			}
 			logger.Info("configuration reloaded successfully")
+			logUnknownConfigKeys(logger, koanfCfg)
 
 			newCfg, err := koanfCfg.Load()
 			if err != nil {
-				logger.Warn("failed to load updated config", "error", err)
+				fail("failed to load updated config", err)
 				continue
 			}
 
@@ -156,10 +173,10 @@ func startReloadHandler(
 			}
 			registeredMu.RUnlock()
 
+			var restarted []string
 			for _, devName := range names {
 				newDevCfg := newCfg.GetDeviceConfig(devName)
-				newRTSPURL := newCfg.MediaMTX.RTSPURL + "/" + devName
-				newHash := deviceConfigHash(newDevCfg, newRTSPURL, newCfg.Stream)
+				newHash := streamConfigHash(newCfg, devName)
 
 				registeredMu.RLock()
 				oldHash := registeredConfigHashes[devName]
@@ -186,12 +203,38 @@ func startReloadHandler(
 				delete(registeredServices, devName)
 				delete(registeredConfigHashes, devName)
 				registeredMu.Unlock()
+				restarted = append(restarted, devName)
 			}
 
 			n := registerDevices(newCfg)
 			if n > 0 {
 				logger.Info("registered new/restarted devices on reload", "count", n)
 			}
+
+			var changed []string
+			managed := newCfg.MediaMTX.Managed
+			if broadcast != nil {
+				changed = config.Diff(broadcast.Current(), newCfg)
+				managed = managed || broadcast.Current().MediaMTX.Managed
+				broadcast.Publish(newCfg)
+			}
+			needRestart := restartRequired(changed, managed)
+			sort.Strings(restarted)
+			logger.Info("configuration reload applied",
+				"event", "config_reload",
+				"success", true,
+				"changed", changed,
+				"restarted_streams", restarted,
+				"restart_required", needRestart)
+			if len(needRestart) > 0 {
+				logger.Warn("some changed settings only take effect after a daemon restart", "keys", needRestart)
+			}
+			tracker.record(health.ReloadInfo{
+				Success:          true,
+				Changed:          changed,
+				RestartedStreams: restarted,
+				RestartRequired:  needRestart,
+			})
 		case <-ctx.Done():
 			return
 		}
@@ -199,7 +242,9 @@ func startReloadHandler(
 }
 
 // startFailedStreamRecovery starts a goroutine that periodically clears failed
-// stream registrations so the device polling loop can re-register them (P-3 fix).
+// stream registrations so the next device scan can re-register them (P-3 fix).
+// A config arriving on updates (may be nil) re-times the loop to its
+// monitor.interval.
 func startFailedStreamRecovery(
 	ctx context.Context,
 	logger *slog.Logger,
@@ -208,6 +253,7 @@ func startFailedStreamRecovery(
 	registeredMu *sync.RWMutex,
 	registeredServices map[string]bool,
 	registeredConfigHashes map[string]string,
+	updates <-chan *config.Config,
 ) {
 	if recoveryInterval <= 0 {
 		recoveryInterval = 5 * time.Minute
@@ -217,12 +263,47 @@ func startFailedStreamRecovery(
 
 	for {
 		select {
+		case newCfg := <-updates:
+			interval := newCfg.Monitor.Interval
+			if interval <= 0 {
+				interval = 5 * time.Minute
+			}
+			if interval != recoveryInterval {
+				logger.Info("failed-stream recovery interval changed", "old", recoveryInterval, "new", interval)
+				recoveryInterval = interval
+				ticker.Reset(interval)
+			}
 		case <-ticker.C:
 			statuses := sup.Status()
 			for _, status := range statuses {
 				if status.State != supervisor.ServiceStateFailed {
 					continue
 				}
+				// Only device streams are cleared for re-registration. Other
+				// supervised services (the managed MediaMTX server) are
+				// restarted by the supervisor itself and would never be
+				// re-added by the device poller if removed here.
+				registeredMu.RLock()
+				isStream := registeredServices[status.Name]
+				registeredMu.RUnlock()
+				if dev, ok := recorderDevice(status.Name); ok {
+					// A recorder is only (re)started with its publisher,
+					// so a failed one is cleared together with it.
+					logger.Info("attempting recovery of failed recorder", "device", dev, "restarts", status.Restarts)
+					if !stopRecorder(logger, sup, dev, registeredMu, registeredConfigHashes) {
+						continue
+					}
+					registeredMu.RLock()
+					isStream = registeredServices[dev]
+					registeredMu.RUnlock()
+					if !isStream {
+						continue
+					}
+					status.Name = dev
+				}
+				if !isStream {
+					continue
+				}
 				logger.Info("attempting recovery of failed stream", "device", status.Name, "restarts", status.Restarts)
 				if removeErr := sup.Remove(status.Name); removeErr != nil {
 					logger.Warn("failed to remove failed service for recovery", "device", status.Name, "error", removeErr)
@@ -245,6 +326,16 @@ func startFailedStreamRecovery(
 //
 // H-2 fix: Uses separate StallCheckInterval (default 60s) instead of the
 // general monitor interval (5 min).
+//
+// A config arriving on updates (may be nil) is applied in place: a new
+// mediamtx.api_url gets a new client, a new stall_check_interval resets the
+// ticker, and monitor.enabled=false pauses checking (clearing stall state)
+// until it is re-enabled.
+//
+// Each MediaMTX path a device publishes is checked on its own: the device's
+// and one per rendition. A stall on any of them restarts the device's one
+// FFmpeg process, which feeds them all. After each check the rendition stall
+// counts are handed to renditions (may be nil) for /healthz.
 func startStallDetector(
 	ctx context.Context,
 	logger *slog.Logger,
@@ -253,12 +344,17 @@ func startStallDetector(
 	registeredMu *sync.RWMutex,
 	registeredServices map[string]bool,
 	registeredConfigHashes map[string]string,
+	updates <-chan *config.Config,
+	renditions *renditionHealth,
 ) {
-	mtxClient := mediamtx.NewClient(cfg.MediaMTX.APIURL)
-	checkInterval := cfg.Monitor.StallCheckInterval
-	if checkInterval <= 0 {
-		checkInterval = 60 * time.Second
+	stallCheckInterval := func(c *config.Config) time.Duration {
+		if c.Monitor.StallCheckInterval <= 0 {
+			return 60 * time.Second
+		}
+		return c.Monitor.StallCheckInterval
 	}
+	mtxClient := mediamtx.NewClient(cfg.MediaMTX.APIURL)
+	checkInterval := stallCheckInterval(cfg)
 	ticker := time.NewTicker(checkInterval)
 	defer ticker.Stop()
 
@@ -271,6 +367,32 @@ func startStallDetector(
 
 	for {
 		select {
+		case newCfg := <-updates:
+			if newCfg.MediaMTX.APIURL != cfg.MediaMTX.APIURL {
+				mtxClient = mediamtx.NewClient(newCfg.MediaMTX.APIURL)
+				// Byte counters from another server are meaningless here.
+				clear(prevBytes)
+				clear(stallCount)
+			}
+			if interval := stallCheckInterval(newCfg); interval != checkInterval {
+				checkInterval = interval
+				ticker.Reset(interval)
+			}
+			maxStallChecks = newCfg.Monitor.MaxStallChecks
+			if maxStallChecks <= 0 {
+				maxStallChecks = 3
+			}
+			if !newCfg.Monitor.Enabled {
+				clear(prevBytes)
+				clear(stallCount)
+			}
+			cfg = newCfg
+			logger.Info("stall detector reconfigured",
+				"enabled", cfg.Monitor.Enabled,
+				"interval", checkInterval,
+				"max_stall_checks", maxStallChecks,
+				"api_url", cfg.MediaMTX.APIURL)
+
 		case <-ticker.C:
 			registeredMu.RLock()
 			names := make([]string, 0, len(registeredServices))
@@ -279,13 +401,29 @@ func startStallDetector(
 			}
 			registeredMu.RUnlock()
 
+			// record_only devices publish nothing, so MediaMTX has no path
+			// to watch and every check would look like a stall.
+			published := make(map[string][]string, len(names))
+			for _, name := range names {
+				if paths := publishedPaths(cfg.GetDeviceConfig(name), name); len(paths) > 0 {
+					published[name] = paths
+				}
+			}
+			if !cfg.Monitor.Enabled {
+				renditions.update(published, nil)
+				continue
+			}
+
 			// Prune stall state for devices removed elsewhere (SIGHUP reload,
-			// failed-stream recovery). Without this, a stale prevBytes/stallCount
-			// carried into a re-registered device triggers a spurious restart or
-			// bogus "stalled" warnings right after a reload.
+			// failed-stream recovery) and renditions no longer configured.
+			// Without this, a stale prevBytes/stallCount carried into a
+			// re-registered device triggers a spurious restart or bogus
+			// "stalled" warnings right after a reload.
 			live := make(map[string]struct{}, len(names))
-			for _, n := range names {
-				live[n] = struct{}{}
+			for _, paths := range published {
+				for _, p := range paths {
+					live[p] = struct{}{}
+				}
 			}
 			for n := range stallCount {
 				if _, ok := live[n]; !ok {
@@ -295,32 +433,45 @@ func startStallDetector(
 			}
 
 			for _, name := range names {
-				stats, err := mtxClient.GetStreamStats(ctx, name)
-				if err != nil {
-					logger.Debug("stream health check failed", "stream", name, "error", err)
+				paths, ok := published[name]
+				if !ok {
 					continue
 				}
+				devCfg := cfg.GetDeviceConfig(name)
+				limit := maxStallChecks
+				if devCfg.MaxStallChecks > 0 {
+					limit = devCfg.MaxStallChecks // per-device override
+				}
+
+				for _, path := range paths {
+					stats, err := mtxClient.GetStreamStats(ctx, path)
+					if err != nil {
+						logger.Debug("stream health check failed", "stream", path, "error", err)
+						continue
+					}
 
-				if stats.Ready && stats.BytesReceived > 0 {
-					// Only a byte counter that did NOT advance since the last check
-					// is a stall. A DECREASE means the publisher reconnected (a new
-					// RTSP session resets the counter) — a restart, not a stall — so
-					// it resets the count rather than driving toward a restart.
-					if prev, ok := prevBytes[name]; ok && stats.BytesReceived == prev {
-						stallCount[name]++
-						logger.Warn("stream data stalled", "stream", name, "bytes", stats.BytesReceived, "stall_count", stallCount[name])
+					if stats.Ready && stats.BytesReceived > 0 {
+						// Only a byte counter that did NOT advance since the last check
+						// is a stall. A DECREASE means the publisher reconnected (a new
+						// RTSP session resets the counter) — a restart, not a stall — so
+						// it resets the count rather than driving toward a restart.
+						if prev, ok := prevBytes[path]; ok && stats.BytesReceived == prev {
+							stallCount[path]++
+							logger.Warn("stream data stalled", "stream", path, "bytes", stats.BytesReceived, "stall_count", stallCount[path])
+						} else {
+							stallCount[path] = 0
+						}
+						prevBytes[path] = stats.BytesReceived
 					} else {
-						stallCount[name] = 0
+						stallCount[path]++
+						logger.Warn("stream not ready or no data", "stream", path, "ready", stats.Ready, "bytes", stats.BytesReceived, "stall_count", stallCount[path])
 					}
-					prevBytes[name] = stats.BytesReceived
-				} else {
-					stallCount[name]++
-					logger.Warn("stream not ready or no data", "stream", name, "ready", stats.Ready, "bytes", stats.BytesReceived, "stall_count", stallCount[name])
-				}
 
-				if cfg.Monitor.RestartUnhealthy && stallCount[name] >= maxStallChecks {
-					logger.Warn("restarting stalled stream", "stream", name, "stall_count", stallCount[name])
-					// Belt-and-suspenders cleanup: kick any lingering RTSP
+					if !cfg.Monitor.RestartUnhealthy || stallCount[path] < limit {
+						continue
+					}
+					logger.Warn("restarting stalled stream", "stream", path, "device", name, "stall_count", stallCount[path])
+					// Belt-and-suspenders cleanup: kick any lingering
 					// reader sessions attached to this stalled path before
 					// removing the publisher. In most cases MediaMTX will
 					// tear down readers itself when the publisher exits,
@@ -329,20 +480,25 @@ func startStallDetector(
 					// in the "stuck reader back-pressuring the publisher"
 					// case. Failures here are non-fatal: we still proceed
 					// to the hard restart below.
-					kickStalledPathReaders(ctx, logger, mtxClient, name)
+					kickStalledPathReaders(ctx, logger, mtxClient, path)
 
 					if removeErr := sup.Remove(name); removeErr != nil {
 						logger.Warn("failed to remove stalled service", "stream", name, "error", removeErr)
-						continue
+						break
 					}
 					registeredMu.Lock()
 					delete(registeredServices, name)
 					delete(registeredConfigHashes, name)
 					registeredMu.Unlock()
-					delete(stallCount, name)
-					delete(prevBytes, name)
+					// The restart resets every path the process feeds.
+					for _, p := range paths {
+						delete(stallCount, p)
+						delete(prevBytes, p)
+					}
+					break
 				}
 			}
+			renditions.update(published, stallCount)
 		case <-ctx.Done():
 			return
 		}
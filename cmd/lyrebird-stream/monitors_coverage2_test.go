@@ -56,7 +56,7 @@ func TestStartReloadHandlerLoadErrorPath(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices)
+		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices, nil, nil)
 		close(done)
 	}()
 
@@ -107,7 +107,7 @@ func TestStartReloadHandlerRemoveErrorPath(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices)
+		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices, nil, nil)
 		close(done)
 	}()
 
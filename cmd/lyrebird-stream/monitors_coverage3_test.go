@@ -50,7 +50,7 @@ func TestStartStallDetectorDefaultMaxStallChecks(t *testing.T) {
 
 	// startStallDetector executes `maxStallChecks = 3` before entering the
 	// for loop, then exits immediately via ctx.Done().
-	startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes)
+	startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes, nil, nil)
 	// Reaching here without panic means the default-assignment branch executed.
 }
 
@@ -31,7 +31,7 @@ func TestStartFailedStreamRecoveryNegativeInterval(t *testing.T) {
 	hashes := map[string]string{}
 
 	// Negative interval also triggers the `recoveryInterval = 5 * time.Minute` branch.
-	startFailedStreamRecovery(ctx, logger, -1, sup, &mu, services, hashes)
+	startFailedStreamRecovery(ctx, logger, -1, sup, &mu, services, hashes, nil)
 }
 
 // TestStartFailedStreamRecoveryRunningServiceSkips covers monitors.go:165-167 —
@@ -91,7 +91,7 @@ func TestStartFailedStreamRecoveryRunningServiceSkips(t *testing.T) {
 	done := make(chan struct{})
 	go func() {
 		// Short interval so the ticker fires quickly and the continue branch executes.
-		startFailedStreamRecovery(ctx, logger, 50*time.Millisecond, sup, &mu, services, hashes)
+		startFailedStreamRecovery(ctx, logger, 50*time.Millisecond, sup, &mu, services, hashes, nil)
 		close(done)
 	}()
 
@@ -167,7 +167,7 @@ failed:
 
 	done := make(chan struct{})
 	go func() {
-		startFailedStreamRecovery(ctx, logger, 30*time.Millisecond, sup, &mu, services, hashes)
+		startFailedStreamRecovery(ctx, logger, 30*time.Millisecond, sup, &mu, services, hashes, nil)
 		close(done)
 	}()
 
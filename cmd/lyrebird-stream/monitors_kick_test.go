@@ -271,3 +271,52 @@ func TestKickStalledPathReaders_RespectsTimeout(t *testing.T) {
 		t.Fatalf("kickStalledPathReaders did not return within %v", kickRecoveryTimeout+2*time.Second)
 	}
 }
+
+// TestKickStalledPathReaders_KicksNonRTSPReaders verifies that WebRTC and SRT
+// readers on the stalled path are kicked through their own endpoints, while
+// HLS muxers (no kick endpoint) are left alone.
+func TestKickStalledPathReaders_KicksNonRTSPReaders(t *testing.T) {
+	const webrtcID = "55555555-aaaa-bbbb-cccc-dddddddddddd"
+	const srtID = "66666666-aaaa-bbbb-cccc-dddddddddddd"
+
+	var (
+		mu    sync.Mutex
+		kicks []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/rtspsessions/list", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"items":[]}`)
+	})
+	mux.HandleFunc("/v3/webrtcsessions/list", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"items":[{"id":"`+webrtcID+`","state":"read","path":"mic1"}]}`)
+	})
+	mux.HandleFunc("/v3/srtconns/list", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"items":[{"id":"`+srtID+`","state":"read","path":"mic1"}]}`)
+	})
+	mux.HandleFunc("/v3/hlsmuxers/list", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"items":[{"path":"mic1"}]}`)
+	})
+	record := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		kicks = append(kicks, r.URL.Path)
+		mu.Unlock()
+	}
+	mux.HandleFunc("/v3/webrtcsessions/kick/", record)
+	mux.HandleFunc("/v3/srtconns/kick/", record)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	logger, buf := newTestLogger()
+	kickStalledPathReaders(context.Background(), logger, mediamtx.NewClient(server.URL), "mic1")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(kicks) != 2 ||
+		kicks[0] != "/v3/webrtcsessions/kick/"+webrtcID ||
+		kicks[1] != "/v3/srtconns/kick/"+srtID {
+		t.Errorf("kicks = %v, want webrtc then srt reader", kicks)
+	}
+	if !strings.Contains(buf.String(), "protocol=webrtc") {
+		t.Errorf("expected protocol in kick log, got: %s", buf.String())
+	}
+}
@@ -46,7 +46,7 @@ func TestStartFailedStreamRecoveryWithFailedService(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startFailedStreamRecovery(ctx, logger, 50*time.Millisecond, sup, &mu, services, hashes)
+		startFailedStreamRecovery(ctx, logger, 50*time.Millisecond, sup, &mu, services, hashes, nil)
 		close(done)
 	}()
 
@@ -46,7 +46,7 @@ func TestStartReloadHandlerReloadError(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices)
+		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices, nil, nil)
 		close(done)
 	}()
 
@@ -99,7 +99,7 @@ func TestStartReloadHandlerDeviceConfigUnchanged(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices)
+		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices, nil, nil)
 		close(done)
 	}()
 
@@ -163,7 +163,7 @@ func TestStartReloadHandlerDeviceConfigChanged(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices)
+		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices, nil, nil)
 		close(done)
 	}()
 
@@ -229,7 +229,7 @@ func TestStartReloadHandlerRegistersNewDevicesOnReload(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices)
+		startReloadHandler(ctx, logger, reloadCh, koanfCfg, sup, &mu, services, hashes, registerDevices, nil, nil)
 		close(done)
 	}()
 
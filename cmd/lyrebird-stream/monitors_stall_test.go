@@ -78,7 +78,7 @@ func TestStartStallDetectorDataStalled(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes)
+		startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes, nil, nil)
 		close(done)
 	}()
 
@@ -137,7 +137,7 @@ func TestStartStallDetectorStreamNotReady(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes)
+		startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes, nil, nil)
 		close(done)
 	}()
 
@@ -201,7 +201,7 @@ func TestStartStallDetectorHealthyStream(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startStallDetector(ctx, logger, cfg, sup, &statsMu, services, hashes)
+		startStallDetector(ctx, logger, cfg, sup, &statsMu, services, hashes, nil, nil)
 		close(done)
 	}()
 
@@ -88,7 +88,7 @@ func TestStartReloadHandlerContextCancellation(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startReloadHandler(ctx, logger, reloadCh, nil, sup, &mu, services, hashes, registerDevices)
+		startReloadHandler(ctx, logger, reloadCh, nil, sup, &mu, services, hashes, registerDevices, nil, nil)
 		close(done)
 	}()
 
@@ -118,7 +118,7 @@ func TestStartReloadHandlerNilKoanfConfig(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startReloadHandler(ctx, logger, reloadCh, nil, sup, &mu, services, hashes, registerDevices)
+		startReloadHandler(ctx, logger, reloadCh, nil, sup, &mu, services, hashes, registerDevices, nil, nil)
 		close(done)
 	}()
 
@@ -149,7 +149,7 @@ func TestStartFailedStreamRecoveryContextCancellation(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startFailedStreamRecovery(ctx, logger, time.Second, sup, &mu, services, hashes)
+		startFailedStreamRecovery(ctx, logger, time.Second, sup, &mu, services, hashes, nil)
 		close(done)
 	}()
 
@@ -175,7 +175,7 @@ func TestStartFailedStreamRecoveryDefaultInterval(t *testing.T) {
 	done := make(chan struct{})
 	go func() {
 		// Pass 0 to use default (5 min)
-		startFailedStreamRecovery(ctx, logger, 0, sup, &mu, services, hashes)
+		startFailedStreamRecovery(ctx, logger, 0, sup, &mu, services, hashes, nil)
 		close(done)
 	}()
 
@@ -204,7 +204,7 @@ func TestStartStallDetectorContextCancellation(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes)
+		startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes, nil, nil)
 		close(done)
 	}()
 
@@ -232,7 +232,7 @@ func TestStartStallDetectorDefaultInterval(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes)
+		startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes, nil, nil)
 		close(done)
 	}()
 
@@ -264,7 +264,7 @@ func TestStartStallDetectorWithRegisteredDevices(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes)
+		startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes, nil, nil)
 		close(done)
 	}()
 
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+// recorderSuffix names the record-only service of a device with recorder:
+// separate. Stream names are sanitized to [A-Za-z0-9_], so
+// "<device>.recorder" never collides with a stream.
+//
+// A recorder is registered in registeredConfigHashes only, never in
+// registeredServices: the stall detector, the reload diff and failed-stream
+// recovery walk registeredServices, so restarting a publisher leaves its
+// recorder (and the recording) running. startDeviceStream brings the recorder
+// in line with the config each time it registers the publisher, and
+// unregisterRemovedDevice and the scheduler stop it with the publisher.
+const recorderSuffix = ".recorder"
+
+// jackClientNameMax is JACK's client name limit (64 bytes with the NUL).
+const jackClientNameMax = 63
+
+func recorderServiceName(devName string) string {
+	return devName + recorderSuffix
+}
+
+// recorderDevice returns the device of recorder service name, or false if
+// name is not a recorder.
+func recorderDevice(name string) (string, bool) {
+	return strings.CutSuffix(name, recorderSuffix)
+}
+
+// sharedInput returns in as opened by two processes at once. An ALSA hw:
+// device admits one capture stream, so the publisher and recorder both open
+// the card through the dsnoop plugin; sound servers, network pulls and test
+// signals need no change.
+func sharedInput(in deviceInput) deviceInput {
+	if in.format == "" && in.network == nil && in.test == nil && in.card >= 0 {
+		in.device = fmt.Sprintf("dsnoop:CARD=%d,DEV=0", in.card)
+	}
+	return in
+}
+
+// recorderInput returns the recorder's copy of the publisher input in. JACK
+// clients need distinct names, so the recorder's gets a "_rec" suffix.
+func recorderInput(in deviceInput) deviceInput {
+	in = sharedInput(in)
+	if in.format == "jack" {
+		in.device = in.device[:min(len(in.device), jackClientNameMax-len("_rec"))] + "_rec"
+	}
+	return in
+}
+
+// recorderConfigHash is streamConfigHash without the RTSP URL and with the
+// recorder's input, so a reload that only touches publishing keeps the
+// recorder running while a new card number restarts it.
+func recorderConfigHash(cfg *config.Config, devName string, in deviceInput) string {
+	return inputConfigHash(cfg, devName, "") + "/" + in.device
+}
+
+// syncRecorder starts, restarts or stops the recorder of devName so that it
+// matches cfg: it runs when the device records separately, and restarts only
+// when its own settings or input changed.
+func syncRecorder(
+	logger *slog.Logger,
+	cfg *config.Config,
+	devName string,
+	in deviceInput,
+	flags daemonFlags,
+	ffmpegPath string,
+	sup *supervisor.Supervisor,
+	registeredMu *sync.RWMutex,
+	registeredConfigHashes map[string]string,
+) {
+	name := recorderServiceName(devName)
+	devCfg := cfg.GetDeviceConfig(devName)
+	registeredMu.RLock()
+	oldHash, running := registeredConfigHashes[name]
+	registeredMu.RUnlock()
+
+	if !devCfg.RecordsSeparately() {
+		if running {
+			stopRecorder(logger, sup, devName, registeredMu, registeredConfigHashes)
+		}
+		return
+	}
+	rec := recorderInput(in)
+	hash := recorderConfigHash(cfg, devName, rec)
+	if running {
+		if oldHash == hash {
+			return
+		}
+		logger.Info("recorder settings changed, restarting recorder", "device", devName)
+		if !stopRecorder(logger, sup, devName, registeredMu, registeredConfigHashes) {
+			return
+		}
+	}
+
+	mgrCfg := managerConfig(logger, cfg, devCfg, devName, rec, flags, ffmpegPath)
+	// The lock is per manager, while segments keep the stream's name.
+	mgrCfg.DeviceName = name
+	mgrCfg.RecordOnly = true
+	mgrCfg.LocalRecordDir = devCfg.LocalRecordDir
+	mgrCfg.Logger = logger.With("component", "recorder", "device", devName)
+	if !addStreamService(logger, sup, name, mgrCfg) {
+		return
+	}
+	registeredMu.Lock()
+	registeredConfigHashes[name] = hash
+	registeredMu.Unlock()
+	logger.Info("registered recorder", "device", devName, "input", rec.device,
+		"local_record_dir", devCfg.LocalRecordDir)
+}
+
+// stopRecorder stops the recorder of devName, if one is registered, and
+// reports whether none is left running.
+func stopRecorder(
+	logger *slog.Logger,
+	sup *supervisor.Supervisor,
+	devName string,
+	registeredMu *sync.RWMutex,
+	registeredConfigHashes map[string]string,
+) bool {
+	name := recorderServiceName(devName)
+	registeredMu.RLock()
+	_, running := registeredConfigHashes[name]
+	registeredMu.RUnlock()
+	if !running {
+		return true
+	}
+	if err := sup.Remove(name); err != nil {
+		logger.Warn("failed to stop recorder", "device", devName, "error", err)
+		return false
+	}
+	registeredMu.Lock()
+	delete(registeredConfigHashes, name)
+	registeredMu.Unlock()
+	logger.Info("recorder stopped", "device", devName)
+	return true
+}
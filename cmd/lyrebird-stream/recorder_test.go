@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/stream"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+func TestRecorderInput(t *testing.T) {
+	alsa := deviceInput{device: "hw:2,0", card: 2}
+	if got := sharedInput(alsa).device; got != "dsnoop:CARD=2,DEV=0" {
+		t.Errorf("sharedInput(alsa) = %q, want dsnoop:CARD=2,DEV=0", got)
+	}
+	if got := recorderInput(alsa).device; got != "dsnoop:CARD=2,DEV=0" {
+		t.Errorf("recorderInput(alsa) = %q, want dsnoop:CARD=2,DEV=0", got)
+	}
+
+	pulse := deviceInput{format: "pulse", device: "alsa_input.usb-mic", card: -1}
+	if got := recorderInput(pulse).device; got != pulse.device {
+		t.Errorf("recorderInput(pulse) = %q, want the same source", got)
+	}
+	test := deviceInput{test: &stream.TestSignal{}, card: -1}
+	if got := recorderInput(test); got.device != "" || got.test == nil {
+		t.Errorf("recorderInput(test) = %+v, want the signal unchanged", got)
+	}
+
+	jack := deviceInput{format: "jack", device: "lyrebird_mixer", card: -1}
+	if got := recorderInput(jack).device; got != "lyrebird_mixer_rec" {
+		t.Errorf("recorderInput(jack) = %q, want lyrebird_mixer_rec", got)
+	}
+	jack.device = strings.Repeat("x", jackClientNameMax)
+	if got := recorderInput(jack).device; len(got) != jackClientNameMax || !strings.HasSuffix(got, "_rec") {
+		t.Errorf("recorderInput(long jack) = %q, want %d bytes ending in _rec", got, jackClientNameMax)
+	}
+
+	if dev, ok := recorderDevice(recorderServiceName("blue_yeti")); !ok || dev != "blue_yeti" {
+		t.Errorf("recorderDevice = %q, %v; want blue_yeti", dev, ok)
+	}
+	if _, ok := recorderDevice("blue_yeti"); ok {
+		t.Error("a stream name is not a recorder")
+	}
+}
+
+// TestSeparateRecorderLifecycle verifies that a device with recorder:
+// separate gets a recorder next to its publisher, that restarting the
+// publisher (stall recovery, a reload that only changes publishing) leaves
+// the recorder running, and that the recorder goes with its device.
+func TestSeparateRecorderLifecycle(t *testing.T) {
+	origDetect := detectAudioDevices
+	t.Cleanup(func() { detectAudioDevices = origDetect })
+	detectAudioDevices = func(string) ([]*audio.Device, error) { return nil, nil }
+
+	ctx := context.Background()
+	var logBuf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logBuf, nil))
+	cfg := config.DefaultConfig()
+	cfg.Stream.USBStabilizationDelay = 0
+	cfg.Stream.DeviceRemovalGrace = 0
+	cfg.Stream.LocalRecordDir = t.TempDir()
+	cfg.Stream.SegmentFormat = "ogg"
+	cfg.TestSources = map[string]config.TestSourceConfig{"lyrebird_test": {Signal: "beep"}}
+	cfg.Devices = map[string]config.DeviceConfig{"lyrebird_test": {Recorder: config.RecorderSeparate}}
+
+	flags := daemonFlags{LockDir: t.TempDir()}
+	sup := supervisor.New(supervisor.Config{})
+	var mu sync.RWMutex
+	services := make(map[string]bool)
+	hashes := make(map[string]string)
+	cards := make(map[string]int)
+	register := func(cfg *config.Config) int {
+		return registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards)
+	}
+	supervised := func() []string {
+		var names []string
+		for _, s := range sup.Status() {
+			names = append(names, s.Name)
+		}
+		slices.Sort(names)
+		return names
+	}
+	// restartPublisher clears the publisher the way the stall detector and
+	// the reload handler do.
+	restartPublisher := func() {
+		t.Helper()
+		if err := sup.Remove("lyrebird_test"); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		delete(services, "lyrebird_test")
+		delete(hashes, "lyrebird_test")
+		mu.Unlock()
+	}
+
+	if n := register(cfg); n != 1 {
+		t.Fatalf("registered %d streams, want 1", n)
+	}
+	if got, want := supervised(), []string{"lyrebird_test", "lyrebird_test.recorder"}; !slices.Equal(got, want) {
+		t.Fatalf("supervised services = %v, want %v", got, want)
+	}
+	if services[recorderServiceName("lyrebird_test")] {
+		t.Error("the recorder must not be a registered stream, or the stall detector would restart it")
+	}
+
+	// A publisher restart keeps the recorder.
+	restartPublisher()
+	if n := register(cfg); n != 1 {
+		t.Fatalf("re-registered %d streams, want 1", n)
+	}
+	if got := strings.Count(logBuf.String(), "registered recorder"); got != 1 {
+		t.Errorf("recorder registered %d times across a publisher restart, want 1", got)
+	}
+
+	// Publishing settings do not touch the recording; recording settings do.
+	in := configuredInputs(cfg)["lyrebird_test"]
+	rtsp := *cfg
+	rtsp.MediaMTX.RTSPURL = "rtsp://10.0.0.2:8554"
+	if recorderConfigHash(cfg, "lyrebird_test", in) != recorderConfigHash(&rtsp, "lyrebird_test", in) {
+		t.Error("a new RTSP URL should not restart the recorder")
+	}
+	segments := *cfg
+	segments.Stream.SegmentDuration = 600
+	if recorderConfigHash(cfg, "lyrebird_test", in) == recorderConfigHash(&segments, "lyrebird_test", in) {
+		t.Error("a new segment duration should restart the recorder")
+	}
+	restartPublisher()
+	register(&segments)
+	if !strings.Contains(logBuf.String(), "recorder settings changed, restarting recorder") {
+		t.Error("expected the recorder to restart for a new segment duration")
+	}
+
+	// Back to the tee recorder: the recorder stops.
+	tee := segments
+	tee.Devices = map[string]config.DeviceConfig{"lyrebird_test": {Recorder: config.RecorderTee}}
+	restartPublisher()
+	register(&tee)
+	if got, want := supervised(), []string{"lyrebird_test"}; !slices.Equal(got, want) {
+		t.Errorf("supervised services with the tee recorder = %v, want %v", got, want)
+	}
+
+	// The recorder goes with its device.
+	restartPublisher()
+	register(&segments)
+	none := segments
+	none.TestSources = nil
+	if removed := removeVanishedDevices(logger, &none, time.Now(), newVanishedDevices(), sup,
+		&mu, services, hashes, cards); !slices.Equal(removed, []string{"lyrebird_test"}) {
+		t.Errorf("removed %v, want [lyrebird_test]", removed)
+	}
+	if got := supervised(); len(got) != 0 || len(hashes) != 0 {
+		t.Errorf("after removal: services %v, hashes %v; want none", got, hashes)
+	}
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/health"
+)
+
+// configBroadcast fans a reloaded configuration out to the daemon's
+// background subsystems.
+//
+// Each subsystem subscribes once at startup and reconfigures itself when a
+// new *config.Config arrives (restarting its ticker, rebinding its listener).
+// Subscriber channels hold one value and a publish replaces any config the
+// subscriber has not consumed yet, so a slow subsystem only ever sees the
+// latest configuration and Publish never blocks the reload handler.
+type configBroadcast struct {
+	mu      sync.Mutex
+	current *config.Config
+	subs    []chan *config.Config
+}
+
+func newConfigBroadcast(initial *config.Config) *configBroadcast {
+	return &configBroadcast{current: initial}
+}
+
+// Current returns the most recently published configuration.
+func (b *configBroadcast) Current() *config.Config {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.current
+}
+
+// Subscribe returns a channel that receives every configuration published
+// after the call.
+func (b *configBroadcast) Subscribe() <-chan *config.Config {
+	ch := make(chan *config.Config, 1)
+	b.mu.Lock()
+	b.subs = append(b.subs, ch)
+	b.mu.Unlock()
+	return ch
+}
+
+// Publish makes cfg current and delivers it to every subscriber.
+func (b *configBroadcast) Publish(cfg *config.Config) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.current = cfg
+	for _, ch := range b.subs {
+		// Latest wins: drop a config the subscriber has not picked up yet.
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- cfg
+	}
+}
+
+// restartRequiredPrefixes are config keys the running daemon cannot apply;
+// a reload that changes them is reported as needing a daemon restart.
+var restartRequiredPrefixes = []string{
+	"mediamtx.managed",
+	"mediamtx.binary_path",
+	"mediamtx.ready_timeout",
+}
+
+// managedRestartRequiredPrefixes are further such keys in managed mode: the
+// managed MediaMTX listens where its generated mediamtx.yml, written once at
+// startup, says.
+var managedRestartRequiredPrefixes = []string{
+	"mediamtx.api_url",
+	"mediamtx.rtsp_url",
+}
+
+// restartRequired returns the keys in changed that only take effect after a
+// daemon restart. managed reports whether MediaMTX is managed before or
+// after the reload.
+func restartRequired(changed []string, managed bool) []string {
+	prefixes := restartRequiredPrefixes
+	if managed {
+		prefixes = slices.Concat(restartRequiredPrefixes, managedRestartRequiredPrefixes)
+	}
+	var out []string
+	for _, k := range changed {
+		for _, p := range prefixes {
+			if k == p || strings.HasPrefix(k, p+".") {
+				out = append(out, k)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// reloadTracker records the outcome of the last configuration reload for
+// /healthz. It implements health.ReloadInfoProvider.
+type reloadTracker struct {
+	mu   sync.Mutex
+	last *health.ReloadInfo
+}
+
+// LastReload implements health.ReloadInfoProvider.
+func (t *reloadTracker) LastReload() *health.ReloadInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.last == nil {
+		return nil
+	}
+	ri := *t.last
+	return &ri
+}
+
+func (t *reloadTracker) record(ri health.ReloadInfo) {
+	if t == nil {
+		return
+	}
+	if ri.Time.IsZero() {
+		ri.Time = time.Now()
+	}
+	t.mu.Lock()
+	t.last = &ri
+	t.mu.Unlock()
+}
@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/health"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+func TestConfigBroadcastLatestWins(t *testing.T) {
+	initial := config.DefaultConfig()
+	b := newConfigBroadcast(initial)
+	if b.Current() != initial {
+		t.Fatal("Current() should return the initial config")
+	}
+
+	a, c := b.Subscribe(), b.Subscribe()
+	first, second := config.DefaultConfig(), config.DefaultConfig()
+	b.Publish(first)
+	b.Publish(second) // neither subscriber has consumed first yet
+
+	for i, ch := range []<-chan *config.Config{a, c} {
+		select {
+		case got := <-ch:
+			if got != second {
+				t.Errorf("subscriber %d got a stale config", i)
+			}
+		default:
+			t.Errorf("subscriber %d received nothing", i)
+		}
+		select {
+		case <-ch:
+			t.Errorf("subscriber %d received more than the latest config", i)
+		default:
+		}
+	}
+	if b.Current() != second {
+		t.Error("Current() should return the last published config")
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	changed := []string{
+		"monitor.interval",
+		"mediamtx.managed",
+		"mediamtx.binary_path",
+		"mediamtx.rtsp_url",
+		"mediamtx.api_url",
+		"mediamtx.managed_extra", // prefix match must respect key boundaries
+	}
+	got := restartRequired(changed, false)
+	want := []string{"mediamtx.managed", "mediamtx.binary_path"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("restartRequired() = %v, want %v", got, want)
+	}
+
+	// The managed MediaMTX was configured with its URLs at startup.
+	got = restartRequired(changed, true)
+	want = []string{"mediamtx.managed", "mediamtx.binary_path", "mediamtx.rtsp_url", "mediamtx.api_url"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("restartRequired(managed) = %v, want %v", got, want)
+	}
+}
+
+func TestReloadTracker(t *testing.T) {
+	var nilTracker *reloadTracker
+	nilTracker.record(health.ReloadInfo{Success: true}) // must not panic
+
+	tr := &reloadTracker{}
+	if tr.LastReload() != nil {
+		t.Error("LastReload() before any reload should be nil")
+	}
+	tr.record(health.ReloadInfo{Success: false, Error: "boom"})
+	got := tr.LastReload()
+	if got == nil || got.Success || got.Error != "boom" || got.Time.IsZero() {
+		t.Fatalf("LastReload() = %+v", got)
+	}
+	got.Error = "mutated"
+	if tr.LastReload().Error != "boom" {
+		t.Error("LastReload() must return a copy")
+	}
+}
+
+// TestStartReloadHandlerPublishesAndRecords verifies that a successful
+// reload publishes the new config, reports the changed keys and records the
+// outcome for /healthz.
+func TestStartReloadHandlerPublishesAndRecords(t *testing.T) {
+	cfgPath := t.TempDir() + "/config.yaml"
+	writeTestConfig(t, cfgPath, minimalConfig())
+	koanfCfg, cfg, err := loadConfigurationKoanf(cfgPath)
+	if err != nil || koanfCfg == nil {
+		t.Fatalf("loadConfigurationKoanf: err=%v", err)
+	}
+
+	writeTestConfig(t, cfgPath, minimalConfig()+"monitor:\n  interval: 7s\n")
+
+	var sb syncBuffer
+	logger := slog.New(slog.NewTextHandler(&sb, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broadcast := newConfigBroadcast(cfg)
+	updates := broadcast.Subscribe()
+	tracker := &reloadTracker{}
+	reloadCh := make(chan struct{}, 1)
+	var mu sync.RWMutex
+	go startReloadHandler(ctx, logger, reloadCh, koanfCfg, supervisor.New(supervisor.Config{}),
+		&mu, map[string]bool{}, map[string]string{}, func(*config.Config) int { return 0 },
+		broadcast, tracker)
+
+	reloadCh <- struct{}{}
+	select {
+	case got := <-updates:
+		if got.Monitor.Interval != 7*time.Second {
+			t.Errorf("published monitor.interval = %v, want 7s", got.Monitor.Interval)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reloaded config was not published")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for tracker.LastReload() == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	ri := tracker.LastReload()
+	if ri == nil || !ri.Success {
+		t.Fatalf("LastReload() = %+v, want success", ri)
+	}
+	if fmt.Sprint(ri.Changed) != "[monitor.interval]" {
+		t.Errorf("Changed = %v, want [monitor.interval]", ri.Changed)
+	}
+	if !sb.Contains("event=config_reload") {
+		t.Errorf("missing structured config_reload event:\n%s", sb.String())
+	}
+}
+
+// TestStartHealthEndpointRebind verifies that a reload changing
+// monitor.health_addr moves the listener, and that an unbindable address
+// keeps the previous one.
+func TestStartHealthEndpointRebind(t *testing.T) {
+	freeAddr := func() string {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("net.Listen: %v", err)
+		}
+		defer func() { _ = ln.Close() }()
+		return ln.Addr().String()
+	}
+	reachable := func(addr string) bool {
+		resp, err := http.Get("http://" + addr + "/healthz")
+		if err != nil {
+			return false
+		}
+		_ = resp.Body.Close()
+		return true
+	}
+	waitReachable := func(addr string, want bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for reachable(addr) != want && time.Now().Before(deadline) {
+			time.Sleep(20 * time.Millisecond)
+		}
+		if reachable(addr) != want {
+			t.Fatalf("reachable(%s) = %v, want %v", addr, !want, want)
+		}
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := config.DefaultConfig()
+	cfg.Monitor.HealthAddr = freeAddr()
+	updates := make(chan *config.Config, 1)
+	startHealthEndpoint(ctx, logger, cfg, supervisor.New(supervisor.Config{}), nil, nil, updates, &reloadTracker{}, nil, nil)
+	waitReachable(cfg.Monitor.HealthAddr, true)
+
+	moved := config.DefaultConfig()
+	moved.Monitor.HealthAddr = freeAddr()
+	updates <- moved
+	waitReachable(moved.Monitor.HealthAddr, true)
+	waitReachable(cfg.Monitor.HealthAddr, false)
+
+	// Occupy the next address so the rebind fails.
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	defer func() { _ = busy.Close() }()
+	blocked := config.DefaultConfig()
+	blocked.Monitor.HealthAddr = busy.Addr().String()
+	updates <- blocked
+	time.Sleep(2500 * time.Millisecond) // serveHealth waits up to 2s for the failed bind
+	waitReachable(moved.Monitor.HealthAddr, true)
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/health"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/stream"
+)
+
+// streamRenditions returns the further encodings the publisher of device
+// devName sends to MediaMTX, or nil when it has none or publishes nothing.
+func streamRenditions(cfg *config.Config, devCfg config.DeviceConfig, devName string) []stream.Rendition {
+	if !devCfg.PublishesRTSP() {
+		return nil
+	}
+	var out []stream.Rendition
+	for _, r := range devCfg.ResolvedRenditions() {
+		name := r.StreamName(devName)
+		out = append(out, stream.Rendition{
+			StreamName: name,
+			RTSPURL:    fmt.Sprintf("%s/%s", cfg.MediaMTX.RTSPURL, name),
+			SampleRate: r.SampleRate,
+			Channels:   r.Channels,
+			Bitrate:    r.Bitrate,
+			Codec:      r.Codec,
+			Opus:       r.Opus.Settings(),
+		})
+	}
+	return out
+}
+
+// publishedPaths returns the MediaMTX paths the publisher of device devName
+// feeds: its own and one per rendition. A device that publishes nothing
+// feeds none.
+func publishedPaths(devCfg config.DeviceConfig, devName string) []string {
+	if !devCfg.PublishesRTSP() {
+		return nil
+	}
+	paths := []string{devName}
+	for _, r := range devCfg.Renditions {
+		paths = append(paths, r.StreamName(devName))
+	}
+	return paths
+}
+
+// renditionHealth is the stall detector's view of each device's renditions,
+// which supervisorStatusProvider reports in /healthz next to the device's
+// own service. A rendition shares its device's FFmpeg process, and so its
+// state, uptime and restarts, but has its own stall count.
+type renditionHealth struct {
+	mu     sync.Mutex
+	paths  map[string][]string // device -> rendition paths
+	stalls map[string]int      // path -> consecutive failed stall checks
+}
+
+// update replaces the tracked renditions with those of the registered
+// devices in paths (device -> published paths, its own first) and their
+// current stall counts. It is a no-op on a nil receiver.
+func (h *renditionHealth) update(paths map[string][]string, stalls map[string]int) {
+	if h == nil {
+		return
+	}
+	renditions := make(map[string][]string, len(paths))
+	for dev, p := range paths {
+		if len(p) > 1 {
+			renditions[dev] = p[1:]
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.paths = renditions
+	h.stalls = maps.Clone(stalls)
+}
+
+// services returns the health entries of the renditions of the device whose
+// service is svc.
+func (h *renditionHealth) services(svc health.ServiceInfo) []health.ServiceInfo {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var out []health.ServiceInfo
+	for _, path := range h.paths[svc.Name] {
+		info := svc
+		info.Name = path
+		if n := h.stalls[path]; n > 0 {
+			info.Healthy = false
+			info.Error = fmt.Sprintf("not ready or no data in MediaMTX for %d stall check(s)", n)
+		}
+		out = append(out, info)
+	}
+	return out
+}
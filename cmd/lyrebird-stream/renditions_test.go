@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/health"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+func renditionTestConfig() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Devices["bird_box"] = config.DeviceConfig{
+		Renditions: []config.RenditionConfig{{Suffix: "_lo", Bitrate: "24k", Channels: 1}},
+	}
+	return cfg
+}
+
+func TestStreamRenditions(t *testing.T) {
+	cfg := renditionTestConfig()
+	devCfg := cfg.GetDeviceConfig("bird_box")
+
+	got := streamRenditions(cfg, devCfg, "bird_box")
+	if len(got) != 1 {
+		t.Fatalf("streamRenditions = %+v, want one", got)
+	}
+	r := got[0]
+	if r.StreamName != "bird_box_lo" || r.RTSPURL != cfg.MediaMTX.RTSPURL+"/bird_box_lo" {
+		t.Errorf("rendition path = %q, %q", r.StreamName, r.RTSPURL)
+	}
+	if r.Codec != devCfg.Codec || r.SampleRate != devCfg.SampleRate || r.Bitrate != "24k" || r.Channels != 1 {
+		t.Errorf("rendition = %+v, want the device's codec and rate at 24k mono", r)
+	}
+	if paths := publishedPaths(devCfg, "bird_box"); strings.Join(paths, ",") != "bird_box,bird_box_lo" {
+		t.Errorf("publishedPaths = %v", paths)
+	}
+
+	devCfg.Mode = config.DeviceModeRecordOnly
+	if got := streamRenditions(cfg, devCfg, "bird_box"); got != nil {
+		t.Errorf("a record_only device publishes no renditions, got %+v", got)
+	}
+	if paths := publishedPaths(devCfg, "bird_box"); paths != nil {
+		t.Errorf("a record_only device publishes no paths, got %v", paths)
+	}
+}
+
+// TestStreamConfigHashRenditions verifies that a rendition change restarts
+// the publisher but not a separate recorder.
+func TestStreamConfigHashRenditions(t *testing.T) {
+	cfg := renditionTestConfig()
+	stream, recorder := streamConfigHash(cfg, "bird_box"), recorderConfigHash(cfg, "bird_box", deviceInput{device: "hw:1,0"})
+
+	dev := cfg.Devices["bird_box"]
+	dev.Renditions = []config.RenditionConfig{{Suffix: "_lo", Bitrate: "32k", Channels: 1}}
+	cfg.Devices["bird_box"] = dev
+	if streamConfigHash(cfg, "bird_box") == stream {
+		t.Error("a changed rendition bitrate should change the stream hash")
+	}
+	if recorderConfigHash(cfg, "bird_box", deviceInput{device: "hw:1,0"}) != recorder {
+		t.Error("a changed rendition should not change the recorder hash")
+	}
+}
+
+// TestStartStallDetectorRenditionStall verifies that a rendition whose path
+// stalls restarts its device's process even while the main path is healthy,
+// and that the rendition reports its stall in /healthz until then.
+func TestStartStallDetectorRenditionStall(t *testing.T) {
+	var logBuf syncBuffer
+	logger := slog.New(slog.NewTextHandler(&logBuf, nil))
+
+	var mainBytes atomic.Int64
+	fakeServer := newFakeMediaMTXServer(t, func(name string) mediamtxPathResponse {
+		if name == "bird_box_lo" {
+			return mediamtxPathResponse{Name: name, Ready: true, BytesReceived: 500} // never advances
+		}
+		return mediamtxPathResponse{Name: name, Ready: true, BytesReceived: mainBytes.Add(1000)}
+	})
+	defer fakeServer.Close()
+
+	sup := supervisor.New(supervisor.Config{ShutdownTimeout: 2 * time.Second})
+	if err := sup.Add(&mockService{name: "bird_box"}); err != nil {
+		t.Fatalf("sup.Add: %v", err)
+	}
+	supCtx, supCancel := context.WithCancel(context.Background())
+	defer supCancel()
+	go func() { _ = sup.Run(supCtx) }()
+	time.Sleep(50 * time.Millisecond)
+
+	cfg := renditionTestConfig()
+	cfg.MediaMTX.APIURL = fakeServer.URL
+	cfg.Monitor.Enabled = true
+	cfg.Monitor.StallCheckInterval = 50 * time.Millisecond
+	cfg.Monitor.MaxStallChecks = 3
+	cfg.Monitor.RestartUnhealthy = true
+
+	var mu sync.RWMutex
+	services := map[string]bool{"bird_box": true}
+	hashes := map[string]string{"bird_box": "hash"}
+	renditions := &renditionHealth{}
+	provider := &supervisorStatusProvider{sup: sup, renditions: renditions}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		startStallDetector(ctx, logger, cfg, sup, &mu, services, hashes, nil, renditions)
+		close(done)
+	}()
+
+	// Tick 1 records the byte counts, tick 2 counts the first stall.
+	var stalled bool
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline) && !stalled; time.Sleep(10 * time.Millisecond) {
+		for _, svc := range provider.Services(ctx) {
+			if svc.Name == "bird_box_lo" && !svc.Healthy && strings.Contains(svc.Error, "stall check") {
+				stalled = true
+			}
+		}
+	}
+	if !stalled {
+		t.Errorf("a stalled rendition should be unhealthy in /healthz: %+v", provider.Services(ctx))
+	}
+
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		mu.RLock()
+		registered := services["bird_box"]
+		mu.RUnlock()
+		if !registered {
+			break
+		}
+	}
+	cancel()
+	<-done
+
+	mu.RLock()
+	defer mu.RUnlock()
+	if services["bird_box"] {
+		t.Error("a stalled rendition should restart its device's stream")
+	}
+	if !logBuf.Contains("stream=bird_box_lo device=bird_box") {
+		t.Errorf("expected the restart to name the stalled rendition, log:\n%s", logBuf.String())
+	}
+}
+
+func TestRenditionHealthServices(t *testing.T) {
+	h := &renditionHealth{}
+	h.update(map[string][]string{"bird_box": {"bird_box", "bird_box_lo"}, "plain": {"plain"}},
+		map[string]int{"bird_box": 2})
+	parent := health.ServiceInfo{Name: "bird_box", State: "running", Healthy: true, Restarts: 1}
+
+	got := h.services(parent)
+	if len(got) != 1 || got[0].Name != "bird_box_lo" || !got[0].Healthy || got[0].Restarts != 1 {
+		t.Errorf("services = %+v, want a healthy bird_box_lo sharing the device's state", got)
+	}
+	if got := h.services(health.ServiceInfo{Name: "plain"}); got != nil {
+		t.Errorf("a device without renditions has no extra entries, got %+v", got)
+	}
+
+	var nilHealth *renditionHealth
+	nilHealth.update(nil, nil) // must not panic
+}
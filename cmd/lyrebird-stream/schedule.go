@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/health"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+// scheduleCheckInterval is how often runScheduler re-evaluates the device
+// schedules. Window edges are therefore honoured to within this interval.
+const scheduleCheckInterval = 15 * time.Second
+
+// clockJumpThreshold is how far the wall clock may move against the
+// monotonic clock between two checks before it is logged as a clock jump.
+const clockJumpThreshold = time.Minute
+
+// minPlausibleClock is the earliest wall-clock time schedules trust. A
+// Raspberry Pi without an RTC boots at the epoch (or the last fake-hwclock
+// save) until NTP syncs; evaluating windows against that time would stop
+// streams that should be running, so schedules fail open until then.
+var minPlausibleClock = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// scheduleAllows reports whether devCfg's operating windows include now.
+// Devices without a schedule, and any device while the clock is not yet
+// plausible, are always allowed.
+func scheduleAllows(devCfg config.DeviceConfig, now time.Time) bool {
+	if now.Before(minPlausibleClock) {
+		return true
+	}
+	sched, err := devCfg.Schedule.Compile()
+	if err != nil {
+		return true // rejected by Validate at load; never stop a stream over it
+	}
+	return sched.Active(now)
+}
+
+// scheduleTracker holds the last evaluated schedule state of each scheduled
+// device. It implements health.ScheduleInfoProvider.
+type scheduleTracker struct {
+	mu    sync.Mutex
+	infos map[string]health.ScheduleInfo
+}
+
+func newScheduleTracker() *scheduleTracker {
+	return &scheduleTracker{infos: make(map[string]health.ScheduleInfo)}
+}
+
+// Schedules returns the tracked states sorted by device name.
+func (t *scheduleTracker) Schedules() []health.ScheduleInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]health.ScheduleInfo, 0, len(t.infos))
+	for _, info := range t.infos {
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Device < out[j].Device })
+	return out
+}
+
+// replace swaps in infos and returns the previous states.
+func (t *scheduleTracker) replace(infos map[string]health.ScheduleInfo) map[string]health.ScheduleInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev := t.infos
+	t.infos = infos
+	return prev
+}
+
+// runScheduler stops streams when their device leaves its operating window
+// and registers devices as soon as one opens, re-evaluating every
+// scheduleCheckInterval against the live config.
+//
+// Windows are re-evaluated against the current wall clock on every check
+// rather than by arming a timer for the next edge, so a clock step (NTP
+// syncing an RTC-less Pi, a manual date change) simply takes effect at the
+// next check: a step into a window starts the stream, a step out stops it.
+// Steps are logged as event=clock_jump.
+func runScheduler(
+	ctx context.Context,
+	logger *slog.Logger,
+	tracker *scheduleTracker,
+	current func() *config.Config,
+	registerDevices func(cfg *config.Config) int,
+	sup *supervisor.Supervisor,
+	registeredMu *sync.RWMutex,
+	registeredServices map[string]bool,
+	registeredConfigHashes map[string]string,
+	registeredCardNumbers map[string]int,
+) {
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		// Sub with monotonic readings is immune to clock steps; stripping
+		// them (Round(0)) compares wall clocks.
+		if jump := now.Round(0).Sub(last.Round(0)) - now.Sub(last); jump.Abs() > clockJumpThreshold {
+			logger.Warn("system clock jumped, re-evaluating schedules",
+				"event", "clock_jump", "offset", jump.Round(time.Second))
+		}
+		last = now
+
+		cfg := current()
+		_, opened := applySchedules(logger, cfg, now, tracker, sup,
+			registeredMu, registeredServices, registeredConfigHashes, registeredCardNumbers)
+		if opened {
+			registerDevices(cfg)
+		}
+	}
+}
+
+// applySchedules evaluates the schedule of every detected or registered
+// device at now, records the result in tracker, and stops the streams of
+// devices outside their windows. It returns the stopped streams, and whether
+// any window opened since the previous evaluation.
+func applySchedules(
+	logger *slog.Logger,
+	cfg *config.Config,
+	now time.Time,
+	tracker *scheduleTracker,
+	sup *supervisor.Supervisor,
+	registeredMu *sync.RWMutex,
+	registeredServices map[string]bool,
+	registeredConfigHashes map[string]string,
+	registeredCardNumbers map[string]int,
+) (stopped []string, opened bool) {
+	names := make(map[string]bool)
+	if devices, err := detectAudioDevices("/proc/asound"); err == nil {
+		for _, dev := range devices {
+			if name, ignored := deviceStreamName(cfg, dev); !ignored {
+				names[name] = true
+			}
+		}
+	}
+	for name := range configuredInputs(cfg) {
+		names[name] = true
+	}
+	registeredMu.RLock()
+	for name := range registeredServices {
+		names[name] = true
+	}
+	registeredMu.RUnlock()
+
+	infos := make(map[string]health.ScheduleInfo)
+	for name := range names {
+		devCfg := cfg.GetDeviceConfig(name)
+		sched, err := devCfg.Schedule.Compile()
+		if err != nil || sched == nil {
+			continue
+		}
+		info := health.ScheduleInfo{Device: name, Active: scheduleAllows(devCfg, now)}
+		if next := sched.NextChange(now); !next.IsZero() && !now.Before(minPlausibleClock) {
+			info.NextChange = &next
+		}
+		infos[name] = info
+	}
+	prev := tracker.replace(infos)
+
+	for name, info := range infos {
+		if p, seen := prev[name]; !seen || p.Active != info.Active {
+			attrs := []any{"event", "schedule_window", "device", name, "active", info.Active}
+			if info.NextChange != nil {
+				attrs = append(attrs, "next_change", info.NextChange.Format(time.RFC3339))
+			}
+			logger.Info("device schedule window", attrs...)
+			if seen && info.Active {
+				opened = true
+			}
+		}
+		if info.Active {
+			continue
+		}
+
+		registeredMu.RLock()
+		running := registeredServices[name]
+		registeredMu.RUnlock()
+		if !running {
+			continue
+		}
+		if err := sup.Remove(name); err != nil {
+			logger.Warn("failed to stop stream outside its schedule; will retry",
+				"device", name, "error", err)
+			continue
+		}
+		registeredMu.Lock()
+		delete(registeredServices, name)
+		delete(registeredConfigHashes, name)
+		delete(registeredCardNumbers, name)
+		registeredMu.Unlock()
+		stopRecorder(logger, sup, name, registeredMu, registeredConfigHashes)
+		logger.Info("device outside its operating window, stream stopped",
+			"event", "schedule_stop", "device", name)
+		stopped = append(stopped, name)
+	}
+	return stopped, opened
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+// dawnChorusConfig returns a config whose "birds" device runs 04:00-09:00.
+func dawnChorusConfig() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Stream.USBStabilizationDelay = 0
+	cfg.Devices = map[string]config.DeviceConfig{
+		"birds": {Schedule: config.ScheduleConfig{
+			Windows: []config.ScheduleWindow{{Start: "04:00", End: "09:00"}},
+		}},
+	}
+	return cfg
+}
+
+func TestScheduleAllows(t *testing.T) {
+	devCfg := dawnChorusConfig().GetDeviceConfig("birds")
+	day := time.Date(2026, time.May, 4, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"inside window", day.Add(5 * time.Hour), true},
+		{"before window", day.Add(3 * time.Hour), false},
+		{"at window end", day.Add(9 * time.Hour), false},
+		// An RTC-less Pi before NTP sync: fail open rather than stop streams.
+		{"implausible clock", time.Date(1970, time.January, 1, 12, 0, 0, 0, time.Local), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scheduleAllows(devCfg, tt.now); got != tt.want {
+				t.Errorf("scheduleAllows(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+
+	if !scheduleAllows(config.DeviceConfig{}, day.Add(3*time.Hour)) {
+		t.Error("device without schedule not allowed")
+	}
+}
+
+// TestRegisterNewDevicesOutsideSchedule verifies that a device outside its
+// operating window is not started.
+func TestRegisterNewDevicesOutsideSchedule(t *testing.T) {
+	origDetect := detectAudioDevices
+	t.Cleanup(func() { detectAudioDevices = origDetect })
+	detectAudioDevices = func(string) ([]*audio.Device, error) {
+		return []*audio.Device{{Name: "birds", CardNumber: 1}, {Name: "always", CardNumber: 2}}, nil
+	}
+
+	// A window on a day two days from now never includes the present.
+	other := strings.ToLower(time.Now().AddDate(0, 0, 2).Weekday().String()[:3])
+	cfg := config.DefaultConfig()
+	cfg.Stream.USBStabilizationDelay = 0
+	cfg.Devices = map[string]config.DeviceConfig{
+		"birds": {Schedule: config.ScheduleConfig{
+			Windows: []config.ScheduleWindow{{Start: "00:00", End: "00:01", Days: []string{other}}},
+		}},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	sup := supervisor.New(supervisor.Config{})
+	var mu sync.RWMutex
+	services := make(map[string]bool)
+	n := registerNewDevices(context.Background(), logger, cfg, daemonFlags{LockDir: t.TempDir()}, "/fake/ffmpeg",
+		nil, sup, &mu, services, make(map[string]string), make(map[string]int))
+	if n != 1 || !services["always"] || services["birds"] {
+		t.Fatalf("registered %d %v, want only always", n, keysOf(services))
+	}
+}
+
+// TestApplySchedules verifies that a stream is stopped when its window
+// closes, and that the window opening again is reported so the caller
+// re-registers the device.
+func TestApplySchedules(t *testing.T) {
+	origDetect := detectAudioDevices
+	t.Cleanup(func() { detectAudioDevices = origDetect })
+	detectAudioDevices = func(string) ([]*audio.Device, error) {
+		return []*audio.Device{{Name: "birds", CardNumber: 1}}, nil
+	}
+
+	cfg := dawnChorusConfig()
+	var logBuf syncBuffer
+	logger := slog.New(slog.NewTextHandler(&logBuf, nil))
+	sup := supervisor.New(supervisor.Config{})
+	if err := sup.Add(&mockService{name: "birds"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.RWMutex
+	services := map[string]bool{"birds": true}
+	hashes := map[string]string{"birds": "h"}
+	cards := map[string]int{"birds": 1}
+	tracker := newScheduleTracker()
+	day := time.Date(2026, time.May, 4, 0, 0, 0, 0, time.Local)
+
+	// Inside the window: nothing stopped, state recorded.
+	stopped, opened := applySchedules(logger, cfg, day.Add(8*time.Hour), tracker, sup, &mu, services, hashes, cards)
+	if len(stopped) != 0 || opened {
+		t.Fatalf("inside window: stopped=%v opened=%v", stopped, opened)
+	}
+	infos := tracker.Schedules()
+	if len(infos) != 1 || !infos[0].Active || infos[0].NextChange == nil || !infos[0].NextChange.Equal(day.Add(9*time.Hour)) {
+		t.Fatalf("schedules = %+v", infos)
+	}
+
+	// The window closes (or the clock steps past it): the stream stops.
+	stopped, _ = applySchedules(logger, cfg, day.Add(10*time.Hour), tracker, sup, &mu, services, hashes, cards)
+	if len(stopped) != 1 || services["birds"] || hashes["birds"] != "" {
+		t.Fatalf("after window: stopped=%v services=%v", stopped, keysOf(services))
+	}
+	if !logBuf.Contains("event=schedule_stop") {
+		t.Errorf("missing schedule_stop event in log:\n%s", logBuf.String())
+	}
+
+	// Next morning the window opens again.
+	_, opened = applySchedules(logger, cfg, day.Add(28*time.Hour), tracker, sup, &mu, services, hashes, cards)
+	if !opened {
+		t.Error("window opening not reported")
+	}
+}
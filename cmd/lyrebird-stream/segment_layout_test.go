@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/segment"
+)
+
+// TestCleanupSegmentsDailyLayout verifies that retention finds segments in
+// per-day subdirectories, attributes templated names to their device, and
+// removes the day directories it empties.
+func TestCleanupSegmentsDailyLayout(t *testing.T) {
+	dir := t.TempDir()
+	logger := slog.New(slog.DiscardHandler)
+	cfg := config.DefaultConfig()
+	cfg.Timecode.HostID = "north site"
+	cfg.Stream.LocalRecordDir = dir
+	cfg.Stream.SegmentMaxAge = 7 * 24 * time.Hour
+	cfg.Stream.SegmentName = "{station}_{stream}_{iso}"
+	cfg.Stream.SegmentTimezone = config.SegmentTimezoneUTC
+	cfg.Stream.SegmentLayout = config.SegmentLayoutDaily
+	cfg.Devices["bird_box"] = config.DeviceConfig{SegmentMaxAge: 90 * 24 * time.Hour}
+	cfg.Devices["legacy"] = config.DeviceConfig{SegmentName: segment.DefaultTemplate, SegmentMaxAge: 90 * 24 * time.Hour}
+
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	oldDay := old.UTC().Format(segment.DayDirFormat)
+	today := time.Now().UTC().Format(segment.DayDirFormat)
+	files := map[string]bool{ // path -> should survive
+		oldDay + "/north_site_bird_box_20260101T000000Z.ogg": true,  // 90-day policy
+		oldDay + "/north_site_plain_20260101T000000Z.ogg":    false, // shared 7-day policy
+		"legacy_20260101_000000.ogg":                         true,  // 90-day policy, default naming, flat
+		"north_site_legacy_20260101T000000Z.ogg":             false, // not legacy's naming: shared policy
+	}
+	for name := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("audio"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	emptyOld := filepath.Join(dir, "2020-01-01")
+	emptyToday := filepath.Join(dir, today)
+	for _, d := range []string{emptyOld, emptyToday} {
+		if err := os.MkdirAll(d, 0o750); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, p := range retentionPolicies(cfg) {
+		cleanupSegmentFiles(logger, p)
+	}
+
+	for name, keep := range files {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != keep {
+			t.Errorf("%s exists = %v, want %v", name, exists, keep)
+		}
+	}
+	if _, err := os.Stat(emptyOld); !os.IsNotExist(err) {
+		t.Errorf("an old empty day directory should be removed (err = %v)", err)
+	}
+	if _, err := os.Stat(emptyToday); err != nil {
+		t.Errorf("today's day directory must be kept for FFmpeg: %v", err)
+	}
+}
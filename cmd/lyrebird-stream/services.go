@@ -50,15 +50,28 @@ func (s *streamService) Run(ctx context.Context) error {
 // supervisorStatusProvider implements health.StatusProvider by querying the
 // supervisor for live service state. This replaces the nil provider that was
 // previously passed to health.NewHandler (P-4 fix).
+// Each rendition (see renditionHealth) is listed after its device.
+//
+// When registeredMu is set, only the services named in streams (the
+// daemon's registeredConfigHashes: device streams and their recorders) are
+// reported. The daemon's own services, the upstream monitor or the managed
+// MediaMTX, are always supervised, and counting them would keep /healthz
+// from reporting that no stream runs, or that all devices are idle.
 type supervisorStatusProvider struct {
-	sup *supervisor.Supervisor
+	sup          *supervisor.Supervisor
+	renditions   *renditionHealth // may be nil
+	registeredMu *sync.RWMutex    // may be nil: report every service
+	streams      map[string]string
 }
 
 func (p *supervisorStatusProvider) Services(context.Context) []health.ServiceInfo {
 	statuses := p.sup.Status()
-	services := make([]health.ServiceInfo, len(statuses))
-	for i, s := range statuses {
-		services[i] = health.ServiceInfo{
+	services := make([]health.ServiceInfo, 0, len(statuses))
+	for _, s := range statuses {
+		if !p.isStream(s.Name) {
+			continue
+		}
+		info := health.ServiceInfo{
 			Name:     s.Name,
 			State:    s.State.String(),
 			Uptime:   s.Uptime,
@@ -66,12 +79,26 @@ func (p *supervisorStatusProvider) Services(context.Context) []health.ServiceInf
 			Restarts: s.Restarts,
 		}
 		if s.LastError != nil {
-			services[i].Error = s.LastError.Error()
+			info.Error = s.LastError.Error()
+		}
+		services = append(services, info)
+		if p.renditions != nil {
+			services = append(services, p.renditions.services(info)...)
 		}
 	}
 	return services
 }
 
+func (p *supervisorStatusProvider) isStream(name string) bool {
+	if p.registeredMu == nil {
+		return true
+	}
+	p.registeredMu.RLock()
+	defer p.registeredMu.RUnlock()
+	_, ok := p.streams[name]
+	return ok
+}
+
 // sysInfoCacheTTL bounds how often the (subprocess-backed) system info is
 // recomputed, so a burst of /healthz + /metrics scrapes runs timedatectl at
 // most once per interval. ntpProbeTimeout bounds the timedatectl subprocess so
@@ -92,6 +119,16 @@ type daemonSystemInfoProvider struct {
 	cacheAt time.Time
 }
 
+// update replaces the disk-space inputs after a config reload and drops the
+// cached result so the next request reflects them.
+func (p *daemonSystemInfoProvider) update(recordDir string, diskLowThreshold uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.recordDir = recordDir
+	p.diskLowThreshold = diskLowThreshold
+	p.cacheAt = time.Time{}
+}
+
 // SystemInfo returns cached system info, refreshing it (at most once per
 // sysInfoCacheTTL) via a subprocess bounded by ctx/ntpProbeTimeout.
 func (p *daemonSystemInfoProvider) SystemInfo(ctx context.Context) health.SystemInfo {
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+// TestRegisterSoundServerSources verifies that entries with a source: block
+// are started without any detected card, are not torn down by the removal
+// scan, and that a card resolving to such an entry is not streamed directly.
+func TestRegisterSoundServerSources(t *testing.T) {
+	origDetect := detectAudioDevices
+	t.Cleanup(func() { detectAudioDevices = origDetect })
+	// The card PipeWire holds resolves to the desk_mic entry by name.
+	detectAudioDevices = func(string) ([]*audio.Device, error) {
+		return []*audio.Device{{Name: "desk_mic", CardNumber: 1}}, nil
+	}
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	cfg := config.DefaultConfig()
+	cfg.Stream.USBStabilizationDelay = 0
+	cfg.Devices = map[string]config.DeviceConfig{
+		"desk_mic":   {Source: config.SourceConfig{Type: config.SourcePipeWire, Name: "alsa_input.usb-desk"}},
+		"studio_bus": {Source: config.SourceConfig{Type: config.SourceJACK, Ports: []string{"system:capture_1"}}},
+	}
+	flags := daemonFlags{LockDir: t.TempDir()}
+	sup := supervisor.New(supervisor.Config{})
+
+	var mu sync.RWMutex
+	services := make(map[string]bool)
+	hashes := make(map[string]string)
+	cards := make(map[string]int)
+
+	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards); n != 2 {
+		t.Fatalf("registered %d streams, want 2 (both sources)", n)
+	}
+	if !services["desk_mic"] || !services["studio_bus"] {
+		t.Fatalf("services = %v, want desk_mic and studio_bus", keysOf(services))
+	}
+	if _, ok := cards["desk_mic"]; ok {
+		t.Error("a sound-server source must not be pinned to an ALSA card")
+	}
+
+	// Nothing new on the next poll.
+	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards); n != 0 {
+		t.Errorf("re-poll registered %d streams, want 0", n)
+	}
+
+	// No card is detected for either source, yet neither counts as removed.
+	cfg.Stream.DeviceRemovalGrace = 0
+	if removed := removeVanishedDevices(logger, cfg, time.Now(), newVanishedDevices(), sup,
+		&mu, services, hashes, cards); len(removed) != 0 {
+		t.Errorf("removal scan stopped %v, want none", removed)
+	}
+
+	// Dropping the entry from the config lets the removal scan stop it.
+	delete(cfg.Devices, "studio_bus")
+	if removed := removeVanishedDevices(logger, cfg, time.Now(), newVanishedDevices(), sup,
+		&mu, services, hashes, cards); len(removed) != 1 || removed[0] != "studio_bus" {
+		t.Errorf("removal scan stopped %v, want [studio_bus]", removed)
+	}
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+// TestRegisterStaticSources verifies that static_sources: are registered
+// through the normal registration path without any detected device, that
+// the removal scan keeps them, and that the registration hash covers the
+// pull settings.
+func TestRegisterStaticSources(t *testing.T) {
+	origDetect := detectAudioDevices
+	t.Cleanup(func() { detectAudioDevices = origDetect })
+	detectAudioDevices = func(string) ([]*audio.Device, error) { return nil, nil }
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	cfg := config.DefaultConfig()
+	cfg.Stream.USBStabilizationDelay = 0
+	cfg.Stream.DeviceRemovalGrace = 0
+	cfg.StaticSources = map[string]config.StaticSourceConfig{
+		"gate_mic": {URL: "rtsp://192.168.1.50/audio"},
+	}
+	flags := daemonFlags{LockDir: t.TempDir()}
+	sup := supervisor.New(supervisor.Config{})
+
+	var mu sync.RWMutex
+	services := make(map[string]bool)
+	hashes := make(map[string]string)
+	cards := make(map[string]int)
+
+	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards); n != 1 {
+		t.Fatalf("registered %d streams, want 1", n)
+	}
+	if !services["gate_mic"] {
+		t.Fatalf("services = %v, want gate_mic", keysOf(services))
+	}
+	if hashes["gate_mic"] != streamConfigHash(cfg, "gate_mic") {
+		t.Error("registration hash should be streamConfigHash")
+	}
+
+	if removed := removeVanishedDevices(logger, cfg, time.Now(), newVanishedDevices(), sup,
+		&mu, services, hashes, cards); len(removed) != 0 {
+		t.Errorf("removal scan stopped %v, want none", removed)
+	}
+
+	changed := *cfg
+	changed.StaticSources = map[string]config.StaticSourceConfig{
+		"gate_mic": {URL: "rtsp://192.168.1.51/audio"},
+	}
+	if streamConfigHash(cfg, "gate_mic") == streamConfigHash(&changed, "gate_mic") {
+		t.Error("a changed URL should change the hash so the stream restarts on reload")
+	}
+}
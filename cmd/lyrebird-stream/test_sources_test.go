@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/stream"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+// TestRegisterTestSources verifies that test_sources: are registered like
+// any configured input, carry their signal settings to the manager, and
+// restart when the signal changes.
+func TestRegisterTestSources(t *testing.T) {
+	origDetect := detectAudioDevices
+	t.Cleanup(func() { detectAudioDevices = origDetect })
+	detectAudioDevices = func(string) ([]*audio.Device, error) { return nil, nil }
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	cfg := config.DefaultConfig()
+	cfg.Stream.USBStabilizationDelay = 0
+	cfg.Stream.DeviceRemovalGrace = 0
+	cfg.TestSources = map[string]config.TestSourceConfig{
+		"lyrebird_test": {Signal: "beep", Ident: "dtmf", Period: 5 * time.Second},
+	}
+
+	in := configuredInputs(cfg)["lyrebird_test"]
+	want := stream.TestSignal{Kind: "beep", Ident: "dtmf", Period: 5 * time.Second}
+	if in.test == nil || *in.test != want || in.card != -1 {
+		t.Fatalf("configuredInputs()[lyrebird_test] = %+v, want test signal %+v", in, want)
+	}
+
+	flags := daemonFlags{LockDir: t.TempDir()}
+	sup := supervisor.New(supervisor.Config{})
+	var mu sync.RWMutex
+	services := make(map[string]bool)
+	hashes := make(map[string]string)
+	cards := make(map[string]int)
+
+	if n := registerNewDevices(ctx, logger, cfg, flags, "/fake/ffmpeg", nil, sup, &mu, services, hashes, cards); n != 1 {
+		t.Fatalf("registered %d streams, want 1", n)
+	}
+	if removed := removeVanishedDevices(logger, cfg, time.Now(), newVanishedDevices(), sup,
+		&mu, services, hashes, cards); len(removed) != 0 {
+		t.Errorf("removal scan stopped %v, want none", removed)
+	}
+
+	changed := *cfg
+	changed.TestSources = map[string]config.TestSourceConfig{
+		"lyrebird_test": {Signal: "sweep"},
+	}
+	if streamConfigHash(cfg, "lyrebird_test") == streamConfigHash(&changed, "lyrebird_test") {
+		t.Error("a changed signal should change the hash so the stream restarts on reload")
+	}
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/mediamtx"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/stream"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+// upstreamProbeInterval is how often an externally run RTSP server is probed.
+const upstreamProbeInterval = 5 * time.Second
+
+// upstreamResumeJitter spreads stream restarts after an upstream outage over
+// this window, so a station with many microphones does not hit a freshly
+// restarted MediaMTX with every publisher in the same instant.
+const upstreamResumeJitter = 3 * time.Second
+
+// setupUpstream returns the shared stream.Upstream every stream manager
+// consults before (re)starting FFmpeg, registering whatever service drives it
+// with sup.
+//
+// In managed mode this is the supervised MediaMTX itself, whose readiness
+// comes from its API (Ping). Otherwise an UpstreamMonitor probes the RTSP
+// port from rtsp_url; a non-RTSP url (e.g. a test sink) disables the gate and
+// setupUpstream returns nil, restoring the plain backoff behaviour. When
+// live is non-nil the probe follows rtsp_url changes made by a hot reload.
+func setupUpstream(
+	logger *slog.Logger,
+	cfg *config.Config,
+	flags daemonFlags,
+	sup *supervisor.Supervisor,
+	live *configBroadcast,
+) (stream.Upstream, error) {
+	if cfg.MediaMTX.Managed {
+		svc, err := startManagedMediaMTX(logger, cfg, flags, sup)
+		if err != nil {
+			return nil, err
+		}
+		return svc, nil
+	}
+
+	rtspURL := cfg.MediaMTX.RTSPURL
+	if _, err := mediamtx.RTSPDialAddr(rtspURL); err != nil {
+		logger.Warn("upstream health check disabled: rtsp_url is not probeable", "error", err)
+		return nil, nil
+	}
+	mon, err := stream.NewUpstreamMonitor(stream.UpstreamMonitorConfig{
+		Probe: func(ctx context.Context) error {
+			if live != nil {
+				return mediamtx.ProbeRTSP(ctx, live.Current().MediaMTX.RTSPURL)
+			}
+			return mediamtx.ProbeRTSP(ctx, rtspURL)
+		},
+		Interval: upstreamProbeInterval,
+		Logger:   logger.With("component", "upstream"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := sup.Add(mon); err != nil {
+		return nil, fmt.Errorf("failed to register upstream monitor: %w", err)
+	}
+	return mon, nil
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/stream"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/supervisor"
+)
+
+func TestSetupUpstream_ProbesExternalRTSP(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sup := supervisor.New(supervisor.Config{ShutdownTimeout: time.Second})
+	cfg := config.DefaultConfig()
+
+	up, err := setupUpstream(logger, cfg, daemonFlags{LockDir: t.TempDir()}, sup, nil)
+	if err != nil {
+		t.Fatalf("setupUpstream() error = %v", err)
+	}
+	if _, ok := up.(*stream.UpstreamMonitor); !ok {
+		t.Fatalf("setupUpstream() = %T, want *stream.UpstreamMonitor", up)
+	}
+	if sup.ServiceCount() != 1 {
+		t.Errorf("ServiceCount() = %d, want the monitor registered", sup.ServiceCount())
+	}
+}
+
+func TestSetupUpstream_NonRTSPURLDisablesGate(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sup := supervisor.New(supervisor.Config{ShutdownTimeout: time.Second})
+	cfg := config.DefaultConfig()
+	cfg.MediaMTX.RTSPURL = "/dev/null"
+
+	up, err := setupUpstream(logger, cfg, daemonFlags{LockDir: t.TempDir()}, sup, nil)
+	if err != nil {
+		t.Fatalf("setupUpstream() error = %v", err)
+	}
+	if up != nil {
+		t.Errorf("setupUpstream() = %v, want nil", up)
+	}
+	if sup.ServiceCount() != 0 {
+		t.Errorf("ServiceCount() = %d, want 0", sup.ServiceCount())
+	}
+}
@@ -15,7 +15,9 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
 	"github.com/tomtom215/lyrebirdaudio-go/internal/diagnostics"
+	"go.yaml.in/yaml/v3"
 )
 
 // createDiagnosticBundle collects diagnostic information into a tar.gz archive
@@ -32,7 +34,17 @@ func createDiagnosticBundle(outputPath string) error {
 	}
 	defer os.RemoveAll(tmpDir)
 
+	// Values resolved from secret references in the config are scrubbed from
+	// everything collected, service logs included. If the config cannot be
+	// loaded there are no resolved secrets to scrub.
+	redactor := config.NewRedactor()
+	kc, effective, cfgErr := loadEffectiveConfig(defaultConfigPath)
+	if cfgErr == nil {
+		redactor = kc.Redactor()
+	}
+
 	writeFile := func(name, content string) {
+		content = redactor.Redact(content)
 		// #nosec G703 -- name is always a hardcoded filename literal; tmpDir is from os.MkdirTemp
 		if err := os.WriteFile(filepath.Join(tmpDir, filepath.Clean(name)), []byte(content), 0600); err != nil {
 			fmt.Printf("  warning: failed to write %s: %v\n", name, err)
@@ -79,6 +91,11 @@ func createDiagnosticBundle(outputPath string) error {
 	if data, err := os.ReadFile(defaultConfigPath); err == nil {
 		writeFile("config.yaml", string(data))
 	}
+	if cfgErr == nil {
+		if data, err := yaml.Marshal(effective); err == nil {
+			writeFile("config_effective.yaml", string(data))
+		}
+	}
 
 	// Collect health endpoint response.
 	httpClient := &http.Client{Timeout: 5 * time.Second}
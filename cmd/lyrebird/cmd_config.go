@@ -3,12 +3,16 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"go.yaml.in/yaml/v3"
 )
 
 // runMigrate migrates configuration from bash to YAML.
@@ -115,6 +119,16 @@ func runValidate(args []string) error {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
+	// Unknown keys only warn unless config.strict is set, in which case
+	// LoadConfig has already rejected the file.
+	unknown, err := config.UnknownConfigKeys(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	for _, u := range unknown {
+		fmt.Printf("[!] Warning: %s\n", u)
+	}
+
 	fmt.Println("✓ Configuration is valid")
 	fmt.Printf("✓ Loaded %d device configuration(s)\n", len(cfg.Devices))
 
@@ -128,3 +142,160 @@ func runValidate(args []string) error {
 
 	return nil
 }
+
+// configUsage lists the `lyrebird config` subcommands.
+const configUsage = `usage: lyrebird config <subcommand> [--config PATH]
+  show [--origin]                 Effective configuration (--origin: where each value came from)
+  get <key> [--origin]            One value, or every value under a section
+  set <key> <value> [--reload]    Validate, back up and save a value to the config file
+  effective <device>              Merged settings for one device, with origins
+  diff [backup]                   Compare the config file with a backup (default: newest)
+  restore <backup> [--reload]     Restore the config file from a backup
+  schema                          JSON Schema for config.yaml (editor autocompletion, CI checks)`
+
+// runConfig dispatches the `lyrebird config <subcommand>` family.
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", configUsage)
+	}
+	switch args[0] {
+	case "show":
+		return runConfigShow(args[1:])
+	case "get":
+		return runConfigGet(args[1:])
+	case "set":
+		return runConfigSet(args[1:])
+	case "effective":
+		return runConfigEffective(args[1:])
+	case "diff":
+		return runConfigDiff(args[1:])
+	case "restore":
+		return runConfigRestore(args[1:])
+	case "schema":
+		return runConfigSchema()
+	default:
+		return fmt.Errorf("unknown config subcommand: %s (run 'lyrebird help' for usage)", args[0])
+	}
+}
+
+// configArgs holds the flags shared by the config subcommands.
+type configArgs struct {
+	configPath string
+	origin     bool
+	reload     bool
+	positional []string
+}
+
+func parseConfigArgs(args []string) configArgs {
+	ca := configArgs{configPath: defaultConfigPath}
+	for i := 0; i < len(args); i++ {
+		switch {
+		case strings.HasPrefix(args[i], "--config="):
+			ca.configPath = strings.TrimPrefix(args[i], "--config=")
+		case args[i] == "--config" && i+1 < len(args):
+			ca.configPath = args[i+1]
+			i++
+		case args[i] == "--origin":
+			ca.origin = true
+		case args[i] == "--reload":
+			ca.reload = true
+		default:
+			ca.positional = append(ca.positional, args[i])
+		}
+	}
+	return ca
+}
+
+// loadEffectiveConfig loads configPath the way lyrebird-stream does: the
+// file, its conf.d include directory and LYREBIRD_* environment overrides.
+// Values resolved from secret references are redacted in the returned config.
+func loadEffectiveConfig(configPath string) (*config.KoanfConfig, *config.Config, error) {
+	kc, cfg, err := loadRuntimeConfig(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Every caller prints the result: never show resolved secrets.
+	return kc, kc.Redacted(cfg), nil
+}
+
+// loadRuntimeConfig is loadEffectiveConfig without the redaction, for
+// commands that connect to the configured servers rather than print the
+// config.
+func loadRuntimeConfig(configPath string) (*config.KoanfConfig, *config.Config, error) {
+	kc, err := config.NewKoanfConfig(
+		config.WithYAMLFile(configPath),
+		config.WithIncludeDir(config.DefaultIncludeDir(configPath)),
+		config.WithEnvPrefix("LYREBIRD"),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg, err := kc.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	return kc, cfg, nil
+}
+
+// runConfigShow prints the effective configuration. With --origin it prints
+// one line per key naming the file, environment variable or built-in default
+// that set the value.
+func runConfigShow(args []string) error {
+	ca := parseConfigArgs(args)
+
+	kc, cfg, err := loadEffectiveConfig(ca.configPath)
+	if err != nil {
+		return err
+	}
+
+	if !ca.origin {
+		data, err := yaml.Marshal(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+		fmt.Print(string(data))
+		return nil
+	}
+
+	flat := config.Flatten(cfg)
+	for _, k := range sortedKeys(flat) {
+		fmt.Printf("%-40s %-24s # %s\n", k, formatConfigValue(flat[k]), kc.Origin(k))
+	}
+	return nil
+}
+
+// runConfigSchema prints the JSON Schema for config.yaml.
+func runConfigSchema() error {
+	data, err := json.MarshalIndent(config.JSONSchema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// sortedKeys returns the keys of a flattened config in order.
+func sortedKeys(flat map[string]any) []string {
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatConfigValue renders a flattened config value the way it is written
+// in YAML (durations as "10s" rather than nanoseconds).
+func formatConfigValue(v any) string {
+	switch val := v.(type) {
+	case time.Duration:
+		return val.String()
+	case string:
+		if val == "" {
+			return `""`
+		}
+		return val
+	default:
+		return fmt.Sprint(val)
+	}
+}
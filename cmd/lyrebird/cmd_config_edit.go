@@ -0,0 +1,283 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+)
+
+// reloadDaemonFn asks the running daemon to reload its configuration.
+// Replaced in tests.
+var reloadDaemonFn = func() error {
+	out, err := exec.Command("systemctl", "reload", "lyrebird-stream").CombinedOutput() // #nosec G204 -- literal arguments
+	if err != nil {
+		return fmt.Errorf("systemctl reload lyrebird-stream failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// maybeReloadDaemon triggers a daemon reload when --reload was given, or
+// tells the operator how to apply the change.
+func maybeReloadDaemon(reload bool) error {
+	if !reload {
+		fmt.Println("Apply with: sudo systemctl reload lyrebird-stream (or pass --reload)")
+		return nil
+	}
+	if err := reloadDaemonFn(); err != nil {
+		return err
+	}
+	fmt.Println("✓ Daemon reload requested")
+	return nil
+}
+
+// runConfigGet prints the effective value of one key, or every key under a
+// section (e.g. "monitor").
+func runConfigGet(args []string) error {
+	ca := parseConfigArgs(args)
+	if len(ca.positional) != 1 {
+		return fmt.Errorf("usage: lyrebird config get <key> [--origin] [--config PATH]")
+	}
+	key := ca.positional[0]
+
+	kc, cfg, err := loadEffectiveConfig(ca.configPath)
+	if err != nil {
+		return err
+	}
+	flat := config.Flatten(cfg)
+
+	if v, ok := flat[key]; ok {
+		if ca.origin {
+			fmt.Printf("%s # %s\n", formatConfigValue(v), kc.Origin(key))
+		} else {
+			fmt.Println(formatConfigValue(v))
+		}
+		return nil
+	}
+
+	found := false
+	for _, k := range sortedKeys(flat) {
+		if !strings.HasPrefix(k, key+".") {
+			continue
+		}
+		found = true
+		if ca.origin {
+			fmt.Printf("%-40s %-24s # %s\n", k, formatConfigValue(flat[k]), kc.Origin(k))
+		} else {
+			fmt.Printf("%s: %s\n", k, formatConfigValue(flat[k]))
+		}
+	}
+	if !found {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+	return nil
+}
+
+// runConfigSet sets one key in the config file. The change is validated
+// before anything is written, and the previous file is backed up.
+//
+// Only the main config file is edited; a conf.d snippet or LYREBIRD_*
+// variable that sets the same key still wins, which is reported.
+func runConfigSet(args []string) error {
+	ca := parseConfigArgs(args)
+	if len(ca.positional) != 2 {
+		return fmt.Errorf("usage: lyrebird config set <key> <value> [--reload] [--config PATH]")
+	}
+	key, value := ca.positional[0], ca.positional[1]
+
+	// The file is rewritten from a Config, which cannot represent a
+	// <key>_file reference; refuse instead of dropping it.
+	// #nosec G304 -- config path is administrator-controlled
+	if data, err := os.ReadFile(ca.configPath); err == nil {
+		if refs, err := config.FindSecretFileKeys(data); err == nil && len(refs) > 0 {
+			return fmt.Errorf("%s uses secret file references (%s) that config set would drop; edit the file directly",
+				ca.configPath, strings.Join(refs, ", "))
+		}
+	}
+
+	cfg, err := config.LoadConfig(ca.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Set(key, value); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("refusing to save invalid configuration: %w", err)
+	}
+
+	backupPath, err := config.BackupBeforeSave(cfg, ca.configPath, config.GetBackupDir(ca.configPath))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Set %s = %s in %s\n", key, value, ca.configPath)
+	if backupPath != "" {
+		fmt.Printf("✓ Previous config backed up to %s\n", backupPath)
+	}
+
+	if kc, _, err := loadEffectiveConfig(ca.configPath); err != nil {
+		fmt.Printf("[!] Warning: effective configuration is invalid: %v\n", err)
+	} else if origin := kc.Origin(key); origin != ca.configPath && origin != "default" {
+		fmt.Printf("[!] Note: %s is overridden by %s; the effective value is unchanged\n", key, origin)
+	}
+
+	return maybeReloadDaemon(ca.reload)
+}
+
+// runConfigEffective prints the settings a device actually streams with:
+// its own entry merged over the defaults (GetDeviceConfig), including
+// include files and environment overrides, with the source of each value.
+func runConfigEffective(args []string) error {
+	ca := parseConfigArgs(args)
+	if len(ca.positional) != 1 {
+		return fmt.Errorf("usage: lyrebird config effective <device> [--config PATH]")
+	}
+	device := ca.positional[0]
+
+	kc, cfg, err := loadEffectiveConfig(ca.configPath)
+	if err != nil {
+		return err
+	}
+
+	own, configured := cfg.Devices[device]
+	fmt.Printf("Effective settings for device %q:\n", device)
+	if !configured {
+		fmt.Println("  (no device-specific settings; defaults apply)")
+	}
+
+	merged := config.Flatten(cfg.GetDeviceConfig(device))
+	ownFlat := config.Flatten(own)
+	defaultFlat := config.Flatten(cfg.Default)
+	for _, k := range sortedKeys(merged) {
+		// match:/ignore: select the entry; they are not settings it resolves.
+		if k == "ignore" || strings.HasPrefix(k, "match.") {
+			continue
+		}
+		// GetDeviceConfig takes the first non-zero value of the device entry,
+		// default:, then the stream:/monitor: setting the field falls back to.
+		origin := kc.Origin("default." + k)
+		if v, ok := ownFlat[k]; ok && configured && !reflect.ValueOf(v).IsZero() {
+			origin = kc.Origin("devices." + device + "." + k)
+		} else if fallback, ok := config.DeviceSettingFallback(k); ok && reflect.ValueOf(defaultFlat[k]).IsZero() {
+			origin = kc.Origin(fallback)
+		}
+		fmt.Printf("  %-24s %-16s # %s\n", k, formatConfigValue(merged[k]), origin)
+	}
+	return nil
+}
+
+// runConfigDiff compares the config file with a backup, newest by default.
+func runConfigDiff(args []string) error {
+	ca := parseConfigArgs(args)
+	if len(ca.positional) > 1 {
+		return fmt.Errorf("usage: lyrebird config diff [backup] [--config PATH]")
+	}
+	backupArg := ""
+	if len(ca.positional) == 1 {
+		backupArg = ca.positional[0]
+	}
+
+	backupPath, err := resolveBackup(ca.configPath, backupArg)
+	if err != nil {
+		return err
+	}
+	old, err := config.LoadConfig(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to load backup: %w", err)
+	}
+	cur, err := config.LoadConfig(ca.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	keys := config.Diff(old, cur)
+	if len(keys) == 0 {
+		fmt.Printf("No differences between %s and %s\n", backupPath, ca.configPath)
+		return nil
+	}
+	fmt.Printf("--- %s\n+++ %s\n", backupPath, ca.configPath)
+	oldFlat, curFlat := config.Flatten(old), config.Flatten(cur)
+	for _, k := range keys {
+		fmt.Printf("  %s: %s -> %s\n", k, flatValueOrUnset(oldFlat, k), flatValueOrUnset(curFlat, k))
+	}
+	return nil
+}
+
+// runConfigRestore replaces the config file with a backup after checking
+// that the backup is a valid configuration. The current file is itself
+// backed up first (config.RestoreBackup).
+func runConfigRestore(args []string) error {
+	ca := parseConfigArgs(args)
+	if len(ca.positional) != 1 {
+		listConfigBackups(ca.configPath)
+		return fmt.Errorf("usage: lyrebird config restore <backup> [--reload] [--config PATH]")
+	}
+
+	backupPath, err := resolveBackup(ca.configPath, ca.positional[0])
+	if err != nil {
+		return err
+	}
+	if _, err := config.LoadConfig(backupPath); err != nil {
+		return fmt.Errorf("refusing to restore %s: %w", backupPath, err)
+	}
+
+	previous, err := config.RestoreBackup(backupPath, ca.configPath, config.GetBackupDir(ca.configPath))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Restored %s from %s\n", ca.configPath, backupPath)
+	if previous != "" {
+		fmt.Printf("✓ Previous config backed up to %s\n", previous)
+	}
+	return maybeReloadDaemon(ca.reload)
+}
+
+// resolveBackup turns a backup argument into a path: "" or "latest" selects
+// the newest backup of configPath, a bare file name is looked up in the
+// backup directory, anything else is used as a path.
+func resolveBackup(configPath, arg string) (string, error) {
+	backupDir := config.GetBackupDir(configPath)
+	if arg == "" || arg == "latest" {
+		backups, err := config.ListBackups(backupDir, filepath.Base(configPath))
+		if err != nil {
+			return "", err
+		}
+		if len(backups) == 0 {
+			return "", fmt.Errorf("no backups of %s in %s", configPath, backupDir)
+		}
+		return backups[0].Path, nil
+	}
+	if !strings.ContainsRune(arg, os.PathSeparator) {
+		if _, err := os.Stat(arg); err != nil {
+			return filepath.Join(backupDir, arg), nil
+		}
+	}
+	return arg, nil
+}
+
+// listConfigBackups prints the available backups of configPath, newest first.
+func listConfigBackups(configPath string) {
+	backupDir := config.GetBackupDir(configPath)
+	backups, err := config.ListBackups(backupDir, filepath.Base(configPath))
+	if err != nil || len(backups) == 0 {
+		fmt.Printf("No backups of %s in %s\n", configPath, backupDir)
+		return
+	}
+	fmt.Printf("Backups in %s (newest first):\n", backupDir)
+	for _, b := range backups {
+		fmt.Printf("  %s  %s\n", b.Name, b.Timestamp.Format("2006-01-02 15:04:05"))
+	}
+}
+
+func flatValueOrUnset(flat map[string]any, key string) string {
+	if v, ok := flat[key]; ok {
+		return formatConfigValue(v)
+	}
+	return "(unset)"
+}
@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+)
+
+const editFixture = `default:
+  sample_rate: 48000
+  channels: 2
+  bitrate: 128k
+  codec: opus
+devices:
+  blue_yeti:
+    bitrate: 192k
+`
+
+func writeEditFixture(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(editFixture), 0640); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+// stubReloadDaemon replaces reloadDaemonFn and counts calls.
+func stubReloadDaemon(t *testing.T) *int {
+	t.Helper()
+	calls := 0
+	orig := reloadDaemonFn
+	reloadDaemonFn = func() error { calls++; return nil }
+	t.Cleanup(func() { reloadDaemonFn = orig })
+	return &calls
+}
+
+func TestRunConfigGet(t *testing.T) {
+	path := writeEditFixture(t)
+
+	out, err := captureStdout(t, func() error {
+		return runConfig([]string{"get", "devices.blue_yeti.bitrate", "--config", path})
+	})
+	if err != nil || strings.TrimSpace(out) != "192k" {
+		t.Errorf("get leaf = %q, %v; want 192k", out, err)
+	}
+
+	out, err = captureStdout(t, func() error {
+		return runConfig([]string{"get", "monitor", "--config", path})
+	})
+	if err != nil || !strings.Contains(out, "monitor.interval: 5m0s") {
+		t.Errorf("get section = %q, %v; want monitor.* keys", out, err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return runConfig([]string{"get", "monitor.bogus", "--config", path})
+	}); err == nil || !strings.Contains(err.Error(), "unknown config key") {
+		t.Errorf("get unknown = %v, want unknown config key", err)
+	}
+}
+
+func TestRunConfigSetBacksUpAndReloads(t *testing.T) {
+	path := writeEditFixture(t)
+	calls := stubReloadDaemon(t)
+
+	out, err := captureStdout(t, func() error {
+		return runConfig([]string{"set", "default.bitrate", "256k", "--reload", "--config", path})
+	})
+	if err != nil {
+		t.Fatalf("config set: %v", err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Default.Bitrate != "256k" || cfg.Devices["blue_yeti"].Bitrate != "192k" {
+		t.Errorf("saved config = default %q, blue_yeti %q", cfg.Default.Bitrate, cfg.Devices["blue_yeti"].Bitrate)
+	}
+	backups, _ := config.ListBackups(config.GetBackupDir(path), "config.yaml")
+	if len(backups) != 1 {
+		t.Errorf("backups = %d, want 1", len(backups))
+	}
+	if *calls != 1 || !strings.Contains(out, "reload requested") {
+		t.Errorf("reload calls = %d, output:\n%s", *calls, out)
+	}
+}
+
+func TestRunConfigSetRejectsInvalid(t *testing.T) {
+	path := writeEditFixture(t)
+	calls := stubReloadDaemon(t)
+
+	_, err := captureStdout(t, func() error {
+		return runConfig([]string{"set", "default.codec", "mp9", "--reload", "--config", path})
+	})
+	if err == nil || !strings.Contains(err.Error(), "refusing to save invalid configuration") {
+		t.Errorf("set invalid codec = %v, want refusal", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != editFixture {
+		t.Error("config file changed despite invalid value")
+	}
+	if *calls != 0 {
+		t.Error("daemon reloaded after a rejected set")
+	}
+}
+
+func TestRunConfigSetReportsOverride(t *testing.T) {
+	path := writeEditFixture(t)
+	stubReloadDaemon(t)
+	t.Setenv("LYREBIRD_DEFAULT_BITRATE", "64k")
+
+	out, err := captureStdout(t, func() error {
+		return runConfig([]string{"set", "default.bitrate", "256k", "--config", path})
+	})
+	if err != nil {
+		t.Fatalf("config set: %v", err)
+	}
+	if !strings.Contains(out, "overridden by env LYREBIRD_DEFAULT_BITRATE") {
+		t.Errorf("missing override note:\n%s", out)
+	}
+}
+
+func TestRunConfigEffective(t *testing.T) {
+	path := writeEditFixture(t)
+
+	out, err := captureStdout(t, func() error {
+		return runConfig([]string{"effective", "blue_yeti", "--config", path})
+	})
+	if err != nil {
+		t.Fatalf("config effective: %v", err)
+	}
+	for _, want := range []string{"bitrate", "192k", "codec", "opus", "# " + path} {
+		if !strings.Contains(out, want) {
+			t.Errorf("effective output missing %q:\n%s", want, out)
+		}
+	}
+
+	out, _ = captureStdout(t, func() error {
+		return runConfig([]string{"effective", "unknown_mic", "--config", path})
+	})
+	if !strings.Contains(out, "defaults apply") {
+		t.Errorf("unconfigured device output:\n%s", out)
+	}
+}
+
+func TestRunConfigDiffAndRestore(t *testing.T) {
+	path := writeEditFixture(t)
+	stubReloadDaemon(t)
+
+	if _, err := captureStdout(t, func() error {
+		return runConfig([]string{"diff", "--config", path})
+	}); err == nil || !strings.Contains(err.Error(), "no backups") {
+		t.Errorf("diff without backups = %v, want no backups error", err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return runConfig([]string{"set", "monitor.interval", "90s", "--config", path})
+	}); err != nil {
+		t.Fatalf("config set: %v", err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return runConfig([]string{"diff", "--config", path})
+	})
+	if err != nil || !strings.Contains(out, "monitor.interval: 5m0s -> 1m30s") {
+		t.Errorf("diff = %v, output:\n%s", err, out)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return runConfig([]string{"restore", "latest", "--config", path})
+	}); err != nil {
+		t.Fatalf("config restore: %v", err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Monitor.Interval.String() != "5m0s" {
+		t.Errorf("restored monitor.interval = %v, want 5m0s", cfg.Monitor.Interval)
+	}
+
+	out, err = captureStdout(t, func() error {
+		return runConfig([]string{"restore", "--config", path})
+	})
+	if err == nil || !strings.Contains(out, "Backups in") {
+		t.Errorf("restore without argument = %v, output:\n%s", err, out)
+	}
+}
+
+func TestResolveBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	dir := config.GetBackupDir(path)
+
+	got, err := resolveBackup(path, "config.yaml.2026-01-01T00-00-00.bak")
+	if err != nil || got != filepath.Join(dir, "config.yaml.2026-01-01T00-00-00.bak") {
+		t.Errorf("bare name = %q, %v", got, err)
+	}
+	if got, _ := resolveBackup(path, "/tmp/x.bak"); got != "/tmp/x.bak" {
+		t.Errorf("absolute path = %q", got)
+	}
+}
+
+func TestRunConfigEffectiveOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := editFixture + "    max_restart_attempts: 500\nstream:\n  local_record_dir: /rec\n"
+	if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return runConfig([]string{"effective", "blue_yeti", "--config", path})
+	})
+	if err != nil {
+		t.Fatalf("config effective: %v", err)
+	}
+	for _, want := range []string{"max_restart_attempts", "500", "local_record_dir", "/rec"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("effective output missing %q:\n%s", want, out)
+		}
+	}
+	// Inherited stream: settings name their stream: key, not default:.
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "local_record_dir") && !strings.HasSuffix(line, "# "+path) {
+			t.Errorf("local_record_dir origin: %q", line)
+		}
+		if strings.Contains(line, "segment_duration") && !strings.HasSuffix(line, "# default") {
+			t.Errorf("segment_duration origin: %q", line)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func writeShowFixture(t *testing.T) (configPath, snippet string) {
+	t.Helper()
+	dir := t.TempDir()
+	configPath = filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("default:\n  codec: opus\n  bitrate: 128k\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "conf.d"), 0755); err != nil {
+		t.Fatalf("mkdir conf.d: %v", err)
+	}
+	snippet = filepath.Join(dir, "conf.d", "50-bitrate.yaml")
+	if err := os.WriteFile(snippet, []byte("default:\n  bitrate: 192k\n"), 0644); err != nil {
+		t.Fatalf("write snippet: %v", err)
+	}
+	return configPath, snippet
+}
+
+func TestRunConfigShowOrigin(t *testing.T) {
+	configPath, snippet := writeShowFixture(t)
+
+	out, err := captureStdout(t, func() error {
+		return run([]string{"config", "show", "--origin", "--config", configPath})
+	})
+	if err != nil {
+		t.Fatalf("config show --origin: %v", err)
+	}
+
+	for _, want := range []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^default\.bitrate\s+192k\s+# ` + regexp.QuoteMeta(snippet) + `$`),
+		regexp.MustCompile(`(?m)^default\.codec\s+opus\s+# ` + regexp.QuoteMeta(configPath) + `$`),
+		regexp.MustCompile(`(?m)^monitor\.interval\s+5m0s\s+# default$`),
+	} {
+		if !want.MatchString(out) {
+			t.Errorf("output does not match %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunConfigShowYAML(t *testing.T) {
+	configPath, _ := writeShowFixture(t)
+
+	out, err := captureStdout(t, func() error {
+		return runConfig([]string{"show", "--config=" + configPath})
+	})
+	if err != nil {
+		t.Fatalf("config show: %v", err)
+	}
+	if !strings.Contains(out, "bitrate: 192k") {
+		t.Errorf("effective YAML missing include override:\n%s", out)
+	}
+}
+
+func TestRunConfigUsage(t *testing.T) {
+	if err := runConfig(nil); err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("runConfig(nil) = %v, want usage error", err)
+	}
+	if err := runConfig([]string{"bogus"}); err == nil || !strings.Contains(err.Error(), "unknown config subcommand") {
+		t.Errorf("runConfig(bogus) = %v, want unknown subcommand error", err)
+	}
+	if err := runConfigShow([]string{"--config", filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("config show with a missing file should fail")
+	}
+}
+
+func TestRunConfigSchema(t *testing.T) {
+	out, err := captureStdout(t, func() error {
+		return run([]string{"config", "schema"})
+	})
+	if err != nil {
+		t.Fatalf("config schema: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(out), &schema); err != nil {
+		t.Fatalf("schema is not JSON: %v", err)
+	}
+	if schema["$schema"] == nil || schema["properties"] == nil {
+		t.Errorf("schema missing $schema or properties: %v", schema)
+	}
+}
+
+func TestRunValidateUnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("default:\n  codc: opus\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	out, err := captureStdout(t, func() error {
+		return run([]string{"validate", "--config", path})
+	})
+	if err != nil || !strings.Contains(out, `[!] Warning: `+path+`:line 2: unknown key "default.codc" (did you mean "codec"?)`) {
+		t.Errorf("validate = %v, output:\n%s", err, out)
+	}
+
+	// With config.strict the unknown key fails validation.
+	if err := os.WriteFile(path, []byte("default:\n  codc: opus\nconfig:\n  strict: true\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	out, err = captureStdout(t, func() error {
+		return run([]string{"validate", "--config", path})
+	})
+	if err == nil || !strings.Contains(err.Error(), `did you mean "codec"`) {
+		t.Errorf("strict validate = %v, output:\n%s", err, out)
+	}
+}
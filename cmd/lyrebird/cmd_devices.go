@@ -3,8 +3,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
 )
@@ -17,6 +20,10 @@ func runDevices(args []string) error {
 // runDevicesWithPath lists detected USB audio devices from the specified path.
 // Extracted for testability.
 func runDevicesWithPath(asoundPath string, args []string) error {
+	if slices.Contains(args, "--sources") {
+		return runDeviceSources()
+	}
+
 	// Scan for USB audio devices
 	devices, err := audio.DetectDevices(asoundPath)
 	if err != nil {
@@ -40,12 +47,71 @@ func runDevicesWithPath(asoundPath string, args []string) error {
 		if dev.DeviceID != "" {
 			fmt.Printf("  Device ID:     %s\n", dev.DeviceID)
 		}
+		if dev.Serial != "" {
+			fmt.Printf("  Serial:        %s\n", dev.Serial)
+		}
+		if dev.PortPath != "" {
+			fmt.Printf("  USB Port:      %s\n", dev.PortPath)
+		}
 		fmt.Println()
 	}
 
 	return nil
 }
 
+// soundServerDiscoverers list the capture sources of each sound server.
+// Package-level so tests can stub pactl and jack_lsp.
+var soundServerDiscoverers = []struct {
+	server   string
+	discover func(context.Context) ([]audio.Source, error)
+}{
+	{"PulseAudio/PipeWire", audio.DiscoverPulseSources},
+	{"JACK", audio.DiscoverJACKPorts},
+}
+
+// runDeviceSources lists the capture sources of the PulseAudio, PipeWire
+// and JACK servers reachable as the current user, with the source: block
+// that streams each. A server that is not running is reported, not fatal.
+func runDeviceSources() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	found := 0
+	for _, d := range soundServerDiscoverers {
+		sources, err := d.discover(ctx)
+		if err != nil {
+			fmt.Printf("%s: not available (%v)\n\n", d.server, err)
+			continue
+		}
+		for _, src := range sources {
+			if src.Monitor {
+				continue
+			}
+			found++
+			fmt.Printf("Source: %s\n", src.Name)
+			fmt.Printf("  Type:          %s\n", src.Backend)
+			if src.Spec != "" {
+				fmt.Printf("  Format:        %s\n", src.Spec)
+			}
+			fmt.Printf("  Config:\n")
+			fmt.Printf("    devices:\n")
+			fmt.Printf("      %s:\n", src.StableName())
+			fmt.Printf("        source:\n")
+			fmt.Printf("          type: %s\n", src.Backend)
+			if src.Backend == "jack" {
+				fmt.Printf("          ports: [%s]\n", src.Name)
+			} else {
+				fmt.Printf("          name: %s\n", src.Name)
+			}
+			fmt.Println()
+		}
+	}
+	if found == 0 {
+		fmt.Println("No sound-server capture sources found")
+	}
+	return nil
+}
+
 // runDetect detects device capabilities and recommends settings.
 func runDetect(args []string) error {
 	return runDetectWithPath("/proc/asound", args)
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+)
+
+func TestRunDevicesSources(t *testing.T) {
+	orig := soundServerDiscoverers
+	t.Cleanup(func() { soundServerDiscoverers = orig })
+	soundServerDiscoverers = []struct {
+		server   string
+		discover func(context.Context) ([]audio.Source, error)
+	}{
+		{"PulseAudio/PipeWire", func(context.Context) ([]audio.Source, error) {
+			return []audio.Source{
+				{Backend: "pipewire", Name: "alsa_output.hdmi.monitor", Monitor: true},
+				{Backend: "pipewire", Name: "alsa_input.usb-BLUE_Yeti-00.analog-stereo", Spec: "s16le 2ch 48000Hz"},
+			}, nil
+		}},
+		{"JACK", func(context.Context) ([]audio.Source, error) {
+			return nil, errors.New("jack_lsp: executable file not found")
+		}},
+	}
+
+	out, err := captureStdout(t, func() error { return runDevicesWithPath("/nonexistent", []string{"--sources"}) })
+	if err != nil {
+		t.Fatalf("runDevicesWithPath: %v", err)
+	}
+	for _, want := range []string{
+		"Source: alsa_input.usb-BLUE_Yeti-00.analog-stereo",
+		"usb_BLUE_Yeti_00_analog_stereo:",
+		"type: pipewire",
+		"name: alsa_input.usb-BLUE_Yeti-00.analog-stereo",
+		"JACK: not available",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hdmi.monitor") {
+		t.Errorf("monitor sources should be hidden:\n%s", out)
+	}
+}
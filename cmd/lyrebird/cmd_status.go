@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -20,7 +21,7 @@ import (
 )
 
 // statusSessionQueryTimeout caps how long `lyrebird status` will wait for the
-// MediaMTX API when listing active RTSP sessions. Status is an interactive
+// MediaMTX API when listing active reader sessions. Status is an interactive
 // command and must not hang when MediaMTX is down or unreachable.
 const statusSessionQueryTimeout = 2 * time.Second
 
@@ -31,14 +32,25 @@ type StatusOutput struct {
 	ActiveStreams  []StreamStatus `json:"active_streams"`
 	AvailableURLs  []StreamURL    `json:"available_urls"`
 	ActiveSessions []SessionInfo  `json:"active_sessions"`
+	Schedules      []ScheduleInfo `json:"schedules,omitempty"`
 	Error          string         `json:"error,omitempty"`
 }
 
-// SessionInfo summarises one active RTSP session reported by MediaMTX. The
-// field set intentionally mirrors the subset of mediamtx.RTSPSession that is
-// useful to a human operator running `lyrebird status`: who is connected,
-// from where, to which path, and how much traffic has flowed.
+// ScheduleInfo is the operating-window state of a device with a schedule,
+// evaluated from the config at the time `lyrebird status` runs.
+type ScheduleInfo struct {
+	DeviceName string     `json:"device_name"`
+	Active     bool       `json:"active"`
+	NextChange *time.Time `json:"next_change,omitempty"`
+}
+
+// SessionInfo summarises one active session reported by MediaMTX over any
+// protocol (RTSP, WebRTC, RTMP, SRT, HLS). The field set intentionally mirrors
+// the subset of mediamtx.Session that is useful to a human operator running
+// `lyrebird status`: who is connected, how, from where, to which path, and
+// how much traffic has flowed.
 type SessionInfo struct {
+	Protocol      string `json:"protocol,omitempty"`
 	ID            string `json:"id"`
 	RemoteAddr    string `json:"remote_addr"`
 	State         string `json:"state"`
@@ -144,6 +156,16 @@ func runStatus(args []string) error {
 	// leaves the field as an empty (but non-nil) slice.
 	status.ActiveSessions = fetchActiveSessions(cfg.MediaMTX.APIURL)
 
+	// Operating windows of the configured and detected devices.
+	names := make([]string, 0, len(cfg.Devices)+len(devices))
+	for name := range cfg.Devices {
+		names = append(names, name)
+	}
+	for _, dev := range devices {
+		names = append(names, audio.SanitizeDeviceName(dev.Name))
+	}
+	status.Schedules = scheduleStatus(cfg, names, time.Now())
+
 	// Output based on format
 	if jsonOutput {
 		enc := json.NewEncoder(os.Stdout)
@@ -199,7 +221,7 @@ func runStatus(args []string) error {
 	fmt.Println("Active Readers:")
 	fmt.Println("---------------")
 	if len(status.ActiveSessions) == 0 {
-		fmt.Println("  (no active RTSP readers, or MediaMTX API unreachable)")
+		fmt.Println("  (no active readers, or MediaMTX API unreachable)")
 	} else {
 		for _, s := range status.ActiveSessions {
 			// Only readers are interesting here (sessions in "read" state);
@@ -208,18 +230,60 @@ func runStatus(args []string) error {
 				continue
 			}
 			fmt.Printf("  %s <- %s (%s, %s out)\n",
-				s.Path, s.RemoteAddr, s.Transport, formatBytes(s.OutboundBytes))
+				s.Path, sessionRemote(s), sessionTransport(s), formatBytes(s.OutboundBytes))
+		}
+	}
+
+	if len(status.Schedules) > 0 {
+		fmt.Println()
+		fmt.Println("Schedules:")
+		fmt.Println("----------")
+		for _, s := range status.Schedules {
+			state := "outside window (stopped)"
+			if s.Active {
+				state = "inside window"
+			}
+			if s.NextChange != nil {
+				state += ", changes " + s.NextChange.Format("Mon 15:04")
+			}
+			fmt.Printf("  %s: %s\n", s.DeviceName, state)
 		}
 	}
 
 	return nil
 }
 
-// fetchActiveSessions queries the MediaMTX API for the list of active RTSP
-// sessions. It is a fail-soft helper: any error (including connection refused,
-// non-2xx status, or decode failure) results in an empty (non-nil) slice. The
-// function is overridable via fetchActiveSessionsFn so tests can inject a
-// deterministic fake without standing up an HTTP server.
+// scheduleStatus evaluates the schedule of each named device at now. Devices
+// without a schedule are omitted; duplicate names are reported once. The
+// result is sorted by device name.
+func scheduleStatus(cfg *config.Config, names []string, now time.Time) []ScheduleInfo {
+	var out []ScheduleInfo
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		sched, err := cfg.GetDeviceConfig(name).Schedule.Compile()
+		if err != nil || sched == nil {
+			continue
+		}
+		info := ScheduleInfo{DeviceName: name, Active: sched.Active(now)}
+		if next := sched.NextChange(now); !next.IsZero() {
+			info.NextChange = &next
+		}
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DeviceName < out[j].DeviceName })
+	return out
+}
+
+// fetchActiveSessions queries the MediaMTX API for the list of active
+// sessions across every protocol. It is a fail-soft helper: a protocol that
+// fails to list (connection refused, non-2xx status, decode failure) simply
+// contributes no sessions, and total failure results in an empty (non-nil)
+// slice. The function is overridable via fetchActiveSessionsFn so tests can
+// inject a deterministic fake without standing up an HTTP server.
 var fetchActiveSessionsFn = defaultFetchActiveSessions
 
 func fetchActiveSessions(apiURL string) []SessionInfo {
@@ -234,14 +298,14 @@ func defaultFetchActiveSessions(apiURL string) []SessionInfo {
 	defer cancel()
 
 	client := mediamtx.NewClient(apiURL, mediamtx.WithTimeout(statusSessionQueryTimeout))
-	sessions, err := client.ListRTSPSessions(ctx)
-	if err != nil {
-		return []SessionInfo{}
-	}
+	// Partial results are still worth showing; the error only says which
+	// protocols could not be listed.
+	sessions, _ := client.ListSessions(ctx)
 
 	out := make([]SessionInfo, 0, len(sessions))
 	for _, s := range sessions {
 		out = append(out, SessionInfo{
+			Protocol:      string(s.Protocol),
 			ID:            s.ID,
 			RemoteAddr:    s.RemoteAddr,
 			State:         s.State,
@@ -255,6 +319,30 @@ func defaultFetchActiveSessions(apiURL string) []SessionInfo {
 	return out
 }
 
+// sessionRemote returns the reader address for display. HLS muxers are
+// per-path rather than per-client, so they have no address of their own.
+func sessionRemote(s SessionInfo) string {
+	if s.RemoteAddr == "" {
+		return "(hls clients)"
+	}
+	return s.RemoteAddr
+}
+
+// sessionTransport renders the protocol and, for RTSP, the RTP transport,
+// e.g. "RTSP/UDP" or "WEBRTC". Sessions without a protocol (older fetchers)
+// show the bare transport as before.
+func sessionTransport(s SessionInfo) string {
+	proto := strings.ToUpper(s.Protocol)
+	switch {
+	case proto == "":
+		return s.Transport
+	case s.Transport == "":
+		return proto
+	default:
+		return proto + "/" + s.Transport
+	}
+}
+
 // formatBytes formats a byte count as a short human-readable string. It is
 // used only for the human-readable text output of `lyrebird status`; JSON
 // output emits the raw integer.
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+)
+
+func TestScheduleStatus(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Devices = map[string]config.DeviceConfig{
+		"birds": {Schedule: config.ScheduleConfig{
+			Windows: []config.ScheduleWindow{{Start: "04:00", End: "09:00"}},
+		}},
+		"always": {Bitrate: "192k"},
+	}
+	now := time.Date(2026, time.May, 4, 3, 0, 0, 0, time.Local)
+
+	got := scheduleStatus(cfg, []string{"always", "birds", "birds"}, now)
+	if len(got) != 1 {
+		t.Fatalf("scheduleStatus = %+v, want birds only", got)
+	}
+	if got[0].DeviceName != "birds" || got[0].Active {
+		t.Errorf("got %+v, want birds inactive", got[0])
+	}
+	if want := now.Add(time.Hour); got[0].NextChange == nil || !got[0].NextChange.Equal(want) {
+		t.Errorf("NextChange = %v, want %v", got[0].NextChange, want)
+	}
+}
+
+// TestRunStatusJSONIncludesSchedules verifies that configured schedules
+// appear in the JSON output.
+func TestRunStatusJSONIncludesSchedules(t *testing.T) {
+	withStubbedSessionFetcher(t, func(string) []SessionInfo { return []SessionInfo{} })
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	data := "devices:\n  birds:\n    schedule:\n      windows:\n        - start: \"00:00\"\n          end: \"24:00\"\n"
+	if err := os.WriteFile(configPath, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return runStatus([]string{"--lock-dir=" + t.TempDir(), "--config=" + configPath, "--json"})
+	})
+	if err != nil {
+		t.Fatalf("runStatus() error: %v", err)
+	}
+	var parsed StatusOutput
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("json.Unmarshal(%q) error: %v", out, err)
+	}
+	if len(parsed.Schedules) != 1 || parsed.Schedules[0].DeviceName != "birds" || !parsed.Schedules[0].Active {
+		t.Errorf("Schedules = %+v, want birds active", parsed.Schedules)
+	}
+}
@@ -163,7 +163,7 @@ func TestRunStatusTextEmptySessionsMessage(t *testing.T) {
 	if err != nil {
 		t.Fatalf("runStatus() error: %v", err)
 	}
-	if !strings.Contains(out, "(no active RTSP readers") {
+	if !strings.Contains(out, "(no active readers") {
 		t.Errorf("expected fallback message, got:\n%s", out)
 	}
 }
@@ -257,3 +257,52 @@ func TestFormatBytes(t *testing.T) {
 		}
 	}
 }
+
+// TestDefaultFetchActiveSessionsAllProtocols verifies that readers on
+// non-RTSP protocols are reported with their protocol, and that a protocol
+// failing to list does not hide the others.
+func TestDefaultFetchActiveSessionsAllProtocols(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/rtspsessions/list":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/v3/webrtcsessions/list":
+			_, _ = w.Write([]byte(`{"items":[{"id":"aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee","remoteAddr":"10.1.1.2:5000","state":"read","path":"mic","outboundBytes":100}]}`))
+		case "/v3/hlsmuxers/list":
+			_, _ = w.Write([]byte(`{"items":[{"path":"mic","outboundBytes":200}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	got := defaultFetchActiveSessions(server.URL)
+	if len(got) != 2 {
+		t.Fatalf("len(got) = %d, want 2: %+v", len(got), got)
+	}
+	if got[0].Protocol != "webrtc" || got[0].RemoteAddr != "10.1.1.2:5000" {
+		t.Errorf("got[0] = %+v", got[0])
+	}
+	if got[1].Protocol != "hls" || got[1].State != "read" || got[1].OutboundBytes != 200 {
+		t.Errorf("got[1] = %+v", got[1])
+	}
+}
+
+func TestSessionTransport(t *testing.T) {
+	tests := []struct {
+		in   SessionInfo
+		want string
+	}{
+		{SessionInfo{Transport: "UDP"}, "UDP"},
+		{SessionInfo{Protocol: "rtsp", Transport: "TCP"}, "RTSP/TCP"},
+		{SessionInfo{Protocol: "webrtc"}, "WEBRTC"},
+	}
+	for _, tt := range tests {
+		if got := sessionTransport(tt.in); got != tt.want {
+			t.Errorf("sessionTransport(%+v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := sessionRemote(SessionInfo{Protocol: "hls"}); got != "(hls clients)" {
+		t.Errorf("sessionRemote(hls) = %q", got)
+	}
+}
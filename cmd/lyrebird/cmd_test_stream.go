@@ -0,0 +1,292 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/mediamtx"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/segment"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/stream"
+)
+
+// defaultTestStreamName is the stream published when --name is not given
+// and the config defines no test_sources:.
+const defaultTestStreamName = "lyrebird_test"
+
+// Overridable in tests.
+var (
+	lookupTestStreamFFmpeg = func() (string, error) { return exec.LookPath("ffmpeg") }
+	testStreamPollInterval = time.Second
+)
+
+// testStreamArgs holds the parsed test-stream flags.
+type testStreamArgs struct {
+	configPath string
+	name       string
+	signal     string
+	ident      string
+	duration   time.Duration
+	recordDir  string
+}
+
+func parseTestStreamArgs(args []string) (testStreamArgs, error) {
+	ta := testStreamArgs{configPath: defaultConfigPath, duration: 30 * time.Second}
+	for i := 0; i < len(args); i++ {
+		key, value, hasValue := strings.Cut(args[i], "=")
+		if !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			switch key {
+			case "--config", "--name", "--signal", "--ident", "--duration", "--record-dir":
+				value, hasValue = args[i+1], true
+				i++
+			}
+		}
+		if !hasValue {
+			return ta, fmt.Errorf("unknown or incomplete option %q", args[i])
+		}
+		switch key {
+		case "--config":
+			ta.configPath = value
+		case "--name":
+			ta.name = value
+		case "--signal":
+			ta.signal = value
+		case "--ident":
+			ta.ident = value
+		case "--duration":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return ta, fmt.Errorf("--duration must be a positive duration such as 30s (got %q)", value)
+			}
+			ta.duration = d
+		case "--record-dir":
+			ta.recordDir = value
+		default:
+			return ta, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return ta, nil
+}
+
+// runTestStream publishes a generated test signal to MediaMTX for a while
+// through the same stream manager the daemon uses, then reports whether
+// MediaMTX received it and, with --record-dir, whether it was recorded.
+// It verifies an installation end to end before any microphone is plugged
+// in, and fails (non-zero exit) when a check does not pass.
+//
+// The signal and encoding come from test_sources.<name> and
+// devices.<name> when configured, with --signal and --ident overriding.
+func runTestStream(args []string) error {
+	ta, err := parseTestStreamArgs(args)
+	if err != nil {
+		return err
+	}
+
+	_, cfg, err := loadRuntimeConfig(ta.configPath)
+	if err != nil {
+		return err
+	}
+	name := ta.name
+	if name == "" {
+		name = defaultTestStreamName
+		if names := cfg.TestSourceNames(); len(names) > 0 {
+			name = names[0]
+		}
+	}
+	src := cfg.TestSources[name]
+	if ta.signal != "" {
+		src.Signal = ta.signal
+	}
+	switch ta.ident {
+	case "":
+	case "none":
+		src.Ident = ""
+	default:
+		src.Ident = ta.ident
+	}
+	if err := src.Validate(); err != nil {
+		return fmt.Errorf("test signal: %w", err)
+	}
+
+	ffmpegPath, err := lookupTestStreamFFmpeg()
+	if err != nil {
+		return fmt.Errorf("ffmpeg not found: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	client := mediamtx.NewClient(cfg.MediaMTX.APIURL)
+	if stats, err := client.GetStreamStats(ctx, name); err == nil && stats.Ready {
+		return fmt.Errorf("stream %q is already being published (is lyrebird-stream running it?); choose another --name", name)
+	}
+
+	// A private lock directory: the test stream must not need the daemon's
+	// (root-owned) one, and its name is checked against MediaMTX above.
+	lockDir, err := os.MkdirTemp("", "lyrebird-test-stream-")
+	if err != nil {
+		return fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(lockDir) }()
+
+	devCfg := cfg.GetDeviceConfig(name)
+	rtspURL := fmt.Sprintf("%s/%s", cfg.MediaMTX.RTSPURL, name)
+	sig := &stream.TestSignal{
+		Kind:         src.Signal,
+		Frequency:    src.Frequency,
+		EndFrequency: src.EndFrequency,
+		Period:       src.Period,
+		LevelDB:      src.LevelDB,
+		Ident:        src.Ident,
+	}
+	var timecode *stream.Timecode
+	if cfg.Timecode.Enabled {
+		timecode = &stream.Timecode{
+			HostID:    cfg.Timecode.Host(),
+			DeviceID:  "test:" + cmp.Or(src.Signal, stream.TestSignalTone),
+			CheckSync: cfg.Timecode.TimeSource != config.TimeSourceSystem,
+		}
+	}
+	naming := cfg.SegmentNaming(devCfg)
+	mgr, err := stream.NewManager(&stream.ManagerConfig{
+		DeviceName:         name,
+		TestSignal:         sig,
+		Timecode:           timecode,
+		StreamName:         name,
+		SampleRate:         devCfg.SampleRate,
+		Channels:           devCfg.Channels,
+		Bitrate:            devCfg.Bitrate,
+		Codec:              devCfg.Codec,
+		ThreadQueue:        devCfg.ThreadQueue,
+		Opus:               devCfg.Opus.Settings(),
+		AudioFilter:        config.FilterChain(devCfg.Filters),
+		RTSPURL:            rtspURL,
+		LocalRecordDir:     ta.recordDir,
+		SegmentDuration:    devCfg.SegmentDuration,
+		SegmentFormat:      devCfg.SegmentFormat,
+		SegmentNaming:      naming,
+		SegmentAtClockTime: devCfg.SegmentAlign == config.SegmentAlignClock,
+		LockDir:            lockDir,
+		FFmpegPath:         ffmpegPath,
+		StopTimeout:        cfg.Stream.StopTimeout,
+		Backoff:            stream.NewBackoff(time.Second, 5*time.Second, 3),
+		Logger:             slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+	})
+	if err != nil {
+		return fmt.Errorf("test stream: %w", err)
+	}
+	defer func() { _ = mgr.Close() }()
+
+	kind := cmp.Or(src.Signal, stream.TestSignalTone)
+	fmt.Printf("Publishing a %s test signal (%s, %d Hz, %d ch) to %s for %s\n",
+		kind, devCfg.Codec, devCfg.SampleRate, devCfg.Channels, rtspURL, ta.duration)
+	if src.Ident == "dtmf" {
+		fmt.Println("Each period starts with its UTC start time (HHMMSS) as DTMF digits.")
+	}
+	fmt.Printf("Listen with: ffplay %s\n\n", rtspURL)
+
+	start := time.Now()
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	done := make(chan error, 1)
+	go func() { done <- mgr.Run(runCtx) }()
+
+	var (
+		readyAfter           time.Duration
+		firstBytes, received int64
+		runErr               error
+		exited, interrupted  bool
+	)
+	deadline := time.NewTimer(ta.duration)
+	defer deadline.Stop()
+	ticker := time.NewTicker(testStreamPollInterval)
+	defer ticker.Stop()
+poll:
+	for {
+		select {
+		case <-deadline.C:
+			break poll
+		case <-ctx.Done():
+			interrupted = true
+			break poll
+		case runErr = <-done:
+			exited = true
+			break poll
+		case <-ticker.C:
+			stats, err := client.GetStreamStats(ctx, name)
+			if err != nil || !stats.Ready {
+				continue
+			}
+			if readyAfter == 0 {
+				readyAfter = time.Since(start)
+				firstBytes = stats.BytesReceived
+				fmt.Printf("MediaMTX path %s ready after %s\n", name, readyAfter.Round(100*time.Millisecond))
+			}
+			received = stats.BytesReceived
+		}
+	}
+	stop()
+	if !exited {
+		<-done
+	}
+	if interrupted {
+		return fmt.Errorf("test stream interrupted")
+	}
+
+	fmt.Println()
+	fmt.Println("Results:")
+	failed := 0
+	report := func(ok bool, format string, a ...any) {
+		status := "PASS"
+		if !ok {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("  [%s] %s\n", status, fmt.Sprintf(format, a...))
+	}
+	if exited {
+		report(false, "FFmpeg gave up before the test ended: %v", runErr)
+	}
+	report(readyAfter > 0, "MediaMTX path %s ready", name)
+	report(received > firstBytes, "Audio flowing: %d bytes received by MediaMTX", received)
+	if ta.recordDir != "" {
+		segments := recordedSegments(ta.recordDir, naming.Matcher(name), start)
+		report(len(segments) > 0, "Recording: %d segment(s) in %s", len(segments), ta.recordDir)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("test stream failed %d check(s)", failed)
+	}
+	fmt.Println("\nTest stream passed.")
+	return nil
+}
+
+// recordedSegments returns the non-empty segment files in dir (or its
+// per-day subdirectories) whose names match re and that were written since
+// start.
+func recordedSegments(dir string, re *regexp.Regexp, start time.Time) []string {
+	files, _ := segment.List(dir)
+	var segments []string
+	for _, m := range files {
+		if !re.MatchString(filepath.Base(m)) {
+			continue
+		}
+		info, err := os.Stat(m)
+		if err == nil && info.Size() > 0 && !info.ModTime().Before(start.Truncate(time.Second)) {
+			segments = append(segments, m)
+		}
+	}
+	return segments
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubTestStream points test-stream at a stub FFmpeg that runs until
+// interrupted (writing $STUB_SEGMENT first, if set) and at a fake MediaMTX
+// API whose path reports ready with growing byte counts from the readyFrom'th
+// request on (0 = never). It returns the config path.
+func stubTestStream(t *testing.T, readyFrom int64) string {
+	t.Helper()
+	dir := t.TempDir()
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	script := "#!/bin/sh\n[ -n \"$STUB_SEGMENT\" ] && echo audio > \"$STUB_SEGMENT\"\ntrap 'exit 0' INT TERM\nwhile :; do sleep 0.05; done\n"
+	if err := os.WriteFile(ffmpeg, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	origFFmpeg, origPoll := lookupTestStreamFFmpeg, testStreamPollInterval
+	t.Cleanup(func() { lookupTestStreamFFmpeg, testStreamPollInterval = origFFmpeg, origPoll })
+	lookupTestStreamFFmpeg = func() (string, error) { return ffmpeg, nil }
+	testStreamPollInterval = 50 * time.Millisecond
+
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if !strings.HasSuffix(r.URL.Path, "/v3/paths/get/lyrebird_test") || readyFrom == 0 || n < readyFrom {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"name":"lyrebird_test","ready":true,"bytesReceived":%d}`, n*1000)
+	}))
+	t.Cleanup(srv.Close)
+
+	configPath := filepath.Join(dir, "config.yaml")
+	yaml := fmt.Sprintf("mediamtx:\n  api_url: %s\n  rtsp_url: rtsp://127.0.0.1:8554\n", srv.URL)
+	if err := os.WriteFile(configPath, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return configPath
+}
+
+func TestRunTestStreamPass(t *testing.T) {
+	configPath := stubTestStream(t, 2)
+	recordDir := t.TempDir()
+	t.Setenv("STUB_SEGMENT", filepath.Join(recordDir, "lyrebird_test_20260301_120000.wav"))
+
+	out, err := captureStdout(t, func() error {
+		return runTestStream([]string{"--config", configPath, "--signal=beep", "--ident", "dtmf",
+			"--duration", "1s", "--record-dir", recordDir})
+	})
+	if err != nil {
+		t.Fatalf("runTestStream: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"Publishing a beep test signal",
+		"to rtsp://127.0.0.1:8554/lyrebird_test",
+		"DTMF digits",
+		"[PASS] MediaMTX path lyrebird_test ready",
+		"[PASS] Audio flowing",
+		"[PASS] Recording: 1 segment(s)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunTestStreamFail(t *testing.T) {
+	configPath := stubTestStream(t, 0)
+	out, err := captureStdout(t, func() error {
+		return runTestStream([]string{"--config", configPath, "--duration", "300ms", "--record-dir", t.TempDir()})
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed 3 check(s)") {
+		t.Errorf("error = %v, want 3 failed checks\n%s", err, out)
+	}
+	if !strings.Contains(out, "[FAIL] MediaMTX path lyrebird_test ready") {
+		t.Errorf("output missing the failed readiness check:\n%s", out)
+	}
+}
+
+// TestRunTestStreamAlreadyPublished verifies that test-stream does not
+// publish over a stream that is already live, such as a test source run by
+// the daemon.
+func TestRunTestStreamAlreadyPublished(t *testing.T) {
+	configPath := stubTestStream(t, 1)
+	err := runTestStream([]string{"--config", configPath, "--duration", "1s"})
+	if err == nil || !strings.Contains(err.Error(), "already being published") {
+		t.Errorf("error = %v, want already being published", err)
+	}
+}
+
+func TestParseTestStreamArgs(t *testing.T) {
+	ta, err := parseTestStreamArgs([]string{"--name", "bench", "--duration=2m", "--ident", "none"})
+	if err != nil {
+		t.Fatalf("parseTestStreamArgs: %v", err)
+	}
+	if ta.name != "bench" || ta.duration != 2*time.Minute || ta.ident != "none" || ta.configPath != defaultConfigPath {
+		t.Errorf("parsed %+v", ta)
+	}
+	for _, args := range [][]string{{"--duration", "soon"}, {"--duration=-1s"}, {"--loud"}, {"--name"}} {
+		if _, err := parseTestStreamArgs(args); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestRunTestStreamInvalidSignal(t *testing.T) {
+	configPath := stubTestStream(t, 0)
+	err := runTestStream([]string{"--config", configPath, "--ident", "voice"})
+	if err == nil || !strings.Contains(err.Error(), "flite") {
+		t.Errorf("error = %v, want the voice ident rejected", err)
+	}
+}
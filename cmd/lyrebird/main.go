@@ -55,6 +55,8 @@ func run(args []string) error {
 		return runMigrate(commandArgs)
 	case "validate":
 		return runValidate(commandArgs)
+	case "config":
+		return runConfig(commandArgs)
 	case "status":
 		return runStatus(commandArgs)
 	case "setup":
@@ -63,6 +65,8 @@ func run(args []string) error {
 		return runInstallMediaMTX(commandArgs)
 	case "test":
 		return runTest(commandArgs)
+	case "test-stream":
+		return runTestStream(commandArgs)
 	case "diagnose":
 		return runDiagnose(commandArgs)
 	case "check-system":
@@ -86,15 +90,17 @@ USAGE:
 COMMANDS:
     help              Show this help message
     version           Show version information
-    devices           List detected USB audio devices
+    devices           List detected USB audio devices (--sources: PulseAudio/PipeWire/JACK inputs)
     detect            Detect device capabilities and optimal settings
     usb-map           Create udev rules for persistent device mapping
     migrate           Migrate configuration from bash to YAML
     validate          Validate configuration file
+    config            Show, get, set, diff and restore configuration (see 'lyrebird config')
     status            Show stream status
     setup             Interactive setup wizard
     install-mediamtx  Install MediaMTX RTSP server
     test              Test configuration without modifying system
+    test-stream       Publish a generated test signal and verify MediaMTX and recording
     diagnose          Run system diagnostics
     check-system      Check system compatibility
     update            Check for and install updates
@@ -114,6 +120,9 @@ EXAMPLES:
     # List detected USB audio devices
     lyrebird devices
 
+    # List PulseAudio, PipeWire and JACK capture sources
+    lyrebird devices --sources
+
     # Detect device capabilities
     lyrebird detect
 
@@ -132,9 +141,22 @@ EXAMPLES:
     # Validate configuration
     lyrebird validate --config=/etc/lyrebird/config.yaml
 
+    # Show which file (config.yaml, conf.d/*.yaml) or env var set each value
+    lyrebird config show --origin
+
+    # Change one setting (validated, previous file backed up) and apply it
+    sudo lyrebird config set devices.blue_yeti.bitrate 192k --reload
+
+    # Compare with the newest backup, then roll back
+    lyrebird config diff
+    sudo lyrebird config restore latest --reload
+
     # Test configuration without making changes
     lyrebird test --config=/etc/lyrebird/config.yaml
 
+    # Publish a beep with a DTMF timestamp for 60s and check it is recorded
+    lyrebird test-stream --signal beep --ident dtmf --duration 60s --record-dir /tmp/lyrebird-test
+
     # Run system diagnostics
     lyrebird diagnose
 
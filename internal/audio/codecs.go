@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: MIT
+
+package audio
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Codec describes an audio codec a stream can be encoded with: the FFmpeg
+// encoder, the parameters it accepts, and where its output can go.
+type Codec struct {
+	Name        string   // Config name (e.g. "opus")
+	Encoder     string   // FFmpeg encoder (e.g. "libopus")
+	Description string   // Human-readable name for diagnostics
+	UsesBitrate bool     // Whether -b:a applies; PCM codecs have a fixed rate
+	SampleRates []int    // Accepted sample rates in Hz (nil = any)
+	NativeRate  int      // Rate used instead of a sample rate not in SampleRates (0 = such rates are rejected)
+	MaxChannels int      // Maximum channel count (0 = no codec limit)
+	RTSP        bool     // Whether FFmpeg and MediaMTX can carry it over RTSP/RTP
+	Segments    []string // Local-recording containers the encoded stream muxes into
+}
+
+// codecs is every supported codec. Segment compatibility was verified
+// against ffmpeg 7.x for opus and aac (opus muxes only into ogg, aac only
+// into wav); the others follow the container's codec tables. A codec with
+// no Segments cannot be recorded locally, because the RTSP output and the
+// segment muxer share one encode.
+var codecs = []Codec{
+	{
+		// Opus works at 48 kHz internally and RTP Opus always has a 48 kHz
+		// clock (RFC 7587), so other rates (a 44.1 kHz mic) are resampled
+		// rather than rejected; libopus itself refuses to open at them.
+		Name: "opus", Encoder: "libopus", Description: "Opus",
+		UsesBitrate: true,
+		SampleRates: []int{8000, 12000, 16000, 24000, 48000},
+		NativeRate:  48000,
+		RTSP:        true,
+		Segments:    []string{"ogg"},
+	},
+	{
+		Name: "aac", Encoder: "aac", Description: "AAC",
+		UsesBitrate: true,
+		SampleRates: []int{7350, 8000, 11025, 12000, 16000, 22050, 24000, 32000, 44100, 48000, 64000, 88200, 96000},
+		RTSP:        true,
+		Segments:    []string{"wav"},
+	},
+	{
+		// No RTP payload format exists for FLAC in FFmpeg or MediaMTX, so it
+		// is for record-only devices (archive-grade local copies).
+		Name: "flac", Encoder: "flac", Description: "FLAC",
+		MaxChannels: 8,
+		Segments:    []string{"flac", "ogg"},
+	},
+	{
+		// RTP L16 (RFC 3551) is big-endian; no standard container among
+		// wav/flac/ogg takes big-endian PCM.
+		Name: "pcm", Encoder: "pcm_s16be", Description: "PCM L16",
+		RTSP: true,
+	},
+	{
+		Name: "mp3", Encoder: "libmp3lame", Description: "MP3",
+		UsesBitrate: true,
+		SampleRates: []int{8000, 11025, 12000, 16000, 22050, 24000, 32000, 44100, 48000},
+		MaxChannels: 2,
+		RTSP:        true,
+		Segments:    []string{"wav"},
+	},
+	{
+		// G.711 as carried by RTP payload types 0 and 8: 8 kHz mono.
+		Name: "pcmu", Encoder: "pcm_mulaw", Description: "G.711 mu-law",
+		SampleRates: []int{8000},
+		MaxChannels: 1,
+		RTSP:        true,
+		Segments:    []string{"wav"},
+	},
+	{
+		Name: "pcma", Encoder: "pcm_alaw", Description: "G.711 A-law",
+		SampleRates: []int{8000},
+		MaxChannels: 1,
+		RTSP:        true,
+		Segments:    []string{"wav"},
+	},
+	{
+		Name: "speex", Encoder: "libspeex", Description: "Speex",
+		UsesBitrate: true,
+		SampleRates: []int{8000, 16000, 32000},
+		MaxChannels: 2,
+		RTSP:        true,
+		Segments:    []string{"ogg"},
+	},
+}
+
+// Codecs returns every supported codec.
+func Codecs() []Codec {
+	return slices.Clone(codecs)
+}
+
+// CodecNames returns the config names of every supported codec.
+func CodecNames() []string {
+	names := make([]string, 0, len(codecs))
+	for _, c := range codecs {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// LookupCodec returns the codec with the given config name.
+//
+// Example:
+//
+//	c, ok := audio.LookupCodec("pcmu")
+//	// c.Encoder == "pcm_mulaw", c.SampleRates == []int{8000}
+func LookupCodec(name string) (Codec, bool) {
+	for _, c := range codecs {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Codec{}, false
+}
+
+// ValidateCodec checks that name is a supported codec.
+func ValidateCodec(name string) error {
+	if _, ok := LookupCodec(name); !ok {
+		return fmt.Errorf("codec must be one of %s (got %q)", strings.Join(CodecNames(), ", "), name)
+	}
+	return nil
+}
+
+// CheckParams checks a sample rate and channel count against the codec's
+// constraints. Zero values are not checked.
+func (c Codec) CheckParams(sampleRate, channels int) error {
+	if sampleRate > 0 && c.EncodeRate(sampleRate) == 0 {
+		rates := make([]string, len(c.SampleRates))
+		for i, r := range c.SampleRates {
+			rates[i] = strconv.Itoa(r)
+		}
+		return fmt.Errorf("codec %s needs sample_rate %s (got %d)", c.Name, strings.Join(rates, ", "), sampleRate)
+	}
+	if channels > 0 && c.MaxChannels > 0 && channels > c.MaxChannels {
+		return fmt.Errorf("codec %s supports at most %d channel(s) (got %d)", c.Name, c.MaxChannels, channels)
+	}
+	return nil
+}
+
+// EncodeRate returns the rate the encoder runs at for a configured sample
+// rate: the rate itself when the codec accepts it, else NativeRate (0 when
+// the codec has none and the rate is unsupported).
+func (c Codec) EncodeRate(sampleRate int) int {
+	if c.SampleRates == nil || slices.Contains(c.SampleRates, sampleRate) {
+		return sampleRate
+	}
+	return c.NativeRate
+}
+
+// SupportsSegment reports whether the encoded stream can be muxed into a
+// local-recording segment of the given format.
+func (c Codec) SupportsSegment(format string) bool {
+	return slices.Contains(c.Segments, format)
+}
@@ -0,0 +1,81 @@
+package audio
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupCodec(t *testing.T) {
+	for _, name := range []string{"opus", "aac", "flac", "pcm", "mp3", "pcmu", "pcma", "speex"} {
+		c, ok := LookupCodec(name)
+		if !ok || c.Name != name || c.Encoder == "" {
+			t.Errorf("LookupCodec(%q) = %+v, %v", name, c, ok)
+		}
+	}
+	if _, ok := LookupCodec("vorbis"); ok {
+		t.Error("LookupCodec(vorbis) should fail")
+	}
+	if err := ValidateCodec("vorbis"); err == nil || !strings.Contains(err.Error(), "opus, aac, flac") {
+		t.Errorf("ValidateCodec(vorbis) = %v", err)
+	}
+}
+
+func TestCodecCheckParams(t *testing.T) {
+	tests := []struct {
+		codec       string
+		rate, chans int
+		wantErr     string
+	}{
+		{"pcmu", 8000, 1, ""},
+		{"pcmu", 16000, 1, "codec pcmu needs sample_rate 8000 (got 16000)"},
+		{"pcma", 8000, 2, "codec pcma supports at most 1 channel(s) (got 2)"},
+		{"speex", 48000, 1, "codec speex needs sample_rate 8000, 16000, 32000"},
+		{"opus", 44100, 2, ""}, // resampled to 48 kHz
+		{"pcm", 96000, 8, ""},
+		{"mp3", 48000, 6, "codec mp3 supports at most 2 channel(s)"},
+		{"aac", 0, 0, ""},
+	}
+	for _, tt := range tests {
+		c, _ := LookupCodec(tt.codec)
+		err := c.CheckParams(tt.rate, tt.chans)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s.CheckParams(%d, %d) = %v, want %q", tt.codec, tt.rate, tt.chans, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCodecEncodeRate(t *testing.T) {
+	opus, _ := LookupCodec("opus")
+	if got := opus.EncodeRate(44100); got != 48000 {
+		t.Errorf("opus.EncodeRate(44100) = %d, want 48000", got)
+	}
+	if got := opus.EncodeRate(16000); got != 16000 {
+		t.Errorf("opus.EncodeRate(16000) = %d, want 16000", got)
+	}
+	pcmu, _ := LookupCodec("pcmu")
+	if got := pcmu.EncodeRate(48000); got != 0 {
+		t.Errorf("pcmu.EncodeRate(48000) = %d, want 0 (unsupported)", got)
+	}
+}
+
+func TestCodecSupportsSegment(t *testing.T) {
+	tests := []struct {
+		codec, format string
+		want          bool
+	}{
+		{"opus", "ogg", true},
+		{"opus", "wav", false},
+		{"aac", "wav", true},
+		{"flac", "flac", true},
+		{"flac", "wav", false},
+		{"pcmu", "wav", true},
+		{"speex", "ogg", true},
+		{"pcm", "wav", false},
+	}
+	for _, tt := range tests {
+		c, _ := LookupCodec(tt.codec)
+		if got := c.SupportsSegment(tt.format); got != tt.want {
+			t.Errorf("%s.SupportsSegment(%q) = %v, want %v", tt.codec, tt.format, got, tt.want)
+		}
+	}
+}
@@ -9,12 +9,18 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/udev"
 )
 
 // usbIDHalfRegex matches a single USB ID half: exactly four hexadecimal digits.
 // Pre-compiled at package level to avoid recompiling on every call.
 var usbIDHalfRegex = regexp.MustCompile(`^[0-9a-fA-F]{4}$`)
 
+// usbSysfsPath is where USB devices are looked up to resolve a card's serial
+// number and physical port. Overridden in tests.
+var usbSysfsPath = "/sys/bus/usb/devices"
+
 // Device represents a USB audio device detected from ALSA.
 //
 // Fields match the bash implementation output from get_device_info():
@@ -31,6 +37,8 @@ type Device struct {
 	VendorID   string // USB vendor ID (e.g., "0d8c")
 	ProductID  string // USB product ID (e.g., "0014")
 	DeviceID   string // Device ID from /dev/snd/by-id/ (optional)
+	Serial     string // USB serial number (optional; many devices have none)
+	PortPath   string // Physical USB port path, e.g. "1-1.4" (optional)
 }
 
 // FriendlyName returns the sanitized device name for config lookup.
@@ -214,6 +222,8 @@ func getDeviceInfo(asoundPath string, cardNumber int, byIDDir string) (*Device,
 	// Try to find device ID from by-id directory (injectable for tests)
 	deviceIDPath := findDeviceIDPathIn(byIDDir, cardNumber)
 
+	portPath, serial := usbPortAndSerial(cardDir)
+
 	return &Device{
 		CardNumber: cardNumber,
 		Name:       name,
@@ -221,9 +231,37 @@ func getDeviceInfo(asoundPath string, cardNumber int, byIDDir string) (*Device,
 		VendorID:   vendorID,
 		ProductID:  productID,
 		DeviceID:   deviceIDPath,
+		Serial:     serial,
+		PortPath:   portPath,
 	}, nil
 }
 
+// usbPortAndSerial resolves the physical port path and serial number of a
+// USB sound card from its /proc/asound/cardN/usbbus ("BBB/DDD") entry.
+// Both are empty when the card or its sysfs entry cannot be read; they are
+// only used for device matching, so detection does not fail on them.
+func usbPortAndSerial(cardDir string) (portPath, serial string) {
+	// #nosec G304 - Reading from /proc/asound (kernel filesystem)
+	data, err := os.ReadFile(filepath.Join(cardDir, "usbbus"))
+	if err != nil {
+		return "", ""
+	}
+	bus, dev, ok := strings.Cut(strings.TrimSpace(string(data)), "/")
+	if !ok {
+		return "", ""
+	}
+	busNum, err1 := strconv.Atoi(bus)
+	devNum, err2 := strconv.Atoi(dev)
+	if err1 != nil || err2 != nil {
+		return "", ""
+	}
+	portPath, _, serial, err = udev.GetUSBPhysicalPort(usbSysfsPath, busNum, devNum)
+	if err != nil {
+		return "", ""
+	}
+	return portPath, serial
+}
+
 // ParseUSBID parses a USB ID string into vendor and product IDs.
 //
 // Format: "VVVV:PPPP" where V=vendor hex, P=product hex
@@ -264,3 +264,50 @@ func TestGetDeviceInfoWithByID(t *testing.T) {
 		}
 	})
 }
+
+// TestGetDeviceInfoPortAndSerial verifies the serial number and physical
+// port are resolved through /proc/asound/cardN/usbbus and sysfs.
+func TestGetDeviceInfoPortAndSerial(t *testing.T) {
+	write := func(t *testing.T, path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	asoundDir := t.TempDir()
+	write(t, filepath.Join(asoundDir, "card1", "usbid"), "0d8c:0014\n")
+	write(t, filepath.Join(asoundDir, "card1", "id"), "Device\n")
+	write(t, filepath.Join(asoundDir, "card1", "usbbus"), "001/007\n")
+	write(t, filepath.Join(asoundDir, "card2", "usbid"), "0d8c:0014\n")
+	write(t, filepath.Join(asoundDir, "card2", "id"), "Device_1\n")
+
+	sysfs := t.TempDir()
+	write(t, filepath.Join(sysfs, "1-1.4", "busnum"), "1\n")
+	write(t, filepath.Join(sysfs, "1-1.4", "devnum"), "7\n")
+	write(t, filepath.Join(sysfs, "1-1.4", "serial"), "SN-42\n")
+
+	orig := usbSysfsPath
+	usbSysfsPath = sysfs
+	t.Cleanup(func() { usbSysfsPath = orig })
+
+	dev, err := getDeviceInfo(asoundDir, 1, "/nonexistent/by-id")
+	if err != nil {
+		t.Fatalf("getDeviceInfo() error = %v", err)
+	}
+	if dev.PortPath != "1-1.4" || dev.Serial != "SN-42" {
+		t.Errorf("PortPath, Serial = %q, %q; want 1-1.4, SN-42", dev.PortPath, dev.Serial)
+	}
+
+	// No usbbus entry: detection still succeeds, identity fields stay empty.
+	dev, err = getDeviceInfo(asoundDir, 2, "/nonexistent/by-id")
+	if err != nil {
+		t.Fatalf("getDeviceInfo() error = %v", err)
+	}
+	if dev.PortPath != "" || dev.Serial != "" {
+		t.Errorf("PortPath, Serial = %q, %q; want empty", dev.PortPath, dev.Serial)
+	}
+}
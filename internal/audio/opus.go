@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: MIT
+
+package audio
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// OpusSettings are libopus encoder options. Zero fields leave the libopus
+// default (application audio, 20 ms frames, VBR on, complexity 10, no FEC).
+type OpusSettings struct {
+	Application   string  // "voip" (speech), "audio" (music, birdsong) or "lowdelay"
+	FrameDuration float64 // Frame length in ms: 2.5, 5, 10, 20, 40, 60, 80, 100 or 120
+	VBR           string  // "on", "off" (CBR) or "constrained"
+	Complexity    int     // Encoder effort, 1 (cheapest) to 10 (best)
+	PacketLoss    int     // Expected packet loss in percent, 1 to 100; tunes FEC
+	FEC           bool    // In-band forward error correction (needs PacketLoss)
+}
+
+// Opus encoder presets per quality tier.
+var opusPresets = map[QualityTier]OpusSettings{
+	// Speech over constrained, possibly lossy links: longer frames cut
+	// per-packet overhead at low bitrates, and FEC recovers lost packets.
+	QualityLow: {
+		Application:   "voip",
+		FrameDuration: 40,
+		VBR:           "on",
+		Complexity:    5,
+		PacketLoss:    10,
+		FEC:           true,
+	},
+	// General field recording: full-band audio mode at moderate effort, which
+	// leaves headroom on a Raspberry Pi running several streams.
+	QualityNormal: {
+		Application:   "audio",
+		FrameDuration: 20,
+		VBR:           "on",
+		Complexity:    8,
+	},
+	// Music and bioacoustics archives: maximum effort, constrained VBR so the
+	// stream bitrate stays predictable.
+	QualityHigh: {
+		Application:   "audio",
+		FrameDuration: 20,
+		VBR:           "constrained",
+		Complexity:    10,
+	},
+}
+
+// OpusPreset returns the Opus encoder settings for a quality tier.
+//
+// Example:
+//
+//	s := audio.OpusPreset(audio.QualityLow) // voip, 40 ms frames, FEC
+func OpusPreset(tier QualityTier) OpusSettings {
+	if p, ok := opusPresets[tier]; ok {
+		return p
+	}
+	return opusPresets[QualityNormal]
+}
+
+// Merge returns s with every non-zero field of o applied.
+func (s OpusSettings) Merge(o OpusSettings) OpusSettings {
+	if o.Application != "" {
+		s.Application = o.Application
+	}
+	if o.FrameDuration != 0 {
+		s.FrameDuration = o.FrameDuration
+	}
+	if o.VBR != "" {
+		s.VBR = o.VBR
+	}
+	if o.Complexity != 0 {
+		s.Complexity = o.Complexity
+	}
+	if o.PacketLoss != 0 {
+		s.PacketLoss = o.PacketLoss
+	}
+	if o.FEC {
+		s.FEC = true
+	}
+	return s
+}
+
+// opusFrameDurations are the frame lengths libopus accepts, in ms.
+var opusFrameDurations = []float64{2.5, 5, 10, 20, 40, 60, 80, 100, 120}
+
+// Validate checks each set field against the values libopus accepts.
+func (s OpusSettings) Validate() error {
+	switch s.Application {
+	case "", "voip", "audio", "lowdelay":
+	default:
+		return fmt.Errorf("application must be voip, audio or lowdelay (got %q)", s.Application)
+	}
+	if s.FrameDuration != 0 && !slices.Contains(opusFrameDurations, s.FrameDuration) {
+		return fmt.Errorf("frame_duration must be one of 2.5, 5, 10, 20, 40, 60, 80, 100, 120 ms (got %v)", s.FrameDuration)
+	}
+	switch s.VBR {
+	case "", "on", "off", "constrained":
+	default:
+		return fmt.Errorf("vbr must be on, off or constrained (got %q)", s.VBR)
+	}
+	if s.Complexity < 0 || s.Complexity > 10 {
+		return fmt.Errorf("complexity must be between 1 and 10 (got %d)", s.Complexity)
+	}
+	if s.PacketLoss < 0 || s.PacketLoss > 100 {
+		return fmt.Errorf("packet_loss must be between 1 and 100 percent (got %d)", s.PacketLoss)
+	}
+	return nil
+}
+
+// ValidateResolved checks the rules between fields once every layer is
+// merged.
+func (s OpusSettings) ValidateResolved() error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if s.FEC && s.PacketLoss == 0 {
+		return fmt.Errorf("fec needs packet_loss: libopus only adds FEC data for an expected loss above 0%%")
+	}
+	return nil
+}
+
+// Args returns the libopus encoder options for the set fields, for use after
+// -c:a libopus. Zero fields add nothing, leaving the libopus default.
+//
+// Example:
+//
+//	audio.OpusPreset(audio.QualityLow).Args()
+//	// [-application voip -frame_duration 40 -vbr on -compression_level 5 -packet_loss 10 -fec 1]
+func (s OpusSettings) Args() []string {
+	var args []string
+	if s.Application != "" {
+		args = append(args, "-application", s.Application)
+	}
+	if s.FrameDuration != 0 {
+		args = append(args, "-frame_duration", strconv.FormatFloat(s.FrameDuration, 'f', -1, 64))
+	}
+	if s.VBR != "" {
+		args = append(args, "-vbr", s.VBR)
+	}
+	if s.Complexity != 0 {
+		args = append(args, "-compression_level", strconv.Itoa(s.Complexity))
+	}
+	if s.PacketLoss != 0 {
+		args = append(args, "-packet_loss", strconv.Itoa(s.PacketLoss))
+	}
+	if s.FEC {
+		args = append(args, "-fec", "1")
+	}
+	return args
+}
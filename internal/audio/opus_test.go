@@ -0,0 +1,82 @@
+package audio
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOpusPreset(t *testing.T) {
+	low := OpusPreset(QualityLow)
+	if low.Application != "voip" || !low.FEC || low.PacketLoss == 0 {
+		t.Errorf("low preset = %+v, want voip with FEC", low)
+	}
+	if high := OpusPreset(QualityHigh); high.Complexity != 10 || high.VBR != "constrained" {
+		t.Errorf("high preset = %+v", high)
+	}
+	if got := OpusPreset("bogus"); got != OpusPreset(QualityNormal) {
+		t.Errorf("unknown tier = %+v, want the normal preset", got)
+	}
+	for _, tier := range []QualityTier{QualityLow, QualityNormal, QualityHigh} {
+		if err := OpusPreset(tier).ValidateResolved(); err != nil {
+			t.Errorf("preset %s invalid: %v", tier, err)
+		}
+	}
+}
+
+func TestOpusSettingsMerge(t *testing.T) {
+	got := OpusPreset(QualityLow).Merge(OpusSettings{FrameDuration: 60, Complexity: 3})
+	want := OpusPreset(QualityLow)
+	want.FrameDuration, want.Complexity = 60, 3
+	if got != want {
+		t.Errorf("Merge = %+v, want %+v", got, want)
+	}
+}
+
+func TestOpusSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       OpusSettings
+		wantErr string
+	}{
+		{"zero", OpusSettings{}, ""},
+		{"fractional frame", OpusSettings{FrameDuration: 2.5}, ""},
+		{"application", OpusSettings{Application: "music"}, `application must be voip, audio or lowdelay (got "music")`},
+		{"frame", OpusSettings{FrameDuration: 30}, "frame_duration must be one of"},
+		{"vbr", OpusSettings{VBR: "yes"}, "vbr must be on, off or constrained"},
+		{"complexity", OpusSettings{Complexity: 11}, "complexity must be between 1 and 10"},
+		{"packet loss", OpusSettings{PacketLoss: 101}, "packet_loss must be between 1 and 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.s.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := (OpusSettings{FEC: true}).ValidateResolved(); err == nil || !strings.Contains(err.Error(), "fec needs packet_loss") {
+		t.Errorf("FEC without packet_loss = %v", err)
+	}
+}
+
+func TestOpusSettingsArgs(t *testing.T) {
+	want := []string{"-application", "voip", "-frame_duration", "40", "-vbr", "on",
+		"-compression_level", "5", "-packet_loss", "10", "-fec", "1"}
+	if got := OpusPreset(QualityLow).Args(); !slices.Equal(got, want) {
+		t.Errorf("Args() = %v, want %v", got, want)
+	}
+	if got := (OpusSettings{FrameDuration: 2.5}).Args(); !slices.Equal(got, []string{"-frame_duration", "2.5"}) {
+		t.Errorf("Args() = %v", got)
+	}
+	if got := (OpusSettings{}).Args(); len(got) != 0 {
+		t.Errorf("zero Args() = %v, want none", got)
+	}
+}
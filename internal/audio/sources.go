@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: MIT
+
+package audio
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Source is a capture input offered by a sound server rather than a USB card
+// under /proc/asound. It is what a device entry's source: block names.
+type Source struct {
+	Backend string // "pulse", "pipewire" or "jack" (the source.type to configure)
+	Name    string // source.name (pulse, pipewire) or the port for source.ports (jack)
+	Spec    string // Sample format as the server reports it (e.g. "s16le 2ch 48000Hz"); empty for JACK
+	Monitor bool   // pulse/pipewire: a monitor of an output (what is playing), not a microphone
+}
+
+// StableName returns a deterministic stream name for the source, derived
+// from the server's own identifier rather than an index, so it survives
+// reboots and servers renumbering their sources. It is the suggested device
+// entry name; any entry name works.
+//
+// Example:
+//
+//	Source{Backend: "pipewire", Name: "alsa_input.usb-BLUE_Yeti-00.analog-stereo"}.StableName()
+//	// "usb_BLUE_Yeti_00_analog_stereo"
+func (s Source) StableName() string {
+	return SanitizeDeviceName(strings.TrimPrefix(s.Name, "alsa_input."))
+}
+
+// DiscoverPulseSources lists the sources of the PulseAudio or PipeWire
+// server the current user reaches, using pactl. PipeWire answers through
+// pipewire-pulse and reports its sources with driver "PipeWire".
+func DiscoverPulseSources(ctx context.Context) ([]Source, error) {
+	out, err := exec.CommandContext(ctx, "pactl", "list", "short", "sources").Output()
+	if err != nil {
+		return nil, fmt.Errorf("pactl list short sources: %w", err)
+	}
+	return ParsePactlSources(string(out)), nil
+}
+
+// ParsePactlSources parses "pactl list short sources" output: one source
+// per line, tab-separated as index, name, driver, sample spec and state.
+func ParsePactlSources(out string) []Source {
+	var sources []Source
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		if len(fields) < 3 || fields[1] == "" {
+			continue
+		}
+		src := Source{Backend: "pulse", Name: fields[1], Monitor: strings.HasSuffix(fields[1], ".monitor")}
+		if strings.EqualFold(fields[2], "PipeWire") {
+			src.Backend = "pipewire"
+		}
+		if len(fields) > 3 {
+			src.Spec = fields[3]
+		}
+		sources = append(sources, src)
+	}
+	return sources
+}
+
+// DiscoverJACKPorts lists the capture ports of the running JACK server
+// (or PipeWire's JACK emulation), using jack_lsp.
+func DiscoverJACKPorts(ctx context.Context) ([]Source, error) {
+	out, err := exec.CommandContext(ctx, "jack_lsp", "-p").Output()
+	if err != nil {
+		return nil, fmt.Errorf("jack_lsp -p: %w", err)
+	}
+	return ParseJACKPorts(string(out)), nil
+}
+
+// ParseJACKPorts parses "jack_lsp -p" output: each port name is followed by
+// an indented "properties:" line. Only output ports (audio leaving a client,
+// which is what can be recorded) are returned.
+func ParseJACKPorts(out string) []Source {
+	var sources []Source
+	port := ""
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			port = strings.TrimSpace(line)
+			continue
+		}
+		props, ok := strings.CutPrefix(strings.TrimSpace(line), "properties:")
+		if !ok || port == "" {
+			continue
+		}
+		for _, p := range strings.Split(props, ",") {
+			if strings.TrimSpace(p) == "output" {
+				sources = append(sources, Source{Backend: "jack", Name: port})
+				break
+			}
+		}
+		port = ""
+	}
+	return sources
+}
@@ -0,0 +1,60 @@
+package audio
+
+import (
+	"testing"
+)
+
+func TestParsePactlSources(t *testing.T) {
+	out := "49\talsa_output.pci-0000_00_1f.3.analog-stereo.monitor\tPipeWire\ts32le 2ch 48000Hz\tSUSPENDED\n" +
+		"50\talsa_input.usb-BLUE_Yeti-00.analog-stereo\tPipeWire\ts16le 2ch 48000Hz\tRUNNING\n" +
+		"\n" +
+		"3\talsa_input.pci.analog-stereo\tmodule-alsa-card.c\ts16le 2ch 44100Hz\tIDLE\n"
+
+	got := ParsePactlSources(out)
+	if len(got) != 3 {
+		t.Fatalf("got %d sources, want 3: %+v", len(got), got)
+	}
+	if !got[0].Monitor || got[0].Backend != "pipewire" {
+		t.Errorf("monitor source = %+v", got[0])
+	}
+	want := Source{Backend: "pipewire", Name: "alsa_input.usb-BLUE_Yeti-00.analog-stereo", Spec: "s16le 2ch 48000Hz"}
+	if got[1] != want {
+		t.Errorf("source = %+v, want %+v", got[1], want)
+	}
+	if got[2].Backend != "pulse" {
+		t.Errorf("PulseAudio source backend = %q, want pulse", got[2].Backend)
+	}
+}
+
+func TestParseJACKPorts(t *testing.T) {
+	out := "system:capture_1\n" +
+		"\tproperties: output,physical,terminal,\n" +
+		"system:playback_1\n" +
+		"\tproperties: input,physical,terminal,\n" +
+		"mixer:out_left\n" +
+		"\tproperties: output,\n"
+
+	got := ParseJACKPorts(out)
+	if len(got) != 2 || got[0].Name != "system:capture_1" || got[1].Name != "mixer:out_left" {
+		t.Fatalf("ParseJACKPorts = %+v, want the two output ports", got)
+	}
+	if got[0].Backend != "jack" {
+		t.Errorf("backend = %q, want jack", got[0].Backend)
+	}
+}
+
+func TestSourceStableName(t *testing.T) {
+	tests := []struct {
+		src  Source
+		want string
+	}{
+		{Source{Backend: "pipewire", Name: "alsa_input.usb-BLUE_Yeti-00.analog-stereo"}, "usb_BLUE_Yeti_00_analog_stereo"},
+		{Source{Backend: "pulse", Name: "bluez_input.00:1B:66:AA:BB:CC"}, "bluez_input_00_1B_66_AA_BB_CC"},
+		{Source{Backend: "jack", Name: "system:capture_1"}, "system_capture_1"},
+	}
+	for _, tt := range tests {
+		if got := tt.src.StableName(); got != tt.want {
+			t.Errorf("StableName(%q) = %q, want %q", tt.src.Name, got, tt.want)
+		}
+	}
+}
@@ -4,10 +4,13 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
 	"go.yaml.in/yaml/v3"
 )
 
@@ -16,9 +19,21 @@ const ConfigFilePath = "/etc/lyrebird/config.yaml"
 
 // Config represents the complete LyreBird configuration.
 type Config struct {
+	// Version is the schema version of the file (see CurrentConfigVersion).
+	// Older files are migrated on load; 0 means written before versioning.
+	Version int `yaml:"version" koanf:"version"`
+
 	// Devices contains device-specific configuration keyed by sanitized device name.
 	Devices map[string]DeviceConfig `yaml:"devices" koanf:"devices"`
 
+	// StaticSources are network streams (IP microphones, remote RTSP/HTTP/SRT
+	// sources) pulled and republished like a local device, keyed by stream name.
+	StaticSources map[string]StaticSourceConfig `yaml:"static_sources,omitempty" koanf:"static_sources"`
+
+	// TestSources publish a generated test signal through the full pipeline,
+	// for verifying an installation without hardware, keyed by stream name.
+	TestSources map[string]TestSourceConfig `yaml:"test_sources,omitempty" koanf:"test_sources"`
+
 	// Default configuration used when device-specific config not found.
 	Default DeviceConfig `yaml:"default" koanf:"default"`
 
@@ -30,15 +45,86 @@ type Config struct {
 
 	// Monitor settings for health checks.
 	Monitor MonitorConfig `yaml:"monitor" koanf:"monitor"`
+
+	// ConfigFile controls how the daemon follows edits to its config file.
+	ConfigFile ConfigFileConfig `yaml:"config" koanf:"config"`
+
+	// Timecode controls the capture-time metadata embedded in recordings
+	// and published streams.
+	Timecode TimecodeConfig `yaml:"timecode" koanf:"timecode"`
 }
 
-// DeviceConfig contains FFmpeg encoding parameters for a device.
+// DeviceConfig contains FFmpeg encoding parameters for a device, plus
+// optional per-device overrides of the stream: and monitor: settings.
+//
+// Every field left at its zero value is inherited (see GetDeviceConfig): a
+// device entry inherits from default:, and the override fields of default:
+// inherit from stream: and monitor:. A consequence is that an override cannot
+// set a field back to zero (e.g. "no retention limit" for one device when
+// stream: sets one); use a large value instead.
 type DeviceConfig struct {
-	SampleRate  int    `yaml:"sample_rate" koanf:"sample_rate"`   // Sample rate in Hz (e.g., 48000)
-	Channels    int    `yaml:"channels" koanf:"channels"`         // Number of audio channels (1=mono, 2=stereo)
-	Bitrate     string `yaml:"bitrate" koanf:"bitrate"`           // Bitrate (e.g., "128k", "192k")
-	Codec       string `yaml:"codec" koanf:"codec"`               // Audio codec ("opus" or "aac")
-	ThreadQueue int    `yaml:"thread_queue" koanf:"thread_queue"` // FFmpeg thread queue size
+	SampleRate  int        `yaml:"sample_rate" koanf:"sample_rate"`   // Sample rate in Hz (e.g., 48000)
+	Channels    int        `yaml:"channels" koanf:"channels"`         // Number of audio channels (1=mono, 2=stereo)
+	Bitrate     string     `yaml:"bitrate" koanf:"bitrate"`           // Bitrate (e.g., "128k", "192k")
+	Codec       string     `yaml:"codec" koanf:"codec"`               // Audio codec: opus, aac, flac, pcm (L16), mp3, pcmu/pcma (G.711) or speex
+	ThreadQueue int        `yaml:"thread_queue" koanf:"thread_queue"` // FFmpeg thread queue size
+	Opus        OpusConfig `yaml:"opus,omitempty" koanf:"opus"`       // libopus tuning; used only with codec opus
+
+	// Overrides of stream: and monitor: settings (empty/0 = inherit).
+	Mode                 string        `yaml:"mode,omitempty" koanf:"mode"`                                       // "both" (default), "record_only" (no RTSP publish) or "stream_only" (no local recording)
+	Recorder             string        `yaml:"recorder,omitempty" koanf:"recorder"`                               // Mode both: "tee" (default, one FFmpeg publishes and records) or "separate" (a second long-lived FFmpeg records)
+	LocalRecordDir       string        `yaml:"local_record_dir,omitempty" koanf:"local_record_dir"`               // Overrides stream.local_record_dir
+	SegmentDuration      int           `yaml:"segment_duration,omitempty" koanf:"segment_duration"`               // Overrides stream.segment_duration (seconds)
+	SegmentFormat        string        `yaml:"segment_format,omitempty" koanf:"segment_format"`                   // Overrides stream.segment_format
+	SegmentName          string        `yaml:"segment_name,omitempty" koanf:"segment_name"`                       // Overrides stream.segment_name
+	SegmentTimezone      string        `yaml:"segment_timezone,omitempty" koanf:"segment_timezone"`               // Overrides stream.segment_timezone
+	SegmentLayout        string        `yaml:"segment_layout,omitempty" koanf:"segment_layout"`                   // Overrides stream.segment_layout
+	SegmentAlign         string        `yaml:"segment_align,omitempty" koanf:"segment_align"`                     // Overrides stream.segment_align
+	SegmentMaxAge        time.Duration `yaml:"segment_max_age,omitempty" koanf:"segment_max_age"`                 // Overrides stream.segment_max_age for this device's segments
+	SegmentMaxTotalBytes int64         `yaml:"segment_max_total_bytes,omitempty" koanf:"segment_max_total_bytes"` // Overrides stream.segment_max_total_bytes for this device's segments
+	InitialRestartDelay  time.Duration `yaml:"initial_restart_delay,omitempty" koanf:"initial_restart_delay"`     // Overrides stream.initial_restart_delay
+	MaxRestartDelay      time.Duration `yaml:"max_restart_delay,omitempty" koanf:"max_restart_delay"`             // Overrides stream.max_restart_delay
+	MaxRestartAttempts   int           `yaml:"max_restart_attempts,omitempty" koanf:"max_restart_attempts"`       // Overrides stream.max_restart_attempts
+	MaxStallChecks       int           `yaml:"max_stall_checks,omitempty" koanf:"max_stall_checks"`               // Overrides monitor.max_stall_checks
+
+	Schedule   ScheduleConfig    `yaml:"schedule,omitempty" koanf:"schedule"`     // Daily operating windows (default: always on)
+	Filters    []FilterConfig    `yaml:"filters,omitempty" koanf:"filters"`       // Audio processing before encoding, in order; replaces the inherited list (default: none)
+	Renditions []RenditionConfig `yaml:"renditions,omitempty" koanf:"renditions"` // Further encodings published from the same capture as <device><suffix>; replaces the inherited list (default: none)
+
+	// Entry selection (device entries only; not inherited).
+	Match  DeviceMatch  `yaml:"match,omitempty" koanf:"match"`   // Select devices by USB ID, serial, by-id name, port or card name instead of by entry name (see ResolveDevice)
+	Ignore bool         `yaml:"ignore,omitempty" koanf:"ignore"` // Never stream devices that resolve to this entry
+	Source SourceConfig `yaml:"source,omitempty" koanf:"source"` // Capture from PulseAudio, PipeWire or JACK instead of a detected USB card
+}
+
+// Device modes (DeviceConfig.Mode).
+const (
+	DeviceModeBoth       = "both"        // Publish to RTSP and record locally (when local_record_dir is set)
+	DeviceModeRecordOnly = "record_only" // Record locally only; nothing is published to MediaMTX
+	DeviceModeStreamOnly = "stream_only" // Publish to RTSP only, even when local_record_dir is set
+)
+
+// Recorders (DeviceConfig.Recorder): how a device in mode both records.
+const (
+	RecorderTee      = "tee"      // The publishing FFmpeg also writes the segments; any restart cuts the recording
+	RecorderSeparate = "separate" // A second FFmpeg records on its own and keeps running when the publisher restarts
+)
+
+// RecordsSeparately reports whether a resolved device config records in a
+// recorder process of its own, next to the publisher.
+func (d DeviceConfig) RecordsSeparately() bool {
+	return d.Recorder == RecorderSeparate && d.RecordsLocally() && d.PublishesRTSP()
+}
+
+// RecordsLocally reports whether a resolved device config writes local
+// recording segments.
+func (d DeviceConfig) RecordsLocally() bool {
+	return d.LocalRecordDir != "" && d.Mode != DeviceModeStreamOnly
+}
+
+// PublishesRTSP reports whether a resolved device config publishes to MediaMTX.
+func (d DeviceConfig) PublishesRTSP() bool {
+	return d.Mode != DeviceModeRecordOnly
 }
 
 // StreamConfig contains stream lifecycle management settings.
@@ -51,15 +137,23 @@ type StreamConfig struct {
 	LocalRecordDir        string        `yaml:"local_record_dir" koanf:"local_record_dir"`               // C-1 fix: local recording directory (empty = disabled)
 	SegmentDuration       int           `yaml:"segment_duration" koanf:"segment_duration"`               // C-1 fix: segment duration in seconds (default: 3600)
 	SegmentFormat         string        `yaml:"segment_format" koanf:"segment_format"`                   // C-1 fix: segment container (default: ogg). Must be compatible with the codec: opus→ogg, aac→wav (validated at load)
+	SegmentName           string        `yaml:"segment_name,omitempty" koanf:"segment_name"`             // Segment file name template (default: {stream}_{date}_{time}; see segment.ValidateTemplate)
+	SegmentTimezone       string        `yaml:"segment_timezone,omitempty" koanf:"segment_timezone"`     // Time zone of segment names, day directories and boundaries: "local" (default) or "utc"
+	SegmentLayout         string        `yaml:"segment_layout,omitempty" koanf:"segment_layout"`         // "flat" (default) or "daily" (per-day YYYY-MM-DD subdirectories)
+	SegmentAlign          string        `yaml:"segment_align,omitempty" koanf:"segment_align"`           // Segment boundaries: "start" (default, relative to FFmpeg start) or "clock" (multiples of segment_duration from midnight)
 	SegmentMaxAge         time.Duration `yaml:"segment_max_age" koanf:"segment_max_age"`                 // GAP-1c: max age of recording segments before deletion (0 = no limit)
 	SegmentMaxTotalBytes  int64         `yaml:"segment_max_total_bytes" koanf:"segment_max_total_bytes"` // GAP-1c: max total bytes in LocalRecordDir before oldest deletion (0 = no limit)
+	DeviceRemovalGrace    time.Duration `yaml:"device_removal_grace" koanf:"device_removal_grace"`       // How long a registered device may be missing before its stream is stopped (0 = stop immediately)
 }
 
 // MediaMTXConfig contains MediaMTX server integration settings.
 type MediaMTXConfig struct {
-	APIURL     string `yaml:"api_url" koanf:"api_url"`         // API endpoint (e.g., "http://localhost:9997")
-	RTSPURL    string `yaml:"rtsp_url" koanf:"rtsp_url"`       // RTSP server URL (e.g., "rtsp://localhost:8554")
-	ConfigPath string `yaml:"config_path" koanf:"config_path"` // Path to mediamtx.yml
+	APIURL       string        `yaml:"api_url" koanf:"api_url"`             // API endpoint (e.g., "http://localhost:9997")
+	RTSPURL      string        `yaml:"rtsp_url" koanf:"rtsp_url"`           // RTSP server URL (e.g., "rtsp://localhost:8554")
+	ConfigPath   string        `yaml:"config_path" koanf:"config_path"`     // Path to mediamtx.yml
+	Managed      bool          `yaml:"managed" koanf:"managed"`             // Run MediaMTX as a supervised child of lyrebird-stream with a generated config (default: false, use the standalone mediamtx.service)
+	BinaryPath   string        `yaml:"binary_path" koanf:"binary_path"`     // mediamtx executable used in managed mode (default: /usr/local/bin/mediamtx)
+	ReadyTimeout time.Duration `yaml:"ready_timeout" koanf:"ready_timeout"` // Managed mode: how long a (re)started MediaMTX may take to answer its API (default: 30s)
 }
 
 // MonitorConfig contains health monitoring settings.
@@ -73,6 +167,71 @@ type MonitorConfig struct {
 	DiskLowThresholdMB int64         `yaml:"disk_low_threshold_mb" koanf:"disk_low_threshold_mb"` // GAP-1d: warn when free disk < this value in MB (0 = disabled)
 }
 
+// ConfigFileConfig controls automatic reload of the configuration file.
+//
+// With Watch enabled the daemon reloads on file-system events as if it had
+// received SIGHUP. Editors that save via a temporary file and rename emit
+// several events per save; they are coalesced into one reload once the file
+// has been quiet for WatchDebounce.
+//
+// Keys that no field reads (typos, settings removed by a later release) are
+// reported; see FindUnknownKeys. The daemon logs them as warnings, so a
+// station still starts after an upgrade; with Strict enabled a config file
+// containing them is rejected instead.
+type ConfigFileConfig struct {
+	Watch         bool          `yaml:"watch" koanf:"watch"`                   // Reload automatically when the config file changes
+	WatchDebounce time.Duration `yaml:"watch_debounce" koanf:"watch_debounce"` // Quiet period before a watched change is reloaded (default: 500ms)
+	Strict        bool          `yaml:"strict" koanf:"strict"`                 // Reject unknown keys (default: false, they are logged as warnings)
+}
+
+// Validate checks config-file watch settings.
+func (c *ConfigFileConfig) Validate() error {
+	if c.WatchDebounce < 0 {
+		return fmt.Errorf("watch_debounce must not be negative (got %v)", c.WatchDebounce)
+	}
+	return nil
+}
+
+// Time sources (TimecodeConfig.TimeSource).
+const (
+	TimeSourceNTP    = "ntp"    // Check that the clock is NTP-synchronized at each start
+	TimeSourceSystem = "system" // Trust the system clock without checking
+)
+
+// TimecodeConfig controls the capture metadata written at every FFmpeg
+// start: the capture start time (UTC), whether the clock was synchronized,
+// and host and device identifiers. It goes into each recording segment's
+// container metadata (Ogg/FLAC comments, WAV INFO and bext) and into the
+// session name of the SDP announced to MediaMTX, so recordings from several
+// stations can be aligned and their timestamps trusted or discounted.
+type TimecodeConfig struct {
+	Enabled    bool   `yaml:"enabled" koanf:"enabled"`         // Embed capture metadata (default: true)
+	TimeSource string `yaml:"time_source" koanf:"time_source"` // "ntp" (default): check NTP sync at each start and warn if recording starts unsynchronized; "system": trust the clock unchecked (GPS/PTP-disciplined hosts)
+	HostID     string `yaml:"host_id" koanf:"host_id"`         // Station identifier in the metadata (default: the hostname)
+}
+
+// Validate checks the timecode settings.
+func (t *TimecodeConfig) Validate() error {
+	switch t.TimeSource {
+	case "", TimeSourceNTP, TimeSourceSystem:
+	default:
+		return fmt.Errorf("time_source must be ntp or system (got %q)", t.TimeSource)
+	}
+	if strings.ContainsAny(t.HostID, "\x00\n\r") {
+		return fmt.Errorf("host_id must be a single line")
+	}
+	return nil
+}
+
+// Host returns the station identifier: HostID, or else the hostname.
+func (t TimecodeConfig) Host() string {
+	if t.HostID != "" {
+		return t.HostID
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
 // LoadConfig reads and parses the configuration file.
 //
 // Parameters:
@@ -100,13 +259,38 @@ func LoadConfig(path string) (*Config, error) {
 	// Parse YAML on top of the built-in defaults so that omitted fields keep
 	// their documented default (matching the daemon's koanf loader). yaml.v3
 	// only sets fields present in the document, leaving the rest untouched.
+	//
+	// Files written by older releases are upgraded in memory first; the
+	// daemon rewrites them on disk with MigrateConfigFile.
+	data, _, err = migrateLoadedYAML(data)
+	if err != nil {
+		return nil, err
+	}
 	cfg := *DefaultConfig()
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
 	}
 
-	// Validate configuration
-	if err := cfg.Validate(); err != nil {
+	// yaml.v3 ignores keys with no matching field, so a typo silently falls
+	// back to the default. Reject them unless the file opts out.
+	if cfg.ConfigFile.Strict {
+		unknown, err := FindUnknownKeys(path, data)
+		if err != nil {
+			return nil, err
+		}
+		if len(unknown) > 0 {
+			return nil, &UnknownKeysError{Keys: unknown}
+		}
+	}
+
+	// Validate configuration. Secret references are left unresolved (see
+	// secrets.go), so a static source URL from url_file is validated as the
+	// equivalent inline reference.
+	vcfg, err := withStaticURLFiles(&cfg, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := vcfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
@@ -209,10 +393,10 @@ func (c *Config) saveWith(path string, createTemp atomicCreateTemp) error {
 // GetDeviceConfig returns configuration for a device, falling back to defaults.
 //
 // This is the primary config lookup method used by the stream manager.
-// It performs a two-stage lookup:
-//  1. Check for device-specific configuration
-//  2. Fall back to default configuration
-//  3. Merge defaults for any unset fields
+// Each field is taken from the first layer that sets it (non-zero):
+//  1. The device's own entry under devices:
+//  2. default:
+//  3. stream: / monitor: (override fields only, see DeviceSettingFallback)
 //
 // Parameters:
 //   - deviceName: Sanitized device name (e.g., "blue_yeti")
@@ -226,32 +410,123 @@ func (c *Config) saveWith(path string, createTemp atomicCreateTemp) error {
 //	devCfg := cfg.GetDeviceConfig("blue_yeti")
 //	fmt.Printf("Sample rate: %d\n", devCfg.SampleRate)
 func (c *Config) GetDeviceConfig(deviceName string) DeviceConfig {
-	// Start with default config
-	result := c.Default
-
-	// Look up device-specific config
+	// Start with the global stream:/monitor: settings the overrides inherit.
+	result := DeviceConfig{
+		LocalRecordDir:       c.Stream.LocalRecordDir,
+		SegmentDuration:      c.Stream.SegmentDuration,
+		SegmentFormat:        c.Stream.SegmentFormat,
+		SegmentName:          c.Stream.SegmentName,
+		SegmentTimezone:      c.Stream.SegmentTimezone,
+		SegmentLayout:        c.Stream.SegmentLayout,
+		SegmentAlign:         c.Stream.SegmentAlign,
+		SegmentMaxAge:        c.Stream.SegmentMaxAge,
+		SegmentMaxTotalBytes: c.Stream.SegmentMaxTotalBytes,
+		InitialRestartDelay:  c.Stream.InitialRestartDelay,
+		MaxRestartDelay:      c.Stream.MaxRestartDelay,
+		MaxRestartAttempts:   c.Stream.MaxRestartAttempts,
+		MaxStallChecks:       c.Monitor.MaxStallChecks,
+	}
+	result.overlay(c.Default)
+
+	// Override defaults with device-specific values (if set)
 	if devCfg, ok := c.Devices[deviceName]; ok {
-		// Override defaults with device-specific values (if set)
-		if devCfg.SampleRate != 0 {
-			result.SampleRate = devCfg.SampleRate
-		}
-		if devCfg.Channels != 0 {
-			result.Channels = devCfg.Channels
-		}
-		if devCfg.Bitrate != "" {
-			result.Bitrate = devCfg.Bitrate
-		}
-		if devCfg.Codec != "" {
-			result.Codec = devCfg.Codec
-		}
-		if devCfg.ThreadQueue != 0 {
-			result.ThreadQueue = devCfg.ThreadQueue
-		}
+		result.overlay(devCfg)
+		// source: is entry-only (default: rejects it), so it is copied
+		// rather than overlaid.
+		result.Source = devCfg.Source
 	}
 
 	return result
 }
 
+// overlay copies every non-zero field of o into d.
+func (d *DeviceConfig) overlay(o DeviceConfig) {
+	if o.SampleRate != 0 {
+		d.SampleRate = o.SampleRate
+	}
+	if o.Channels != 0 {
+		d.Channels = o.Channels
+	}
+	if o.Bitrate != "" {
+		d.Bitrate = o.Bitrate
+	}
+	if o.Codec != "" {
+		d.Codec = o.Codec
+	}
+	if o.ThreadQueue != 0 {
+		d.ThreadQueue = o.ThreadQueue
+	}
+	if o.Mode != "" {
+		d.Mode = o.Mode
+	}
+	if o.Recorder != "" {
+		d.Recorder = o.Recorder
+	}
+	if o.LocalRecordDir != "" {
+		d.LocalRecordDir = o.LocalRecordDir
+	}
+	if o.SegmentDuration != 0 {
+		d.SegmentDuration = o.SegmentDuration
+	}
+	if o.SegmentFormat != "" {
+		d.SegmentFormat = o.SegmentFormat
+	}
+	if o.SegmentName != "" {
+		d.SegmentName = o.SegmentName
+	}
+	if o.SegmentTimezone != "" {
+		d.SegmentTimezone = o.SegmentTimezone
+	}
+	if o.SegmentLayout != "" {
+		d.SegmentLayout = o.SegmentLayout
+	}
+	if o.SegmentAlign != "" {
+		d.SegmentAlign = o.SegmentAlign
+	}
+	if o.SegmentMaxAge != 0 {
+		d.SegmentMaxAge = o.SegmentMaxAge
+	}
+	if o.SegmentMaxTotalBytes != 0 {
+		d.SegmentMaxTotalBytes = o.SegmentMaxTotalBytes
+	}
+	if o.InitialRestartDelay != 0 {
+		d.InitialRestartDelay = o.InitialRestartDelay
+	}
+	if o.MaxRestartDelay != 0 {
+		d.MaxRestartDelay = o.MaxRestartDelay
+	}
+	if o.MaxRestartAttempts != 0 {
+		d.MaxRestartAttempts = o.MaxRestartAttempts
+	}
+	if o.MaxStallChecks != 0 {
+		d.MaxStallChecks = o.MaxStallChecks
+	}
+	d.Schedule.overlay(o.Schedule)
+	d.Opus.overlay(o.Opus)
+	if len(o.Filters) > 0 {
+		d.Filters = o.Filters
+	}
+	if len(o.Renditions) > 0 {
+		d.Renditions = o.Renditions
+	}
+}
+
+// DeviceSettingFallback returns the global key a DeviceConfig field inherits
+// from when neither the device nor default: sets it, e.g.
+// "local_record_dir" -> "stream.local_record_dir". ok is false for the
+// encoding fields, which default: always sets.
+func DeviceSettingFallback(field string) (key string, ok bool) {
+	switch field {
+	case "max_stall_checks":
+		return "monitor." + field, true
+	case "local_record_dir", "segment_duration", "segment_format", "segment_name", "segment_timezone",
+		"segment_layout", "segment_align", "segment_max_age",
+		"segment_max_total_bytes", "initial_restart_delay", "max_restart_delay", "max_restart_attempts":
+		return "stream." + field, true
+	}
+	return "", false
+}
+
 // Validate checks configuration for invalid values.
 //
 // Returns:
@@ -260,20 +535,54 @@ func (c *Config) GetDeviceConfig(deviceName string) DeviceConfig {
 // Validation rules:
 //   - sample_rate must be positive
 //   - channels must be between 1 and 32
-//   - bitrate cannot be empty
-//   - codec must be "opus" or "aac"
+//   - bitrate cannot be empty (for codecs that use one)
+//   - codec must be a supported codec (see audio.CodecNames), and the sample
+//     rate, channels, mode and segment format must suit it
 //   - segment_format must be "wav", "flac", or "ogg" (if set)
 func (c *Config) Validate() error {
+	if c.Version < 0 || c.Version > CurrentConfigVersion {
+		return fmt.Errorf("version must be between 0 and %d (got %d)", CurrentConfigVersion, c.Version)
+	}
+
 	// Validate default config
 	if err := c.Default.Validate(); err != nil {
 		return fmt.Errorf("default config: %w", err)
 	}
+	if err := c.Default.validateOverrides(); err != nil {
+		return fmt.Errorf("default config: %w", err)
+	}
+
+	if !c.Default.Match.IsZero() || c.Default.Ignore || !c.Default.Source.IsZero() {
+		return fmt.Errorf("default config: match, ignore and source are only valid on device entries")
+	}
 
 	// Validate each device config
 	for name, devCfg := range c.Devices {
 		if err := devCfg.ValidatePartial(); err != nil {
 			return fmt.Errorf("device %q: %w", name, err)
 		}
+		if err := devCfg.Match.Validate(); err != nil {
+			return fmt.Errorf("device %q: %w", name, err)
+		}
+		if err := devCfg.Source.Validate(); err != nil {
+			return fmt.Errorf("device %q: %w", name, err)
+		}
+		if !devCfg.Source.IsZero() && !devCfg.Match.IsZero() {
+			return fmt.Errorf("device %q: source and match cannot both be set: match selects a USB card, source a sound-server input", name)
+		}
+		if ports, ch := len(devCfg.Source.Ports), c.GetDeviceConfig(name).Channels; ch > 0 && ports > ch {
+			return fmt.Errorf("device %q: source.ports lists %d ports for %d channel(s)", name, ports, ch)
+		}
+	}
+
+	if err := c.validateStaticSources(); err != nil {
+		return err
+	}
+	if err := c.validateTestSources(); err != nil {
+		return err
+	}
+	if err := c.validateRenditionNames(); err != nil {
+		return err
 	}
 
 	// Validate stream config (GAP-1b)
@@ -281,36 +590,27 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("stream config: %w", err)
 	}
 
-	// Codec/container compatibility for local recording. FFmpeg encodes once and
-	// muxes the SAME stream to both the RTSP output and the segment file, so the
-	// segment container must accept that codec. Verified empirically against
-	// ffmpeg 7.x: among wav/flac/ogg, opus muxes ONLY into ogg and aac ONLY into
-	// wav. A mismatch makes the segment writer fail — now silently, because the
-	// segment slave is onfail=ignore — so recordings would vanish without a
-	// trace. Reject it at load time instead. Only relevant when recording is on.
-	if c.Stream.LocalRecordDir != "" && c.Stream.SegmentFormat != "" {
-		checked := map[string]struct{}{}
-		checkCodec := func(codec string) error {
-			if codec == "" {
-				return nil
-			}
-			if _, ok := checked[codec]; ok {
-				return nil
-			}
-			checked[codec] = struct{}{}
-			if !segmentFormatSupportsCodec(codec, c.Stream.SegmentFormat) {
-				return fmt.Errorf("local recording: codec %q cannot be muxed into segment_format %q; use segment_format %q for codec %q",
-					codec, c.Stream.SegmentFormat, requiredSegmentFormat(codec), codec)
-			}
-			return nil
-		}
-		if err := checkCodec(c.Default.Codec); err != nil {
-			return err
-		}
-		for name, devCfg := range c.Devices {
-			if err := checkCodec(devCfg.Codec); err != nil {
-				return fmt.Errorf("device %q: %w", name, err)
-			}
+	if err := c.MediaMTX.Validate(); err != nil {
+		return fmt.Errorf("mediamtx config: %w", err)
+	}
+
+	if err := c.ConfigFile.Validate(); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	if err := c.Timecode.Validate(); err != nil {
+		return fmt.Errorf("timecode: %w", err)
+	}
+
+	// Rules spanning layers (codec vs segment format, restart delay order,
+	// record_only without a directory) are checked on the resolved settings
+	// of every configured device and of the defaults unconfigured devices get.
+	if err := c.GetDeviceConfig("").validateResolved(); err != nil {
+		return err
+	}
+	for name := range c.Devices {
+		if err := c.GetDeviceConfig(name).validateResolved(); err != nil {
+			return fmt.Errorf("device %q: %w", name, err)
 		}
 	}
 
@@ -318,32 +618,22 @@ func (c *Config) Validate() error {
 }
 
 // segmentFormatSupportsCodec reports whether ffmpeg can mux audioCodec into a
-// local-recording segment container of segFormat. Verified empirically against
-// ffmpeg 7.x: among the supported segment formats (wav/flac/ogg), opus muxes
-// only into ogg and aac only into wav. An unknown codec is not blocked here
-// (codec validity is enforced by DeviceConfig.Validate).
+// local-recording segment container of segFormat (see audio.Codec.Segments).
+// An unknown codec is not blocked here (codec validity is enforced by
+// DeviceConfig.Validate).
 func segmentFormatSupportsCodec(audioCodec, segFormat string) bool {
-	switch audioCodec {
-	case "opus":
-		return segFormat == "ogg"
-	case "aac":
-		return segFormat == "wav"
-	default:
-		return true
-	}
+	c, ok := audio.LookupCodec(audioCodec)
+	return !ok || c.SupportsSegment(segFormat)
 }
 
 // requiredSegmentFormat returns the segment container ffmpeg can mux the given
-// codec into, for use in a helpful validation error. Empty for unknown codecs.
+// codec into, for use in a helpful validation error. Empty for unknown codecs
+// and for codecs that cannot be recorded locally.
 func requiredSegmentFormat(audioCodec string) string {
-	switch audioCodec {
-	case "opus":
-		return "ogg"
-	case "aac":
-		return "wav"
-	default:
-		return ""
+	if c, ok := audio.LookupCodec(audioCodec); ok && len(c.Segments) > 0 {
+		return c.Segments[0]
 	}
+	return ""
 }
 
 // Validate checks stream configuration for invalid values.
@@ -362,6 +652,9 @@ func (s *StreamConfig) Validate() error {
 	if s.SegmentMaxTotalBytes < 0 {
 		return fmt.Errorf("segment_max_total_bytes must not be negative")
 	}
+	if err := validateSegmentNaming(s.SegmentName, s.SegmentTimezone, s.SegmentLayout, s.SegmentAlign); err != nil {
+		return err
+	}
 	// Restart/backoff timing. These are load-bearing for 24/7 reliability and
 	// were previously unchecked, so a config that passed validation could still
 	// break streaming outright.
@@ -388,6 +681,36 @@ func (s *StreamConfig) Validate() error {
 	if s.USBStabilizationDelay < 0 {
 		return fmt.Errorf("usb_stabilization_delay must not be negative (got %v)", s.USBStabilizationDelay)
 	}
+	if s.DeviceRemovalGrace < 0 {
+		return fmt.Errorf("device_removal_grace must not be negative (got %v)", s.DeviceRemovalGrace)
+	}
+	return nil
+}
+
+// Validate checks MediaMTX settings that only matter in managed mode, where
+// lyrebird generates the server's config from api_url and rtsp_url and must
+// therefore be able to derive listen addresses from them.
+func (m *MediaMTXConfig) Validate() error {
+	if !m.Managed {
+		return nil
+	}
+	if m.BinaryPath == "" {
+		return fmt.Errorf("binary_path is required when managed is enabled")
+	}
+	if m.ReadyTimeout < time.Second {
+		return fmt.Errorf("ready_timeout must be at least 1s (got %v); "+
+			"a bare number is interpreted as nanoseconds — write a unit like 30s", m.ReadyTimeout)
+	}
+	for _, u := range []struct{ key, value, scheme string }{
+		{"api_url", m.APIURL, "http"},
+		{"rtsp_url", m.RTSPURL, "rtsp"},
+	} {
+		parsed, err := url.Parse(u.value)
+		if err != nil || parsed.Scheme != u.scheme || parsed.Port() == "" {
+			return fmt.Errorf("%s must be a %s:// URL with an explicit port when managed is enabled (got %q)",
+				u.key, u.scheme, u.value)
+		}
+	}
 	return nil
 }
 
@@ -404,14 +727,14 @@ func (d *DeviceConfig) Validate() error {
 	if d.Channels > 32 {
 		return fmt.Errorf("channels must be between 1 and 32")
 	}
-	if d.Bitrate == "" {
-		return fmt.Errorf("bitrate cannot be empty")
-	}
 	if d.Codec == "" {
 		return fmt.Errorf("codec cannot be empty")
 	}
-	if d.Codec != "opus" && d.Codec != "aac" {
-		return fmt.Errorf("codec must be opus or aac")
+	if err := audio.ValidateCodec(d.Codec); err != nil {
+		return err
+	}
+	if c, _ := audio.LookupCodec(d.Codec); c.UsesBitrate && d.Bitrate == "" {
+		return fmt.Errorf("bitrate cannot be empty")
 	}
 	return nil
 }
@@ -430,8 +753,127 @@ func (d *DeviceConfig) ValidatePartial() error {
 	if d.Channels > 32 {
 		return fmt.Errorf("channels must be between 1 and 32")
 	}
-	if d.Codec != "" && d.Codec != "opus" && d.Codec != "aac" {
-		return fmt.Errorf("codec must be opus or aac")
+	if d.Codec != "" {
+		if err := audio.ValidateCodec(d.Codec); err != nil {
+			return err
+		}
+	}
+	return d.validateOverrides()
+}
+
+// validateOverrides checks the explicitly set stream:/monitor: override
+// fields. Cross-field rules that need the inherited values (codec vs segment
+// format, restart delay ordering) are checked on the resolved config in
+// Config.Validate.
+func (d *DeviceConfig) validateOverrides() error {
+	switch d.Mode {
+	case "", DeviceModeBoth, DeviceModeRecordOnly, DeviceModeStreamOnly:
+	default:
+		return fmt.Errorf("mode must be one of %s, %s, %s (got %q)",
+			DeviceModeBoth, DeviceModeRecordOnly, DeviceModeStreamOnly, d.Mode)
+	}
+	switch d.Recorder {
+	case "", RecorderTee, RecorderSeparate:
+	default:
+		return fmt.Errorf("recorder must be one of %s, %s (got %q)", RecorderTee, RecorderSeparate, d.Recorder)
+	}
+	switch d.SegmentFormat {
+	case "", "wav", "flac", "ogg":
+	default:
+		return fmt.Errorf("segment_format must be one of wav, flac, ogg (got %q)", d.SegmentFormat)
+	}
+	if err := validateSegmentNaming(d.SegmentName, d.SegmentTimezone, d.SegmentLayout, d.SegmentAlign); err != nil {
+		return err
+	}
+	if d.SegmentDuration < 0 {
+		return fmt.Errorf("segment_duration must not be negative (0 means inherit)")
+	}
+	if d.SegmentMaxAge < 0 {
+		return fmt.Errorf("segment_max_age must not be negative (0 means inherit)")
+	}
+	if d.SegmentMaxTotalBytes < 0 {
+		return fmt.Errorf("segment_max_total_bytes must not be negative (0 means inherit)")
+	}
+	if d.InitialRestartDelay != 0 && d.InitialRestartDelay < time.Second {
+		return fmt.Errorf("initial_restart_delay must be at least 1s (got %v); "+
+			"a bare number is interpreted as nanoseconds — write a unit like 10s", d.InitialRestartDelay)
+	}
+	if d.MaxRestartDelay < 0 {
+		return fmt.Errorf("max_restart_delay must not be negative (0 means inherit)")
+	}
+	if d.MaxRestartAttempts < 0 {
+		return fmt.Errorf("max_restart_attempts must not be negative (0 means inherit)")
+	}
+	if d.MaxStallChecks < 0 {
+		return fmt.Errorf("max_stall_checks must not be negative (0 means inherit)")
+	}
+	if err := validateFilters(d.Filters); err != nil {
+		return err
+	}
+	if err := validateRenditions(d.Renditions); err != nil {
+		return err
+	}
+	if err := d.Opus.validateSyntax(); err != nil {
+		return err
+	}
+	return d.Schedule.validateSyntax()
+}
+
+// validateResolved checks the rules that span inherited layers on a config
+// returned by GetDeviceConfig.
+func (d DeviceConfig) validateResolved() error {
+	if d.MaxRestartDelay < d.InitialRestartDelay {
+		return fmt.Errorf("max_restart_delay (%v) must be >= initial_restart_delay (%v)",
+			d.MaxRestartDelay, d.InitialRestartDelay)
+	}
+	if d.Mode == DeviceModeRecordOnly && d.LocalRecordDir == "" {
+		return fmt.Errorf("mode %s needs local_record_dir (here or under stream:)", DeviceModeRecordOnly)
+	}
+	if err := d.segmentNaming("").Validate(); err != nil {
+		return fmt.Errorf("segment_name: %w", err)
+	}
+	if d.SegmentAlign == SegmentAlignClock && d.SegmentDuration > 0 && 86400%d.SegmentDuration != 0 {
+		return fmt.Errorf("segment_align %s needs a segment_duration that divides a day evenly (got %ds)",
+			SegmentAlignClock, d.SegmentDuration)
+	}
+	// Codec/container compatibility for local recording. FFmpeg encodes once and
+	// muxes the SAME stream to both the RTSP output and the segment file, so the
+	// segment container must accept that codec. Verified empirically against
+	// ffmpeg 7.x: among wav/flac/ogg, opus muxes ONLY into ogg and aac ONLY into
+	// wav. A mismatch makes the segment writer fail — now silently, because the
+	// segment slave is onfail=ignore — so recordings would vanish without a
+	// trace. Reject it at load time instead. Only relevant when recording is on.
+	if d.RecordsLocally() && d.SegmentFormat != "" && d.Codec != "" &&
+		!segmentFormatSupportsCodec(d.Codec, d.SegmentFormat) {
+		if requiredSegmentFormat(d.Codec) == "" {
+			return fmt.Errorf("local recording: codec %q cannot be muxed into any segment_format; use mode %s or another codec",
+				d.Codec, DeviceModeStreamOnly)
+		}
+		return fmt.Errorf("local recording: codec %q cannot be muxed into segment_format %q; use segment_format %q for codec %q",
+			d.Codec, d.SegmentFormat, requiredSegmentFormat(d.Codec), d.Codec)
+	}
+	// Codec parameter constraints (e.g. G.711 is 8 kHz mono, Opus has a
+	// fixed set of rates) and RTSP carriage. A bitrate set for a codec
+	// without one (PCM, G.711, FLAC) is ignored rather than rejected, since
+	// it is usually inherited from default:.
+	if c, ok := audio.LookupCodec(d.Codec); ok {
+		if err := c.CheckParams(d.SampleRate, d.Channels); err != nil {
+			return err
+		}
+		if !c.RTSP && d.PublishesRTSP() {
+			return fmt.Errorf("codec %q cannot be published over RTSP; use mode %s", d.Codec, DeviceModeRecordOnly)
+		}
+	}
+	if d.Codec == "opus" {
+		if err := d.Opus.Settings().ValidateResolved(); err != nil {
+			return fmt.Errorf("opus: %w", err)
+		}
+	}
+	if err := d.validateResolvedRenditions(); err != nil {
+		return err
+	}
+	if _, err := d.Schedule.Compile(); err != nil {
+		return err
 	}
 	return nil
 }
@@ -453,6 +895,7 @@ func (d *DeviceConfig) ValidatePartial() error {
 //	cfg.Save("/etc/lyrebird/config.yaml")
 func DefaultConfig() *Config {
 	return &Config{
+		Version: CurrentConfigVersion,
 		Devices: make(map[string]DeviceConfig),
 		Default: DeviceConfig{
 			SampleRate:  48000,
@@ -477,9 +920,11 @@ func DefaultConfig() *Config {
 			// Example: local_record_dir: /var/lib/lyrebird/recordings
 		},
 		MediaMTX: MediaMTXConfig{
-			APIURL:     "http://localhost:9997",
-			RTSPURL:    "rtsp://localhost:8554",
-			ConfigPath: "/etc/mediamtx/mediamtx.yml",
+			APIURL:       "http://localhost:9997",
+			RTSPURL:      "rtsp://localhost:8554",
+			ConfigPath:   "/etc/mediamtx/mediamtx.yml",
+			BinaryPath:   "/usr/local/bin/mediamtx",
+			ReadyTimeout: 30 * time.Second,
 		},
 		Monitor: MonitorConfig{
 			Enabled:            true,
@@ -490,5 +935,14 @@ func DefaultConfig() *Config {
 			HealthAddr:         "127.0.0.1:9998", // GAP-8: default health endpoint address
 			DiskLowThresholdMB: 1024,             // GAP-1d: warn when free disk < 1 GB
 		},
+		ConfigFile: ConfigFileConfig{
+			Watch:         false,
+			WatchDebounce: 500 * time.Millisecond,
+			Strict:        false,
+		},
+		Timecode: TimecodeConfig{
+			Enabled:    true,
+			TimeSource: TimeSourceNTP,
+		},
 	}
 }
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCodecValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"g711 at 8 kHz mono", "devices:\n  phone:\n    codec: pcmu\n    sample_rate: 8000\n    channels: 1\n", ""},
+		{"g711 at 48 kHz", "devices:\n  phone:\n    codec: pcma\n    sample_rate: 48000\n    channels: 1\n",
+			`device "phone": codec pcma needs sample_rate 8000`},
+		{"g711 stereo", "devices:\n  phone:\n    codec: pcmu\n    sample_rate: 8000\n",
+			`device "phone": codec pcmu supports at most 1 channel(s) (got 2)`},
+		{"pcm needs no bitrate", "default:\n  codec: pcm\n  bitrate: \"\"\n", ""},
+		{"opus at 44.1 kHz is resampled", "devices:\n  mic:\n    codec: opus\n    sample_rate: 44100\n", ""},
+		{"flac over rtsp", "devices:\n  archive:\n    codec: flac\n",
+			`device "archive": codec "flac" cannot be published over RTSP; use mode record_only`},
+		{"flac record only", "devices:\n  archive:\n    codec: flac\n    mode: record_only\n    local_record_dir: /var/audio\n    segment_format: flac\n", ""},
+		{"flac into wav", "devices:\n  archive:\n    codec: flac\n    mode: record_only\n    local_record_dir: /var/audio\n    segment_format: wav\n",
+			`codec "flac" cannot be muxed into segment_format "wav"; use segment_format "flac"`},
+		{"pcm recorded", "devices:\n  raw:\n    codec: pcm\n    local_record_dir: /var/audio\n    segment_format: wav\n",
+			`codec "pcm" cannot be muxed into any segment_format; use mode stream_only`},
+		{"speex into ogg", "devices:\n  voice:\n    codec: speex\n    sample_rate: 16000\n    channels: 1\n    local_record_dir: /var/audio\n    segment_format: ogg\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadOverrides(t, tt.yaml)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeviceMatchMatches(t *testing.T) {
+	id := DeviceIdentity{
+		Name:     "Yeti",
+		CardName: "Yeti",
+		USBID:    "0D8C:0014",
+		Serial:   "REV8_123",
+		ByID:     "usb-BLUE_Yeti_REV8_123-00",
+		Port:     "1-1.4",
+	}
+	tests := []struct {
+		name  string
+		match DeviceMatch
+		want  bool
+	}{
+		{"empty matches nothing", DeviceMatch{}, false},
+		{"usb id case-insensitive", DeviceMatch{USBID: "0d8c:0014"}, true},
+		{"usb id glob", DeviceMatch{USBID: "0d8c:*"}, true},
+		{"usb id mismatch", DeviceMatch{USBID: "046d:*"}, false},
+		{"serial", DeviceMatch{Serial: "REV8_*"}, true},
+		{"by-id", DeviceMatch{ByID: "usb-BLUE_Yeti*"}, true},
+		{"port", DeviceMatch{Port: "1-1.4"}, true},
+		{"port mismatch", DeviceMatch{Port: "1-1.3"}, false},
+		{"card name regex", DeviceMatch{CardName: "^Ye"}, true},
+		{"all criteria ANDed", DeviceMatch{USBID: "0d8c:0014", Port: "1-1.3"}, false},
+		{"priority alone matches nothing", DeviceMatch{Priority: 5}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match.Matches(id); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// A criterion never matches an unknown (empty) identity field.
+	if (DeviceMatch{Serial: "*"}).Matches(DeviceIdentity{Name: "x"}) {
+		t.Error("serial glob matched a device without a serial")
+	}
+}
+
+func TestResolveDevice(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Devices = map[string]DeviceConfig{
+		"any_blue": {Match: DeviceMatch{USBID: "0d8c:*"}},
+		"porch":    {Match: DeviceMatch{USBID: "0d8c:*", Port: "1-1.4", Priority: 10}},
+		"Webcam":   {Ignore: true},
+		"Yeti":     {Bitrate: "192k"},
+	}
+
+	tests := []struct {
+		name        string
+		id          DeviceIdentity
+		wantName    string
+		wantIgnored bool
+	}{
+		{"higher priority wins", DeviceIdentity{Name: "Yeti", USBID: "0d8c:0014", Port: "1-1.4"}, "porch", false},
+		{"lower priority fallback", DeviceIdentity{Name: "Yeti", USBID: "0d8c:0014", Port: "1-1.2"}, "any_blue", false},
+		{"name fallback", DeviceIdentity{Name: "Yeti", USBID: "1234:5678"}, "Yeti", false},
+		{"ignored by name", DeviceIdentity{Name: "Webcam", USBID: "046d:0825"}, "Webcam", true},
+		{"unconfigured", DeviceIdentity{Name: "Other"}, "Other", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, ignored := cfg.ResolveDevice(tt.id)
+			if name != tt.wantName || ignored != tt.wantIgnored {
+				t.Errorf("ResolveDevice() = %q, %v; want %q, %v", name, ignored, tt.wantName, tt.wantIgnored)
+			}
+		})
+	}
+}
+
+func TestValidateDeviceMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		wantErr string
+	}{
+		{"valid", func(c *Config) {
+			c.Devices = map[string]DeviceConfig{"a": {Match: DeviceMatch{USBID: "0d8c:*", CardName: "^Y"}}}
+		}, ""},
+		{"bad glob", func(c *Config) {
+			c.Devices = map[string]DeviceConfig{"a": {Match: DeviceMatch{Port: "1-[1"}}}
+		}, "match.port"},
+		{"bad regex", func(c *Config) {
+			c.Devices = map[string]DeviceConfig{"a": {Match: DeviceMatch{CardName: "("}}}
+		}, "match.card_name"},
+		{"match under default", func(c *Config) {
+			c.Default.Match = DeviceMatch{Port: "1-1"}
+		}, "only valid on device entries"},
+		{"ignore under default", func(c *Config) {
+			c.Default.Ignore = true
+		}, "only valid on device entries"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigDeviceMatch(t *testing.T) {
+	cfg, err := loadOverrides(t, `
+devices:
+  porch:
+    match:
+      usb_id: "0d8c:0014"
+      port: "1-1.4"
+      priority: 5
+    bitrate: 192k
+  webcam:
+    match:
+      usb_id: "046d:*"
+    ignore: true
+`)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if got := cfg.Devices["porch"].Match; got.Port != "1-1.4" || got.Priority != 5 {
+		t.Errorf("porch match = %+v", got)
+	}
+	if !cfg.Devices["webcam"].Ignore {
+		t.Error("webcam ignore not loaded")
+	}
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const overridesConfig = `default:
+  codec: opus
+  segment_max_age: 72h
+devices:
+  bird_box:
+    mode: record_only
+    local_record_dir: /srv/birdbox
+    segment_max_age: 2160h
+    max_restart_attempts: 1000
+    initial_restart_delay: 2s
+    max_stall_checks: 1
+  test_mic:
+    mode: stream_only
+    recorder: separate
+  barn:
+    recorder: separate
+stream:
+  local_record_dir: /var/lib/lyrebird/recordings
+  segment_format: ogg
+  segment_max_age: 168h
+  max_restart_attempts: 50
+monitor:
+  max_stall_checks: 3
+`
+
+func loadOverrides(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return LoadConfig(path)
+}
+
+func TestGetDeviceConfigOverrides(t *testing.T) {
+	cfg, err := loadOverrides(t, overridesConfig)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	bird := cfg.GetDeviceConfig("bird_box")
+	if bird.LocalRecordDir != "/srv/birdbox" || bird.SegmentMaxAge != 2160*time.Hour ||
+		bird.MaxRestartAttempts != 1000 || bird.InitialRestartDelay != 2*time.Second || bird.MaxStallChecks != 1 {
+		t.Errorf("bird_box = %+v, want its own overrides", bird)
+	}
+	if bird.SegmentFormat != "ogg" || bird.MaxRestartDelay != 300*time.Second {
+		t.Errorf("bird_box = %+v, want segment_format and max_restart_delay from stream:", bird)
+	}
+	if bird.RecordsLocally() != true || bird.PublishesRTSP() {
+		t.Errorf("bird_box mode %q: records=%v publishes=%v", bird.Mode, bird.RecordsLocally(), bird.PublishesRTSP())
+	}
+
+	// default: overrides stream: for every device that does not set a value.
+	other := cfg.GetDeviceConfig("unconfigured")
+	if other.SegmentMaxAge != 72*time.Hour || other.MaxRestartAttempts != 50 || other.MaxStallChecks != 3 {
+		t.Errorf("unconfigured = %+v, want 72h from default:, 50 and 3 from stream:/monitor:", other)
+	}
+	if !other.RecordsLocally() || !other.PublishesRTSP() {
+		t.Error("unconfigured device should record and publish")
+	}
+
+	if test := cfg.GetDeviceConfig("test_mic"); test.RecordsLocally() || !test.PublishesRTSP() {
+		t.Errorf("test_mic (stream_only) records=%v publishes=%v", test.RecordsLocally(), test.PublishesRTSP())
+	}
+
+	// A separate recorder only applies when the device both records and
+	// publishes.
+	if !cfg.GetDeviceConfig("barn").RecordsSeparately() {
+		t.Error("barn should record in a separate recorder")
+	}
+	if cfg.GetDeviceConfig("test_mic").RecordsSeparately() || other.RecordsSeparately() {
+		t.Error("test_mic (stream_only) and unconfigured devices should not record separately")
+	}
+}
+
+func TestValidateDeviceOverrides(t *testing.T) {
+	tests := []struct {
+		name, yaml, want string
+	}{
+		{"bad mode", "devices:\n  a:\n    mode: sometimes\n", "mode must be one of"},
+		{"bad recorder", "devices:\n  a:\n    recorder: pipe\n", "recorder must be one of"},
+		{"bad segment format", "devices:\n  a:\n    segment_format: mp4\n", "segment_format must be one of"},
+		{"sub-second delay", "devices:\n  a:\n    initial_restart_delay: 500ms\n", "initial_restart_delay must be at least 1s"},
+		{"negative attempts", "devices:\n  a:\n    max_restart_attempts: -1\n", "max_restart_attempts must not be negative"},
+		{"record_only without dir", "devices:\n  a:\n    mode: record_only\n", "needs local_record_dir"},
+		{"delay order across layers", "devices:\n  a:\n    initial_restart_delay: 10m\n", `device "a": max_restart_delay`},
+		{"codec vs device format", "devices:\n  a:\n    local_record_dir: /rec\n    segment_format: wav\n", `device "a": local recording: codec "opus"`},
+		{"bad default override", "default:\n  mode: nope\n", "default config: mode must be one of"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadOverrides(t, tt.yaml)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfig() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+
+	// A per-device override can also fix a global mismatch for that device.
+	ok := "stream:\n  local_record_dir: /rec\n  segment_format: wav\ndefault:\n  codec: aac\ndevices:\n  a:\n    codec: opus\n    segment_format: ogg\n"
+	if _, err := loadOverrides(t, ok); err != nil {
+		t.Errorf("LoadConfig() = %v, want per-device segment_format accepted", err)
+	}
+}
+
+func TestDeviceSettingFallback(t *testing.T) {
+	for field, want := range map[string]string{
+		"local_record_dir":     "stream.local_record_dir",
+		"max_restart_attempts": "stream.max_restart_attempts",
+		"max_stall_checks":     "monitor.max_stall_checks",
+	} {
+		if got, ok := DeviceSettingFallback(field); !ok || got != want {
+			t.Errorf("DeviceSettingFallback(%q) = %q, %v; want %q", field, got, ok, want)
+		}
+	}
+	if _, ok := DeviceSettingFallback("codec"); ok {
+		t.Error("codec has no stream:/monitor: fallback")
+	}
+}
+
+func TestKoanfDeviceOverrideEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("stream:\n  local_record_dir: /rec\n"), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("LYREBIRD_DEVICES_BIRD_BOX_MAX_RESTART_ATTEMPTS", "500")
+	t.Setenv("LYREBIRD_DEVICES_BIRD_BOX_MODE", "record_only")
+
+	kc, err := NewKoanfConfig(WithYAMLFile(path), WithEnvPrefix("LYREBIRD"))
+	if err != nil {
+		t.Fatalf("NewKoanfConfig() error = %v", err)
+	}
+	cfg, err := kc.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	got := cfg.GetDeviceConfig("bird_box")
+	if got.MaxRestartAttempts != 500 || got.Mode != DeviceModeRecordOnly {
+		t.Errorf("bird_box = %+v, want env overrides", got)
+	}
+}
@@ -145,10 +145,10 @@ func TestDeviceConfigValidatePartial(t *testing.T) {
 			cfg: DeviceConfig{
 				SampleRate: 48000,
 				Channels:   2,
-				Codec:      "mp3",
+				Codec:      "vorbis",
 			},
 			wantErr: true,
-			errMsg:  "codec must be opus or aac",
+			errMsg:  `codec must be one of opus, aac, flac, pcm, mp3, pcmu, pcma, speex (got "vorbis")`,
 		},
 		{
 			name: "zero values allowed (partial config)",
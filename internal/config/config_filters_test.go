@@ -0,0 +1,99 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const filtersConfig = `
+default:
+  filters:
+    - type: highpass
+      frequency: 80
+devices:
+  lav_mic:
+    filters:
+      - {type: highpass, frequency: 120}
+      - {type: denoise}
+      - {type: compressor, threshold_db: -20, ratio: 3}
+      - {type: gain, gain_db: 6}
+      - {type: loudnorm, target_lufs: -16}
+  plain_mic:
+    bitrate: 192k
+`
+
+func TestFiltersConfigLayers(t *testing.T) {
+	for _, loader := range []struct {
+		name string
+		load func(t *testing.T) (*Config, error)
+	}{
+		{"LoadConfig", func(t *testing.T) (*Config, error) { return loadOverrides(t, filtersConfig) }},
+		{"KoanfConfig", func(t *testing.T) (*Config, error) {
+			kc, err := NewKoanfConfig(WithYAMLFile(writeIncludeFixture(t, filtersConfig, nil)), WithEnvPrefix("LYREBIRD_FILTERS_TEST"))
+			if err != nil {
+				return nil, err
+			}
+			return kc.Load()
+		}},
+	} {
+		t.Run(loader.name, func(t *testing.T) {
+			cfg, err := loader.load(t)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+
+			want := "highpass=f=120,afftdn=nr=12:nf=-50," +
+				"acompressor=threshold=0.1:ratio=3:attack=20:release=250:makeup=1," +
+				"volume=6dB,loudnorm=I=-16:TP=-2:LRA=7"
+			if got := FilterChain(cfg.GetDeviceConfig("lav_mic").Filters); got != want {
+				t.Errorf("lav_mic chain = %q, want %q", got, want)
+			}
+			// A device without filters inherits the default: list.
+			if got := FilterChain(cfg.GetDeviceConfig("plain_mic").Filters); got != "highpass=f=80" {
+				t.Errorf("plain_mic chain = %q, want inherited highpass=f=80", got)
+			}
+		})
+	}
+}
+
+func TestFilterChainEmpty(t *testing.T) {
+	if got := FilterChain(nil); got != "" {
+		t.Errorf("FilterChain(nil) = %q, want empty", got)
+	}
+}
+
+func TestFiltersValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters string
+		wantErr string
+	}{
+		{"unknown type", "[{type: reverb}]", `filters[0]: unknown type "reverb"`},
+		{"missing type", "[{gain_db: 3}]", "filters[0]: type is required"},
+		{"missing frequency", "[{type: lowpass}]", "filters[0]: lowpass needs frequency"},
+		{"field of another type", "[{type: gain, gain_db: 3, frequency: 100}]", "filters[0]: frequency does not apply to type gain"},
+		{"gain out of range", "[{type: gain, gain_db: 80}]", "filters[0]: gain_db must be between -60 and 40"},
+		{"positive threshold", "[{type: highpass, frequency: 80}, {type: compressor, threshold_db: 3}]", "filters[1]: threshold_db must be between -60 and 0"},
+		{"loudnorm target", "[{type: loudnorm, target_lufs: 0}, {type: loudnorm, target_lufs: -2}]", "filters[1]: target_lufs must be between -70 and -5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadOverrides(t, "devices:\n  mic:\n    filters: "+tt.filters+"\n")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := loadOverrides(t, "default:\n  filters: [{type: gain, gain_db: 100}]\n"); err == nil ||
+		!strings.Contains(err.Error(), "default config") {
+		t.Errorf("default: error = %v, want a default config error", err)
+	}
+}
+
+func TestFiltersStrictKeys(t *testing.T) {
+	_, err := loadOverrides(t, "devices:\n  mic:\n    filters:\n      - {type: gain, gain: 3}\n"+strictConfig)
+	if err == nil || !strings.Contains(err.Error(), "filters[0].gain") {
+		t.Errorf("error = %v, want unknown key filters[0].gain", err)
+	}
+}
@@ -32,7 +32,7 @@ func TestHotReloadRejectsInvalidAndKeepsLastGood(t *testing.T) {
 	}
 
 	// Overwrite with a semantically-invalid config (unsupported codec).
-	bad := "default:\n  sample_rate: 48000\n  channels: 2\n  bitrate: 128k\n  codec: mp3\n"
+	bad := "default:\n  sample_rate: 48000\n  channels: 2\n  bitrate: 128k\n  codec: vorbis\n"
 	if err := os.WriteFile(path, []byte(bad), 0600); err != nil {
 		t.Fatalf("write bad config: %v", err)
 	}
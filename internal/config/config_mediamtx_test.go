@@ -0,0 +1,60 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMediaMTXConfigValidate verifies the managed-mode checks, which must not
+// affect an unmanaged config.
+func TestMediaMTXConfigValidate(t *testing.T) {
+	managed := func() MediaMTXConfig {
+		m := DefaultConfig().MediaMTX
+		m.Managed = true
+		return m
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*MediaMTXConfig)
+		errMsg string
+	}{
+		{"defaults valid", func(*MediaMTXConfig) {}, ""},
+		{"unmanaged ignores fields", func(m *MediaMTXConfig) {
+			m.Managed = false
+			m.BinaryPath = ""
+			m.APIURL = "garbage"
+		}, ""},
+		{"missing binary", func(m *MediaMTXConfig) { m.BinaryPath = "" }, "binary_path is required"},
+		{"bare number timeout", func(m *MediaMTXConfig) { m.ReadyTimeout = 30 }, "ready_timeout must be at least 1s"},
+		{"api without port", func(m *MediaMTXConfig) { m.APIURL = "http://localhost" }, "api_url must be a http:// URL"},
+		{"rtsp wrong scheme", func(m *MediaMTXConfig) { m.RTSPURL = "http://localhost:8554" }, "rtsp_url must be a rtsp:// URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := managed()
+			tt.mutate(&m)
+			err := m.Validate()
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Validate() = %v, want %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestConfigValidateWrapsMediaMTXError(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MediaMTX.Managed = true
+	cfg.MediaMTX.ReadyTimeout = time.Millisecond
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "mediamtx config: ready_timeout") {
+		t.Errorf("Validate() = %v, want wrapped mediamtx error", err)
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+)
+
+const opusConfig = `
+default:
+  opus:
+    preset: normal
+    complexity: 6
+devices:
+  radio_link:
+    opus:
+      preset: low
+      packet_loss: 20
+  dawn_chorus:
+    opus:
+      frame_duration: 60
+  plain_mic:
+    bitrate: 192k
+`
+
+func TestOpusConfigLayers(t *testing.T) {
+	for _, loader := range []struct {
+		name string
+		load func(t *testing.T) (*Config, error)
+	}{
+		{"LoadConfig", func(t *testing.T) (*Config, error) { return loadOverrides(t, opusConfig) }},
+		{"KoanfConfig", func(t *testing.T) (*Config, error) {
+			kc, err := NewKoanfConfig(WithYAMLFile(writeIncludeFixture(t, opusConfig, nil)), WithEnvPrefix("LYREBIRD_OPUS_TEST"))
+			if err != nil {
+				return nil, err
+			}
+			return kc.Load()
+		}},
+	} {
+		t.Run(loader.name, func(t *testing.T) {
+			cfg, err := loader.load(t)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+
+			// The device preset replaces default:'s; complexity 6 from
+			// default: still overrides it, and packet_loss overrides both.
+			want := audio.OpusPreset(audio.QualityLow)
+			want.Complexity, want.PacketLoss = 6, 20
+			if got := cfg.GetDeviceConfig("radio_link").Opus.Settings(); got != want {
+				t.Errorf("radio_link = %+v, want %+v", got, want)
+			}
+
+			want = audio.OpusPreset(audio.QualityNormal)
+			want.Complexity, want.FrameDuration = 6, 60
+			if got := cfg.GetDeviceConfig("dawn_chorus").Opus.Settings(); got != want {
+				t.Errorf("dawn_chorus = %+v, want %+v", got, want)
+			}
+
+			want = audio.OpusPreset(audio.QualityNormal)
+			want.Complexity = 6
+			if got := cfg.GetDeviceConfig("plain_mic").Opus.Settings(); got != want {
+				t.Errorf("plain_mic = %+v, want inherited %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestOpusConfigNoPreset(t *testing.T) {
+	c := OpusConfig{Application: "voip"}
+	if got := c.Settings(); got != (audio.OpusSettings{Application: "voip"}) {
+		t.Errorf("Settings() = %+v, want only the explicit field", got)
+	}
+}
+
+func TestOpusConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"preset", "devices:\n  mic:\n    opus: {preset: ultra}\n", `opus.preset must be low, normal or high (got "ultra")`},
+		{"frame", "devices:\n  mic:\n    opus: {frame_duration: 15}\n", "opus.frame_duration must be one of"},
+		{"complexity", "devices:\n  mic:\n    opus: {complexity: 12}\n", "opus.complexity must be between 1 and 10"},
+		{"fec without loss", "devices:\n  mic:\n    opus: {fec: true}\n", "opus: fec needs packet_loss"},
+		{"default", "default:\n  opus: {vbr: maybe}\n", "default config"},
+		{"unknown key", "devices:\n  mic:\n    opus: {bitrate: 64k}\n" + strictConfig, "opus.bitrate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadOverrides(t, tt.yaml)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// FEC is only checked for devices that encode Opus.
+	if _, err := loadOverrides(t, "devices:\n  mic:\n    codec: aac\n    opus: {fec: true}\n"); err != nil {
+		t.Errorf("aac device with opus block: %v", err)
+	}
+	// The low preset supplies packet_loss, so FEC on top of it is valid.
+	if _, err := loadOverrides(t, "devices:\n  mic:\n    opus: {preset: low, fec: true}\n"); err != nil {
+		t.Errorf("low preset with fec: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenditionsResolve(t *testing.T) {
+	cfg, err := loadOverrides(t, `default:
+  codec: opus
+  bitrate: 256k
+  channels: 2
+  opus:
+    preset: high
+devices:
+  dawn_chorus:
+    renditions:
+      - suffix: _lo
+        bitrate: 24k
+        channels: 1
+        opus:
+          application: voip
+      - suffix: _mid
+        bitrate: 96k
+`)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	got := cfg.GetDeviceConfig("dawn_chorus").ResolvedRenditions()
+	if len(got) != 2 {
+		t.Fatalf("ResolvedRenditions() = %+v, want 2", got)
+	}
+	lo, mid := got[0], got[1]
+	if lo.StreamName("dawn_chorus") != "dawn_chorus_lo" {
+		t.Errorf("StreamName = %q", lo.StreamName("dawn_chorus"))
+	}
+	if lo.Codec != "opus" || lo.Bitrate != "24k" || lo.Channels != 1 || lo.SampleRate != 48000 {
+		t.Errorf("lo = %+v, want opus 24k mono at the device's 48 kHz", lo)
+	}
+	if lo.Opus.Preset != "high" || lo.Opus.Application != "voip" {
+		t.Errorf("lo.Opus = %+v, want the device's preset with application voip", lo.Opus)
+	}
+	if mid.Channels != 2 || mid.Opus.Application != "" {
+		t.Errorf("mid = %+v, want the device's channels and opus settings", mid)
+	}
+	if r := cfg.GetDeviceConfig("other").ResolvedRenditions(); r != nil {
+		t.Errorf("a device without renditions resolved %+v", r)
+	}
+}
+
+func TestValidateRenditions(t *testing.T) {
+	tests := []struct {
+		name, yaml, want string
+	}{
+		{"missing suffix", "devices:\n  a:\n    renditions:\n      - bitrate: 24k\n", "suffix must be letters, digits and underscores"},
+		{"path suffix", "devices:\n  a:\n    renditions:\n      - suffix: /lo\n", "suffix must be"},
+		{"duplicate suffix", "devices:\n  a:\n    renditions:\n      - suffix: _lo\n      - suffix: _lo\n", `suffix "_lo" is used twice`},
+		{"bad codec", "devices:\n  a:\n    renditions:\n      - suffix: _lo\n        codec: vorbis\n", "renditions[0]: codec must be one of"},
+		{"bad opus", "devices:\n  a:\n    renditions:\n      - suffix: _lo\n        opus:\n          complexity: 11\n", "renditions[0]: opus.complexity"},
+		{"not RTSP", "devices:\n  a:\n    renditions:\n      - suffix: _lo\n        codec: flac\n", `rendition "_lo": codec "flac" cannot be published over RTSP`},
+		{"bad rate", "devices:\n  a:\n    renditions:\n      - suffix: _lo\n        codec: pcmu\n", `rendition "_lo": `},
+		{"path taken", "devices:\n  a:\n    renditions:\n      - suffix: _lo\n  a_lo:\n    channels: 1\n", "publishes as a_lo, which is also a configured device"},
+		{"copied static source", "static_sources:\n  cam:\n    url: rtsp://cam/audio\n    copy: true\ndevices:\n  cam:\n    renditions:\n      - suffix: _lo\n", "renditions need re-encoding"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadOverrides(t, tt.yaml)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfig() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+
+	// Renditions inherited by a device that publishes nothing are unused, so
+	// they need not suit its codec.
+	ok := "stream:\n  local_record_dir: /rec\ndefault:\n  renditions:\n    - suffix: _lo\n      bitrate: 24k\ndevices:\n  a:\n    mode: record_only\n    codec: flac\n    segment_format: flac\n"
+	if _, err := loadOverrides(t, ok); err != nil {
+		t.Errorf("LoadConfig() = %v, want renditions of a record_only device ignored", err)
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const scheduleConfig = `
+default:
+  schedule:
+    latitude: 51.5
+    longitude: -0.13
+    windows:
+      - {start: "04:00", end: "09:00"}
+      - {start: "18:00", end: "21:00"}
+devices:
+  dusk_mic:
+    schedule:
+      windows:
+        - start: sunset-30m
+          end: sunset+1h
+          days: [weekdays]
+  always_on:
+    bitrate: 192k
+`
+
+func TestScheduleConfigLayers(t *testing.T) {
+	for _, loader := range []struct {
+		name string
+		load func(t *testing.T) (*Config, error)
+	}{
+		{"LoadConfig", func(t *testing.T) (*Config, error) { return loadOverrides(t, scheduleConfig) }},
+		{"KoanfConfig", func(t *testing.T) (*Config, error) {
+			kc, err := NewKoanfConfig(WithYAMLFile(writeIncludeFixture(t, scheduleConfig, nil)), WithEnvPrefix("LYREBIRD_SCHEDULE_TEST"))
+			if err != nil {
+				return nil, err
+			}
+			return kc.Load()
+		}},
+	} {
+		t.Run(loader.name, func(t *testing.T) {
+			cfg, err := loader.load(t)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+
+			// always_on inherits default:'s windows.
+			inherited := cfg.GetDeviceConfig("always_on").Schedule
+			if len(inherited.Windows) != 2 || inherited.Windows[1].Start != "18:00" {
+				t.Errorf("always_on windows = %+v, want default's two", inherited.Windows)
+			}
+
+			// dusk_mic replaces the windows but keeps default:'s location.
+			dusk := cfg.GetDeviceConfig("dusk_mic").Schedule
+			if len(dusk.Windows) != 1 || dusk.Windows[0].Days[0] != "weekdays" || dusk.Latitude != 51.5 {
+				t.Errorf("dusk_mic schedule = %+v", dusk)
+			}
+			sched, err := dusk.Compile()
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			// Monday 2024-06-17: sunset in London is about 21:20 BST (20:20 UTC).
+			if !sched.Active(time.Date(2024, 6, 17, 20, 30, 0, 0, time.UTC)) {
+				t.Error("dusk_mic inactive just after sunset")
+			}
+			if sched.Active(time.Date(2024, 6, 17, 12, 0, 0, 0, time.UTC)) {
+				t.Error("dusk_mic active at noon")
+			}
+		})
+	}
+}
+
+func TestScheduleConfigCompileAlwaysOn(t *testing.T) {
+	sched, err := ScheduleConfig{}.Compile()
+	if err != nil || sched != nil {
+		t.Fatalf("Compile() of an empty schedule = %v, %v; want nil, nil", sched, err)
+	}
+	if !sched.Active(time.Now()) {
+		t.Error("empty schedule must always be active")
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"bad time", "default:\n  schedule:\n    windows:\n      - {start: \"4pm\", end: \"18:00\"}\n", "schedule.windows[0].start"},
+		{"bad day", "devices:\n  a:\n    schedule:\n      windows:\n        - {start: \"04:00\", end: \"05:00\", days: [someday]}\n", `device "a": schedule.windows[0].days`},
+		{"sun without location", "devices:\n  a:\n    schedule:\n      windows:\n        - {start: sunrise, end: \"09:00\"}\n", "latitude and longitude"},
+		{"bad latitude", "default:\n  schedule:\n    latitude: 95\n", "schedule.latitude"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadOverrides(t, tt.yaml)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfig() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
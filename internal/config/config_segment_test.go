@@ -0,0 +1,73 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/segment"
+)
+
+func TestSegmentNamingOverrides(t *testing.T) {
+	cfg, err := loadOverrides(t, `stream:
+  segment_name: "{station}_{stream}_{iso}"
+  segment_timezone: utc
+  segment_layout: daily
+  segment_align: clock
+timecode:
+  host_id: north
+devices:
+  legacy:
+    segment_name: "{stream}_{date}_{time}"
+    segment_timezone: local
+    segment_layout: flat
+    segment_align: start
+`)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	other := cfg.GetDeviceConfig("other")
+	if other.SegmentAlign != SegmentAlignClock {
+		t.Errorf("other.SegmentAlign = %q, want inherited %q", other.SegmentAlign, SegmentAlignClock)
+	}
+	want := segment.Naming{Template: "{station}_{stream}_{iso}", Station: "north", UTC: true, Daily: true}
+	if got := cfg.SegmentNaming(other); got != want {
+		t.Errorf("SegmentNaming(other) = %+v, want %+v", got, want)
+	}
+
+	legacy := cfg.GetDeviceConfig("legacy")
+	if legacy.SegmentAlign != SegmentAlignStart {
+		t.Errorf("legacy.SegmentAlign = %q, want %q", legacy.SegmentAlign, SegmentAlignStart)
+	}
+	// Without {station} the station is left out, so the naming equals the default.
+	if got := cfg.SegmentNaming(legacy); got != (segment.Naming{Template: segment.DefaultTemplate}) {
+		t.Errorf("SegmentNaming(legacy) = %+v, want the default naming", got)
+	}
+}
+
+func TestValidateSegmentNaming(t *testing.T) {
+	tests := []struct {
+		name, yaml, want string
+	}{
+		{"bad template", "stream:\n  segment_name: \"{stream}_{hour}\"\n", "segment_name: unknown token {hour}"},
+		{"template without stream", "devices:\n  a:\n    segment_name: \"{date}_{time}\"\n", "must contain {stream}"},
+		{"bad timezone", "stream:\n  segment_timezone: Europe/Paris\n", "segment_timezone must be local or utc"},
+		{"bad layout", "devices:\n  a:\n    segment_layout: weekly\n", "segment_layout must be flat or daily"},
+		{"bad align", "stream:\n  segment_align: hour\n", "segment_align must be start or clock"},
+		{"seq with daily", "stream:\n  segment_name: \"{stream}_{seq}\"\n  segment_layout: daily\n", "per-day subdirectories need a date-based template"},
+		{"seq with daily across layers", "stream:\n  segment_name: \"{stream}_{seq}\"\ndevices:\n  a:\n    segment_layout: daily\n", `device "a": segment_name:`},
+		{"clock with uneven duration", "stream:\n  segment_align: clock\n  segment_duration: 7000\n", "divides a day evenly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadOverrides(t, tt.yaml)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfig() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := loadOverrides(t, "stream:\n  segment_align: clock\n  segment_duration: 900\n"); err != nil {
+		t.Errorf("LoadConfig() = %v, want 900s clock alignment accepted", err)
+	}
+}
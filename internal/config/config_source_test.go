@@ -0,0 +1,84 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const sourceConfig = `
+devices:
+  desk_mic:
+    source:
+      type: pipewire
+      name: alsa_input.usb-BLUE_Yeti-00.analog-stereo
+  studio_bus:
+    channels: 2
+    source:
+      type: jack
+      ports: [system:capture_1, system:capture_2]
+  muted:
+    ignore: true
+    source: {type: pulse}
+  blue_yeti:
+    bitrate: 192k
+`
+
+func TestSourceConfigLoad(t *testing.T) {
+	cfg, err := loadOverrides(t, sourceConfig)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := cfg.Sources(); !slices.Equal(got, []string{"desk_mic", "studio_bus"}) {
+		t.Errorf("Sources() = %v, want [desk_mic studio_bus]", got)
+	}
+
+	desk := cfg.GetDeviceConfig("desk_mic")
+	if f, d := desk.Source.Input("desk_mic"); f != "pulse" || d != "alsa_input.usb-BLUE_Yeti-00.analog-stereo" {
+		t.Errorf("desk_mic input = %s %s", f, d)
+	}
+	bus := cfg.GetDeviceConfig("studio_bus")
+	if f, d := bus.Source.Input("studio_bus"); f != "jack" || d != "lyrebird_studio_bus" {
+		t.Errorf("studio_bus input = %s %s", f, d)
+	}
+	if !cfg.GetDeviceConfig("blue_yeti").Source.IsZero() {
+		t.Error("a USB card entry should have no source")
+	}
+}
+
+func TestSourceConfigInput(t *testing.T) {
+	if f, d := (SourceConfig{Type: SourcePulse}).Input("mic"); f != "pulse" || d != "default" {
+		t.Errorf("pulse without name = %s %s, want pulse default", f, d)
+	}
+	long := strings.Repeat("x", 70)
+	if _, d := (SourceConfig{Type: SourceJACK}).Input(long); len(d) != jackClientNameMax {
+		t.Errorf("client name %q not truncated to %d bytes", d, jackClientNameMax)
+	}
+}
+
+func TestSourceConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"unknown type", "devices:\n  m:\n    source: {type: oss}\n", `source.type must be pulse, pipewire or jack (got "oss")`},
+		{"missing type", "devices:\n  m:\n    source: {name: foo}\n", "source.type is required"},
+		{"name with space", "devices:\n  m:\n    source: {type: pulse, name: \"my mic\"}\n", "source.name must not start with '-'"},
+		{"option-like name", "devices:\n  m:\n    source: {type: pulse, name: -dump}\n", "source.name must not start with '-'"},
+		{"ports for pulse", "devices:\n  m:\n    source: {type: pulse, ports: [a:b]}\n", "source.ports only applies to type jack"},
+		{"jack client colon", "devices:\n  m:\n    source: {type: jack, name: a:b}\n", "must be a JACK client name"},
+		{"bad port", "devices:\n  m:\n    source: {type: jack, ports: [capture_1]}\n", "source.ports[0] must be a JACK port name"},
+		{"too many ports", "devices:\n  m:\n    channels: 1\n    source: {type: jack, ports: [a:1, a:2]}\n", "source.ports lists 2 ports for 1 channel(s)"},
+		{"with match", "devices:\n  m:\n    match: {usb_id: \"0d8c:*\"}\n    source: {type: pulse}\n", "source and match cannot both be set"},
+		{"in default", "default:\n  source: {type: pulse}\n", "match, ignore and source are only valid on device entries"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadOverrides(t, tt.yaml)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTestSourcesLoad(t *testing.T) {
+	cfg, err := loadOverrides(t, `
+test_sources:
+  lyrebird_test:
+    signal: sweep
+    end_frequency: 8000
+    period: 20s
+    ident: dtmf
+  tone_check:
+    level_db: -12
+  bench:
+    signal: beep
+devices:
+  lyrebird_test:
+    codec: aac
+    bitrate: 96k
+  bench:
+    ignore: true
+`)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := cfg.TestSourceNames(); !slices.Equal(got, []string{"lyrebird_test", "tone_check"}) {
+		t.Errorf("TestSourceNames() = %v, want [lyrebird_test tone_check]", got)
+	}
+	want := TestSourceConfig{Signal: "sweep", EndFrequency: 8000, Period: 20 * time.Second, Ident: "dtmf"}
+	if got := cfg.TestSources["lyrebird_test"]; got != want {
+		t.Errorf("lyrebird_test = %+v, want %+v", got, want)
+	}
+	if got := cfg.GetDeviceConfig("lyrebird_test").Codec; got != "aac" {
+		t.Errorf("lyrebird_test codec = %q, want aac from devices:", got)
+	}
+}
+
+func TestTestSourcesValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"signal", "test_sources:\n  t: {signal: noise}\n", `test source "t": signal must be tone, sweep or beep`},
+		{"voice ident", "test_sources:\n  t: {ident: voice}\n", "flite filter"},
+		{"ident", "test_sources:\n  t: {ident: morse}\n", "ident must be dtmf"},
+		{"end frequency", "test_sources:\n  t: {signal: tone, end_frequency: 400}\n", "end_frequency only applies to signal sweep"},
+		{"level", "test_sources:\n  t: {level_db: 6}\n", "level_db must be at most 0"},
+		{"short period", "test_sources:\n  t: {ident: dtmf, period: 1s}\n", "at least 2s"},
+		{"bad name", "test_sources:\n  test-tone: {}\n", `test source "test-tone": name must be letters`},
+		{"static clash", "test_sources:\n  t: {}\nstatic_sources:\n  t: {url: \"rtsp://host/a\"}\n", "also defined in static_sources"},
+		{"device source", "test_sources:\n  t: {}\ndevices:\n  t:\n    source: {type: pulse}\n", "devices.t must not set source or match"},
+		{"unknown key", "test_sources:\n  t: {freq: 440}\n" + strictConfig, "test_sources.t.freq"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadOverrides(t, tt.yaml)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTimecodeDefaults(t *testing.T) {
+	cfg, err := loadOverrides(t, "")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !cfg.Timecode.Enabled || cfg.Timecode.TimeSource != TimeSourceNTP || cfg.Timecode.HostID != "" {
+		t.Errorf("Timecode = %+v, want enabled with time_source ntp", cfg.Timecode)
+	}
+
+	cfg, err = loadOverrides(t, "timecode:\n  enabled: false\n  time_source: system\n  host_id: station-3\n")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want := TimecodeConfig{Enabled: false, TimeSource: TimeSourceSystem, HostID: "station-3"}
+	if cfg.Timecode != want {
+		t.Errorf("Timecode = %+v, want %+v", cfg.Timecode, want)
+	}
+}
+
+func TestTimecodeValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"time source", "timecode:\n  time_source: gps\n", `timecode: time_source must be ntp or system (got "gps")`},
+		{"multi-line host", "timecode:\n  host_id: \"a\\nb\"\n", "host_id must be a single line"},
+		{"unknown key", "timecode:\n  source: ntp\n" + strictConfig, "timecode.source"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadOverrides(t, tt.yaml)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
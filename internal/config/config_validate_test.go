@@ -119,11 +119,11 @@ func TestValidateConfig(t *testing.T) {
 					SampleRate: 48000,
 					Channels:   2,
 					Bitrate:    "128k",
-					Codec:      "mp3",
+					Codec:      "vorbis",
 				},
 			},
 			wantErr: true,
-			errMsg:  "default config: codec must be opus or aac",
+			errMsg:  `default config: codec must be one of opus, aac, flac, pcm, mp3, pcmu, pcma, speex (got "vorbis")`,
 		},
 	}
 
@@ -211,12 +211,12 @@ func TestValidateConfigWithInvalidDevice(t *testing.T) {
 				},
 				Devices: map[string]DeviceConfig{
 					"bad_device": {
-						Codec: "mp3",
+						Codec: "vorbis",
 					},
 				},
 			},
 			wantErr: true,
-			errPart: "device \"bad_device\": codec must be opus or aac",
+			errPart: "device \"bad_device\": codec must be one of",
 		},
 		{
 			name: "invalid device - too many channels",
@@ -394,3 +394,30 @@ func TestDefaultConfigRecordsCleanly(t *testing.T) {
 		t.Errorf("default config with recording enabled should validate, got: %v", err)
 	}
 }
+
+func TestValidateDeviceRemovalGrace(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Stream.DeviceRemovalGrace != 0 {
+		t.Errorf("default device_removal_grace = %v, want 0", cfg.Stream.DeviceRemovalGrace)
+	}
+	cfg.Stream.DeviceRemovalGrace = -time.Second
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "device_removal_grace must not be negative") {
+		t.Errorf("Validate() = %v, want device_removal_grace error", err)
+	}
+}
+
+func TestValidateConfigFileWatch(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.ConfigFile.Watch {
+		t.Error("config.watch must default to off")
+	}
+	if cfg.ConfigFile.WatchDebounce != 500*time.Millisecond {
+		t.Errorf("default watch_debounce = %v, want 500ms", cfg.ConfigFile.WatchDebounce)
+	}
+	cfg.ConfigFile.WatchDebounce = -time.Second
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "watch_debounce must not be negative") {
+		t.Errorf("Validate() = %v, want watch_debounce error", err)
+	}
+}
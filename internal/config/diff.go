@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff returns the dotted keys (e.g. "monitor.interval",
+// "devices.blue_yeti.codec") whose values differ between a and b, sorted.
+//
+// Keys are built from the koanf tags, so they match the names used in the
+// YAML file and by KoanfConfig.Get. A device present in only one config is
+// reported by its per-field keys, as if the missing side held zero values.
+func Diff(a, b *Config) []string {
+	if a == nil {
+		a = &Config{}
+	}
+	if b == nil {
+		b = &Config{}
+	}
+	var keys []string
+	diffValue("", reflect.ValueOf(*a), reflect.ValueOf(*b), &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+// diffValue appends to keys the paths below prefix where a and b differ.
+// Structs recurse field by field; string-keyed maps recurse per entry; any
+// other kind is compared as a leaf.
+func diffValue(prefix string, a, b reflect.Value, keys *[]string) {
+	switch a.Kind() {
+	case reflect.Struct:
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := strings.Split(f.Tag.Get("koanf"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			diffValue(joinKey(prefix, name), a.Field(i), b.Field(i), keys)
+		}
+
+	case reflect.Map:
+		if a.Type().Key().Kind() != reflect.String {
+			if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+				*keys = append(*keys, prefix)
+			}
+			return
+		}
+		seen := make(map[string]bool)
+		for _, m := range []reflect.Value{a, b} {
+			iter := m.MapRange()
+			for iter.Next() {
+				k := iter.Key().String()
+				if seen[k] {
+					continue
+				}
+				seen[k] = true
+				diffValue(joinKey(prefix, k), mapIndexOrZero(a, k), mapIndexOrZero(b, k), keys)
+			}
+		}
+
+	default:
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*keys = append(*keys, prefix)
+		}
+	}
+}
+
+// mapIndexOrZero returns m[k], or the zero value of the element type if k
+// is absent (or m is nil).
+func mapIndexOrZero(m reflect.Value, k string) reflect.Value {
+	if v := m.MapIndex(reflect.ValueOf(k).Convert(m.Type().Key())); v.IsValid() {
+		return v
+	}
+	return reflect.Zero(m.Type().Elem())
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	a := DefaultConfig()
+	a.Devices = map[string]DeviceConfig{"blue_yeti": {SampleRate: 48000, Codec: "opus"}}
+
+	b := DefaultConfig()
+	b.Devices = map[string]DeviceConfig{
+		"blue_yeti": {SampleRate: 44100, Codec: "opus"},
+		"new_mic":   {Codec: "aac"},
+	}
+	b.Monitor.Interval = 2 * time.Minute
+	b.MediaMTX.APIURL = "http://10.0.0.1:9997"
+
+	got := Diff(a, b)
+	want := []string{
+		"devices.blue_yeti.sample_rate",
+		"devices.new_mic.codec",
+		"mediamtx.api_url",
+		"monitor.interval",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+}
+
+func TestDiff_Identical(t *testing.T) {
+	if got := Diff(DefaultConfig(), DefaultConfig()); len(got) != 0 {
+		t.Errorf("Diff(default, default) = %v, want none", got)
+	}
+}
+
+func TestDiff_Nil(t *testing.T) {
+	got := Diff(nil, DefaultConfig())
+	if len(got) == 0 {
+		t.Fatal("Diff(nil, default) reported no differences")
+	}
+	for _, k := range got {
+		if k == "" {
+			t.Errorf("empty key in %v", got)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Audio filter types accepted in a filters: list.
+const (
+	FilterGain       = "gain"       // volume change by gain_db
+	FilterHighpass   = "highpass"   // cut below frequency
+	FilterLowpass    = "lowpass"    // cut above frequency
+	FilterDenoise    = "denoise"    // FFT noise reduction (afftdn)
+	FilterCompressor = "compressor" // dynamic range compression (acompressor)
+	FilterLoudnorm   = "loudnorm"   // EBU R128 loudness normalization
+)
+
+// FilterConfig is one step of a device's audio processing chain. Only the
+// fields of its type may be set; zero fields take the defaults noted below.
+//
+// The chain is rendered into FFmpeg's -af argument by FilterChain from these
+// typed fields only, so no configuration value reaches the filter graph as
+// raw text.
+//
+// Example:
+//
+//	filters:
+//	  - {type: highpass, frequency: 120}
+//	  - {type: denoise, reduction_db: 12}
+//	  - {type: gain, gain_db: 6}
+//	  - {type: loudnorm, target_lufs: -16}
+type FilterConfig struct {
+	Type string `yaml:"type" koanf:"type"` // gain, highpass, lowpass, denoise, compressor or loudnorm
+
+	GainDB       float64 `yaml:"gain_db,omitempty" koanf:"gain_db"`               // gain: -60 to 40 dB
+	Frequency    float64 `yaml:"frequency,omitempty" koanf:"frequency"`           // highpass/lowpass: cutoff, 10 to 24000 Hz (required)
+	ReductionDB  float64 `yaml:"reduction_db,omitempty" koanf:"reduction_db"`     // denoise: 0.01 to 97 dB (default 12)
+	NoiseFloorDB float64 `yaml:"noise_floor_db,omitempty" koanf:"noise_floor_db"` // denoise: -80 to -20 dB (default -50)
+	ThresholdDB  float64 `yaml:"threshold_db,omitempty" koanf:"threshold_db"`     // compressor: -60 to 0 dB (default -18)
+	Ratio        float64 `yaml:"ratio,omitempty" koanf:"ratio"`                   // compressor: 1 to 20 (default 4)
+	AttackMS     float64 `yaml:"attack_ms,omitempty" koanf:"attack_ms"`           // compressor: 0.01 to 2000 ms (default 20)
+	ReleaseMS    float64 `yaml:"release_ms,omitempty" koanf:"release_ms"`         // compressor: 0.01 to 9000 ms (default 250)
+	MakeupDB     float64 `yaml:"makeup_db,omitempty" koanf:"makeup_db"`           // compressor: 0 to 36 dB (default 0)
+	TargetLUFS   float64 `yaml:"target_lufs,omitempty" koanf:"target_lufs"`       // loudnorm: -70 to -5 LUFS (default -23)
+	TruePeakDB   float64 `yaml:"true_peak_db,omitempty" koanf:"true_peak_db"`     // loudnorm: -9 to 0 dBTP (default -2)
+	LRA          float64 `yaml:"lra,omitempty" koanf:"lra"`                       // loudnorm: loudness range target, 1 to 50 LU (default 7)
+}
+
+// filterParam is one numeric field of a FilterConfig.
+type filterParam struct {
+	key      string
+	value    float64
+	min, max float64
+	def      float64
+}
+
+// params returns f's fields with their valid ranges and defaults, and the
+// names of the fields set that its type does not use.
+func (f FilterConfig) params() (used []filterParam, stray []string) {
+	all := []struct {
+		filterParam
+		types []string
+	}{
+		{filterParam{"gain_db", f.GainDB, -60, 40, 0}, []string{FilterGain}},
+		{filterParam{"frequency", f.Frequency, 10, 24000, 0}, []string{FilterHighpass, FilterLowpass}},
+		{filterParam{"reduction_db", f.ReductionDB, 0.01, 97, 12}, []string{FilterDenoise}},
+		{filterParam{"noise_floor_db", f.NoiseFloorDB, -80, -20, -50}, []string{FilterDenoise}},
+		{filterParam{"threshold_db", f.ThresholdDB, -60, 0, -18}, []string{FilterCompressor}},
+		{filterParam{"ratio", f.Ratio, 1, 20, 4}, []string{FilterCompressor}},
+		{filterParam{"attack_ms", f.AttackMS, 0.01, 2000, 20}, []string{FilterCompressor}},
+		{filterParam{"release_ms", f.ReleaseMS, 0.01, 9000, 250}, []string{FilterCompressor}},
+		{filterParam{"makeup_db", f.MakeupDB, 0, 36, 0}, []string{FilterCompressor}},
+		{filterParam{"target_lufs", f.TargetLUFS, -70, -5, -23}, []string{FilterLoudnorm}},
+		{filterParam{"true_peak_db", f.TruePeakDB, -9, 0, -2}, []string{FilterLoudnorm}},
+		{filterParam{"lra", f.LRA, 1, 50, 7}, []string{FilterLoudnorm}},
+	}
+	for _, p := range all {
+		applies := false
+		for _, t := range p.types {
+			applies = applies || t == f.Type
+		}
+		switch {
+		case applies:
+			if p.value == 0 {
+				p.value = p.def
+			}
+			used = append(used, p.filterParam)
+		case p.value != 0:
+			stray = append(stray, p.key)
+		}
+	}
+	return used, stray
+}
+
+// Validate checks the type, that only fields of that type are set, and that
+// every value is in range.
+func (f FilterConfig) Validate() error {
+	switch f.Type {
+	case FilterGain, FilterHighpass, FilterLowpass, FilterDenoise, FilterCompressor, FilterLoudnorm:
+	case "":
+		return fmt.Errorf("type is required (gain, highpass, lowpass, denoise, compressor or loudnorm)")
+	default:
+		return fmt.Errorf("unknown type %q (want gain, highpass, lowpass, denoise, compressor or loudnorm)", f.Type)
+	}
+	used, stray := f.params()
+	if len(stray) > 0 {
+		return fmt.Errorf("%s does not apply to type %s", strings.Join(stray, ", "), f.Type)
+	}
+	for _, p := range used {
+		if math.IsNaN(p.value) || p.value < p.min || p.value > p.max {
+			if p.key == "frequency" && p.value == 0 {
+				return fmt.Errorf("%s needs frequency", f.Type)
+			}
+			return fmt.Errorf("%s must be between %v and %v (got %v)", p.key, p.min, p.max, p.value)
+		}
+	}
+	return nil
+}
+
+// validateFilters validates each entry of a filters: list.
+func validateFilters(filters []FilterConfig) error {
+	for i, f := range filters {
+		if err := f.Validate(); err != nil {
+			return fmt.Errorf("filters[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// FilterChain renders filters as an FFmpeg -af filter graph, or "" when
+// there are none. The graph is built from fixed filter names and formatted
+// numbers only. filters must have passed Validate.
+//
+// Example:
+//
+//	FilterChain([]FilterConfig{{Type: "highpass", Frequency: 120}, {Type: "gain", GainDB: 6}})
+//	// "highpass=f=120,volume=6dB"
+func FilterChain(filters []FilterConfig) string {
+	parts := make([]string, 0, len(filters))
+	for _, f := range filters {
+		used, _ := f.params()
+		v := make(map[string]float64, len(used))
+		for _, p := range used {
+			v[p.key] = p.value
+		}
+		switch f.Type {
+		case FilterGain:
+			parts = append(parts, "volume="+formatFilterNum(v["gain_db"])+"dB")
+		case FilterHighpass, FilterLowpass:
+			parts = append(parts, f.Type+"=f="+formatFilterNum(v["frequency"]))
+		case FilterDenoise:
+			parts = append(parts, "afftdn=nr="+formatFilterNum(v["reduction_db"])+
+				":nf="+formatFilterNum(v["noise_floor_db"]))
+		case FilterCompressor:
+			// acompressor takes the threshold and makeup as linear factors.
+			parts = append(parts, "acompressor=threshold="+formatFilterNum(dbToLinear(v["threshold_db"]))+
+				":ratio="+formatFilterNum(v["ratio"])+
+				":attack="+formatFilterNum(v["attack_ms"])+
+				":release="+formatFilterNum(v["release_ms"])+
+				":makeup="+formatFilterNum(dbToLinear(v["makeup_db"])))
+		case FilterLoudnorm:
+			parts = append(parts, "loudnorm=I="+formatFilterNum(v["target_lufs"])+
+				":TP="+formatFilterNum(v["true_peak_db"])+
+				":LRA="+formatFilterNum(v["lra"]))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// formatFilterNum formats v for a filter graph: no exponent, at most six
+// decimals, no trailing zeros.
+func formatFilterNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+}
+
+// dbToLinear converts decibels to an amplitude factor.
+func dbToLinear(db float64) float64 {
+	return math.Pow(10, db/20)
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flatten returns v (a Config, DeviceConfig or other config struct, or a
+// pointer to one) as a map from dotted key to leaf value, e.g.
+// "default.codec" -> "opus". Keys are the ones Diff reports and KoanfConfig
+// uses. A nil pointer yields an empty map.
+func Flatten(v any) map[string]any {
+	out := make(map[string]any)
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return out
+		}
+		rv = rv.Elem()
+	}
+	if rv.IsValid() {
+		flattenValue("", rv, out)
+	}
+	return out
+}
+
+func flattenValue(prefix string, v reflect.Value, out map[string]any) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := strings.Split(f.Tag.Get("koanf"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			flattenValue(joinKey(prefix, name), v.Field(i), out)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			out[prefix] = v.Interface()
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			flattenValue(joinKey(prefix, iter.Key().String()), iter.Value(), out)
+		}
+	default:
+		out[prefix] = v.Interface()
+	}
+}
+
+// Set parses value according to the type of the field at the dotted key and
+// stores it, as if the YAML file had set it. Durations use Go syntax ("10s",
+// "5m"). Map entries such as "devices.blue_yeti.codec" are created on
+// demand. Set does not validate the result; call Validate afterwards.
+//
+// Example:
+//
+//	if err := cfg.Set("devices.blue_yeti.bitrate", "192k"); err != nil {
+//	    return err
+//	}
+func (c *Config) Set(key, value string) error {
+	if key == "" {
+		return fmt.Errorf("empty config key")
+	}
+	return setValue(reflect.ValueOf(c).Elem(), strings.Split(key, "."), key, value)
+}
+
+func setValue(v reflect.Value, path []string, key, value string) error {
+	if len(path) == 0 {
+		return setLeaf(v, key, value)
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.IsExported() && strings.Split(f.Tag.Get("koanf"), ",")[0] == path[0] {
+				return setValue(v.Field(i), path[1:], key, value)
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		// Map elements are not addressable: copy, modify, store back.
+		mk := reflect.ValueOf(path[0]).Convert(v.Type().Key())
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if cur := v.MapIndex(mk); cur.IsValid() {
+			elem.Set(cur)
+		}
+		if err := setValue(elem, path[1:], key, value); err != nil {
+			return err
+		}
+		v.SetMapIndex(mk, elem)
+		return nil
+	}
+	return fmt.Errorf("unknown config key %q", key)
+}
+
+func setLeaf(v reflect.Value, key, value string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid duration %q (use a unit, e.g. 10s)", key, value)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid boolean %q", key, value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s: invalid integer %q", key, value)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s: invalid unsigned integer %q", key, value)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s: invalid number %q", key, value)
+		}
+		v.SetFloat(f)
+	case reflect.Struct, reflect.Map:
+		return fmt.Errorf("%q is a section, not a single setting", key)
+	default:
+		return fmt.Errorf("config key %q cannot be set from the command line", key)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlatten(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Devices = map[string]DeviceConfig{"blue_yeti": {Codec: "aac"}}
+	flat := Flatten(cfg)
+	if flat["default.codec"] != "opus" {
+		t.Errorf(`flat["default.codec"] = %v, want opus`, flat["default.codec"])
+	}
+	if flat["devices.blue_yeti.codec"] != "aac" {
+		t.Errorf(`flat["devices.blue_yeti.codec"] = %v, want aac`, flat["devices.blue_yeti.codec"])
+	}
+	if flat["monitor.interval"] != cfg.Monitor.Interval {
+		t.Errorf(`flat["monitor.interval"] = %v, want %v`, flat["monitor.interval"], cfg.Monitor.Interval)
+	}
+	if len(Flatten(nil)) != 0 {
+		t.Error("Flatten(nil) should be empty")
+	}
+}
+
+func TestFlattenDeviceConfig(t *testing.T) {
+	flat := Flatten(DeviceConfig{Codec: "aac", SampleRate: 44100})
+	if flat["codec"] != "aac" || flat["sample_rate"] != 44100 {
+		t.Errorf("Flatten(DeviceConfig) = %v", flat)
+	}
+}
+
+func TestConfigSet(t *testing.T) {
+	cfg := DefaultConfig()
+	sets := map[string]string{
+		"default.codec":                 "aac",
+		"default.sample_rate":           "44100",
+		"monitor.enabled":               "false",
+		"monitor.interval":              "90s",
+		"monitor.disk_low_threshold_mb": "512",
+		"devices.blue_yeti.bitrate":     "192k",
+	}
+	for k, v := range sets {
+		if err := cfg.Set(k, v); err != nil {
+			t.Fatalf("Set(%q, %q) error = %v", k, v, err)
+		}
+	}
+	if cfg.Default.Codec != "aac" || cfg.Default.SampleRate != 44100 {
+		t.Errorf("default = %+v", cfg.Default)
+	}
+	if cfg.Monitor.Enabled || cfg.Monitor.Interval != 90*time.Second || cfg.Monitor.DiskLowThresholdMB != 512 {
+		t.Errorf("monitor = %+v", cfg.Monitor)
+	}
+	if cfg.Devices["blue_yeti"].Bitrate != "192k" {
+		t.Errorf("devices = %+v", cfg.Devices)
+	}
+
+	// Setting a second field keeps the first.
+	if err := cfg.Set("devices.blue_yeti.channels", "1"); err != nil {
+		t.Fatalf("Set channels: %v", err)
+	}
+	if d := cfg.Devices["blue_yeti"]; d.Bitrate != "192k" || d.Channels != 1 {
+		t.Errorf("blue_yeti = %+v, want bitrate kept and channels set", d)
+	}
+}
+
+func TestConfigSetErrors(t *testing.T) {
+	tests := []struct {
+		key, value, want string
+	}{
+		{"", "x", "empty config key"},
+		{"nope.key", "x", "unknown config key"},
+		{"default.nope", "x", "unknown config key"},
+		{"default", "x", "is a section"},
+		{"default.sample_rate", "fast", "invalid integer"},
+		{"monitor.enabled", "maybe", "invalid boolean"},
+		{"monitor.interval", "30", "invalid duration"},
+	}
+	for _, tt := range tests {
+		err := DefaultConfig().Set(tt.key, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Set(%q, %q) = %v, want %q", tt.key, tt.value, err, tt.want)
+		}
+	}
+}
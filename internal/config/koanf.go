@@ -5,11 +5,17 @@ package config
 import (
 	"context"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env/v2"
 	"github.com/knadh/koanf/providers/file"
@@ -36,22 +42,31 @@ func buildDeviceConfigFieldSuffixes() []string {
 			suffixes = append(suffixes, "_"+name)
 		}
 	}
+	// Longest first, so a field whose name ends with another field's name
+	// (e.g. "_max_restart_delay" vs a future "_restart_delay") wins the match.
+	sort.SliceStable(suffixes, func(i, j int) bool { return len(suffixes[i]) > len(suffixes[j]) })
 	return suffixes
 }
 
 // KoanfConfig wraps koanf for enhanced configuration management.
 //
 // It provides:
-//   - Multiple configuration sources (YAML file + environment variables)
+//   - Multiple configuration sources (YAML file + include directory + environment variables)
 //   - Configuration hot-reload via file watching
-//   - Override precedence (env vars override YAML)
+//   - Override precedence (env vars override include files override YAML)
+//   - Per-key origin tracking (which source set each value)
 //   - Backward compatibility with existing LoadConfig() API
 type KoanfConfig struct {
-	k         *koanf.Koanf
-	mu        sync.RWMutex
-	filePath  string
-	envPrefix string
-	loaded    bool // true after the first successful load; gates validate-before-swap on hot reload
+	k          *koanf.Koanf
+	origins    map[string]string // key -> source that set it; swapped together with k
+	unknown    []UnknownKey      // unknown keys tolerated because config.strict is false
+	secrets    map[string]string // key -> value resolved from a secret reference; swapped together with k
+	redactor   *Redactor         // updated with the secret values on every load
+	mu         sync.RWMutex
+	filePath   string
+	includeDir string
+	envPrefix  string
+	loaded     bool // true after the first successful load; gates validate-before-swap on hot reload
 }
 
 // Option configures a KoanfConfig.
@@ -65,6 +80,37 @@ func WithYAMLFile(path string) Option {
 	}
 }
 
+// WithRedactor makes the loader keep r updated with the resolved secret
+// values, so a log handler created before the config is loaded can redact
+// them (see Redactor.ReplaceAttr).
+func WithRedactor(r *Redactor) Option {
+	return func(kc *KoanfConfig) error {
+		kc.redactor = r
+		return nil
+	}
+}
+
+// WithIncludeDir merges every *.yaml file in dir on top of the YAML file, in
+// lexical file-name order, so later snippets override earlier ones and
+// environment variables still override them all. A missing directory is not
+// an error: it simply contributes nothing.
+//
+// Fleet provisioning tools use this to drop per-device snippets (e.g.
+// conf.d/50-blue-yeti.yaml) without rewriting the main config file.
+func WithIncludeDir(dir string) Option {
+	return func(kc *KoanfConfig) error {
+		kc.includeDir = dir
+		return nil
+	}
+}
+
+// DefaultIncludeDir returns the include directory that accompanies the
+// config file at path: conf.d next to it (/etc/lyrebird/conf.d for the
+// default /etc/lyrebird/config.yaml).
+func DefaultIncludeDir(path string) string {
+	return filepath.Join(filepath.Dir(path), "conf.d")
+}
+
 // WithEnvPrefix sets the environment variable prefix (default: "LYREBIRD").
 func WithEnvPrefix(prefix string) Option {
 	return func(kc *KoanfConfig) error {
@@ -77,8 +123,9 @@ func WithEnvPrefix(prefix string) Option {
 //
 // It loads configuration from multiple sources with the following precedence (highest to lowest):
 //  1. Environment variables (LYREBIRD_*)
-//  2. YAML configuration file
-//  3. Built-in defaults
+//  2. Include directory files (WithIncludeDir), later file names winning
+//  3. YAML configuration file
+//  4. Built-in defaults
 //
 // Parameters:
 //   - opts: Configuration options (WithYAMLFile, WithEnvPrefix, etc.)
@@ -101,6 +148,7 @@ func NewKoanfConfig(opts ...Option) (*KoanfConfig, error) {
 	kc := &KoanfConfig{
 		k:         koanf.New("."),
 		envPrefix: "LYREBIRD",
+		redactor:  NewRedactor(),
 	}
 
 	// Apply options
@@ -165,71 +213,61 @@ func (kc *KoanfConfig) Reload() error {
 func (kc *KoanfConfig) reload() error {
 	// Create a new koanf instance for atomic reload
 	newK := koanf.New(".")
+	origins := make(map[string]string)
+	var unknown []UnknownKey
 
 	// Load YAML file (if specified)
 	if kc.filePath != "" {
-		if err := newK.Load(file.Provider(kc.filePath), yaml.Parser()); err != nil {
+		if err := loadLayer(newK, origins, &unknown, kc.filePath); err != nil {
 			return fmt.Errorf("failed to load YAML file: %w", err)
 		}
 	}
 
-	// Load environment variables (override YAML).
-	// Strategy: transform LYREBIRD_DEVICES_BLUE_YETI_SAMPLE_RATE to
-	// devices.blue_yeti.sample_rate by recognising the known top-level key
-	// prefixes and stripping the suffix for known field names.
-	// The env.Provider Prefix option already strips LYREBIRD_ before the
-	// TransformFunc runs, so the function receives the remainder only.
+	// Merge include files over the main file, in lexical order.
+	// Origins are recorded per layer so `lyrebird config show --origin`
+	// can say which file set each effective value.
+	includes, err := kc.includeFiles()
+	if err != nil {
+		return err
+	}
+	for _, path := range includes {
+		if err := loadLayer(newK, origins, &unknown, path); err != nil {
+			return fmt.Errorf("failed to load include file %s: %w", path, err)
+		}
+	}
+
+	// Load environment variables (override YAML and include files).
+	// envKey transforms LYREBIRD_DEVICES_BLUE_YETI_SAMPLE_RATE to
+	// devices.blue_yeti.sample_rate; the full variable name is kept as the
+	// key's origin.
+	envNames := make(map[string]string) // koanf key -> environment variable
 	envProvider := env.Provider(".", env.Opt{
 		Prefix: kc.envPrefix + "_",
 		TransformFunc: func(k, v string) (string, any) {
-			// k arrives WITHOUT the LYREBIRD_ prefix (stripped by env.Provider).
-			// Convert to lowercase for case-insensitive matching.
-			k = strings.TrimPrefix(k, kc.envPrefix+"_")
-			// Convert to lowercase
-			k = strings.ToLower(k)
-
-			// Known top-level keys that should be separated
-			// DEVICES_XXX -> devices.XXX
-			// DEFAULT_XXX -> default.XXX
-			// STREAM_XXX -> stream.XXX
-			// MEDIAMTX_XXX -> mediamtx.XXX
-			// MONITOR_XXX -> monitor.XXX
-			topLevelKeys := []string{"devices_", "default_", "stream_", "mediamtx_", "monitor_"}
-
-			for _, prefix := range topLevelKeys {
-				if strings.HasPrefix(k, prefix) {
-					// Split: "devices_blue_yeti_sample_rate" -> "devices" + "blue_yeti_sample_rate"
-					rest := strings.TrimPrefix(k, prefix)
-					topLevel := strings.TrimSuffix(prefix, "_")
-
-					// For "devices", we need one more level (device name)
-					if topLevel == "devices" {
-						// "blue_yeti_sample_rate" -> "blue_yeti" + "sample_rate"
-						// Find the last known field name (derived from DeviceConfig tags).
-						for _, field := range deviceConfigFieldSuffixes {
-							if strings.HasSuffix(rest, field) {
-								deviceName := strings.TrimSuffix(rest, field)
-								fieldName := strings.TrimPrefix(field, "_")
-								return topLevel + "." + deviceName + "." + fieldName, v
-							}
-						}
-						// If no known field, treat entire rest as device name
-						return topLevel + "." + rest, v
-					}
-
-					// For other top-levels, just append the rest
-					return topLevel + "." + rest, v
-				}
-			}
-
-			// No known prefix, return as-is with underscores replaced by dots
-			return strings.ReplaceAll(k, "_", "."), v
+			key, val := kc.envKey(k, v)
+			envNames[key] = k
+			return key, val
 		},
 	})
 
 	if err := newK.Load(envProvider, nil); err != nil {
 		return fmt.Errorf("failed to load environment variables: %w", err)
 	}
+	for key, name := range envNames {
+		origins[key] = "env " + name
+	}
+
+	// Unknown keys are fatal only when config.strict is true (which may
+	// itself come from any layer, including LYREBIRD_CONFIG_STRICT).
+	if len(unknown) > 0 && newK.Bool("config.strict") {
+		return &UnknownKeysError{Keys: unknown}
+	}
+
+	// Resolve *_file, ${file:...} and ${cred:...} references (see secrets.go).
+	secrets, err := resolveSecrets(newK, origins)
+	if err != nil {
+		return fmt.Errorf("failed to resolve secret: %w", err)
+	}
 
 	// On a HOT reload (a working config is already live), validate the new
 	// config BEFORE swapping so a syntactically-valid but semantically-invalid
@@ -253,13 +291,138 @@ func (kc *KoanfConfig) reload() error {
 	// Atomic swap (protected by write lock)
 	kc.mu.Lock()
 	kc.k = newK
+	kc.origins = origins
+	kc.unknown = unknown
+	kc.secrets = secrets
 	kc.loaded = true
 	kc.mu.Unlock()
+	kc.redactor.Update(slices.Collect(maps.Values(secrets)))
 
 	return nil
 }
 
-// Watch starts watching the configuration file for changes.
+// envKey maps an environment variable (e.g. LYREBIRD_DEVICES_BLUE_YETI_SAMPLE_RATE)
+// to its koanf key (devices.blue_yeti.sample_rate) by recognising the known
+// top-level key prefixes and stripping the suffix for known field names.
+func (kc *KoanfConfig) envKey(k, v string) (string, any) {
+	k = strings.TrimPrefix(k, kc.envPrefix+"_")
+	// Convert to lowercase
+	k = strings.ToLower(k)
+
+	// Known top-level keys that should be separated
+	// DEVICES_XXX -> devices.XXX
+	// DEFAULT_XXX -> default.XXX
+	// STREAM_XXX -> stream.XXX
+	// MEDIAMTX_XXX -> mediamtx.XXX
+	// MONITOR_XXX -> monitor.XXX
+	// CONFIG_XXX -> config.XXX
+	// TIMECODE_XXX -> timecode.XXX
+	topLevelKeys := []string{"devices_", "default_", "stream_", "mediamtx_", "monitor_", "config_", "timecode_"}
+
+	for _, prefix := range topLevelKeys {
+		if strings.HasPrefix(k, prefix) {
+			// Split: "devices_blue_yeti_sample_rate" -> "devices" + "blue_yeti_sample_rate"
+			rest := strings.TrimPrefix(k, prefix)
+			topLevel := strings.TrimSuffix(prefix, "_")
+
+			// For "devices", we need one more level (device name)
+			if topLevel == "devices" {
+				// "blue_yeti_sample_rate" -> "blue_yeti" + "sample_rate"
+				// Find the last known field name (derived from DeviceConfig tags).
+				for _, field := range deviceConfigFieldSuffixes {
+					if strings.HasSuffix(rest, field) {
+						deviceName := strings.TrimSuffix(rest, field)
+						fieldName := strings.TrimPrefix(field, "_")
+						return topLevel + "." + deviceName + "." + fieldName, v
+					}
+				}
+				// If no known field, treat entire rest as device name
+				return topLevel + "." + rest, v
+			}
+
+			// For other top-levels, just append the rest
+			return topLevel + "." + rest, v
+		}
+	}
+
+	// No known prefix, return as-is with underscores replaced by dots
+	return strings.ReplaceAll(k, "_", "."), v
+}
+
+// loadLayer merges the YAML file at path into k, recording path as the
+// origin of every key it sets and appending its unknown keys to unknown.
+func loadLayer(k *koanf.Koanf, origins map[string]string, unknown *[]UnknownKey, path string) error {
+	// #nosec G304 -- config and include paths are administrator-controlled
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if data, _, err = migrateLoadedYAML(data); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	layer := koanf.New(".")
+	if err := layer.Load(rawYAML(data), yaml.Parser()); err != nil {
+		return err
+	}
+	found, err := FindUnknownKeys(path, data)
+	if err != nil {
+		return err
+	}
+	*unknown = append(*unknown, found...)
+	for _, key := range layer.Keys() {
+		origins[key] = path
+	}
+	return k.Merge(layer)
+}
+
+// rawYAML is a koanf.Provider over bytes already read from disk, so each
+// layer is read once for both loading and unknown-key checking.
+type rawYAML []byte
+
+func (r rawYAML) ReadBytes() ([]byte, error) { return r, nil }
+
+func (r rawYAML) Read() (map[string]interface{}, error) {
+	return nil, fmt.Errorf("rawYAML provider does not support Read")
+}
+
+// includeFiles returns the *.yaml files in the include directory in lexical
+// order. A missing directory yields none.
+func (kc *KoanfConfig) includeFiles() ([]string, error) {
+	if kc.includeDir == "" {
+		return nil, nil
+	}
+	// filepath.Glob returns matches sorted, which is the documented merge order.
+	files, err := filepath.Glob(filepath.Join(kc.includeDir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list include directory: %w", err)
+	}
+	return files, nil
+}
+
+// UnknownKeys returns the unknown keys found in the current configuration's
+// files. It is always empty with config.strict enabled, since such a
+// configuration fails to load.
+func (kc *KoanfConfig) UnknownKeys() []UnknownKey {
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	return append([]UnknownKey(nil), kc.unknown...)
+}
+
+// Origin returns the source that set key in the current configuration: the
+// path of the YAML or include file, "env <NAME>" for an environment
+// variable, or "default" when no source set it and the built-in default
+// applies. key is a leaf key such as "default.codec".
+func (kc *KoanfConfig) Origin(key string) string {
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	if src, ok := kc.origins[key]; ok {
+		return src
+	}
+	return "default"
+}
+
+// Watch starts watching the configuration file, and the include directory
+// if one is configured, for changes.
 //
 // When changes are detected, the callback function is called with the event type
 // and any error that occurred. The configuration is automatically reloaded before
@@ -299,11 +462,13 @@ func (kc *KoanfConfig) Watch(ctx context.Context, callback func(event string, er
 		return fmt.Errorf("cannot watch: no file path specified")
 	}
 
-	// Create file provider with watch capability
-	fp := file.Provider(kc.filePath)
-
-	// Start watching
-	watchErr := fp.Watch(func(event interface{}, err error) {
+	// Events from the main file and the include directory arrive on
+	// different goroutines; serialise them so callback never runs
+	// concurrently with itself.
+	var cbMu sync.Mutex
+	handle := func(err error) {
+		cbMu.Lock()
+		defer cbMu.Unlock()
 		if err != nil {
 			// Propagate error to callback
 			callback("watch error", fmt.Errorf("file watch error: %w", err))
@@ -317,20 +482,73 @@ func (kc *KoanfConfig) Watch(ctx context.Context, callback func(event string, er
 		}
 
 		callback("config reloaded", nil)
+	}
+
+	// Create file provider with watch capability
+	fp := file.Provider(kc.filePath)
+
+	// Start watching
+	watchErr := fp.Watch(func(event interface{}, err error) {
+		handle(err)
 	})
 
 	if watchErr != nil {
 		return fmt.Errorf("failed to start watching: %w", watchErr)
 	}
 
-	// Wait for context cancellation, then stop the fsnotify watcher and its
-	// goroutine so neither is leaked when the caller shuts down.
+	// Watch the include directory too, if there is one. It must exist when
+	// watching starts; a directory created later is picked up by the next
+	// reload but not watched.
+	var dirW *fsnotify.Watcher
+	if fi, err := os.Stat(kc.includeDir); kc.includeDir != "" && err == nil && fi.IsDir() {
+		if dirW, err = fsnotify.NewWatcher(); err == nil {
+			err = dirW.Add(kc.includeDir)
+		}
+		if err != nil {
+			_ = fp.Unwatch()
+			if dirW != nil {
+				_ = dirW.Close()
+			}
+			return fmt.Errorf("failed to watch include directory: %w", err)
+		}
+		go watchIncludeDir(dirW, handle)
+	}
+
+	// Wait for context cancellation, then stop the fsnotify watchers and
+	// their goroutines so none is leaked when the caller shuts down.
 	<-ctx.Done()
 	_ = fp.Unwatch()
+	if dirW != nil {
+		_ = dirW.Close()
+	}
 
 	return nil
 }
 
+// watchIncludeDir forwards changes to *.yaml files in the watched include
+// directory to handle until w is closed. Adding, editing, renaming and
+// deleting a snippet all change the merged config, so each is a change.
+func watchIncludeDir(w *fsnotify.Watcher, handle func(err error)) {
+	for {
+		select {
+		case ev, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if filepath.Ext(ev.Name) != ".yaml" || !ev.Has(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) {
+				continue
+			}
+			handle(nil)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			handle(err)
+			return
+		}
+	}
+}
+
 // GetString retrieves a string value from configuration.
 func (kc *KoanfConfig) GetString(key string) string {
 	kc.mu.RLock()
@@ -371,10 +589,37 @@ func (kc *KoanfConfig) Exists(key string) bool {
 	return k.Exists(key)
 }
 
-// All returns the entire configuration as a map.
+// All returns the entire configuration as a map. Values resolved from a
+// secret reference are replaced by RedactedValue.
 func (kc *KoanfConfig) All() map[string]interface{} {
 	kc.mu.RLock()
-	k := kc.k
+	k, secrets := kc.k, kc.secrets
+	kc.mu.RUnlock()
+	all := k.All()
+	for key := range secrets {
+		if _, ok := all[key]; ok {
+			all[key] = RedactedValue
+		}
+	}
+	return all
+}
+
+// SecretKeys returns the keys whose values were resolved from a secret
+// reference, sorted.
+func (kc *KoanfConfig) SecretKeys() []string {
+	kc.mu.RLock()
+	secrets := kc.secrets
 	kc.mu.RUnlock()
-	return k.All()
+	return slices.Sorted(maps.Keys(secrets))
+}
+
+// Redacted returns a copy of cfg (loaded from kc) with its secret values
+// replaced by RedactedValue, for display.
+func (kc *KoanfConfig) Redacted(cfg *Config) *Config {
+	return RedactConfig(cfg, kc.SecretKeys())
+}
+
+// Redactor returns the redactor kept up to date with the secret values.
+func (kc *KoanfConfig) Redactor() *Redactor {
+	return kc.redactor
 }
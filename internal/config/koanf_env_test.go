@@ -225,3 +225,49 @@ func TestDeviceConfigFieldSuffixesMatchesEnvOverride(t *testing.T) {
 		t.Errorf("Codec = %q, want \"aac\" (env override did not apply)", devCfg.Codec)
 	}
 }
+
+// TestKoanfConfig_ConfigWatchEnvOverride verifies that LYREBIRD_CONFIG_*
+// maps onto the config: section.
+func TestKoanfConfig_ConfigWatchEnvOverride(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("config:\n  watch: false\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	t.Setenv("LYREBIRD_CONFIG_WATCH", "true")
+	t.Setenv("LYREBIRD_CONFIG_WATCH_DEBOUNCE", "2s")
+
+	kc, err := NewKoanfConfig(WithYAMLFile(configPath), WithEnvPrefix("LYREBIRD"))
+	if err != nil {
+		t.Fatalf("NewKoanfConfig failed: %v", err)
+	}
+	cfg, err := kc.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !cfg.ConfigFile.Watch || cfg.ConfigFile.WatchDebounce.String() != "2s" {
+		t.Errorf("config section = %+v, want watch=true debounce=2s", cfg.ConfigFile)
+	}
+}
+
+// TestKoanfConfig_TimecodeEnvOverride verifies that LYREBIRD_TIMECODE_*
+// maps onto the timecode: section, including multi-word field names.
+func TestKoanfConfig_TimecodeEnvOverride(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("timecode:\n  enabled: true\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	t.Setenv("LYREBIRD_TIMECODE_HOST_ID", "station7")
+	t.Setenv("LYREBIRD_TIMECODE_TIME_SOURCE", "system")
+
+	kc, err := NewKoanfConfig(WithYAMLFile(configPath), WithEnvPrefix("LYREBIRD"))
+	if err != nil {
+		t.Fatalf("NewKoanfConfig failed: %v", err)
+	}
+	cfg, err := kc.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Timecode.HostID != "station7" || cfg.Timecode.TimeSource != TimeSourceSystem {
+		t.Errorf("timecode section = %+v, want host_id=station7 time_source=system", cfg.Timecode)
+	}
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeIncludeFixture writes a main config plus the given conf.d snippets
+// and returns the main config path.
+func writeIncludeFixture(t *testing.T, main string, snippets map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(main), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if snippets != nil {
+		if err := os.MkdirAll(DefaultIncludeDir(configPath), 0755); err != nil {
+			t.Fatalf("mkdir conf.d: %v", err)
+		}
+	}
+	for name, body := range snippets {
+		if err := os.WriteFile(filepath.Join(DefaultIncludeDir(configPath), name), []byte(body), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return configPath
+}
+
+func TestDefaultIncludeDir(t *testing.T) {
+	if got := DefaultIncludeDir("/etc/lyrebird/config.yaml"); got != "/etc/lyrebird/conf.d" {
+		t.Errorf("DefaultIncludeDir() = %q, want /etc/lyrebird/conf.d", got)
+	}
+}
+
+// TestKoanfConfig_IncludeDirMergeOrder verifies include files override the
+// main file in lexical order, env vars override them all, and non-.yaml
+// files are ignored.
+func TestKoanfConfig_IncludeDirMergeOrder(t *testing.T) {
+	configPath := writeIncludeFixture(t, "default:\n  bitrate: 128k\n  codec: opus\n  sample_rate: 48000\n",
+		map[string]string{
+			"20-later.yaml":   "default:\n  bitrate: 256k\n",
+			"10-earlier.yaml": "default:\n  bitrate: 192k\n  sample_rate: 44100\n",
+			"30-ignored.yml":  "default:\n  bitrate: 64k\n",
+			"README":          "not yaml",
+		})
+	t.Setenv("LYREBIRD_DEFAULT_SAMPLE_RATE", "96000")
+
+	kc, err := NewKoanfConfig(
+		WithYAMLFile(configPath),
+		WithIncludeDir(DefaultIncludeDir(configPath)),
+		WithEnvPrefix("LYREBIRD"),
+	)
+	if err != nil {
+		t.Fatalf("NewKoanfConfig failed: %v", err)
+	}
+	cfg, err := kc.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Default.Bitrate != "256k" {
+		t.Errorf("bitrate = %q, want 256k from 20-later.yaml", cfg.Default.Bitrate)
+	}
+	if cfg.Default.SampleRate != 96000 {
+		t.Errorf("sample_rate = %d, want 96000 from env", cfg.Default.SampleRate)
+	}
+
+	incDir := DefaultIncludeDir(configPath)
+	origins := map[string]string{
+		"default.codec":       configPath,
+		"default.bitrate":     filepath.Join(incDir, "20-later.yaml"),
+		"default.sample_rate": "env LYREBIRD_DEFAULT_SAMPLE_RATE",
+		"default.channels":    "default",
+	}
+	for key, want := range origins {
+		if got := kc.Origin(key); got != want {
+			t.Errorf("Origin(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestKoanfConfig_IncludeDirMissing(t *testing.T) {
+	configPath := writeIncludeFixture(t, "default:\n  codec: opus\n", nil)
+	kc, err := NewKoanfConfig(WithYAMLFile(configPath), WithIncludeDir(DefaultIncludeDir(configPath)))
+	if err != nil {
+		t.Fatalf("NewKoanfConfig with missing include dir failed: %v", err)
+	}
+	if _, err := kc.Load(); err != nil {
+		t.Errorf("Load failed: %v", err)
+	}
+}
+
+func TestKoanfConfig_IncludeFileInvalid(t *testing.T) {
+	configPath := writeIncludeFixture(t, "default:\n  codec: opus\n",
+		map[string]string{"10-broken.yaml": "default: [unclosed\n"})
+	_, err := NewKoanfConfig(WithYAMLFile(configPath), WithIncludeDir(DefaultIncludeDir(configPath)))
+	if err == nil || !strings.Contains(err.Error(), "10-broken.yaml") {
+		t.Errorf("NewKoanfConfig() = %v, want error naming 10-broken.yaml", err)
+	}
+}
+
+// TestKoanfConfig_WatchIncludeDir verifies that adding a snippet to the
+// include directory triggers a reload.
+func TestKoanfConfig_WatchIncludeDir(t *testing.T) {
+	configPath := writeIncludeFixture(t, "default:\n  bitrate: 128k\n", map[string]string{})
+	kc, err := NewKoanfConfig(WithYAMLFile(configPath), WithIncludeDir(DefaultIncludeDir(configPath)))
+	if err != nil {
+		t.Fatalf("NewKoanfConfig failed: %v", err)
+	}
+
+	events := make(chan string, 16)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		_ = kc.Watch(ctx, func(event string, err error) {
+			if err != nil {
+				events <- "error: " + err.Error()
+				return
+			}
+			events <- event
+		})
+	}()
+	time.Sleep(150 * time.Millisecond)
+
+	snippet := filepath.Join(DefaultIncludeDir(configPath), "50-dev.yaml")
+	if err := os.WriteFile(snippet, []byte("default:\n  bitrate: 320k\n"), 0644); err != nil {
+		t.Fatalf("write snippet: %v", err)
+	}
+
+	deadline := time.After(5 * time.Second)
+	for kc.GetString("default.bitrate") != "320k" {
+		select {
+		case ev := <-events:
+			if strings.HasPrefix(ev, "error") && !strings.Contains(ev, "reload failed") {
+				t.Fatalf("watch error: %s", ev)
+			}
+		case <-deadline:
+			t.Fatalf("include dir change not reloaded; bitrate = %q", kc.GetString("default.bitrate"))
+		}
+	}
+	if got := kc.Origin("default.bitrate"); got != snippet {
+		t.Errorf("Origin(default.bitrate) = %q, want %q", got, snippet)
+	}
+}
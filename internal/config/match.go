@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DeviceMatch selects physical devices for a device entry by hardware
+// identity instead of by sanitized ALSA name. Every field that is set must
+// match (they are ANDed); an empty block matches nothing.
+//
+// Globs use path.Match syntax ("*", "?", "[...]"). USB IDs are compared
+// case-insensitively.
+//
+// Example:
+//
+//	devices:
+//	  bird_box:
+//	    match:
+//	      usb_id: "0d8c:0014"
+//	      port: "1-1.4"
+type DeviceMatch struct {
+	USBID    string `yaml:"usb_id,omitempty" koanf:"usb_id"`       // vendor:product glob, e.g. "0d8c:*"
+	Serial   string `yaml:"serial,omitempty" koanf:"serial"`       // USB serial number glob
+	ByID     string `yaml:"by_id,omitempty" koanf:"by_id"`         // /dev/snd/by-id link name glob, e.g. "usb-BLUE_Yeti*"
+	Port     string `yaml:"port,omitempty" koanf:"port"`           // USB port path glob, e.g. "1-1.4"
+	CardName string `yaml:"card_name,omitempty" koanf:"card_name"` // Regular expression on the ALSA card id (/proc/asound/cardN/id)
+	Priority int    `yaml:"priority,omitempty" koanf:"priority"`   // Higher is evaluated first (default 0); ties go by entry name
+}
+
+// IsZero reports whether no criterion is set.
+func (m DeviceMatch) IsZero() bool {
+	return m.USBID == "" && m.Serial == "" && m.ByID == "" && m.Port == "" && m.CardName == ""
+}
+
+// DeviceIdentity is what is known about a detected device for matching.
+// Fields that could not be read are empty and never match a criterion.
+type DeviceIdentity struct {
+	Name     string // Stable sanitized name (the default config key and stream name)
+	CardName string // Raw ALSA card id
+	USBID    string // vendor:product
+	Serial   string // USB serial number
+	ByID     string // /dev/snd/by-id link name
+	Port     string // USB port path
+}
+
+// Matches reports whether id satisfies every criterion set in m.
+func (m DeviceMatch) Matches(id DeviceIdentity) bool {
+	if m.IsZero() {
+		return false
+	}
+	globs := []struct{ pattern, value string }{
+		{strings.ToLower(m.USBID), strings.ToLower(id.USBID)},
+		{m.Serial, id.Serial},
+		{m.ByID, id.ByID},
+		{m.Port, id.Port},
+	}
+	for _, g := range globs {
+		if g.pattern == "" {
+			continue
+		}
+		if ok, err := path.Match(g.pattern, g.value); err != nil || !ok || g.value == "" {
+			return false
+		}
+	}
+	if m.CardName != "" {
+		re, err := regexp.Compile(m.CardName)
+		if err != nil || id.CardName == "" || !re.MatchString(id.CardName) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that the globs and the card_name expression compile.
+func (m DeviceMatch) Validate() error {
+	for _, g := range []struct{ key, pattern string }{
+		{"usb_id", m.USBID}, {"serial", m.Serial}, {"by_id", m.ByID}, {"port", m.Port},
+	} {
+		if _, err := path.Match(g.pattern, ""); err != nil {
+			return fmt.Errorf("match.%s: invalid glob %q: %w", g.key, g.pattern, err)
+		}
+	}
+	if m.CardName != "" {
+		if _, err := regexp.Compile(m.CardName); err != nil {
+			return fmt.Errorf("match.card_name: invalid regular expression: %w", err)
+		}
+	}
+	return nil
+}
+
+// ResolveDevice returns the device entry name a detected device uses, and
+// whether that entry is marked ignore: true.
+//
+// Entries with a match: block are tried first, in descending
+// match.priority and then by name; the first whose criteria all match wins.
+// Otherwise the device falls back to the entry keyed by its sanitized name,
+// as before. The returned name is also the device's stream name, so a match
+// entry gives two identical microphones distinct streams.
+//
+// Example:
+//
+//	name, ignored := cfg.ResolveDevice(config.DeviceIdentity{Name: "USB_Audio_Device", Port: "1-1.4"})
+//	if !ignored {
+//	    devCfg := cfg.GetDeviceConfig(name)
+//	}
+func (c *Config) ResolveDevice(id DeviceIdentity) (name string, ignored bool) {
+	for _, entry := range c.matchEntries() {
+		if c.Devices[entry].Match.Matches(id) {
+			return entry, c.Devices[entry].Ignore
+		}
+	}
+	return id.Name, c.Devices[id.Name].Ignore
+}
+
+// matchEntries returns the names of entries with a match: block in
+// evaluation order.
+func (c *Config) matchEntries() []string {
+	var names []string
+	for name, d := range c.Devices {
+		if !d.Match.IsZero() {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		pi, pj := c.Devices[names[i]].Match.Priority, c.Devices[names[j]].Match.Priority
+		if pi != pj {
+			return pi > pj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
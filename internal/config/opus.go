@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+)
+
+// OpusConfig tunes the libopus encoder. It applies only when the device's
+// codec is opus. preset picks a starting point (see audio.OpusPreset); each
+// field set alongside it overrides the preset.
+//
+// Example:
+//
+//	devices:
+//	  dawn_chorus:
+//	    opus:
+//	      preset: high
+//	      frame_duration: 40
+//	  radio_link:
+//	    opus:
+//	      application: voip
+//	      packet_loss: 15
+//	      fec: true
+type OpusConfig struct {
+	Preset        string  `yaml:"preset,omitempty" koanf:"preset"`                 // low, normal or high (quality tiers); default: libopus defaults
+	Application   string  `yaml:"application,omitempty" koanf:"application"`       // voip (speech), audio (music, birdsong) or lowdelay
+	FrameDuration float64 `yaml:"frame_duration,omitempty" koanf:"frame_duration"` // Frame length in ms: 2.5, 5, 10, 20, 40, 60, 80, 100 or 120
+	VBR           string  `yaml:"vbr,omitempty" koanf:"vbr"`                       // on, off (CBR) or constrained
+	Complexity    int     `yaml:"complexity,omitempty" koanf:"complexity"`         // Encoder effort, 1 to 10 (lower saves CPU)
+	PacketLoss    int     `yaml:"packet_loss,omitempty" koanf:"packet_loss"`       // Expected packet loss, 1 to 100 percent
+	FEC           bool    `yaml:"fec,omitempty" koanf:"fec"`                       // In-band forward error correction (needs packet_loss)
+}
+
+// overlay applies every field o sets.
+func (c *OpusConfig) overlay(o OpusConfig) {
+	if o.Preset != "" {
+		c.Preset = o.Preset
+	}
+	merged := c.settings().Merge(o.settings())
+	c.Application, c.FrameDuration, c.VBR = merged.Application, merged.FrameDuration, merged.VBR
+	c.Complexity, c.PacketLoss, c.FEC = merged.Complexity, merged.PacketLoss, merged.FEC
+}
+
+// settings returns the explicitly set fields, without the preset.
+func (c OpusConfig) settings() audio.OpusSettings {
+	return audio.OpusSettings{
+		Application:   c.Application,
+		FrameDuration: c.FrameDuration,
+		VBR:           c.VBR,
+		Complexity:    c.Complexity,
+		PacketLoss:    c.PacketLoss,
+		FEC:           c.FEC,
+	}
+}
+
+// Settings returns the encoder settings: the preset, if any, with the
+// explicitly set fields applied.
+func (c OpusConfig) Settings() audio.OpusSettings {
+	var s audio.OpusSettings
+	if c.Preset != "" {
+		s = audio.OpusPreset(audio.QualityTier(c.Preset))
+	}
+	return s.Merge(c.settings())
+}
+
+// validateSyntax checks the preset name and each set field.
+func (c OpusConfig) validateSyntax() error {
+	switch audio.QualityTier(c.Preset) {
+	case "", audio.QualityLow, audio.QualityNormal, audio.QualityHigh:
+	default:
+		return fmt.Errorf("opus.preset must be low, normal or high (got %q)", c.Preset)
+	}
+	if err := c.settings().Validate(); err != nil {
+		return fmt.Errorf("opus.%w", err)
+	}
+	return nil
+}
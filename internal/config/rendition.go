@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+)
+
+// RenditionConfig is a further encoding a device publishes from the same
+// capture, as the MediaMTX path <device><suffix>. The same FFmpeg process
+// encodes every rendition, so the card is opened once. Unset fields take
+// the device's value; opus settings are applied over the device's.
+//
+// Example:
+//
+//	devices:
+//	  dawn_chorus:
+//	    bitrate: 256k
+//	    channels: 2
+//	    renditions:
+//	      - suffix: _lo        # published as dawn_chorus_lo
+//	        bitrate: 24k
+//	        channels: 1
+//	        opus:
+//	          application: voip
+type RenditionConfig struct {
+	Suffix     string     `yaml:"suffix" koanf:"suffix"`                     // Appended to the device name to form the MediaMTX path (letters, digits and underscores, e.g. "_lo")
+	SampleRate int        `yaml:"sample_rate,omitempty" koanf:"sample_rate"` // Sample rate in Hz (default: the device's)
+	Channels   int        `yaml:"channels,omitempty" koanf:"channels"`       // Number of audio channels (default: the device's)
+	Bitrate    string     `yaml:"bitrate,omitempty" koanf:"bitrate"`         // Bitrate (default: the device's)
+	Codec      string     `yaml:"codec,omitempty" koanf:"codec"`             // Audio codec; must be publishable over RTSP (default: the device's)
+	Opus       OpusConfig `yaml:"opus,omitempty" koanf:"opus"`               // libopus tuning applied over the device's; used only with codec opus
+}
+
+var renditionSuffixPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// StreamName returns the MediaMTX path the rendition is published as for
+// device.
+func (r RenditionConfig) StreamName(device string) string {
+	return device + r.Suffix
+}
+
+// ResolvedRenditions returns the renditions of resolved device config d
+// with the unset fields filled in from d.
+func (d DeviceConfig) ResolvedRenditions() []RenditionConfig {
+	if len(d.Renditions) == 0 {
+		return nil
+	}
+	out := make([]RenditionConfig, len(d.Renditions))
+	for i, r := range d.Renditions {
+		opus := d.Opus
+		opus.overlay(r.Opus)
+		out[i] = RenditionConfig{
+			Suffix:     r.Suffix,
+			SampleRate: cmp.Or(r.SampleRate, d.SampleRate),
+			Channels:   cmp.Or(r.Channels, d.Channels),
+			Bitrate:    cmp.Or(r.Bitrate, d.Bitrate),
+			Codec:      cmp.Or(r.Codec, d.Codec),
+			Opus:       opus,
+		}
+	}
+	return out
+}
+
+// validateRenditions checks the renditions: entries on their own.
+func validateRenditions(renditions []RenditionConfig) error {
+	seen := make(map[string]bool, len(renditions))
+	for i, r := range renditions {
+		if !renditionSuffixPattern.MatchString(r.Suffix) {
+			return fmt.Errorf("renditions[%d]: suffix must be letters, digits and underscores (got %q)", i, r.Suffix)
+		}
+		if seen[r.Suffix] {
+			return fmt.Errorf("renditions[%d]: suffix %q is used twice", i, r.Suffix)
+		}
+		seen[r.Suffix] = true
+		if r.SampleRate < 0 || r.Channels < 0 {
+			return fmt.Errorf("renditions[%d]: sample_rate and channels must not be negative (0 means the device's)", i)
+		}
+		if r.Channels > 32 {
+			return fmt.Errorf("renditions[%d]: channels must be between 1 and 32", i)
+		}
+		if r.Codec != "" {
+			if err := audio.ValidateCodec(r.Codec); err != nil {
+				return fmt.Errorf("renditions[%d]: %w", i, err)
+			}
+		}
+		if err := r.Opus.validateSyntax(); err != nil {
+			return fmt.Errorf("renditions[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateResolvedRenditions checks the renditions of resolved device config
+// d against the codec constraints. Renditions of a device that publishes
+// nothing are ignored rather than rejected, since they may be inherited from
+// default:.
+func (d DeviceConfig) validateResolvedRenditions() error {
+	if !d.PublishesRTSP() {
+		return nil
+	}
+	for _, r := range d.ResolvedRenditions() {
+		c, ok := audio.LookupCodec(r.Codec)
+		if !ok {
+			continue
+		}
+		if err := c.CheckParams(r.SampleRate, r.Channels); err != nil {
+			return fmt.Errorf("rendition %q: %w", r.Suffix, err)
+		}
+		if !c.RTSP {
+			return fmt.Errorf("rendition %q: codec %q cannot be published over RTSP", r.Suffix, r.Codec)
+		}
+		if c.UsesBitrate && r.Bitrate == "" {
+			return fmt.Errorf("rendition %q: codec %q needs a bitrate", r.Suffix, r.Codec)
+		}
+		if r.Codec == "opus" {
+			if err := r.Opus.Settings().ValidateResolved(); err != nil {
+				return fmt.Errorf("rendition %q: opus: %w", r.Suffix, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRenditionNames rejects a rendition whose MediaMTX path is the name
+// of a configured device or source, which would then have two publishers.
+func (c *Config) validateRenditionNames() error {
+	taken := make(map[string]bool)
+	for name := range c.Devices {
+		taken[name] = true
+	}
+	for name := range c.StaticSources {
+		taken[name] = true
+	}
+	for name := range c.TestSources {
+		taken[name] = true
+	}
+	for name := range taken {
+		d := c.GetDeviceConfig(name)
+		if !d.PublishesRTSP() {
+			continue
+		}
+		for _, r := range d.Renditions {
+			if path := r.StreamName(name); taken[path] {
+				return fmt.Errorf("device %q: rendition %q publishes as %s, which is also a configured device", name, r.Suffix, path)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/schedule"
+)
+
+// ScheduleConfig restricts a device to daily operating windows in local
+// time. Without windows the device runs around the clock.
+//
+// Example:
+//
+//	default:
+//	  schedule:
+//	    latitude: 51.5
+//	    longitude: -0.13
+//	    windows:
+//	      - {start: "04:00", end: "09:00"}
+//	      - {start: sunset-30m, end: "21:00", days: [weekdays]}
+type ScheduleConfig struct {
+	Windows   []ScheduleWindow `yaml:"windows,omitempty" koanf:"windows"`     // Operating windows; none = always on
+	Latitude  float64          `yaml:"latitude,omitempty" koanf:"latitude"`   // Degrees north, for sunrise/sunset windows
+	Longitude float64          `yaml:"longitude,omitempty" koanf:"longitude"` // Degrees east, for sunrise/sunset windows
+}
+
+// ScheduleWindow is one daily window. start and end are "HH:MM",
+// "sunrise" or "sunset", optionally with an offset ("sunset-30m"). An end at
+// or before start runs past midnight.
+type ScheduleWindow struct {
+	Start string   `yaml:"start" koanf:"start"`         // Window opens
+	End   string   `yaml:"end" koanf:"end"`             // Window closes
+	Days  []string `yaml:"days,omitempty" koanf:"days"` // mon..sun, weekdays, weekends (default: every day)
+}
+
+// IsZero reports whether nothing is set.
+func (s ScheduleConfig) IsZero() bool {
+	return len(s.Windows) == 0 && s.Latitude == 0 && s.Longitude == 0
+}
+
+// overlay applies the settings o sets: its windows replace s's, and a
+// location set on either layer is kept, so default: can hold the location
+// for every device.
+func (s *ScheduleConfig) overlay(o ScheduleConfig) {
+	if len(o.Windows) > 0 {
+		s.Windows = o.Windows
+	}
+	if o.Latitude != 0 || o.Longitude != 0 {
+		s.Latitude, s.Longitude = o.Latitude, o.Longitude
+	}
+}
+
+// validateSyntax checks the windows and coordinates without requiring a
+// location, which may be inherited.
+func (s ScheduleConfig) validateSyntax() error {
+	if s.Latitude < -90 || s.Latitude > 90 {
+		return fmt.Errorf("schedule.latitude must be between -90 and 90 (got %v)", s.Latitude)
+	}
+	if s.Longitude < -180 || s.Longitude > 180 {
+		return fmt.Errorf("schedule.longitude must be between -180 and 180 (got %v)", s.Longitude)
+	}
+	_, err := s.windows()
+	return err
+}
+
+// Compile returns the schedule, or nil (always active) when there are no
+// windows.
+//
+// Example:
+//
+//	sched, err := cfg.GetDeviceConfig("bird_box").Schedule.Compile()
+//	if err == nil && !sched.Active(time.Now()) {
+//	    // outside the device's operating windows
+//	}
+func (s ScheduleConfig) Compile() (*schedule.Schedule, error) {
+	if len(s.Windows) == 0 {
+		return nil, nil
+	}
+	windows, err := s.windows()
+	if err != nil {
+		return nil, err
+	}
+	var loc *schedule.Location
+	if s.Latitude != 0 || s.Longitude != 0 {
+		loc = &schedule.Location{Latitude: s.Latitude, Longitude: s.Longitude}
+	}
+	sched, err := schedule.New(windows, loc)
+	if err != nil {
+		return nil, fmt.Errorf("schedule: %w", err)
+	}
+	return sched, nil
+}
+
+func (s ScheduleConfig) windows() ([]schedule.Window, error) {
+	out := make([]schedule.Window, 0, len(s.Windows))
+	for i, w := range s.Windows {
+		start, err := schedule.ParsePoint(w.Start)
+		if err != nil {
+			return nil, fmt.Errorf("schedule.windows[%d].start: %w", i, err)
+		}
+		end, err := schedule.ParsePoint(w.End)
+		if err != nil {
+			return nil, fmt.Errorf("schedule.windows[%d].end: %w", i, err)
+		}
+		days, err := schedule.ParseDays(w.Days)
+		if err != nil {
+			return nil, fmt.Errorf("schedule.windows[%d].days: %w", i, err)
+		}
+		out = append(out, schedule.Window{Start: start, End: end, Days: days})
+	}
+	return out, nil
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// durationPattern matches Go duration strings such as "10s", "1m30s" or
+// "500ms" — the only form the validators accept without surprises (a bare
+// number is read as nanoseconds).
+const durationPattern = `^-?([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// JSONSchema returns a JSON Schema (draft 2020-12) for config.yaml, generated
+// from the Config struct's koanf tags with DefaultConfig as the defaults.
+//
+// Every object is closed (additionalProperties: false), matching strict
+// loading, so editors flag the same typos FindUnknownKeys does. Device maps
+// accept any device name. Range and enum rules stay in the Validate methods;
+// the schema covers structure and types.
+//
+// Example:
+//
+//	data, _ := json.MarshalIndent(config.JSONSchema(), "", "  ")
+func JSONSchema() map[string]any {
+	s := schemaFor(reflect.TypeOf(Config{}), reflect.ValueOf(*DefaultConfig()))
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s["title"] = "LyreBirdAudio configuration"
+	return s
+}
+
+// schemaFor returns the schema for t. def holds the default value, or is
+// invalid when there is none (map entries).
+func schemaFor(t reflect.Type, def reflect.Value) map[string]any {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		s := map[string]any{"type": "string", "pattern": durationPattern}
+		if def.IsValid() {
+			s["default"] = time.Duration(def.Int()).String()
+		}
+		return s
+	}
+
+	var s map[string]any
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaFor(t.Elem(), reflect.Value{})
+	case reflect.Struct:
+		props := make(map[string]any)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := strings.Split(f.Tag.Get("koanf"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			var fdef reflect.Value
+			if def.IsValid() {
+				fdef = def.Field(i)
+			}
+			props[name] = schemaFor(f.Type, fdef)
+		}
+		// String settings also accept a <name>_file secret reference.
+		for name, ft := range structFields(t) {
+			if _, taken := props[name+secretFileSuffix]; !taken && ft.Kind() == reflect.String {
+				props[name+secretFileSuffix] = map[string]any{
+					"type":        "string",
+					"description": "Absolute path of a file holding the value of " + name,
+				}
+			}
+		}
+		return map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), reflect.Value{})}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), reflect.Value{})}
+	case reflect.String:
+		s = map[string]any{"type": "string"}
+	case reflect.Bool:
+		s = map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		s = map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+	// Zero defaults are omitted for strings only, where "" means "unset".
+	if def.IsValid() && !(def.Kind() == reflect.String && def.String() == "") {
+		s["default"] = def.Interface()
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// schemaAt walks the schema's properties along a dotted key.
+func schemaAt(s map[string]any, key string) map[string]any {
+	for _, part := range strings.Split(key, ".") {
+		props, ok := s["properties"].(map[string]any)
+		if !ok {
+			return nil
+		}
+		s, _ = props[part].(map[string]any)
+		if s == nil {
+			return nil
+		}
+	}
+	return s
+}
+
+func TestJSONSchemaCoversConfig(t *testing.T) {
+	s := JSONSchema()
+	if s["$schema"] == nil || s["additionalProperties"] != false {
+		t.Fatalf("root schema = %v", s)
+	}
+	// Every setting of the default config has a schema entry.
+	for key := range Flatten(DefaultConfig()) {
+		if schemaAt(s, key) == nil {
+			t.Errorf("no schema for %q", key)
+		}
+	}
+	if _, err := json.Marshal(s); err != nil {
+		t.Errorf("schema does not marshal: %v", err)
+	}
+}
+
+func TestJSONSchemaTypes(t *testing.T) {
+	s := JSONSchema()
+	tests := []struct {
+		key, typ string
+		def      any
+	}{
+		{"default.sample_rate", "integer", 48000},
+		{"default.codec", "string", "opus"},
+		{"monitor.enabled", "boolean", true},
+		{"monitor.interval", "string", "5m0s"},
+		{"config.strict", "boolean", false},
+	}
+	for _, tt := range tests {
+		got := schemaAt(s, tt.key)
+		if got == nil || got["type"] != tt.typ || got["default"] != tt.def {
+			t.Errorf("schema for %s = %v, want type %s default %v", tt.key, got, tt.typ, tt.def)
+		}
+	}
+	if schemaAt(s, "monitor.interval")["pattern"] == nil {
+		t.Error("duration fields need a pattern")
+	}
+
+	devices, _ := s["properties"].(map[string]any)["devices"].(map[string]any)
+	entry, _ := devices["additionalProperties"].(map[string]any)
+	if entry == nil || entry["additionalProperties"] != false {
+		t.Errorf("devices schema = %v, want a closed per-device object", devices)
+	}
+	if _, ok := schemaAt(entry, "codec")["default"]; ok {
+		t.Error("per-device fields must not carry defaults; they inherit default:")
+	}
+}
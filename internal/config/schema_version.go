@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// CurrentConfigVersion is the config.yaml schema version this binary writes.
+// Files without a version: key are version 0 (written before versioning).
+//
+// Bump it by appending to schemaMigrations; the two must stay in step.
+const CurrentConfigVersion = 1
+
+// schemaMigration upgrades a config document by one version.
+type schemaMigration struct {
+	Description string
+
+	// Apply rewrites the root mapping node in place. nil means the step
+	// only records the new version (no key changed meaning).
+	Apply func(root *yaml.Node) error
+}
+
+// schemaMigrations[i] upgrades version i to version i+1.
+//
+// Migrations work on the yaml.Node tree rather than on Config so that they
+// can read keys the current struct no longer has, and so that the operator's
+// comments and key order survive the rewrite.
+var schemaMigrations = []schemaMigration{
+	{Description: "record the schema version (version: 1)"},
+}
+
+// MigrationResult describes a schema migration of a config document.
+type MigrationResult struct {
+	From       int      // Version found in the file (0 = unversioned)
+	To         int      // Version after migration
+	Steps      []string // Description of each applied migration, in order
+	BackupPath string   // Backup written before the file was rewritten, if any
+}
+
+// Migrated reports whether any migration was applied.
+func (r *MigrationResult) Migrated() bool {
+	return r != nil && r.To > r.From
+}
+
+// migrateYAML applies every pending schema migration to a config document.
+//
+// The returned data is the input unchanged when the document is already
+// current. rewritten reports whether any migration changed keys (as opposed
+// to only stamping the version); loaders use the original bytes otherwise so
+// that line numbers in unknown-key errors still match the file on disk.
+func migrateYAML(data []byte) (out []byte, res MigrationResult, rewritten bool, err error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, res, false, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		// Empty document (or a type error the decoder reports): nothing to migrate.
+		return data, MigrationResult{From: CurrentConfigVersion, To: CurrentConfigVersion}, false, nil
+	}
+	root := doc.Content[0]
+
+	version, err := documentVersion(root)
+	if err != nil {
+		return nil, res, false, err
+	}
+	res = MigrationResult{From: version, To: version}
+	if version > CurrentConfigVersion {
+		return nil, res, false, fmt.Errorf("config version %d is newer than this binary supports (%d); upgrade lyrebird or restore an older config",
+			version, CurrentConfigVersion)
+	}
+	if version == CurrentConfigVersion {
+		return data, res, false, nil
+	}
+
+	for v := version; v < CurrentConfigVersion; v++ {
+		m := schemaMigrations[v]
+		if m.Apply != nil {
+			if err := m.Apply(root); err != nil {
+				return nil, res, false, fmt.Errorf("config migration %d->%d (%s) failed: %w", v, v+1, m.Description, err)
+			}
+			rewritten = true
+		}
+		res.Steps = append(res.Steps, m.Description)
+		res.To = v + 1
+	}
+	setDocumentVersion(root, res.To)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, res, false, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, res, false, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	return buf.Bytes(), res, rewritten, nil
+}
+
+// documentVersion returns the version: key of a root mapping, or 0 if absent.
+func documentVersion(root *yaml.Node) (int, error) {
+	v := mappingValue(root, "version")
+	if v == nil {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v.Value)
+	if v.Kind != yaml.ScalarNode || err != nil || n < 0 {
+		return 0, fmt.Errorf("line %d: version must be a non-negative integer (got %q)", v.Line, v.Value)
+	}
+	return n, nil
+}
+
+// setDocumentVersion sets the version: key, adding it as the first key.
+// A comment heading the file stays at the top.
+func setDocumentVersion(root *yaml.Node, version int) {
+	value := strconv.Itoa(version)
+	if v := mappingValue(root, "version"); v != nil {
+		v.Value, v.Tag, v.Style = value, "!!int", 0
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	val := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}
+	if len(root.Content) > 0 {
+		key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	root.Content = append([]*yaml.Node{key, val}, root.Content...)
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// MigrateConfigFile upgrades the config file at path to CurrentConfigVersion
+// in place. The original is first copied to backupDir with BackupConfig, and
+// the new file is written atomically with the original permissions. A file
+// that is already current is left untouched (the result has From == To).
+//
+// LoadConfig and KoanfConfig apply the same migrations in memory, so an old
+// file still loads when it cannot be rewritten (e.g. a read-only /etc).
+//
+// Example:
+//
+//	res, err := config.MigrateConfigFile(path, config.GetBackupDir(path))
+//	if err == nil && res.Migrated() {
+//	    log.Printf("config upgraded v%d -> v%d, backup %s", res.From, res.To, res.BackupPath)
+//	}
+func MigrateConfigFile(path, backupDir string) (*MigrationResult, error) {
+	path = filepath.Clean(path)
+	// #nosec G304 -- config path is administrator-controlled
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat config file: %w", err)
+	}
+
+	out, res, _, err := migrateYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Migrated() {
+		return &res, nil
+	}
+
+	backupPath, err := BackupConfig(path, backupDir)
+	if err != nil {
+		return nil, fmt.Errorf("refusing to migrate config without a backup: %w", err)
+	}
+	res.BackupPath = backupPath
+	if err := writeFileAtomic(path, out, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write migrated config: %w", err)
+	}
+	return &res, nil
+}
+
+// migrateLoadedYAML is migrateYAML for the loaders: it returns the migrated
+// document only when a migration changed keys, and the original bytes when
+// the upgrade merely stamps the version (the default already carries it).
+func migrateLoadedYAML(data []byte) ([]byte, MigrationResult, error) {
+	out, res, rewritten, err := migrateYAML(data)
+	if err != nil {
+		return nil, res, err
+	}
+	if !rewritten {
+		return data, res, nil
+	}
+	return out, res, nil
+}
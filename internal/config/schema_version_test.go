@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const unversionedConfig = `# Station 7 microphones
+default:
+  codec: opus # keep opus for RTSP
+devices:
+  blue_yeti:
+    bitrate: 192k
+`
+
+func TestSchemaMigrationsMatchVersion(t *testing.T) {
+	if len(schemaMigrations) != CurrentConfigVersion {
+		t.Fatalf("%d schema migrations for CurrentConfigVersion %d", len(schemaMigrations), CurrentConfigVersion)
+	}
+	if DefaultConfig().Version != CurrentConfigVersion {
+		t.Errorf("DefaultConfig().Version = %d", DefaultConfig().Version)
+	}
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(unversionedConfig), 0640); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	backupDir := filepath.Join(dir, "backups")
+
+	res, err := MigrateConfigFile(path, backupDir)
+	if err != nil {
+		t.Fatalf("MigrateConfigFile() error = %v", err)
+	}
+	if !res.Migrated() || res.From != 0 || res.To != CurrentConfigVersion || len(res.Steps) != CurrentConfigVersion {
+		t.Errorf("result = %+v", res)
+	}
+	backup, err := os.ReadFile(res.BackupPath)
+	if err != nil || string(backup) != unversionedConfig {
+		t.Errorf("backup = %q, %v; want the original file", backup, err)
+	}
+
+	data, _ := os.ReadFile(path)
+	got := string(data)
+	if !strings.HasPrefix(got, "# Station 7 microphones\nversion: 1\n") {
+		t.Errorf("migrated file does not start with the header and version:\n%s", got)
+	}
+	if !strings.Contains(got, "# keep opus for RTSP") {
+		t.Errorf("migrated file lost a comment:\n%s", got)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want 0640", info.Mode().Perm())
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil || cfg.Version != 1 || cfg.Devices["blue_yeti"].Bitrate != "192k" {
+		t.Errorf("LoadConfig after migration = %+v, %v", cfg, err)
+	}
+
+	// A current file is left alone and not backed up again.
+	res, err = MigrateConfigFile(path, backupDir)
+	if err != nil || res.Migrated() {
+		t.Errorf("second MigrateConfigFile() = %+v, %v; want no-op", res, err)
+	}
+	if backups, _ := ListBackups(backupDir, "config.yaml"); len(backups) != 1 {
+		t.Errorf("backups = %d, want 1", len(backups))
+	}
+}
+
+func TestMigrateRejectsBadVersion(t *testing.T) {
+	tests := []struct {
+		name, yaml, want string
+	}{
+		{"newer", "version: 99\n", "newer than this binary supports"},
+		{"not a number", "version: two\n", "non-negative integer"},
+		{"negative", "version: -1\n", "non-negative integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0644); err != nil {
+				t.Fatalf("write config: %v", err)
+			}
+			if _, err := MigrateConfigFile(path, t.TempDir()); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("MigrateConfigFile() = %v, want %q", err, tt.want)
+			}
+			if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfig() = %v, want %q", err, tt.want)
+			}
+			if _, err := NewKoanfConfig(WithYAMLFile(path)); err == nil {
+				t.Error("NewKoanfConfig() accepted the file")
+			}
+		})
+	}
+}
+
+// TestMigrationRewritesKeysInMemory swaps in a migration that renames a key,
+// as a future schema change would, and checks both loaders apply it without
+// touching the file.
+func TestMigrationRewritesKeysInMemory(t *testing.T) {
+	orig := schemaMigrations
+	t.Cleanup(func() { schemaMigrations = orig })
+	schemaMigrations = []schemaMigration{{
+		Description: "rename stream.record_dir to stream.local_record_dir",
+		Apply: func(root *yaml.Node) error {
+			if stream := mappingValue(root, "stream"); stream != nil {
+				for i := 0; i+1 < len(stream.Content); i += 2 {
+					if stream.Content[i].Value == "record_dir" {
+						stream.Content[i].Value = "local_record_dir"
+					}
+				}
+			}
+			return nil
+		},
+	}}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "stream:\n  record_dir: /var/lib/lyrebird/rec\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil || cfg.Stream.LocalRecordDir != "/var/lib/lyrebird/rec" {
+		t.Errorf("LoadConfig() = %+v, %v", cfg, err)
+	}
+	kc, err := NewKoanfConfig(WithYAMLFile(path))
+	if err != nil {
+		t.Fatalf("NewKoanfConfig() error = %v", err)
+	}
+	if got := kc.GetString("stream.local_record_dir"); got != "/var/lib/lyrebird/rec" {
+		t.Errorf("koanf stream.local_record_dir = %q", got)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Error("loading must not rewrite the file")
+	}
+}
+
+func TestValidateVersion(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Version = CurrentConfigVersion + 1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() accepted a version newer than supported")
+	}
+}
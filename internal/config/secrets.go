@@ -0,0 +1,285 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/v2"
+)
+
+// Secret references keep sensitive values (credentials in URLs, API tokens)
+// out of config.yaml, which is group-readable. Any string setting accepts:
+//
+//	rtsp_url_file: /run/secrets/rtsp_url         # whole value from a file
+//	rtsp_url: "${file:/run/secrets/rtsp_url}"     # same, inline
+//	rtsp_url: "rtsp://lyrebird:${cred:rtsp_pw}@localhost:8554"
+//
+// ${cred:NAME} reads $CREDENTIALS_DIRECTORY/NAME, where systemd places
+// credentials declared with LoadCredential= or LoadCredentialEncrypted=. One
+// trailing newline is stripped from file contents.
+//
+// References are resolved by KoanfConfig when it loads, and the resolved
+// values are redacted from KoanfConfig.All, `lyrebird config show`,
+// diagnostics bundles and the daemon's logs. LoadConfig does not resolve them,
+// so `lyrebird config set` never writes a secret back into the file.
+
+// RedactedValue replaces secret values in output.
+const RedactedValue = "<redacted>"
+
+// secretFileSuffix marks a <key>_file indirection for the string setting <key>.
+const secretFileSuffix = "_file"
+
+// credentialsDirEnv is set by systemd for units with LoadCredential=.
+const credentialsDirEnv = "CREDENTIALS_DIRECTORY"
+
+// secretRefPattern matches ${file:PATH} and ${cred:NAME}.
+var secretRefPattern = regexp.MustCompile(`\$\{(file|cred):([^}]*)\}`)
+
+// resolveSecrets replaces every secret reference in k with the value it
+// points to and returns the resolved values by key. A <key>_file entry is
+// replaced by <key>, which inherits its origin.
+func resolveSecrets(k *koanf.Koanf, origins map[string]string) (map[string]string, error) {
+	secrets := make(map[string]string)
+	for _, key := range k.Keys() {
+		raw, ok := k.Get(key).(string)
+		if !ok {
+			continue
+		}
+
+		if base, ok := secretFileBase(key); ok {
+			if k.Exists(base) {
+				return nil, fmt.Errorf("%s and %s are both set; use one", base, key)
+			}
+			val, err := readSecretFile(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			k.Delete(key)
+			if err := k.Set(base, val); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			if origin, ok := origins[key]; ok {
+				origins[base] = origin
+				delete(origins, key)
+			}
+			secrets[base] = val
+			continue
+		}
+
+		if !secretRefPattern.MatchString(raw) {
+			continue
+		}
+		val, err := expandSecretRefs(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if err := k.Set(key, val); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		secrets[key] = val
+	}
+	return secrets, nil
+}
+
+// expandSecretRefs replaces each ${file:...} and ${cred:...} in s.
+func expandSecretRefs(s string) (string, error) {
+	var firstErr error
+	out := secretRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		m := secretRefPattern.FindStringSubmatch(ref)
+		var val string
+		var err error
+		switch m[1] {
+		case "file":
+			val, err = readSecretFile(m[2])
+		case "cred":
+			val, err = readCredential(m[2])
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return val
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return out, nil
+}
+
+// readSecretFile returns the contents of the file at path, which must be
+// absolute, without one trailing newline.
+func readSecretFile(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("secret file path must be absolute: %q", path)
+	}
+	// #nosec G304 -- secret paths are administrator-controlled
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	s := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(s, "\r"), nil
+}
+
+// readCredential returns the systemd credential name.
+func readCredential(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid credential name %q", name)
+	}
+	dir := os.Getenv(credentialsDirEnv)
+	if dir == "" {
+		return "", fmt.Errorf("credential %q: %s is not set (declare it with LoadCredential= in the systemd unit)",
+			name, credentialsDirEnv)
+	}
+	val, err := readSecretFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", fmt.Errorf("credential %q: %w", name, err)
+	}
+	return val, nil
+}
+
+// secretFileBase reports whether key is a <base>_file indirection, that is
+// <base> is a string setting and key itself is not a setting.
+func secretFileBase(key string) (string, bool) {
+	base, ok := strings.CutSuffix(key, secretFileSuffix)
+	if !ok {
+		return "", false
+	}
+	if _, known := keyType(key); known {
+		return "", false
+	}
+	t, ok := keyType(base)
+	return base, ok && t.Kind() == reflect.String
+}
+
+// keyType returns the type of the Config setting at the dotted key. Map
+// entries (device names) match any segment.
+func keyType(key string) (reflect.Type, bool) {
+	t := reflect.TypeOf(Config{})
+	for _, part := range strings.Split(key, ".") {
+		switch t.Kind() {
+		case reflect.Struct:
+			ft, ok := structFields(t)[part]
+			if !ok {
+				return nil, false
+			}
+			t = ft
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, false
+		}
+	}
+	return t, true
+}
+
+// FindSecretFileKeys returns the <key>_file indirections in a YAML config
+// document. Tools that rewrite the file from a Config (which cannot hold
+// them) use it to refuse rather than silently drop the references.
+func FindSecretFileKeys(data []byte) ([]string, error) {
+	k := koanf.New(".")
+	if err := k.Load(rawYAML(data), yaml.Parser()); err != nil {
+		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+	var keys []string
+	for _, key := range k.Keys() {
+		if _, ok := secretFileBase(key); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// RedactConfig returns a copy of cfg with the settings at keys replaced by
+// RedactedValue. cfg is not modified.
+func RedactConfig(cfg *Config, keys []string) *Config {
+	out := *cfg
+	out.Devices = maps.Clone(cfg.Devices)
+	out.StaticSources = maps.Clone(cfg.StaticSources)
+	out.TestSources = maps.Clone(cfg.TestSources)
+	for _, key := range keys {
+		_ = out.Set(key, RedactedValue)
+	}
+	return &out
+}
+
+// Redactor replaces known secret values in text. The zero value redacts
+// nothing; KoanfConfig updates its redactor on every load. It is safe for
+// concurrent use.
+type Redactor struct {
+	mu sync.RWMutex
+	r  *strings.Replacer
+}
+
+// NewRedactor returns a Redactor with no secrets.
+func NewRedactor() *Redactor {
+	return &Redactor{}
+}
+
+// Update replaces the set of secret values.
+func (r *Redactor) Update(values []string) {
+	var vals []string
+	for _, v := range values {
+		if v != "" {
+			vals = append(vals, v)
+		}
+	}
+	// Longest first, so a secret containing another is replaced whole.
+	sort.Slice(vals, func(i, j int) bool { return len(vals[i]) > len(vals[j]) })
+	var rep *strings.Replacer
+	if len(vals) > 0 {
+		pairs := make([]string, 0, 2*len(vals))
+		for _, v := range vals {
+			pairs = append(pairs, v, RedactedValue)
+		}
+		rep = strings.NewReplacer(pairs...)
+	}
+	r.mu.Lock()
+	r.r = rep
+	r.mu.Unlock()
+}
+
+// Redact returns s with every secret value replaced by RedactedValue.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	r.mu.RLock()
+	rep := r.r
+	r.mu.RUnlock()
+	if rep == nil {
+		return s
+	}
+	return rep.Replace(s)
+}
+
+// ReplaceAttr redacts string and error attribute values. It has the
+// signature of slog.HandlerOptions.ReplaceAttr.
+//
+// Example:
+//
+//	redactor := config.NewRedactor()
+//	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{ReplaceAttr: redactor.ReplaceAttr}))
+func (r *Redactor) ReplaceAttr(_ []string, a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(r.Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			if msg := r.Redact(err.Error()); msg != err.Error() {
+				a.Value = slog.StringValue(msg)
+			}
+		}
+	}
+	return a
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/segment"
+)
+
+// Segment time zones (segment_timezone).
+const (
+	SegmentTimezoneLocal = "local" // Segment names and day directories in the system time zone
+	SegmentTimezoneUTC   = "utc"   // In UTC, independent of the station's zone and DST
+)
+
+// Segment layouts (segment_layout).
+const (
+	SegmentLayoutFlat  = "flat"  // Every segment directly in local_record_dir
+	SegmentLayoutDaily = "daily" // One YYYY-MM-DD subdirectory per day
+)
+
+// Segment boundaries (segment_align).
+const (
+	SegmentAlignStart = "start" // Every segment_duration from the FFmpeg start
+	SegmentAlignClock = "clock" // At wall-clock multiples of segment_duration (e.g. :00 for 3600)
+)
+
+// validateSegmentNaming checks the segment naming settings on their own; the
+// combination is checked on the resolved config.
+func validateSegmentNaming(name, timezone, layout, align string) error {
+	if name != "" {
+		if err := segment.ValidateTemplate(name); err != nil {
+			return fmt.Errorf("segment_name: %w", err)
+		}
+	}
+	switch timezone {
+	case "", SegmentTimezoneLocal, SegmentTimezoneUTC:
+	default:
+		return fmt.Errorf("segment_timezone must be %s or %s (got %q)", SegmentTimezoneLocal, SegmentTimezoneUTC, timezone)
+	}
+	switch layout {
+	case "", SegmentLayoutFlat, SegmentLayoutDaily:
+	default:
+		return fmt.Errorf("segment_layout must be %s or %s (got %q)", SegmentLayoutFlat, SegmentLayoutDaily, layout)
+	}
+	switch align {
+	case "", SegmentAlignStart, SegmentAlignClock:
+	default:
+		return fmt.Errorf("segment_align must be %s or %s (got %q)", SegmentAlignStart, SegmentAlignClock, align)
+	}
+	return nil
+}
+
+// segmentNaming returns the segment naming of resolved device config d with
+// station as {station}.
+func (d DeviceConfig) segmentNaming(station string) segment.Naming {
+	return segment.Naming{
+		Template: d.SegmentName,
+		Station:  station,
+		UTC:      d.SegmentTimezone == SegmentTimezoneUTC,
+		Daily:    d.SegmentLayout == SegmentLayoutDaily,
+	}
+}
+
+// SegmentNaming returns how the segments of resolved device config d are
+// named. The station ID (timecode.host_id, or the hostname) is only filled in
+// when the template uses {station}, so namings that do not depend on it
+// compare equal across hosts.
+func (c *Config) SegmentNaming(d DeviceConfig) segment.Naming {
+	var station string
+	if strings.Contains(d.SegmentName, "{station}") {
+		station = c.Timecode.Host()
+	}
+	return d.segmentNaming(station)
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Capture source types accepted in a device entry's source: block.
+const (
+	SourcePulse    = "pulse"    // PulseAudio source, captured over the pulse protocol
+	SourcePipeWire = "pipewire" // PipeWire node, captured through pipewire-pulse
+	SourceJACK     = "jack"     // JACK output ports, connected to FFmpeg's JACK client
+)
+
+// SourceConfig captures a device from a sound server instead of a detected
+// USB card. An entry with a source: block is started from the config alone:
+// it needs no card under /proc/asound, and grabbing the card directly would
+// fail with "device busy" while PipeWire or PulseAudio holds it.
+//
+// Like match:, it is valid on device entries only and is not inherited.
+//
+// Example:
+//
+//	devices:
+//	  desk_mic:
+//	    source:
+//	      type: pipewire
+//	      name: alsa_input.usb-BLUE_Yeti-00.analog-stereo
+//	  studio_bus:
+//	    channels: 2
+//	    source:
+//	      type: jack
+//	      ports: [system:capture_1, system:capture_2]
+type SourceConfig struct {
+	Type  string   `yaml:"type,omitempty" koanf:"type"`   // pulse, pipewire or jack
+	Name  string   `yaml:"name,omitempty" koanf:"name"`   // pulse/pipewire: source name from "lyrebird devices" (default: the server's default source); jack: FFmpeg's client name (default: lyrebird_<entry>)
+	Ports []string `yaml:"ports,omitempty" koanf:"ports"` // jack: output ports to connect, in channel order (e.g. system:capture_1)
+}
+
+// IsZero reports whether no source is configured, i.e. the entry names a
+// detected USB card.
+func (s SourceConfig) IsZero() bool {
+	return s.Type == "" && s.Name == "" && len(s.Ports) == 0
+}
+
+// jackClientNameMax is JACK's client name limit (jack_client_name_size() - 1).
+const jackClientNameMax = 63
+
+// Validate checks the type, the name and that ports are only given for JACK.
+func (s SourceConfig) Validate() error {
+	if s.IsZero() {
+		return nil
+	}
+	switch s.Type {
+	case SourcePulse, SourcePipeWire, SourceJACK:
+	case "":
+		return fmt.Errorf("source.type is required (pulse, pipewire or jack)")
+	default:
+		return fmt.Errorf("source.type must be pulse, pipewire or jack (got %q)", s.Type)
+	}
+	if strings.HasPrefix(s.Name, "-") || strings.IndexFunc(s.Name, func(r rune) bool {
+		return unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) != -1 {
+		return fmt.Errorf("source.name must not start with '-' or contain spaces or control characters (got %q)", s.Name)
+	}
+	if s.Type != SourceJACK {
+		if len(s.Ports) > 0 {
+			return fmt.Errorf("source.ports only applies to type jack")
+		}
+		return nil
+	}
+	if strings.Contains(s.Name, ":") || len(s.Name) > jackClientNameMax {
+		return fmt.Errorf("source.name must be a JACK client name: no ':' and at most %d bytes (got %q)", jackClientNameMax, s.Name)
+	}
+	for i, p := range s.Ports {
+		client, port, ok := strings.Cut(p, ":")
+		if !ok || client == "" || port == "" {
+			return fmt.Errorf("source.ports[%d] must be a JACK port name like system:capture_1 (got %q)", i, p)
+		}
+	}
+	return nil
+}
+
+// Input returns the FFmpeg input format and device (the -f and -i values)
+// for the source of device entry entry. PipeWire is captured through its
+// PulseAudio server (pipewire-pulse), which FFmpeg's pulse input speaks.
+//
+// Example:
+//
+//	SourceConfig{Type: "jack"}.Input("studio_bus") // "jack", "lyrebird_studio_bus"
+func (s SourceConfig) Input(entry string) (format, device string) {
+	if s.Type == SourceJACK {
+		if s.Name == "" {
+			name := "lyrebird_" + entry
+			if len(name) > jackClientNameMax {
+				name = name[:jackClientNameMax]
+			}
+			return "jack", name
+		}
+		return "jack", s.Name
+	}
+	if s.Name == "" {
+		return "pulse", "default"
+	}
+	return "pulse", s.Name
+}
+
+// Sources returns the names of the device entries that capture from a sound
+// server (see SourceConfig), sorted, leaving out entries marked ignore: true.
+func (c *Config) Sources() []string {
+	var names []string
+	for name, d := range c.Devices {
+		if !d.Source.IsZero() && !d.Ignore {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
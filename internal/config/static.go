@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/v2"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+)
+
+// StaticSourceConfig pulls a stream from the network (an IP microphone or
+// another RTSP server) and supervises it like a USB device: it is
+// republished to MediaMTX under its key and recorded locally per the
+// device settings. Encoding, recording, schedule and filter settings come
+// from devices.<key> and default: exactly as for a local device.
+//
+// The URL takes secret references like any string setting, so credentials
+// can stay out of config.yaml (url_file:, ${cred:NAME}).
+//
+// Example:
+//
+//	static_sources:
+//	  gate_mic:
+//	    url: "rtsp://lyrebird:${cred:gate_pw}@192.168.1.50:554/audio"
+//	    timeout: 15s
+//	  stream_relay:
+//	    url: https://radio.example.org/live.ogg
+//	    copy: true
+//	    reconnect_delay_max: 30s
+//	devices:
+//	  gate_mic:
+//	    bitrate: 64k
+type StaticSourceConfig struct {
+	URL               string        `yaml:"url" koanf:"url"`                                           // rtsp://, rtsps://, http://, https:// or srt:// source
+	Copy              bool          `yaml:"copy,omitempty" koanf:"copy"`                               // Republish the source's audio without re-encoding; codec must then name the source's codec
+	RTSPTransport     string        `yaml:"rtsp_transport,omitempty" koanf:"rtsp_transport"`           // rtsp: tcp (default) or udp
+	Timeout           time.Duration `yaml:"timeout,omitempty" koanf:"timeout"`                         // Restart the pull after this long without data (default 10s)
+	ReconnectDelayMax time.Duration `yaml:"reconnect_delay_max,omitempty" koanf:"reconnect_delay_max"` // http(s): let FFmpeg reconnect for up to this delay before the pull is restarted (0 = restart only)
+}
+
+// Scheme returns the URL scheme in lower case, or "" if the URL does not
+// parse.
+func (s StaticSourceConfig) Scheme() string {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme
+}
+
+// RedactedURL returns the URL with any password replaced, for logs.
+func (s StaticSourceConfig) RedactedURL() string {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return RedactedValue
+	}
+	return u.Redacted()
+}
+
+// Validate checks the URL and that each option suits its scheme.
+//
+// LoadConfig does not resolve secret references, so references in the URL
+// are masked before it is parsed. A URL that begins with a reference (or
+// comes from url_file) has no known scheme until the daemon resolves it;
+// only its scheme-independent options are checked.
+func (s StaticSourceConfig) Validate() error {
+	scheme, err := s.validateURL()
+	if err != nil {
+		return err
+	}
+	isRTSP := scheme == "rtsp" || scheme == "rtsps"
+	switch {
+	case s.RTSPTransport == "":
+	case scheme != "" && !isRTSP:
+		return fmt.Errorf("rtsp_transport only applies to rtsp URLs")
+	case s.RTSPTransport != "tcp" && s.RTSPTransport != "udp":
+		return fmt.Errorf("rtsp_transport must be tcp or udp (got %q)", s.RTSPTransport)
+	}
+	if s.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative (got %s)", s.Timeout)
+	}
+	if s.ReconnectDelayMax < 0 {
+		return fmt.Errorf("reconnect_delay_max must not be negative (got %s)", s.ReconnectDelayMax)
+	}
+	if s.ReconnectDelayMax > 0 && scheme != "" && scheme != "http" && scheme != "https" {
+		return fmt.Errorf("reconnect_delay_max only applies to http and https URLs; other sources are restarted with backoff")
+	}
+	return nil
+}
+
+// validateURL checks the URL with its secret references masked and returns
+// its scheme, or "" if a reference supplies the scheme.
+func (s StaticSourceConfig) validateURL() (string, error) {
+	if strings.HasPrefix(s.URL, "${") && secretRefPattern.MatchString(s.URL) {
+		return "", nil
+	}
+	u, err := url.Parse(secretRefPattern.ReplaceAllString(s.URL, "secret"))
+	if s.URL == "" || err != nil || u.Host == "" {
+		return "", fmt.Errorf("url must be an absolute rtsp, rtsps, http, https or srt URL")
+	}
+	switch u.Scheme {
+	case "rtsp", "rtsps", "http", "https", "srt":
+	default:
+		return "", fmt.Errorf("url scheme must be rtsp, rtsps, http, https or srt (got %q)", u.Scheme)
+	}
+	return u.Scheme, nil
+}
+
+// withStaticURLFiles returns a copy of cfg in which each static source URL
+// that the YAML document data sets with url_file is the equivalent ${file:}
+// reference, so LoadConfig can validate it without reading the file.
+func withStaticURLFiles(cfg *Config, data []byte) (*Config, error) {
+	k := koanf.New(".")
+	if err := k.Load(rawYAML(data), yaml.Parser()); err != nil {
+		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+	out := *cfg
+	out.StaticSources = maps.Clone(cfg.StaticSources)
+	for name, src := range out.StaticSources {
+		if path := k.String("static_sources." + name + ".url" + secretFileSuffix); path != "" {
+			src.URL = "${file:" + path + "}"
+			out.StaticSources[name] = src
+		}
+	}
+	return &out, nil
+}
+
+// validateStaticSources checks every static_sources: entry and its device
+// settings.
+func (c *Config) validateStaticSources() error {
+	for name, src := range c.StaticSources {
+		if audio.SanitizeDeviceName(name) != name {
+			return fmt.Errorf("static source %q: name must be letters, digits and underscores, not starting with a digit", name)
+		}
+		if err := src.Validate(); err != nil {
+			return fmt.Errorf("static source %q: %w", name, err)
+		}
+		if dev, ok := c.Devices[name]; ok && (!dev.Source.IsZero() || !dev.Match.IsZero()) {
+			return fmt.Errorf("static source %q: devices.%s must not set source or match; the stream's input is its URL", name, name)
+		}
+		if src.Copy && len(c.GetDeviceConfig(name).Filters) > 0 {
+			return fmt.Errorf("static source %q: filters need re-encoding and cannot be used with copy", name)
+		}
+		if src.Copy && len(c.GetDeviceConfig(name).Renditions) > 0 {
+			return fmt.Errorf("static source %q: renditions need re-encoding and cannot be used with copy", name)
+		}
+	}
+	return nil
+}
+
+// StaticSourceNames returns the static_sources: keys, sorted, leaving out
+// those whose device entry is marked ignore: true.
+func (c *Config) StaticSourceNames() []string {
+	var names []string
+	for name := range c.StaticSources {
+		if !c.Devices[name].Ignore {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
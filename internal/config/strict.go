@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// UnknownKey is a key in a YAML config document that no Config field reads.
+//
+// Both yaml.v3 and koanf silently ignore such keys, so a typo like
+// `sample_rat: 44100` used to leave the device on the default sample rate
+// with no indication anything was wrong.
+type UnknownKey struct {
+	File       string // Source file, when known
+	Key        string // Dotted key, e.g. "devices.blue_yeti.sample_rat"
+	Line       int    // 1-based line of the key in File
+	Suggestion string // Closest valid sibling key, or "" if none is close
+}
+
+// String formats the key as "file:line: unknown key ... (did you mean ...?)".
+func (u UnknownKey) String() string {
+	var b strings.Builder
+	if u.File != "" {
+		fmt.Fprintf(&b, "%s:", u.File)
+	}
+	fmt.Fprintf(&b, "line %d: unknown key %q", u.Line, u.Key)
+	if u.Suggestion != "" {
+		fmt.Fprintf(&b, " (did you mean %q?)", u.Suggestion)
+	}
+	return b.String()
+}
+
+// UnknownKeysError is returned when a config file contains unknown keys and
+// config.strict is enabled.
+type UnknownKeysError struct {
+	Keys []UnknownKey
+}
+
+func (e *UnknownKeysError) Error() string {
+	parts := make([]string, len(e.Keys))
+	for i, k := range e.Keys {
+		parts[i] = k.String()
+	}
+	return fmt.Sprintf("unknown configuration keys (set config.strict: false to only warn): %s",
+		strings.Join(parts, "; "))
+}
+
+// FindUnknownKeys parses data as a YAML config document and returns every
+// key that does not correspond to a Config field, in document order. file
+// is recorded in each result. Map entries such as device names are free-form;
+// the keys inside them are checked against the entry type.
+func FindUnknownKeys(file string, data []byte) ([]UnknownKey, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+	var out []UnknownKey
+	findUnknown(&doc, reflect.TypeOf(Config{}), "", file, &out)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Line < out[j].Line })
+	return out, nil
+}
+
+// UnknownConfigKeys returns the unknown keys of the config file at path, read
+// as LoadConfig reads it (upgraded in memory by the schema migrations).
+func UnknownConfigKeys(path string) ([]UnknownKey, error) {
+	// #nosec G304 - Config path is from administrator-controlled configuration
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	data, _, err = migrateLoadedYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	return FindUnknownKeys(path, data)
+}
+
+func findUnknown(node *yaml.Node, t reflect.Type, prefix, file string, out *[]UnknownKey) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, c := range node.Content {
+			findUnknown(c, t, prefix, file, out)
+		}
+		return
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			findUnknown(node.Alias, t, prefix, file, out)
+		}
+		return
+	}
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return // a type mismatch is reported by the decoder
+		}
+		fields := structFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if k.Value == "<<" {
+				findUnknown(v, t, prefix, file, out) // YAML merge key
+				continue
+			}
+			ft, ok := fields[k.Value]
+			if !ok && isSecretFileKey(k.Value, fields) {
+				continue
+			}
+			if !ok {
+				*out = append(*out, UnknownKey{
+					File:       file,
+					Key:        joinKey(prefix, k.Value),
+					Line:       k.Line,
+					Suggestion: closestKey(k.Value, fields),
+				})
+				continue
+			}
+			findUnknown(v, ft, joinKey(prefix, k.Value), file, out)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode || t.Key().Kind() != reflect.String {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			findUnknown(node.Content[i+1], t.Elem(), joinKey(prefix, node.Content[i].Value), file, out)
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			findUnknown(item, t.Elem(), fmt.Sprintf("%s[%d]", prefix, i), file, out)
+		}
+	}
+}
+
+// isSecretFileKey reports whether name is a <field>_file secret reference
+// for a string field (see secrets.go).
+func isSecretFileKey(name string, fields map[string]reflect.Type) bool {
+	base, ok := strings.CutSuffix(name, secretFileSuffix)
+	return ok && fields[base] != nil && fields[base].Kind() == reflect.String
+}
+
+// structFields maps the koanf tag names of t's exported fields to their types.
+func structFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("koanf"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// closestKey returns the valid key nearest to key by edit distance, if it
+// is close enough to be a plausible typo.
+func closestKey(key string, valid map[string]reflect.Type) string {
+	best, bestDist := "", -1
+	for name := range valid {
+		d := editDistance(key, name)
+		if bestDist < 0 || d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	// Allow roughly one typo per three characters, at least one.
+	limit := len(key) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	if bestDist < 0 || bestDist > limit {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const typoConfig = `default:
+  sample_rate: 48000
+  codec: opus
+devices:
+  blue_yeti:
+    sample_rat: 44100
+monitr:
+  enabled: true
+`
+
+// strictConfig turns on config.strict when appended to a config document.
+const strictConfig = "config:\n  strict: true\n"
+
+func TestFindUnknownKeys(t *testing.T) {
+	got, err := FindUnknownKeys("config.yaml", []byte(typoConfig))
+	if err != nil {
+		t.Fatalf("FindUnknownKeys() error = %v", err)
+	}
+	want := []UnknownKey{
+		{File: "config.yaml", Key: "devices.blue_yeti.sample_rat", Line: 6, Suggestion: "sample_rate"},
+		{File: "config.yaml", Key: "monitr", Line: 7, Suggestion: "monitor"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("FindUnknownKeys() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("key %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if s := got[0].String(); s != `config.yaml:line 6: unknown key "devices.blue_yeti.sample_rat" (did you mean "sample_rate"?)` {
+		t.Errorf("String() = %s", s)
+	}
+}
+
+func TestFindUnknownKeysNoSuggestion(t *testing.T) {
+	got, err := FindUnknownKeys("", []byte("stream:\n  completely_unrelated: 1\n"))
+	if err != nil {
+		t.Fatalf("FindUnknownKeys() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Suggestion != "" {
+		t.Errorf("FindUnknownKeys() = %+v, want one key without suggestion", got)
+	}
+	if strings.Contains(got[0].String(), "did you mean") {
+		t.Errorf("String() = %s, want no suggestion", got[0])
+	}
+}
+
+func TestFindUnknownKeysCleanAndInvalid(t *testing.T) {
+	clean := "default:\n  codec: opus\nmonitor:\n  interval: 5m\nconfig:\n  strict: true\n"
+	if got, err := FindUnknownKeys("", []byte(clean)); err != nil || len(got) != 0 {
+		t.Errorf("clean config = %+v, %v; want none", got, err)
+	}
+	if _, err := FindUnknownKeys("", []byte("default: [unclosed\n")); err == nil {
+		t.Error("invalid YAML should fail")
+	}
+}
+
+func TestLoadConfigStrict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(typoConfig+strictConfig), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	_, err := LoadConfig(path)
+	var uerr *UnknownKeysError
+	if !errors.As(err, &uerr) || len(uerr.Keys) != 2 {
+		t.Fatalf("LoadConfig() = %v, want UnknownKeysError with 2 keys", err)
+	}
+	if !strings.Contains(err.Error(), "did you mean \"sample_rate\"") {
+		t.Errorf("error lacks suggestion: %v", err)
+	}
+
+	// By default the file loads and the unknown keys are left to callers
+	// to report (UnknownConfigKeys).
+	if err := os.WriteFile(path, []byte(typoConfig), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := LoadConfig(path); err != nil {
+		t.Errorf("LoadConfig() without strict = %v", err)
+	}
+	if unknown, err := UnknownConfigKeys(path); err != nil || len(unknown) != 2 {
+		t.Errorf("UnknownConfigKeys() = %v, %v, want 2 keys", unknown, err)
+	}
+}
+
+func TestKoanfConfigStrict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(typoConfig), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	// By default the config loads and the keys are kept for the warnings.
+	kc, err := NewKoanfConfig(WithYAMLFile(path))
+	if err != nil {
+		t.Fatalf("NewKoanfConfig() without strict: %v", err)
+	}
+	if got := kc.UnknownKeys(); len(got) != 2 {
+		t.Errorf("UnknownKeys() = %+v, want 2 keys", got)
+	}
+
+	if err := os.WriteFile(path, []byte(typoConfig+strictConfig), 0644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	_, err = NewKoanfConfig(WithYAMLFile(path))
+	var uerr *UnknownKeysError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("NewKoanfConfig() = %v, want UnknownKeysError", err)
+	}
+
+	// The env var overrides the file, and the keys stay available as warnings.
+	t.Setenv("LYREBIRD_CONFIG_STRICT", "false")
+	kc, err = NewKoanfConfig(WithYAMLFile(path), WithEnvPrefix("LYREBIRD"))
+	if err != nil {
+		t.Fatalf("NewKoanfConfig() with LYREBIRD_CONFIG_STRICT=false: %v", err)
+	}
+	if got := kc.UnknownKeys(); len(got) != 2 || got[0].File != path {
+		t.Errorf("UnknownKeys() = %+v", got)
+	}
+}
+
+// TestKoanfConfigStrictIncludeAndReload verifies that unknown keys in an
+// include file are reported with that file, and that a hot reload adding a
+// typo keeps the last-known-good config.
+func TestKoanfConfigStrictIncludeAndReload(t *testing.T) {
+	configPath := writeIncludeFixture(t, "default:\n  codec: opus\n"+strictConfig, map[string]string{})
+	kc, err := NewKoanfConfig(WithYAMLFile(configPath), WithIncludeDir(DefaultIncludeDir(configPath)))
+	if err != nil {
+		t.Fatalf("NewKoanfConfig failed: %v", err)
+	}
+
+	snippet := filepath.Join(DefaultIncludeDir(configPath), "10-typo.yaml")
+	if err := os.WriteFile(snippet, []byte("default:\n  codc: aac\n"), 0644); err != nil {
+		t.Fatalf("write snippet: %v", err)
+	}
+	err = kc.Reload()
+	if err == nil || !strings.Contains(err.Error(), snippet+":line 2") {
+		t.Fatalf("Reload() = %v, want unknown key in %s", err, snippet)
+	}
+	if got := kc.GetString("default.codec"); got != "opus" {
+		t.Errorf("codec after rejected reload = %q, want opus", got)
+	}
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+)
+
+// TestSourceConfig publishes a generated signal through the full daemon
+// pipeline (encoder, MediaMTX, local recording) so an installation can be
+// verified before any microphone is plugged in. The stream is supervised
+// like a device and takes its encoding and recording settings from
+// devices.<key> and default:.
+//
+// ident: dtmf starts every period with its UTC start time as DTMF digits
+// (HHMMSS), so a decoder run on a recording or on a client's playback shows
+// the latency and any gaps. A spoken timestamp is not offered: FFmpeg's
+// flite filter is missing from almost every distribution build.
+//
+// Example:
+//
+//	test_sources:
+//	  lyrebird_test:
+//	    signal: beep
+//	    ident: dtmf
+//	    period: 10s
+type TestSourceConfig struct {
+	Signal       string        `yaml:"signal,omitempty" koanf:"signal"`               // tone (default), sweep or beep
+	Frequency    float64       `yaml:"frequency,omitempty" koanf:"frequency"`         // tone/beep Hz (default 1000); sweep start Hz (default 20)
+	EndFrequency float64       `yaml:"end_frequency,omitempty" koanf:"end_frequency"` // sweep end Hz (default 20000)
+	Period       time.Duration `yaml:"period,omitempty" koanf:"period"`               // Sweep length, beep and ident interval (default 10s)
+	LevelDB      float64       `yaml:"level_db,omitempty" koanf:"level_db"`           // Peak level in dBFS (default -20)
+	Ident        string        `yaml:"ident,omitempty" koanf:"ident"`                 // dtmf: start each period with the UTC time as DTMF digits
+}
+
+// Validate checks the signal settings.
+func (s TestSourceConfig) Validate() error {
+	switch s.Signal {
+	case "", "tone", "sweep", "beep":
+	default:
+		return fmt.Errorf("signal must be tone, sweep or beep (got %q)", s.Signal)
+	}
+	switch s.Ident {
+	case "", "dtmf":
+	case "voice":
+		return fmt.Errorf("ident voice needs FFmpeg's flite filter, which distribution builds lack; use dtmf")
+	default:
+		return fmt.Errorf("ident must be dtmf (got %q)", s.Ident)
+	}
+	if s.Frequency < 0 || s.EndFrequency < 0 {
+		return fmt.Errorf("frequency and end_frequency must not be negative")
+	}
+	if s.EndFrequency > 0 && s.Signal != "sweep" {
+		return fmt.Errorf("end_frequency only applies to signal sweep")
+	}
+	if s.LevelDB > 0 {
+		return fmt.Errorf("level_db must be at most 0 (got %g)", s.LevelDB)
+	}
+	if s.Period < 0 {
+		return fmt.Errorf("period must not be negative (got %s)", s.Period)
+	}
+	if s.Ident == "dtmf" && s.Period > 0 && s.Period < 2*time.Second {
+		return fmt.Errorf("period must be at least 2s to fit the DTMF ident (got %s)", s.Period)
+	}
+	return nil
+}
+
+// validateTestSources checks every test_sources: entry.
+func (c *Config) validateTestSources() error {
+	for name, src := range c.TestSources {
+		if audio.SanitizeDeviceName(name) != name {
+			return fmt.Errorf("test source %q: name must be letters, digits and underscores, not starting with a digit", name)
+		}
+		if err := src.Validate(); err != nil {
+			return fmt.Errorf("test source %q: %w", name, err)
+		}
+		if _, ok := c.StaticSources[name]; ok {
+			return fmt.Errorf("test source %q: also defined in static_sources", name)
+		}
+		if dev, ok := c.Devices[name]; ok && (!dev.Source.IsZero() || !dev.Match.IsZero()) {
+			return fmt.Errorf("test source %q: devices.%s must not set source or match; the stream's input is the test signal", name, name)
+		}
+	}
+	return nil
+}
+
+// TestSourceNames returns the test_sources: keys, sorted, leaving out those
+// whose device entry is marked ignore: true.
+func (c *Config) TestSourceNames() []string {
+	var names []string
+	for name := range c.TestSources {
+		if !c.Devices[name].Ignore {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
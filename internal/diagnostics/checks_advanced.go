@@ -10,9 +10,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/config"
 )
 
 // checkKernelModules verifies that required audio kernel modules are loaded.
@@ -138,10 +141,36 @@ func (r *Runner) checkFFmpegCodecs(ctx context.Context) CheckResult {
 			"Reinstall FFmpeg with full codec support: apt-get install ffmpeg")
 	}
 
+	// Beyond the defaults: can FFmpeg encode every codec the config uses?
+	if result.Status == StatusOK {
+		status, message, details := evaluateCodecEncoders(string(output), configuredCodecs(r.opts.ConfigPath))
+		result.Details += "\nStream codecs: " + details
+		if status != StatusOK {
+			result.Status, result.Message = status, message
+			result.Suggestions = append(result.Suggestions,
+				"Install an FFmpeg build with the missing encoders, or choose another codec in the config")
+		}
+	}
+
 	result.Duration = time.Since(start)
 	return result
 }
 
+// configuredCodecs returns the codecs used by the default and by each
+// device entry of the config at path, or nil when it cannot be loaded.
+func configuredCodecs(path string) []string {
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return nil
+	}
+	names := []string{cfg.Default.Codec}
+	for name := range cfg.Devices {
+		names = append(names, cfg.GetDeviceConfig(name).Codec)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
 // checkUSBStability checks kernel dmesg for recent USB disconnect/error events.
 func (r *Runner) checkUSBStability(ctx context.Context) CheckResult {
 	start := time.Now()
@@ -9,6 +9,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
 )
 
 // evaluateKernelModules checks which required/optional modules are loaded from /proc/modules content.
@@ -151,6 +153,43 @@ func evaluateCodecsOutput(
 	return StatusOK, "All required codecs available", strings.Join(found, "; ")
 }
 
+// evaluateCodecEncoders reports which of the supported stream codecs (see
+// audio.Codecs) FFmpeg can encode, from `ffmpeg -encoders` output. A codec
+// the config uses whose encoder is missing is a warning: that stream fails on
+// every start. Codecs the config does not use are informational.
+func evaluateCodecEncoders(encoderOutput string, configured []string) (status CheckStatus, message, details string) {
+	available := make(map[string]bool)
+	for _, line := range strings.Split(encoderOutput, "\n") {
+		// " A....D libopus   libopus Opus" -> flags, name, description.
+		if fields := strings.Fields(line); len(fields) >= 2 && strings.HasPrefix(fields[0], "A") {
+			available[fields[1]] = true
+		}
+	}
+
+	inUse := make(map[string]bool, len(configured))
+	for _, name := range configured {
+		inUse[name] = true
+	}
+
+	var parts, missing []string
+	for _, c := range audio.Codecs() {
+		state := "available"
+		if !available[c.Encoder] {
+			state = "missing"
+			if inUse[c.Name] {
+				missing = append(missing, fmt.Sprintf("%s (encoder %s)", c.Name, c.Encoder))
+			}
+		}
+		parts = append(parts, fmt.Sprintf("%s [%s]: %s", c.Name, c.Encoder, state))
+	}
+	details = strings.Join(parts, "; ")
+
+	if len(missing) > 0 {
+		return StatusWarning, "Configured codecs missing from FFmpeg: " + strings.Join(missing, ", "), details
+	}
+	return StatusOK, "All configured codecs available", details
+}
+
 // evaluateFFmpegOutput determines FFmpeg status from version and codec output.
 // Returns status, message, and details (first line of version output).
 func evaluateFFmpegOutput(versionOut, codecOut string) (CheckStatus, string, string) {
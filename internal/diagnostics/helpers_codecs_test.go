@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+
+package diagnostics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluateCodecEncoders(t *testing.T) {
+	encoders := ` Encoders:
+ V..... = Video
+ ------
+ A....D aac                  AAC (Advanced Audio Coding)
+ A....D libopus              libopus Opus
+ A....D pcm_mulaw            PCM mu-law / G.711 mu-law
+ V....D libx264              libx264 H.264
+`
+	status, msg, details := evaluateCodecEncoders(encoders, []string{"opus", "pcmu"})
+	if status != StatusOK {
+		t.Errorf("status = %v (%s), want OK", status, msg)
+	}
+	for _, want := range []string{"pcmu [pcm_mulaw]: available", "mp3 [libmp3lame]: missing", "pcma [pcm_alaw]: missing"} {
+		if !strings.Contains(details, want) {
+			t.Errorf("details missing %q: %s", want, details)
+		}
+	}
+
+	status, msg, _ = evaluateCodecEncoders(encoders, []string{"opus", "speex"})
+	if status != StatusWarning || !strings.Contains(msg, "speex (encoder libspeex)") {
+		t.Errorf("status = %v, msg = %q; want warning naming speex", status, msg)
+	}
+}
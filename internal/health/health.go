@@ -58,18 +58,50 @@ type SystemInfoProvider interface {
 	SystemInfo(ctx context.Context) SystemInfo
 }
 
+// ReloadInfo summarizes the most recent configuration reload (SIGHUP).
+type ReloadInfo struct {
+	Time             time.Time `json:"time"`
+	Success          bool      `json:"success"`
+	Error            string    `json:"error,omitempty"`
+	Changed          []string  `json:"changed,omitempty"`           // dotted config keys whose value changed
+	RestartedStreams []string  `json:"restarted_streams,omitempty"` // streams restarted to apply the change
+	RestartRequired  []string  `json:"restart_required,omitempty"`  // changed keys that only apply after a daemon restart
+}
+
+// ReloadInfoProvider returns the result of the last configuration reload, or
+// nil if the configuration has not been reloaded since startup.
+type ReloadInfoProvider interface {
+	LastReload() *ReloadInfo
+}
+
+// ScheduleInfo is the operating-window state of one scheduled device.
+type ScheduleInfo struct {
+	Device     string     `json:"device"`
+	Active     bool       `json:"active"`                // inside an operating window
+	NextChange *time.Time `json:"next_change,omitempty"` // when Active next flips; nil if not within a week
+}
+
+// ScheduleInfoProvider returns the state of every device with a schedule.
+type ScheduleInfoProvider interface {
+	Schedules() []ScheduleInfo
+}
+
 // Response is the JSON body returned by the health endpoint.
 type Response struct {
-	Status    string        `json:"status"`
-	Timestamp time.Time     `json:"timestamp"`
-	Services  []ServiceInfo `json:"services"`
-	System    *SystemInfo   `json:"system,omitempty"`
+	Status    string         `json:"status"`
+	Timestamp time.Time      `json:"timestamp"`
+	Services  []ServiceInfo  `json:"services"`
+	System    *SystemInfo    `json:"system,omitempty"`
+	Reload    *ReloadInfo    `json:"last_reload,omitempty"`
+	Schedules []ScheduleInfo `json:"schedules,omitempty"`
 }
 
 // Handler serves the /healthz and /metrics endpoints.
 type Handler struct {
-	provider    StatusProvider
-	sysProvider SystemInfoProvider
+	provider       StatusProvider
+	sysProvider    SystemInfoProvider
+	reloadProvider ReloadInfoProvider
+	schedProvider  ScheduleInfoProvider
 }
 
 // NewHandler creates a health check HTTP handler.
@@ -85,6 +117,23 @@ func (h *Handler) WithSystemInfo(p SystemInfoProvider) *Handler {
 	return h
 }
 
+// WithReloadInfo attaches an optional reload status provider. When set, the
+// last configuration reload is included in /healthz, and a failed reload
+// (the daemon kept its previous configuration) reports "degraded".
+func (h *Handler) WithReloadInfo(p ReloadInfoProvider) *Handler {
+	h.reloadProvider = p
+	return h
+}
+
+// WithScheduleInfo attaches an optional schedule provider. When set, device
+// schedules are included in /healthz and /metrics, and having no running
+// streams because every device is outside its window reports "idle" (HTTP
+// 200) instead of "unhealthy".
+func (h *Handler) WithScheduleInfo(p ScheduleInfoProvider) *Handler {
+	h.schedProvider = p
+	return h
+}
+
 // ServeHTTP implements http.Handler, routing to /healthz and /metrics.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch r.URL.Path {
@@ -111,8 +160,20 @@ func (h *Handler) serveHealth(w http.ResponseWriter, r *http.Request) {
 	}
 	resp.Services = services
 
-	// A missing or unhealthy service is a hard failure (HTTP 503).
-	serviceFailure := len(services) == 0
+	var scheduledOff bool
+	if h.schedProvider != nil {
+		resp.Schedules = h.schedProvider.Schedules()
+		for _, si := range resp.Schedules {
+			if !si.Active {
+				scheduledOff = true
+			}
+		}
+	}
+
+	// A missing or unhealthy service is a hard failure (HTTP 503), unless
+	// nothing runs because devices are outside their operating windows.
+	idle := len(services) == 0 && scheduledOff
+	serviceFailure := len(services) == 0 && !idle
 	for _, svc := range services {
 		if !svc.Healthy {
 			serviceFailure = true
@@ -130,16 +191,28 @@ func (h *Handler) serveHealth(w http.ResponseWriter, r *http.Request) {
 		ntpWarning = !si.NTPSynced
 	}
 
+	reloadFailed := false
+	if h.reloadProvider != nil {
+		if ri := h.reloadProvider.LastReload(); ri != nil {
+			resp.Reload = ri
+			reloadFailed = !ri.Success
+		}
+	}
+
 	// Only hard failures return 503. NTP desync is a SOFT warning: it is
 	// surfaced in the body as "degraded" but kept at HTTP 200, so a routine
 	// clock re-sync (e.g. chrony stepping the clock) does not flap a systemd
-	// watchdog or load balancer keying on the status code.
+	// watchdog or load balancer keying on the status code. A failed reload is
+	// soft for the same reason: the daemon keeps running on its previous,
+	// valid configuration.
 	hardFailure := serviceFailure || diskLow
 	switch {
 	case serviceFailure:
 		resp.Status = "unhealthy"
-	case diskLow, ntpWarning:
+	case diskLow, ntpWarning, reloadFailed:
 		resp.Status = "degraded"
+	case idle:
+		resp.Status = "idle"
 	default:
 		resp.Status = "healthy"
 	}
@@ -231,6 +304,21 @@ func (h *Handler) serveMetrics(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(&sb, "lyrebird_ntp_synced %d\n", ntpSynced)
 	}
 
+	// Schedule metrics.
+	if h.schedProvider != nil {
+		if scheds := h.schedProvider.Schedules(); len(scheds) > 0 {
+			fmt.Fprintln(&sb, "# HELP lyrebird_schedule_active 1 when the device is inside an operating window.")
+			fmt.Fprintln(&sb, "# TYPE lyrebird_schedule_active gauge")
+			for _, si := range scheds {
+				v := 0
+				if si.Active {
+					v = 1
+				}
+				fmt.Fprintf(&sb, "lyrebird_schedule_active{device=%q} %d\n", si.Device, v)
+			}
+		}
+	}
+
 	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte(sb.String()))
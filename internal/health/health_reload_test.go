@@ -0,0 +1,78 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// mockReloadProvider implements ReloadInfoProvider for testing.
+type mockReloadProvider struct {
+	info *ReloadInfo
+}
+
+func (m *mockReloadProvider) LastReload() *ReloadInfo {
+	return m.info
+}
+
+func serveReload(t *testing.T, p ReloadInfoProvider) Response {
+	t.Helper()
+	provider := &mockProvider{
+		services: []ServiceInfo{{Name: "blue_yeti", State: "running", Healthy: true}},
+	}
+	h := NewHandler(provider).WithReloadInfo(p)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return resp
+}
+
+func TestWithReloadInfoNoReloadYet(t *testing.T) {
+	resp := serveReload(t, &mockReloadProvider{})
+	if resp.Status != "healthy" {
+		t.Errorf("status = %q, want healthy", resp.Status)
+	}
+	if resp.Reload != nil {
+		t.Errorf("last_reload = %+v, want omitted before the first reload", resp.Reload)
+	}
+}
+
+func TestWithReloadInfoSuccess(t *testing.T) {
+	resp := serveReload(t, &mockReloadProvider{info: &ReloadInfo{
+		Time:             time.Now(),
+		Success:          true,
+		Changed:          []string{"monitor.interval"},
+		RestartedStreams: []string{"blue_yeti"},
+	}})
+	if resp.Status != "healthy" {
+		t.Errorf("status = %q, want healthy", resp.Status)
+	}
+	if resp.Reload == nil || !resp.Reload.Success {
+		t.Fatalf("last_reload = %+v, want successful reload", resp.Reload)
+	}
+	if len(resp.Reload.Changed) != 1 || resp.Reload.Changed[0] != "monitor.interval" {
+		t.Errorf("changed = %v", resp.Reload.Changed)
+	}
+}
+
+func TestWithReloadInfoFailureDegrades(t *testing.T) {
+	resp := serveReload(t, &mockReloadProvider{info: &ReloadInfo{
+		Time:    time.Now(),
+		Success: false,
+		Error:   "invalid configuration: bad codec",
+	}})
+	if resp.Status != "degraded" {
+		t.Errorf("status = %q, want degraded after a failed reload", resp.Status)
+	}
+	if resp.Reload == nil || resp.Reload.Error == "" {
+		t.Errorf("last_reload = %+v, want error recorded", resp.Reload)
+	}
+}
@@ -0,0 +1,65 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockScheduleProvider struct {
+	infos []ScheduleInfo
+}
+
+func (m *mockScheduleProvider) Schedules() []ScheduleInfo { return m.infos }
+
+func TestWithScheduleInfo(t *testing.T) {
+	next := time.Date(2024, 6, 3, 18, 0, 0, 0, time.UTC)
+	offOnly := &mockScheduleProvider{infos: []ScheduleInfo{{Device: "dawn_mic", Active: false, NextChange: &next}}}
+
+	tests := []struct {
+		name       string
+		services   []ServiceInfo
+		sched      ScheduleInfoProvider
+		wantCode   int
+		wantStatus string
+	}{
+		{"no streams, all scheduled off", nil, offOnly, http.StatusOK, "idle"},
+		{"no streams, no schedules", nil, &mockScheduleProvider{}, http.StatusServiceUnavailable, "unhealthy"},
+		{"no streams, scheduled on", nil, &mockScheduleProvider{infos: []ScheduleInfo{{Device: "dawn_mic", Active: true}}},
+			http.StatusServiceUnavailable, "unhealthy"},
+		{"running stream", []ServiceInfo{{Name: "other", State: "running", Healthy: true}}, offOnly, http.StatusOK, "healthy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(&mockProvider{services: tt.services}).WithScheduleInfo(tt.sched)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			if rec.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", rec.Code, tt.wantCode)
+			}
+			var resp Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if resp.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", resp.Status, tt.wantStatus)
+			}
+		})
+	}
+
+	h := NewHandler(&mockProvider{}).WithScheduleInfo(offOnly)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if !strings.Contains(rec.Body.String(), `"next_change":"2024-06-03T18:00:00Z"`) {
+		t.Errorf("body lacks next_change: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), `lyrebird_schedule_active{device="dawn_mic"} 0`) {
+		t.Errorf("metrics lack schedule gauge:\n%s", rec.Body.String())
+	}
+}
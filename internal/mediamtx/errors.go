@@ -9,16 +9,22 @@ import "errors"
 // Callers should use errors.Is to match these values, since wrapping with
 // fmt.Errorf("%w", …) is used to attach context.
 var (
-	// ErrSessionNotFound is returned when an RTSP session identified by ID
-	// does not exist on the server. This typically means the session has
-	// already disconnected, and is often not a fatal condition — for example,
+	// ErrSessionNotFound is returned when a session or connection identified
+	// by ID (RTSP, WebRTC, RTMP or SRT) does not exist on the server. This
+	// typically means the session has already disconnected, and is often not
+	// a fatal condition — for example,
 	// a stall-recovery caller can treat it as "already gone" and skip
 	// further work.
-	ErrSessionNotFound = errors.New("mediamtx: rtsp session not found")
+	ErrSessionNotFound = errors.New("mediamtx: session not found")
 
 	// ErrInvalidSessionID is returned when a caller passes a session ID
 	// that is not a valid UUID. The MediaMTX API uses UUIDs to identify
 	// sessions, and validating before sending protects against URL path
 	// injection via attacker-controlled input.
-	ErrInvalidSessionID = errors.New("mediamtx: invalid rtsp session id")
+	ErrInvalidSessionID = errors.New("mediamtx: invalid session id")
+
+	// ErrKickUnsupported is returned by KickSession for protocols whose
+	// MediaMTX API has no kick endpoint. HLS is served by per-path muxers
+	// rather than per-client sessions, so there is nothing to disconnect.
+	ErrKickUnsupported = errors.New("mediamtx: protocol does not support kicking")
 )
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: MIT
+
+package mediamtx
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// managedConfigFile is the subset of mediamtx.yml that lyrebird sets when it
+// runs MediaMTX itself. Every key not listed keeps the server's built-in
+// default, so WebRTC, HLS, RTMP and SRT readers work exactly as they would
+// with a stock install.
+type managedConfigFile struct {
+	LogLevel    string                    `yaml:"logLevel"`
+	API         bool                      `yaml:"api"`
+	APIAddress  string                    `yaml:"apiAddress"`
+	RTSP        bool                      `yaml:"rtsp"`
+	RTSPAddress string                    `yaml:"rtspAddress"`
+	Paths       map[string]map[string]any `yaml:"paths"`
+}
+
+// ManagedConfig renders the mediamtx.yml used when lyrebird-stream supervises
+// MediaMTX itself, deriving the listen addresses from lyrebird's own
+// mediamtx.api_url and mediamtx.rtsp_url so the two can never disagree.
+//
+//   - The API listens on exactly the host:port of apiURL, so a loopback
+//     api_url keeps the control API off the network.
+//   - RTSP listens on all interfaces at the port of rtspURL: lyrebird
+//     publishes over loopback, but listeners connect from elsewhere.
+//   - A catch-all "all_others" path accepts every device stream without
+//     per-path configuration.
+//
+// Both URLs must carry an explicit port; config.MediaMTXConfig.Validate
+// enforces this at load time for managed mode.
+func ManagedConfig(apiURL, rtspURL string) ([]byte, error) {
+	api, err := url.Parse(apiURL)
+	if err != nil || api.Host == "" || api.Port() == "" {
+		return nil, fmt.Errorf("api URL %q must include host and port", apiURL)
+	}
+	rtsp, err := url.Parse(rtspURL)
+	if err != nil || rtsp.Port() == "" {
+		return nil, fmt.Errorf("rtsp URL %q must include a port", rtspURL)
+	}
+
+	doc := managedConfigFile{
+		LogLevel:    "info",
+		API:         true,
+		APIAddress:  api.Host,
+		RTSP:        true,
+		RTSPAddress: net.JoinHostPort("", rtsp.Port()),
+		Paths:       map[string]map[string]any{"all_others": {}},
+	}
+	data, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal mediamtx config: %w", err)
+	}
+	header := "# Generated by lyrebird-stream (mediamtx.managed: true). Do not edit:\n" +
+		"# this file is rewritten from /etc/lyrebird/config.yaml on every start.\n"
+	return append([]byte(header), data...), nil
+}
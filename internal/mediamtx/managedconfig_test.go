@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: MIT
+
+package mediamtx
+
+import (
+	"strings"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func TestManagedConfig_DerivesAddresses(t *testing.T) {
+	data, err := ManagedConfig("http://127.0.0.1:9997", "rtsp://localhost:8554")
+	if err != nil {
+		t.Fatalf("ManagedConfig() error: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "# Generated by lyrebird-stream") {
+		t.Errorf("missing generated-file header:\n%s", data)
+	}
+
+	var got map[string]any
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("generated config is not valid YAML: %v\n%s", err, data)
+	}
+	if got["apiAddress"] != "127.0.0.1:9997" {
+		t.Errorf("apiAddress = %v, want 127.0.0.1:9997", got["apiAddress"])
+	}
+	if got["rtspAddress"] != ":8554" {
+		t.Errorf("rtspAddress = %v, want :8554", got["rtspAddress"])
+	}
+	if got["api"] != true || got["rtsp"] != true {
+		t.Errorf("api/rtsp must be enabled: api=%v rtsp=%v", got["api"], got["rtsp"])
+	}
+	paths, ok := got["paths"].(map[string]any)
+	if !ok {
+		t.Fatalf("paths = %T, want map", got["paths"])
+	}
+	if _, ok := paths["all_others"]; !ok {
+		t.Errorf("paths missing all_others: %v", paths)
+	}
+}
+
+func TestManagedConfig_RejectsPortlessURLs(t *testing.T) {
+	tests := []struct {
+		name, api, rtsp string
+	}{
+		{"api without port", "http://localhost", "rtsp://localhost:8554"},
+		{"rtsp without port", "http://localhost:9997", "rtsp://localhost"},
+		{"garbage api", "://", "rtsp://localhost:8554"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ManagedConfig(tt.api, tt.rtsp); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: MIT
+
+package mediamtx
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// ProbeRTSP checks that the RTSP server in rtspURL accepts TCP connections.
+//
+// It is the cheapest signal that a publisher can connect, and unlike Ping it
+// works when the MediaMTX control API is disabled or bound elsewhere. A URL
+// without a port uses the scheme default (554 for rtsp, 322 for rtsps).
+func ProbeRTSP(ctx context.Context, rtspURL string) error {
+	addr, err := RTSPDialAddr(rtspURL)
+	if err != nil {
+		return err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("RTSP server not reachable: %w", err)
+	}
+	_ = conn.Close()
+	return nil
+}
+
+// RTSPDialAddr returns the host:port ProbeRTSP dials for rtspURL, or an
+// error if rtspURL is not an rtsp:// or rtsps:// URL with a host.
+func RTSPDialAddr(rtspURL string) (string, error) {
+	u, err := url.Parse(rtspURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid RTSP URL: %w", err)
+	}
+	var defaultPort string
+	switch u.Scheme {
+	case "rtsp":
+		defaultPort = "554"
+	case "rtsps":
+		defaultPort = "322"
+	default:
+		return "", fmt.Errorf("invalid RTSP URL %q: scheme must be rtsp or rtsps", rtspURL)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("invalid RTSP URL %q: missing host", rtspURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: MIT
+
+package mediamtx
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestRTSPDialAddr(t *testing.T) {
+	tests := []struct {
+		url, want, errMsg string
+	}{
+		{"rtsp://localhost:8554", "localhost:8554", ""},
+		{"rtsp://localhost:8554/mic", "localhost:8554", ""},
+		{"rtsp://10.0.0.5", "10.0.0.5:554", ""},
+		{"rtsps://[::1]", "[::1]:322", ""},
+		{"http://localhost:8554", "", "scheme must be rtsp"},
+		{"rtsp://", "", "missing host"},
+	}
+	for _, tt := range tests {
+		got, err := RTSPDialAddr(tt.url)
+		if tt.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("RTSPDialAddr(%q) err = %v, want %q", tt.url, err, tt.errMsg)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("RTSPDialAddr(%q) = %q, %v; want %q", tt.url, got, err, tt.want)
+		}
+	}
+}
+
+func TestProbeRTSP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+
+	if err := ProbeRTSP(context.Background(), "rtsp://"+addr); err != nil {
+		t.Errorf("ProbeRTSP() on listening port = %v, want nil", err)
+	}
+
+	_ = ln.Close()
+	if err := ProbeRTSP(context.Background(), "rtsp://"+addr); err == nil {
+		t.Error("ProbeRTSP() on closed port = nil, want error")
+	}
+}
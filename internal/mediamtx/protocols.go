@@ -0,0 +1,271 @@
+// SPDX-License-Identifier: MIT
+
+package mediamtx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Protocol identifies one of the MediaMTX servers a reader or publisher can
+// be attached through. Each protocol has its own v3 API resource with its own
+// response shape; Session normalises them into one type.
+type Protocol string
+
+// Protocols supported by ListSessions and KickSession.
+const (
+	ProtocolRTSP   Protocol = "rtsp"
+	ProtocolWebRTC Protocol = "webrtc"
+	ProtocolRTMP   Protocol = "rtmp"
+	ProtocolSRT    Protocol = "srt"
+	ProtocolHLS    Protocol = "hls"
+)
+
+// protocolResources maps each protocol to its v3 API resource name, used to
+// build both /v3/{resource}/list and /v3/{resource}/kick/{id}. The slice order
+// is the order ListSessions queries them in, RTSP first because that is the
+// protocol lyrebird itself publishes with.
+var protocolResources = []struct {
+	protocol Protocol
+	resource string
+	kickable bool
+}{
+	{ProtocolRTSP, "rtspsessions", true},
+	{ProtocolWebRTC, "webrtcsessions", true},
+	{ProtocolRTMP, "rtmpconns", true},
+	{ProtocolSRT, "srtconns", true},
+	{ProtocolHLS, "hlsmuxers", false},
+}
+
+// AllProtocols returns every protocol ListSessions knows how to query, in
+// query order. A fresh slice is returned on each call.
+func AllProtocols() []Protocol {
+	out := make([]Protocol, 0, len(protocolResources))
+	for _, p := range protocolResources {
+		out = append(out, p.protocol)
+	}
+	return out
+}
+
+// Session is a protocol-agnostic view of one reader or publisher attached to
+// a MediaMTX path, regardless of whether it arrived over RTSP, WebRTC, RTMP,
+// SRT or HLS.
+//
+// HLS is the odd one out: MediaMTX reports one muxer per path rather than one
+// entry per client, so an HLS Session has no ID or RemoteAddr, is always in
+// the "read" state, and cannot be kicked (KickSession returns
+// ErrKickUnsupported).
+type Session struct {
+	// Protocol is the server the session is attached through.
+	Protocol Protocol `json:"protocol"`
+
+	// ID is the session or connection UUID. Empty for HLS muxers.
+	ID string `json:"id,omitempty"`
+
+	// Created is the RFC3339 timestamp at which the session was opened.
+	Created string `json:"created"`
+
+	// RemoteAddr is the client address in host:port form. Empty for HLS.
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+
+	// State is one of "idle", "read", "publish".
+	State string `json:"state"`
+
+	// Path is the MediaMTX path name the session is attached to.
+	Path string `json:"path"`
+
+	// Query is the URL query string supplied by the client, if any.
+	Query string `json:"query,omitempty"`
+
+	// User is the authenticated user, or empty if none.
+	User string `json:"user,omitempty"`
+
+	// Transport is the RTSP RTP transport ("UDP", "TCP", …). Only RTSP
+	// reports it; it is empty for every other protocol.
+	Transport string `json:"transport,omitempty"`
+
+	// InboundBytes is the total number of bytes received from the client.
+	InboundBytes uint64 `json:"inboundBytes"`
+
+	// OutboundBytes is the total number of bytes sent to the client.
+	OutboundBytes uint64 `json:"outboundBytes"`
+}
+
+// IsReader reports whether the session is consuming a path (state "read")
+// rather than publishing to it or sitting idle.
+func (s *Session) IsReader() bool {
+	return s.State == "read"
+}
+
+// Kickable reports whether KickSession can disconnect this session.
+func (s *Session) Kickable() bool {
+	_, kickable, ok := lookupProtocol(s.Protocol)
+	return ok && kickable && s.ID != ""
+}
+
+// wireSession is the union of the fields reported by the WebRTC, RTMP and SRT
+// session/connection endpoints and the HLS muxer endpoint. Only the fields
+// Session exposes are decoded.
+//
+// SRT connections on older servers report traffic only as bytesReceived /
+// bytesSent, so both spellings are decoded and the modern one preferred, in
+// the same spirit as Path.TotalInboundBytes.
+type wireSession struct {
+	ID            string `json:"id"`
+	Created       string `json:"created"`
+	RemoteAddr    string `json:"remoteAddr"`
+	State         string `json:"state"`
+	Path          string `json:"path"`
+	Query         string `json:"query"`
+	User          string `json:"user"`
+	InboundBytes  uint64 `json:"inboundBytes"`
+	OutboundBytes uint64 `json:"outboundBytes"`
+	BytesReceived uint64 `json:"bytesReceived"`
+	BytesSent     uint64 `json:"bytesSent"`
+}
+
+func (w *wireSession) toSession(p Protocol) Session {
+	s := Session{
+		Protocol:      p,
+		ID:            w.ID,
+		Created:       w.Created,
+		RemoteAddr:    w.RemoteAddr,
+		State:         w.State,
+		Path:          w.Path,
+		Query:         w.Query,
+		User:          w.User,
+		InboundBytes:  w.InboundBytes,
+		OutboundBytes: w.OutboundBytes,
+	}
+	if s.InboundBytes == 0 {
+		s.InboundBytes = w.BytesReceived
+	}
+	if s.OutboundBytes == 0 {
+		s.OutboundBytes = w.BytesSent
+	}
+	if p == ProtocolHLS {
+		// A muxer exists only while something is reading the path over HLS.
+		s.State = "read"
+	}
+	return s
+}
+
+// ListSessions returns the active sessions of every requested protocol, or of
+// all protocols when none are given, transparently paginating each endpoint
+// the same way ListRTSPSessions does.
+//
+// API endpoints: GET /v3/rtspsessions/list, /v3/webrtcsessions/list,
+// /v3/rtmpconns/list, /v3/srtconns/list, /v3/hlsmuxers/list
+//
+// Behavior:
+//   - A protocol whose list endpoint answers 404 is skipped silently: that is
+//     how MediaMTX reports a server that is disabled in mediamtx.yml.
+//   - Any other per-protocol failure does not discard the sessions already
+//     collected. The returned slice holds every session that could be listed
+//     and err joins the per-protocol failures (errors.Join), so callers can
+//     act on partial results and still log what was missed.
+//   - Cancellation stops the walk between protocols; ctx.Err() is joined
+//     into the returned error.
+//
+// The returned slice is always non-nil; it may be empty.
+func (c *Client) ListSessions(ctx context.Context, protocols ...Protocol) ([]Session, error) {
+	if len(protocols) == 0 {
+		protocols = AllProtocols()
+	}
+
+	all := []Session{}
+	var errs []error
+	for _, p := range protocols {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		sessions, err := c.listProtocolSessions(ctx, p)
+		if err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("listing %s sessions: %w", p, err))
+			continue
+		}
+		all = append(all, sessions...)
+	}
+
+	return all, errors.Join(errs...)
+}
+
+// listProtocolSessions lists every session of a single protocol.
+func (c *Client) listProtocolSessions(ctx context.Context, p Protocol) ([]Session, error) {
+	if p == ProtocolRTSP {
+		// RTSP keeps its richer dedicated type; convert rather than decode
+		// twice so Transport is preserved.
+		rtsp, err := c.ListRTSPSessions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]Session, 0, len(rtsp))
+		for i := range rtsp {
+			r := &rtsp[i]
+			out = append(out, Session{
+				Protocol:      ProtocolRTSP,
+				ID:            r.ID,
+				Created:       r.Created,
+				RemoteAddr:    r.RemoteAddr,
+				State:         r.State,
+				Path:          r.Path,
+				Query:         r.Query,
+				User:          r.User,
+				Transport:     r.Transport,
+				InboundBytes:  r.InboundBytes,
+				OutboundBytes: r.OutboundBytes,
+			})
+		}
+		return out, nil
+	}
+
+	resource, _, ok := lookupProtocol(p)
+	if !ok {
+		return nil, fmt.Errorf("unknown protocol %q", p)
+	}
+	items, err := listAllPages[wireSession](ctx, c, resource, string(p)+" sessions")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Session, 0, len(items))
+	for i := range items {
+		out = append(out, items[i].toSession(p))
+	}
+	return out, nil
+}
+
+// KickSession forcibly disconnects s using the kick endpoint of its protocol.
+//
+// API endpoints: POST /v3/rtspsessions/kick/{id}, /v3/webrtcsessions/kick/{id},
+// /v3/rtmpconns/kick/{id}, /v3/srtconns/kick/{id}
+//
+// The error contract matches KickRTSPSession (ErrSessionNotFound on 404,
+// ErrInvalidSessionID for a non-UUID ID). HLS sessions, which have no kick
+// endpoint, return a wrapped ErrKickUnsupported without any request.
+func (c *Client) KickSession(ctx context.Context, s Session) error {
+	resource, kickable, ok := lookupProtocol(s.Protocol)
+	if !ok {
+		return fmt.Errorf("kick session: unknown protocol %q", s.Protocol)
+	}
+	if !kickable {
+		return fmt.Errorf("%w: %s", ErrKickUnsupported, s.Protocol)
+	}
+	return c.kick(ctx, resource, s.ID)
+}
+
+// lookupProtocol returns the API resource name for p and whether it can be
+// kicked; ok is false for an unknown protocol.
+func lookupProtocol(p Protocol) (resource string, kickable, ok bool) {
+	for _, r := range protocolResources {
+		if r.protocol == p {
+			return r.resource, r.kickable, true
+		}
+	}
+	return "", false, false
+}
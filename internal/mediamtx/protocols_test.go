@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: MIT
+
+package mediamtx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newProtocolServer answers /v3/{resource}/list with the body registered for
+// that resource and 404s everything else, mimicking MediaMTX with only some
+// servers enabled. Kick calls are recorded as "resource/id".
+func newProtocolServer(t *testing.T, lists map[string]string) (*httptest.Server, func() []string) {
+	t.Helper()
+	var (
+		mu    sync.Mutex
+		kicks []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v3/"), "/")
+		if len(parts) < 2 {
+			http.NotFound(w, r)
+			return
+		}
+		body, ok := lists[parts[0]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		switch {
+		case parts[1] == "list" && r.Method == http.MethodGet:
+			_, _ = io.WriteString(w, body)
+		case parts[1] == "kick" && r.Method == http.MethodPost && len(parts) == 3:
+			mu.Lock()
+			kicks = append(kicks, parts[0]+"/"+parts[2])
+			mu.Unlock()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), kicks...)
+	}
+}
+
+func TestListSessions_AllProtocolsNormalised(t *testing.T) {
+	srv, _ := newProtocolServer(t, map[string]string{
+		"rtspsessions":   `{"pageCount":1,"items":[{"id":"` + validUUID + `","state":"read","path":"mic","transport":"TCP","outboundBytes":10}]}`,
+		"webrtcsessions": `{"pageCount":1,"items":[{"id":"22222222-3333-4444-5555-666666666666","state":"read","path":"mic","remoteAddr":"10.0.0.2:5000","outboundBytes":20}]}`,
+		"rtmpconns":      `{"pageCount":1,"items":[{"id":"33333333-3333-4444-5555-666666666666","state":"publish","path":"cam","inboundBytes":30}]}`,
+		"srtconns":       `{"pageCount":1,"items":[{"id":"44444444-3333-4444-5555-666666666666","state":"read","path":"mic","bytesSent":40}]}`,
+		"hlsmuxers":      `{"pageCount":1,"items":[{"path":"mic","created":"2025-01-01T00:00:00Z","outboundBytes":50}]}`,
+	})
+
+	sessions, err := NewClient(srv.URL).ListSessions(context.Background())
+	if err != nil {
+		t.Fatalf("ListSessions() error: %v", err)
+	}
+	if len(sessions) != 5 {
+		t.Fatalf("len(sessions) = %d, want 5: %+v", len(sessions), sessions)
+	}
+
+	want := []struct {
+		proto    Protocol
+		state    string
+		outbound uint64
+	}{
+		{ProtocolRTSP, "read", 10},
+		{ProtocolWebRTC, "read", 20},
+		{ProtocolRTMP, "publish", 0},
+		{ProtocolSRT, "read", 40}, // legacy bytesSent fallback
+		{ProtocolHLS, "read", 50}, // muxers are always readers
+	}
+	for i, w := range want {
+		s := sessions[i]
+		if s.Protocol != w.proto || s.State != w.state || s.OutboundBytes != w.outbound {
+			t.Errorf("sessions[%d] = %+v, want protocol=%s state=%s outbound=%d", i, s, w.proto, w.state, w.outbound)
+		}
+	}
+	if sessions[0].Transport != "TCP" {
+		t.Errorf("RTSP transport = %q, want TCP", sessions[0].Transport)
+	}
+	if sessions[2].InboundBytes != 30 {
+		t.Errorf("RTMP inbound = %d, want 30", sessions[2].InboundBytes)
+	}
+	if sessions[4].Kickable() {
+		t.Error("HLS session must not be kickable")
+	}
+}
+
+// TestListSessions_DisabledProtocolSkipped verifies that a 404 list response
+// (server disabled in mediamtx.yml) is not reported as an error.
+func TestListSessions_DisabledProtocolSkipped(t *testing.T) {
+	srv, _ := newProtocolServer(t, map[string]string{
+		"rtspsessions": `{"items":[{"id":"` + validUUID + `","state":"read","path":"mic"}]}`,
+	})
+
+	sessions, err := NewClient(srv.URL).ListSessions(context.Background())
+	if err != nil {
+		t.Fatalf("ListSessions() error: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Protocol != ProtocolRTSP {
+		t.Errorf("sessions = %+v, want the single RTSP session", sessions)
+	}
+}
+
+// TestListSessions_PartialFailure verifies that one failing protocol does
+// not hide sessions from the others.
+func TestListSessions_PartialFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/rtspsessions/list":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = io.WriteString(w, "boom")
+		case "/v3/webrtcsessions/list":
+			_, _ = io.WriteString(w, `{"items":[{"id":"`+validUUID+`","state":"read","path":"mic"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	sessions, err := NewClient(srv.URL).ListSessions(context.Background())
+	if err == nil {
+		t.Fatal("expected error for failing RTSP list")
+	}
+	if !strings.Contains(err.Error(), "listing rtsp sessions") || !strings.Contains(err.Error(), "status 500") {
+		t.Errorf("error = %v, want rtsp status 500", err)
+	}
+	if len(sessions) != 1 || sessions[0].Protocol != ProtocolWebRTC {
+		t.Errorf("sessions = %+v, want the WebRTC session", sessions)
+	}
+}
+
+func TestListSessions_SubsetOfProtocols(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_, _ = io.WriteString(w, `{"items":[]}`)
+	}))
+	defer srv.Close()
+
+	sessions, err := NewClient(srv.URL).ListSessions(context.Background(), ProtocolSRT)
+	if err != nil {
+		t.Fatalf("ListSessions() error: %v", err)
+	}
+	if sessions == nil {
+		t.Error("sessions must be non-nil")
+	}
+	if len(paths) != 1 || paths[0] != "/v3/srtconns/list" {
+		t.Errorf("requested paths = %v, want only /v3/srtconns/list", paths)
+	}
+}
+
+func TestListSessions_CanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	sessions, err := NewClient("http://127.0.0.1:1").ListSessions(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if sessions == nil || len(sessions) != 0 {
+		t.Errorf("sessions = %v, want empty non-nil", sessions)
+	}
+}
+
+func TestKickSession_RoutesByProtocol(t *testing.T) {
+	srv, kicks := newProtocolServer(t, map[string]string{
+		"rtspsessions": "", "webrtcsessions": "", "rtmpconns": "", "srtconns": "",
+	})
+	client := NewClient(srv.URL)
+
+	for _, p := range []Protocol{ProtocolRTSP, ProtocolWebRTC, ProtocolRTMP, ProtocolSRT} {
+		if err := client.KickSession(context.Background(), Session{Protocol: p, ID: validUUID}); err != nil {
+			t.Errorf("KickSession(%s) error: %v", p, err)
+		}
+	}
+
+	got := kicks()
+	want := []string{
+		"rtspsessions/" + validUUID,
+		"webrtcsessions/" + validUUID,
+		"rtmpconns/" + validUUID,
+		"srtconns/" + validUUID,
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("kicks = %v, want %v", got, want)
+	}
+}
+
+func TestKickSession_Errors(t *testing.T) {
+	client := NewClient("http://127.0.0.1:1")
+
+	err := client.KickSession(context.Background(), Session{Protocol: ProtocolHLS, Path: "mic"})
+	if !errors.Is(err, ErrKickUnsupported) {
+		t.Errorf("HLS kick err = %v, want ErrKickUnsupported", err)
+	}
+
+	err = client.KickSession(context.Background(), Session{Protocol: ProtocolWebRTC, ID: "../admin"})
+	if !errors.Is(err, ErrInvalidSessionID) {
+		t.Errorf("bad id err = %v, want ErrInvalidSessionID", err)
+	}
+
+	err = client.KickSession(context.Background(), Session{Protocol: "gopher", ID: validUUID})
+	if err == nil || !strings.Contains(err.Error(), "unknown protocol") {
+		t.Errorf("unknown protocol err = %v", err)
+	}
+}
+
+func TestAllProtocols_ReturnsCopy(t *testing.T) {
+	a := AllProtocols()
+	if len(a) != 5 || a[0] != ProtocolRTSP {
+		t.Fatalf("AllProtocols() = %v", a)
+	}
+	a[0] = "mutated"
+	if AllProtocols()[0] != ProtocolRTSP {
+		t.Error("AllProtocols must return a fresh slice")
+	}
+}
@@ -5,6 +5,7 @@ package mediamtx
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -105,31 +106,79 @@ type RTSPSessionList struct {
 //
 // The returned slice is always non-nil when err is nil; it may be empty.
 func (c *Client) ListRTSPSessions(ctx context.Context) ([]RTSPSession, error) {
-	// Fetch the first page using the server's default itemsPerPage (negative
-	// arguments omit the query params). This keeps the common single-page case
-	// byte-for-byte identical to a plain unpaginated request.
-	first, err := c.ListRTSPSessionsPage(ctx, -1, -1)
+	return listAllPages[RTSPSession](ctx, c, "rtspsessions", "rtsp sessions")
+}
+
+// ListRTSPSessionsPage fetches one page of active RTSP sessions. Pass a
+// negative value for page or itemsPerPage to omit that query parameter and
+// let the server use its default (page 0, 100 items per page as of v1.17.1).
+//
+// API endpoint: GET /v3/rtspsessions/list
+func (c *Client) ListRTSPSessionsPage(ctx context.Context, page, itemsPerPage int) (*RTSPSessionList, error) {
+	p, err := fetchListPage[RTSPSession](ctx, c, "rtspsessions", page, itemsPerPage)
 	if err != nil {
 		return nil, err
 	}
+	return &RTSPSessionList{PageCount: p.PageCount, ItemCount: p.ItemCount, Items: p.Items}, nil
+}
+
+// listPage is the generic server response envelope shared by every MediaMTX
+// v3 "list" endpoint (sessions, connections and muxers alike).
+type listPage[T any] struct {
+	PageCount int64 `json:"pageCount"`
+	ItemCount int64 `json:"itemCount"`
+	Items     []T   `json:"items"`
+}
+
+// apiStatusError is returned for a non-2xx list response whose body could be
+// read. It keeps the historical "API returned status N: body" message while
+// letting callers inspect the status code (ListSessions treats 404 as "this
+// protocol's server is disabled").
+type apiStatusError struct {
+	code int
+	body string
+}
+
+func (e *apiStatusError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.code, e.body)
+}
 
-	// ListRTSPSessionsPage guarantees a non-nil Items slice, so all starts
-	// non-nil and the non-nil-on-success contract holds through accumulation.
+// isNotFound reports whether err is an apiStatusError carrying a 404.
+func isNotFound(err error) bool {
+	var se *apiStatusError
+	return errors.As(err, &se) && se.code == http.StatusNotFound
+}
+
+// listAllPages fetches the first page of resource using the server's default
+// itemsPerPage (negative arguments omit the query params, keeping the common
+// single-page case byte-for-byte identical to a plain unpaginated request),
+// then walks pages 1..PageCount-1 and accumulates every item.
+//
+// PageCount is captured once from the first response and used as a hard
+// upper bound, so the number of iterations is fixed regardless of what later
+// responses claim. Cancellation is honored between pages rather than issuing
+// every remaining request after the caller has given up. label names the
+// resource in error messages (e.g. "rtsp sessions").
+//
+// The returned slice is always non-nil when err is nil.
+func listAllPages[T any](ctx context.Context, c *Client, resource, label string) ([]T, error) {
+	first, err := fetchListPage[T](ctx, c, resource, -1, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	// fetchListPage guarantees a non-nil Items slice, so all starts non-nil
+	// and the non-nil-on-success contract holds through accumulation.
 	all := first.Items
 
-	// PageCount is captured once from the first response and used as a hard
-	// upper bound, so the number of iterations is fixed regardless of what
-	// later responses claim.
 	for page := 1; int64(page) < first.PageCount; page++ {
-		// Honor cancellation between pages rather than issuing every remaining
-		// request after the caller has given up.
 		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
 
-		next, err := c.ListRTSPSessionsPage(ctx, page, -1)
+		next, err := fetchListPage[T](ctx, c, resource, page, -1)
 		if err != nil {
-			return nil, fmt.Errorf("fetching rtsp sessions page %d: %w", page, err)
+			return nil, fmt.Errorf("fetching %s page %d: %w", label, page, err)
 		}
 		all = append(all, next.Items...)
 	}
@@ -137,13 +186,10 @@ func (c *Client) ListRTSPSessions(ctx context.Context) ([]RTSPSession, error) {
 	return all, nil
 }
 
-// ListRTSPSessionsPage fetches one page of active RTSP sessions. Pass a
-// negative value for page or itemsPerPage to omit that query parameter and
-// let the server use its default (page 0, 100 items per page as of v1.17.1).
-//
-// API endpoint: GET /v3/rtspsessions/list
-func (c *Client) ListRTSPSessionsPage(ctx context.Context, page, itemsPerPage int) (*RTSPSessionList, error) {
-	reqURL := c.baseURL + "/v3/rtspsessions/list"
+// fetchListPage fetches one page from GET /v3/{resource}/list. Negative page
+// or itemsPerPage values omit that query parameter.
+func fetchListPage[T any](ctx context.Context, c *Client, resource string, page, itemsPerPage int) (*listPage[T], error) {
+	reqURL := c.baseURL + "/v3/" + resource + "/list"
 
 	// Build query string safely. Negative values mean "use server default".
 	q := url.Values{}
@@ -173,15 +219,15 @@ func (c *Client) ListRTSPSessionsPage(ctx context.Context, page, itemsPerPage in
 		if readErr != nil {
 			return nil, fmt.Errorf("API returned status %d (failed to read body: %w)", resp.StatusCode, readErr)
 		}
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &apiStatusError{code: resp.StatusCode, body: string(body)}
 	}
 
-	var list RTSPSessionList
+	var list listPage[T]
 	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 	if list.Items == nil {
-		list.Items = []RTSPSession{}
+		list.Items = []T{}
 	}
 	return &list, nil
 }
@@ -216,6 +262,13 @@ func isValidSessionID(s string) bool {
 //
 // No request body is sent; the endpoint does not accept one.
 func (c *Client) KickRTSPSession(ctx context.Context, id string) error {
+	return c.kick(ctx, "rtspsessions", id)
+}
+
+// kick forcibly disconnects the session or connection id via
+// POST /v3/{resource}/kick/{id}. See KickRTSPSession for the error contract,
+// which is shared by every kickable protocol.
+func (c *Client) kick(ctx context.Context, resource, id string) error {
 	if !isValidSessionID(id) {
 		return fmt.Errorf("%w: %q", ErrInvalidSessionID, id)
 	}
@@ -224,7 +277,7 @@ func (c *Client) KickRTSPSession(ctx context.Context, id string) error {
 	// constrained the input to UUID chars, but escaping ensures that any
 	// future relaxation of the validator cannot by itself become an
 	// injection vector.
-	reqURL := fmt.Sprintf("%s/v3/rtspsessions/kick/%s", c.baseURL, url.PathEscape(id))
+	reqURL := fmt.Sprintf("%s/v3/%s/kick/%s", c.baseURL, resource, url.PathEscape(id))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, nil)
 	if err != nil {
@@ -0,0 +1,257 @@
+// SPDX-License-Identifier: MIT
+
+// Package schedule decides when a stream should run from daily operating
+// windows, e.g. "04:00-09:00 and sunset-30m to sunset+1h30m on weekdays".
+//
+// Window edges are wall-clock times or offsets from sunrise/sunset, which
+// are computed offline from a latitude/longitude (see SunTimes). A schedule
+// is evaluated against a timestamp rather than by arming timers, so callers
+// that re-check periodically recover on their own when the system clock
+// steps (an NTP sync on a Raspberry Pi without an RTC).
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PointKind is the reference a window edge is measured from.
+type PointKind int
+
+const (
+	Clock   PointKind = iota // Offset is time since local midnight
+	Sunrise                  // Offset is relative to sunrise
+	Sunset                   // Offset is relative to sunset
+)
+
+// Point is one edge of a window.
+type Point struct {
+	Kind   PointKind
+	Offset time.Duration
+}
+
+// ParsePoint parses a window edge: "HH:MM" (24-hour local time), "sunrise",
+// "sunset", or either of those with a signed Go duration offset such as
+// "sunrise-30m" or "sunset+1h30m".
+func ParsePoint(s string) (Point, error) {
+	s = strings.TrimSpace(s)
+	for _, ref := range []struct {
+		name string
+		kind PointKind
+	}{{"sunrise", Sunrise}, {"sunset", Sunset}} {
+		rest, ok := strings.CutPrefix(s, ref.name)
+		if !ok {
+			continue
+		}
+		if rest == "" {
+			return Point{Kind: ref.kind}, nil
+		}
+		if rest[0] != '+' && rest[0] != '-' {
+			return Point{}, fmt.Errorf("invalid time %q: expected %s+DURATION or %s-DURATION", s, ref.name, ref.name)
+		}
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return Point{}, fmt.Errorf("invalid time %q: %w", s, err)
+		}
+		if d <= -12*time.Hour || d >= 12*time.Hour {
+			return Point{}, fmt.Errorf("invalid time %q: offset must be less than 12h", s)
+		}
+		return Point{Kind: ref.kind, Offset: d}, nil
+	}
+
+	hh, mm, ok := strings.Cut(s, ":")
+	h, errH := strconv.Atoi(hh)
+	m, errM := strconv.Atoi(mm)
+	if !ok || errH != nil || errM != nil || len(mm) != 2 || h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return Point{}, fmt.Errorf("invalid time %q: expected HH:MM, sunrise[±DURATION] or sunset[±DURATION]", s)
+	}
+	return Point{Kind: Clock, Offset: time.Duration(h)*time.Hour + time.Duration(m)*time.Minute}, nil
+}
+
+// String formats p the way ParsePoint accepts it.
+func (p Point) String() string {
+	var name string
+	switch p.Kind {
+	case Sunrise:
+		name = "sunrise"
+	case Sunset:
+		name = "sunset"
+	default:
+		return fmt.Sprintf("%02d:%02d", int(p.Offset.Hours()), int(p.Offset.Minutes())%60)
+	}
+	switch {
+	case p.Offset > 0:
+		return name + "+" + p.Offset.String()
+	case p.Offset < 0:
+		return name + p.Offset.String()
+	}
+	return name
+}
+
+// dayNames maps accepted day names to weekday bit masks.
+var dayNames = map[string]uint8{
+	"sun": 1 << time.Sunday, "mon": 1 << time.Monday, "tue": 1 << time.Tuesday,
+	"wed": 1 << time.Wednesday, "thu": 1 << time.Thursday, "fri": 1 << time.Friday,
+	"sat":      1 << time.Saturday,
+	"weekdays": 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday,
+	"weekends": 1<<time.Saturday | 1<<time.Sunday,
+	"daily":    0x7f,
+}
+
+// ParseDays parses day names ("mon".."sun", "weekdays", "weekends",
+// "daily", case-insensitive) into a weekday bit mask. No names means every
+// day.
+func ParseDays(days []string) (uint8, error) {
+	if len(days) == 0 {
+		return 0x7f, nil
+	}
+	var mask uint8
+	for _, d := range days {
+		bits, ok := dayNames[strings.ToLower(strings.TrimSpace(d))]
+		if !ok {
+			return 0, fmt.Errorf("invalid day %q: expected mon..sun, weekdays, weekends or daily", d)
+		}
+		mask |= bits
+	}
+	return mask, nil
+}
+
+// Window is one daily operating window. An End at or before Start runs past
+// midnight into the next day. Days selects the days the window starts on.
+type Window struct {
+	Start Point
+	End   Point
+	Days  uint8 // weekday bit mask (1<<time.Sunday ...); see ParseDays
+}
+
+// Location is the observer position for sunrise/sunset windows.
+type Location struct {
+	Latitude  float64 // degrees, north positive
+	Longitude float64 // degrees, east positive
+}
+
+// Schedule is a set of windows. A nil *Schedule is always active.
+type Schedule struct {
+	windows []Window
+	loc     *Location
+}
+
+// New returns a schedule of windows. loc is required when any window edge
+// is relative to sunrise or sunset.
+func New(windows []Window, loc *Location) (*Schedule, error) {
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("schedule has no windows")
+	}
+	for i, w := range windows {
+		if w.Days == 0 {
+			return nil, fmt.Errorf("window %d: no days selected", i+1)
+		}
+		if (w.Start.Kind != Clock || w.End.Kind != Clock) && loc == nil {
+			return nil, fmt.Errorf("window %d: sunrise/sunset times need a latitude and longitude", i+1)
+		}
+	}
+	if loc != nil && (loc.Latitude < -90 || loc.Latitude > 90 || loc.Longitude < -180 || loc.Longitude > 180) {
+		return nil, fmt.Errorf("invalid location %.4f,%.4f", loc.Latitude, loc.Longitude)
+	}
+	return &Schedule{windows: windows, loc: loc}, nil
+}
+
+// Active reports whether t falls inside any window, using t's location as
+// local time.
+//
+// Example:
+//
+//	if !sched.Active(time.Now()) {
+//	    // outside the operating window: stop the stream
+//	}
+func (s *Schedule) Active(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	// Day -1 for windows running past midnight, +1 for a sun edge whose
+	// negative offset moves tomorrow's start into today.
+	for _, iv := range s.intervals(t, -1, 1) {
+		if !t.Before(iv[0]) && t.Before(iv[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextChange returns the first time after t at which Active changes, or the
+// zero time if it does not change within the next eight days (for example a
+// sunrise window during polar night).
+func (s *Schedule) NextChange(t time.Time) time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	var edges []time.Time
+	for _, iv := range s.intervals(t, -1, 8) {
+		for _, e := range iv {
+			if e.After(t) {
+				edges = append(edges, e)
+			}
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].Before(edges[j]) })
+	now := s.Active(t)
+	for _, e := range edges {
+		if s.Active(e) != now {
+			return e
+		}
+	}
+	return time.Time{}
+}
+
+// intervals returns the [start, end) intervals of every window starting on
+// the days from t's date+fromDay to t's date+toDay. Days on which a sun
+// edge does not occur (polar day or night) contribute nothing.
+func (s *Schedule) intervals(t time.Time, fromDay, toDay int) [][2]time.Time {
+	var out [][2]time.Time
+	y, m, d := t.Date()
+	for off := fromDay; off <= toDay; off++ {
+		day := time.Date(y, m, d+off, 0, 0, 0, 0, t.Location())
+		for _, w := range s.windows {
+			if w.Days&(1<<day.Weekday()) == 0 {
+				continue
+			}
+			start, ok := s.resolve(w.Start, day)
+			if !ok {
+				continue
+			}
+			end, ok := s.resolve(w.End, day)
+			if !ok {
+				continue
+			}
+			if !end.After(start) {
+				// Past midnight: the end is on the following day.
+				if end, ok = s.resolve(w.End, time.Date(y, m, d+off+1, 0, 0, 0, 0, t.Location())); !ok {
+					continue
+				}
+			}
+			out = append(out, [2]time.Time{start, end})
+		}
+	}
+	return out
+}
+
+// resolve returns p on the local date day (midnight in its location).
+func (s *Schedule) resolve(p Point, day time.Time) (time.Time, bool) {
+	if p.Kind == Clock {
+		y, m, d := day.Date()
+		h := int(p.Offset / time.Hour)
+		mins := int(p.Offset % time.Hour / time.Minute)
+		return time.Date(y, m, d, h, mins, 0, 0, day.Location()), true
+	}
+	rise, set, ok := SunTimes(day, s.loc.Latitude, s.loc.Longitude)
+	if !ok {
+		return time.Time{}, false
+	}
+	if p.Kind == Sunrise {
+		return rise.Add(p.Offset), true
+	}
+	return set.Add(p.Offset), true
+}
@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: MIT
+
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePoint(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Point
+		wantErr bool
+	}{
+		{"04:00", Point{Clock, 4 * time.Hour}, false},
+		{"21:30", Point{Clock, 21*time.Hour + 30*time.Minute}, false},
+		{"24:00", Point{Clock, 24 * time.Hour}, false},
+		{"sunrise", Point{Sunrise, 0}, false},
+		{"sunset-30m", Point{Sunset, -30 * time.Minute}, false},
+		{"sunrise+1h30m", Point{Sunrise, 90 * time.Minute}, false},
+		{"4:00", Point{Clock, 4 * time.Hour}, false},
+		{"04:0", Point{}, true},
+		{"25:00", Point{}, true},
+		{"24:30", Point{}, true},
+		{"sunset30m", Point{}, true},
+		{"sunrise+13h", Point{}, true},
+		{"noon", Point{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePoint(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParsePoint(%q) = %+v, %v; want %+v, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+		if err == nil {
+			if back, _ := ParsePoint(got.String()); back != got {
+				t.Errorf("ParsePoint(%q).String() = %q does not round-trip", tt.in, got.String())
+			}
+		}
+	}
+}
+
+func TestParseDays(t *testing.T) {
+	mask, err := ParseDays([]string{"Mon", "weekends"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint8(1<<time.Monday | 1<<time.Saturday | 1<<time.Sunday); mask != want {
+		t.Errorf("ParseDays() = %07b, want %07b", mask, want)
+	}
+	if mask, _ := ParseDays(nil); mask != 0x7f {
+		t.Errorf("ParseDays(nil) = %07b, want every day", mask)
+	}
+	if _, err := ParseDays([]string{"funday"}); err == nil {
+		t.Error("ParseDays(funday) succeeded")
+	}
+}
+
+func mustWindow(t *testing.T, start, end string, days ...string) Window {
+	t.Helper()
+	s, err := ParsePoint(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := ParsePoint(end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mask, err := ParseDays(days)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Window{Start: s, End: e, Days: mask}
+}
+
+func TestScheduleActiveAndNextChange(t *testing.T) {
+	loc := time.UTC
+	at := func(day, hh, mm int) time.Time { return time.Date(2024, 6, day, hh, mm, 0, 0, loc) } // 2024-06-03 is a Monday
+
+	s, err := New([]Window{
+		mustWindow(t, "04:00", "09:00"),
+		mustWindow(t, "18:00", "21:00"),
+		mustWindow(t, "23:00", "01:00", "fri"), // past midnight, Fridays only
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		t      time.Time
+		active bool
+		next   time.Time
+	}{
+		{at(3, 3, 59), false, at(3, 4, 0)},
+		{at(3, 4, 0), true, at(3, 9, 0)},
+		{at(3, 8, 59), true, at(3, 9, 0)},
+		{at(3, 9, 0), false, at(3, 18, 0)},
+		{at(3, 20, 0), true, at(3, 21, 0)},
+		{at(3, 23, 30), false, at(4, 4, 0)}, // Monday: no late window
+		{at(7, 23, 30), true, at(8, 1, 0)},  // Friday 23:30
+		{at(8, 0, 30), true, at(8, 1, 0)},   // ...continues into Saturday
+		{at(8, 1, 0), false, at(8, 4, 0)},
+	}
+	for _, tt := range tests {
+		if got := s.Active(tt.t); got != tt.active {
+			t.Errorf("Active(%s) = %v, want %v", tt.t.Format("Mon 15:04"), got, tt.active)
+		}
+		if got := s.NextChange(tt.t); !got.Equal(tt.next) {
+			t.Errorf("NextChange(%s) = %s, want %s", tt.t.Format("Mon 15:04"), got.Format("Mon 15:04"), tt.next.Format("Mon 15:04"))
+		}
+	}
+
+	var always *Schedule
+	if !always.Active(at(3, 12, 0)) || !always.NextChange(at(3, 12, 0)).IsZero() {
+		t.Error("nil schedule must always be active and never change")
+	}
+}
+
+func TestScheduleSunWindow(t *testing.T) {
+	bst := time.FixedZone("BST", 3600)
+	london := &Location{Latitude: 51.5074, Longitude: -0.1278}
+	s, err := New([]Window{mustWindow(t, "sunrise-30m", "sunrise+2h")}, london)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sunrise on 2024-06-21 is about 04:43 BST.
+	for _, c := range []struct {
+		hh, mm int
+		want   bool
+	}{{4, 0, false}, {4, 20, true}, {6, 30, true}, {6, 50, false}} {
+		tm := time.Date(2024, 6, 21, c.hh, c.mm, 0, 0, bst)
+		if got := s.Active(tm); got != c.want {
+			t.Errorf("Active(%s) = %v, want %v", tm.Format("15:04"), got, c.want)
+		}
+	}
+
+	if _, err := New([]Window{mustWindow(t, "sunset", "23:00")}, nil); err == nil {
+		t.Error("New() accepted a sun window without a location")
+	}
+	if _, err := New([]Window{mustWindow(t, "04:00", "05:00")}, &Location{Latitude: 91}); err == nil {
+		t.Error("New() accepted latitude 91")
+	}
+	if _, err := New(nil, nil); err == nil {
+		t.Error("New() accepted no windows")
+	}
+}
+
+// TestSchedulePolarNight verifies a sunrise window simply never opens when
+// the sun does not rise, instead of failing.
+func TestSchedulePolarNight(t *testing.T) {
+	s, err := New([]Window{mustWindow(t, "sunrise", "sunset")}, &Location{Latitude: 69.65, Longitude: 18.96})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC)
+	if s.Active(tm) {
+		t.Error("Active during polar night")
+	}
+}
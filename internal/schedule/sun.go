@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: MIT
+
+package schedule
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	julianUnixEpoch = 2440587.5 // Julian date of 1970-01-01T00:00:00Z
+	julianJ2000     = 2451545.0 // Julian date of 2000-01-01T12:00:00
+	degToRad        = math.Pi / 180
+)
+
+// SunTimes returns sunrise and sunset on the local calendar date of day at
+// the given latitude and longitude (degrees, north and east positive), in
+// day's location. ok is false during polar day or night, when the sun does
+// not cross the horizon that day.
+//
+// It uses the sunrise equation with the standard -0.833° altitude for
+// refraction and the solar disc, which is accurate to a minute or two away
+// from the poles; no network or ephemeris data is needed.
+//
+// Example:
+//
+//	rise, set, ok := schedule.SunTimes(time.Now(), 51.5, -0.13) // London
+func SunTimes(day time.Time, latitude, longitude float64) (sunrise, sunset time.Time, ok bool) {
+	y, m, d := day.Date()
+	noonUTC := time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+	n := math.Round(float64(noonUTC.Unix())/86400 + julianUnixEpoch - julianJ2000)
+
+	// Mean solar time, solar mean anomaly and equation of the center.
+	jStar := n - longitude/360
+	meanAnomaly := math.Mod(357.5291+0.98560028*jStar, 360)
+	mRad := meanAnomaly * degToRad
+	center := 1.9148*math.Sin(mRad) + 0.0200*math.Sin(2*mRad) + 0.0003*math.Sin(3*mRad)
+
+	// Ecliptic longitude, solar transit and declination.
+	lambda := math.Mod(meanAnomaly+center+180+102.9372, 360) * degToRad
+	transit := julianJ2000 + jStar + 0.0053*math.Sin(mRad) - 0.0069*math.Sin(2*lambda)
+	sinDecl := math.Sin(lambda) * math.Sin(23.4397*degToRad)
+	cosDecl := math.Cos(math.Asin(sinDecl))
+
+	// Hour angle of sunrise/sunset.
+	phi := latitude * degToRad
+	cosOmega := (math.Sin(-0.833*degToRad) - math.Sin(phi)*sinDecl) / (math.Cos(phi) * cosDecl)
+	if cosOmega < -1 || cosOmega > 1 || math.IsNaN(cosOmega) {
+		return time.Time{}, time.Time{}, false
+	}
+	omega := math.Acos(cosOmega) / degToRad
+
+	toTime := func(julian float64) time.Time {
+		secs := (julian - julianUnixEpoch) * 86400
+		return time.Unix(0, int64(secs*1e9)).In(day.Location()).Round(time.Second)
+	}
+	return toTime(transit - omega/360), toTime(transit + omega/360), true
+}
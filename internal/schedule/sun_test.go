@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: MIT
+
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSunTimes(t *testing.T) {
+	bst := time.FixedZone("BST", 3600)
+	aest := time.FixedZone("AEST", 10*3600)
+	tests := []struct {
+		name          string
+		day           time.Time
+		lat, lon      float64
+		rise, set     string // local HH:MM
+		wantNoSunrise bool
+	}{
+		// Published values (timeanddate.com), rounded to the minute.
+		{"London midsummer", time.Date(2024, 6, 21, 0, 0, 0, 0, bst), 51.5074, -0.1278, "04:43", "21:21", false},
+		{"Sydney midwinter", time.Date(2024, 6, 21, 0, 0, 0, 0, aest), -33.8688, 151.2093, "07:00", "16:54", false},
+		{"Equator equinox", time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC), 0, 0, "06:04", "18:11", false},
+		{"Tromsø polar night", time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), 69.6492, 18.9553, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rise, set, ok := SunTimes(tt.day, tt.lat, tt.lon)
+			if tt.wantNoSunrise {
+				if ok {
+					t.Fatalf("SunTimes() = %v, %v; want no sunrise", rise, set)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("SunTimes() reported no sunrise")
+			}
+			for _, c := range []struct {
+				got  time.Time
+				want string
+			}{{rise, tt.rise}, {set, tt.set}} {
+				want, _ := time.ParseInLocation("2006-01-02 15:04",
+					tt.day.Format("2006-01-02 ")+c.want, tt.day.Location())
+				if diff := c.got.Sub(want).Abs(); diff > 3*time.Minute {
+					t.Errorf("got %s, want %s (±3m)", c.got.Format("15:04:05"), c.want)
+				}
+			}
+		})
+	}
+}
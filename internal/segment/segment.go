@@ -0,0 +1,270 @@
+// SPDX-License-Identifier: MIT
+
+// Package segment names local recording segments and finds them again.
+//
+// A segment name comes from a template such as "{station}_{stream}_{iso}",
+// which is turned into the strftime (or %d sequence) pattern FFmpeg's segment
+// muxer expands, and into a regular expression that recognizes the segments
+// of one stream, so that retention and other listings attribute each file to
+// its device without parsing FFmpeg's output. Segments are laid out flat in
+// the recording directory or in per-day subdirectories (YYYY-MM-DD).
+package segment
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultTemplate is the segment name used when none is configured:
+// <stream>_YYYYMMDD_HHMMSS.
+const DefaultTemplate = "{stream}_{date}_{time}"
+
+// DayDirFormat is the time layout of per-day subdirectory names.
+const DayDirFormat = "2006-01-02"
+
+// Template tokens.
+const (
+	tokenStream  = "{stream}"  // Stream (device) name
+	tokenStation = "{station}" // Station ID (timecode.host_id or the hostname)
+	tokenDate    = "{date}"    // YYYYMMDD
+	tokenTime    = "{time}"    // HHMMSS
+	tokenISO     = "{iso}"     // ISO 8601 basic: YYYYMMDDTHHMMSS plus Z (UTC) or ±hhmm
+	tokenSeq     = "{seq}"     // Sequence number, 6 digits, continuing across restarts
+)
+
+var (
+	tokenPattern   = regexp.MustCompile(`\{[a-z]+\}`)
+	literalPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]*$`)
+	dayDirPattern  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	unsafeStation  = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+)
+
+// Naming describes how a stream's segments are named and laid out. The zero
+// value is the default: DefaultTemplate, local time, flat.
+type Naming struct {
+	Template string // File name template without extension ("" = DefaultTemplate)
+	Station  string // Value of {station}; characters unsafe in file names become "_"
+	UTC      bool   // Name segments (and align them) by UTC instead of local time
+	Daily    bool   // Place segments in per-day YYYY-MM-DD subdirectories
+}
+
+// ValidateTemplate checks a segment name template: only known tokens and
+// the literal characters A-Z a-z 0-9 _ . -, a {stream} token so segments can
+// be attributed to their device, and a {time}, {iso} or {seq} token so
+// successive segments get distinct names. {seq} cannot be combined with the
+// date and time tokens: FFmpeg expands either a strftime pattern or a
+// sequence number, not both.
+func ValidateTemplate(tmpl string) error {
+	for _, tok := range tokenPattern.FindAllString(tmpl, -1) {
+		switch tok {
+		case tokenStream, tokenStation, tokenDate, tokenTime, tokenISO, tokenSeq:
+		default:
+			return fmt.Errorf("unknown token %s (use {stream}, {station}, {date}, {time}, {iso} or {seq})", tok)
+		}
+	}
+	if lit := tokenPattern.ReplaceAllString(tmpl, ""); !literalPattern.MatchString(lit) {
+		return fmt.Errorf("template %q may only contain tokens and the characters A-Z a-z 0-9 _ . -", tmpl)
+	}
+	if !strings.Contains(tmpl, tokenStream) {
+		return fmt.Errorf("template %q must contain {stream}", tmpl)
+	}
+	timed := strings.Contains(tmpl, tokenTime) || strings.Contains(tmpl, tokenISO)
+	seq := strings.Contains(tmpl, tokenSeq)
+	switch {
+	case seq && (timed || strings.Contains(tmpl, tokenDate)):
+		return fmt.Errorf("template %q: {seq} cannot be combined with {date}, {time} or {iso}", tmpl)
+	case !timed && !seq:
+		return fmt.Errorf("template %q must contain {time}, {iso} or {seq} so segment names are unique", tmpl)
+	}
+	return nil
+}
+
+// Validate checks n, including the template.
+func (n Naming) Validate() error {
+	if err := ValidateTemplate(n.template()); err != nil {
+		return err
+	}
+	if n.Daily && n.Sequenced() {
+		return fmt.Errorf("per-day subdirectories need a date-based template, not {seq}")
+	}
+	return nil
+}
+
+func (n Naming) template() string {
+	if n.Template == "" {
+		return DefaultTemplate
+	}
+	return n.Template
+}
+
+func (n Naming) station() string {
+	if s := unsafeStation.ReplaceAllString(n.Station, "_"); s != "" {
+		return s
+	}
+	return "unknown"
+}
+
+// Sequenced reports whether segments are numbered ({seq}) rather than named
+// by time.
+func (n Naming) Sequenced() bool {
+	return strings.Contains(n.template(), tokenSeq)
+}
+
+// expand replaces each token of the template with tok(token) and each
+// literal run with lit(run).
+func (n Naming) expand(stream string, tok func(string) string, lit func(string) string) string {
+	tmpl := n.template()
+	var b strings.Builder
+	last := 0
+	for _, loc := range tokenPattern.FindAllStringIndex(tmpl, -1) {
+		b.WriteString(lit(tmpl[last:loc[0]]))
+		switch t := tmpl[loc[0]:loc[1]]; t {
+		case tokenStream:
+			b.WriteString(lit(stream))
+		case tokenStation:
+			b.WriteString(lit(n.station()))
+		default:
+			b.WriteString(tok(t))
+		}
+		last = loc[1]
+	}
+	b.WriteString(lit(tmpl[last:]))
+	return b.String()
+}
+
+// Pattern returns the FFmpeg segment muxer output pattern for stream's
+// segments with extension ext, relative to the recording directory, and
+// whether it is a strftime pattern (false: a %d sequence pattern, see
+// Sequenced). A UTC naming needs FFmpeg to run with TZ=UTC, since strftime
+// expands local time.
+func (n Naming) Pattern(stream, ext string) (pattern string, strftime bool) {
+	name := n.expand(stream, func(tok string) string {
+		switch tok {
+		case tokenDate:
+			return "%Y%m%d"
+		case tokenTime:
+			return "%H%M%S"
+		case tokenISO:
+			if n.UTC {
+				return "%Y%m%dT%H%M%SZ"
+			}
+			return "%Y%m%dT%H%M%S%z"
+		}
+		return "%06d"
+	}, func(s string) string { return s })
+	if n.Daily {
+		name = filepath.Join("%Y-%m-%d", name)
+	}
+	return name + "." + ext, !n.Sequenced()
+}
+
+// Path returns the path, relative to the recording directory, of stream's
+// segment number seq or (for time-based names) the one starting at t.
+func (n Naming) Path(stream, ext string, t time.Time, seq int) string {
+	if n.UTC {
+		t = t.UTC()
+	} else {
+		t = t.Local()
+	}
+	name := n.expand(stream, func(tok string) string {
+		switch tok {
+		case tokenDate:
+			return t.Format("20060102")
+		case tokenTime:
+			return t.Format("150405")
+		case tokenISO:
+			if n.UTC {
+				return t.Format("20060102T150405Z")
+			}
+			return t.Format("20060102T150405-0700")
+		}
+		return fmt.Sprintf("%06d", seq)
+	}, func(s string) string { return s })
+	if n.Daily {
+		name = filepath.Join(n.DayDir(t), name)
+	}
+	return name + "." + ext
+}
+
+// DayDir returns the per-day subdirectory for segments starting at t.
+func (n Naming) DayDir(t time.Time) string {
+	if n.UTC {
+		return t.UTC().Format(DayDirFormat)
+	}
+	return t.Local().Format(DayDirFormat)
+}
+
+// Matcher returns a regular expression matching the file names (without
+// directory) of stream's segments. For a sequenced naming the submatch named
+// "seq" is the segment number.
+func (n Naming) Matcher(stream string) *regexp.Regexp {
+	expr := n.expand(stream, func(tok string) string {
+		switch tok {
+		case tokenDate:
+			return `\d{8}`
+		case tokenTime:
+			return `\d{6}`
+		case tokenISO:
+			return `\d{8}T\d{6}(?:Z|[+-]\d{4})`
+		}
+		return `(?P<seq>\d+)`
+	}, regexp.QuoteMeta)
+	return regexp.MustCompile(`^` + expr + `\.[A-Za-z0-9]+$`)
+}
+
+// NextSeq returns the number after the highest existing segment number of
+// stream in dir (0 when there is none), so a restart continues the sequence
+// instead of overwriting segment 0.
+func (n Naming) NextSeq(dir, stream string) int {
+	files, _ := List(dir)
+	re := n.Matcher(stream)
+	next := 0
+	for _, f := range files {
+		m := re.FindStringSubmatch(filepath.Base(f))
+		if m == nil {
+			continue
+		}
+		var seq int
+		if _, err := fmt.Sscanf(m[re.SubexpIndex("seq")], "%d", &seq); err == nil && seq >= next {
+			next = seq + 1
+		}
+	}
+	return next
+}
+
+// IsDayDir reports whether name is a per-day subdirectory name.
+func IsDayDir(name string) bool {
+	return dayDirPattern.MatchString(name)
+}
+
+// List returns the paths of the regular files in dir and in its per-day
+// subdirectories. A subdirectory that cannot be read is skipped; an error
+// is returned only when dir itself cannot be read.
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		switch {
+		case e.Type().IsRegular():
+			files = append(files, filepath.Join(dir, e.Name()))
+		case e.IsDir() && IsDayDir(e.Name()):
+			sub, err := os.ReadDir(filepath.Join(dir, e.Name()))
+			if err != nil {
+				continue
+			}
+			for _, s := range sub {
+				if s.Type().IsRegular() {
+					files = append(files, filepath.Join(dir, e.Name(), s.Name()))
+				}
+			}
+		}
+	}
+	return files, nil
+}
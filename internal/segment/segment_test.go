@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: MIT
+
+package segment
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateTemplate(t *testing.T) {
+	for _, ok := range []string{
+		DefaultTemplate,
+		"{station}_{stream}_{iso}",
+		"{stream}-{date}T{time}",
+		"{station}.{stream}.{seq}",
+	} {
+		if err := ValidateTemplate(ok); err != nil {
+			t.Errorf("ValidateTemplate(%q) = %v, want nil", ok, err)
+		}
+	}
+	for tmpl, want := range map[string]string{
+		"{stream}_{hour}":        "unknown token {hour}",
+		"{stream}/{date}_{time}": "may only contain",
+		"{stream}_%Y{time}":      "may only contain",
+		"{date}_{time}":          "must contain {stream}",
+		"{stream}_{date}":        "must contain {time}, {iso} or {seq}",
+		"{stream}_{date}_{seq}":  "{seq} cannot be combined",
+	} {
+		if err := ValidateTemplate(tmpl); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidateTemplate(%q) = %v, want error containing %q", tmpl, err, want)
+		}
+	}
+	if err := (Naming{Template: "{stream}_{seq}", Daily: true}).Validate(); err == nil {
+		t.Error("a sequenced naming cannot use per-day subdirectories")
+	}
+}
+
+func TestNamingPatternAndPath(t *testing.T) {
+	at := time.Date(2026, 3, 1, 23, 30, 5, 0, time.UTC)
+	tests := []struct {
+		name        string
+		n           Naming
+		wantPattern string
+		wantPath    string
+	}{
+		{"default", Naming{UTC: true}, "blue_yeti_%Y%m%d_%H%M%S.ogg", "blue_yeti_20260301_233005.ogg"},
+		{"station iso daily", Naming{Template: "{station}_{stream}_{iso}", Station: "north site/1", UTC: true, Daily: true},
+			"%Y-%m-%d/north_site_1_blue_yeti_%Y%m%dT%H%M%SZ.ogg", "2026-03-01/north_site_1_blue_yeti_20260301T233005Z.ogg"},
+		{"sequence", Naming{Template: "{stream}_{seq}"}, "blue_yeti_%06d.ogg", "blue_yeti_000042.ogg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, strftime := tt.n.Pattern("blue_yeti", "ogg")
+			if pattern != tt.wantPattern || strftime == tt.n.Sequenced() {
+				t.Errorf("Pattern = %q, %v; want %q", pattern, strftime, tt.wantPattern)
+			}
+			path := tt.n.Path("blue_yeti", "ogg", at, 42)
+			if path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", path, tt.wantPath)
+			}
+			if !tt.n.Matcher("blue_yeti").MatchString(filepath.Base(path)) {
+				t.Errorf("Matcher does not match its own name %q", path)
+			}
+		})
+	}
+
+	// Local names carry the zone offset in {iso}.
+	local := Naming{Template: "{stream}_{iso}"}
+	if got := local.Path("a", "wav", at, 0); !strings.HasPrefix(got, "a_"+at.Local().Format("20060102T150405-0700")) {
+		t.Errorf("local Path = %q", got)
+	}
+	if !local.Matcher("a").MatchString("a_20260301T233005+0100.wav") {
+		t.Error("local Matcher should accept a zone offset")
+	}
+}
+
+func TestMatcherAttributesStreams(t *testing.T) {
+	re := Naming{}.Matcher("bird_box")
+	for name, want := range map[string]bool{
+		"bird_box_20260101_000000.ogg":   true,
+		"bird_box_2_20260101_000000.ogg": false, // another stream
+		"bird_box_20260101_000000":       false,
+		"notes.txt":                      false,
+	} {
+		if got := re.MatchString(name); got != want {
+			t.Errorf("Matcher(bird_box).MatchString(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestListAndNextSeq(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"mic_000003.flac",
+		"mic_000011.flac",
+		"other_000099.flac",
+		"2026-03-01/mic_20260301_000000.wav",
+		"notes/ignored.txt",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("audio"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := List(dir)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for i, f := range files {
+		files[i], _ = filepath.Rel(dir, f)
+	}
+	slices.Sort(files)
+	want := []string{"2026-03-01/mic_20260301_000000.wav", "mic_000003.flac", "mic_000011.flac", "other_000099.flac"}
+	if !slices.Equal(files, want) {
+		t.Errorf("List = %v, want %v", files, want)
+	}
+
+	n := Naming{Template: "{stream}_{seq}"}
+	if got := n.NextSeq(dir, "mic"); got != 12 {
+		t.Errorf("NextSeq(mic) = %d, want 12", got)
+	}
+	if got := n.NextSeq(dir, "new"); got != 0 {
+		t.Errorf("NextSeq(new) = %d, want 0", got)
+	}
+	if _, err := List(filepath.Join(dir, "missing")); err == nil {
+		t.Error("List of a missing directory should fail")
+	}
+}
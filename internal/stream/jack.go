@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// jackConnectPath is the jack_connect binary; a variable so tests can stub it.
+var jackConnectPath = "jack_connect"
+
+// jackConnectTimeout bounds how long connectJACKPorts waits for FFmpeg to
+// register its input ports with the JACK server.
+const jackConnectTimeout = 10 * time.Second
+
+// connectJACKPorts connects each of cfg.InputPorts to FFmpeg's JACK input
+// port of the same position (<client>:input_1, input_2, ...). FFmpeg's JACK
+// input registers its ports but never connects them, so without this it
+// records silence.
+//
+// FFmpeg registers the ports shortly after starting, so each connection is
+// retried until it succeeds, ctx ends or jackConnectTimeout passes. A port
+// that cannot be connected is logged; the stream keeps running, because the
+// other channels may still carry audio.
+func (m *Manager) connectJACKPorts(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, jackConnectTimeout)
+	defer cancel()
+
+	for i, src := range m.cfg.InputPorts {
+		dst := fmt.Sprintf("%s:input_%d", m.cfg.ALSADevice, i+1)
+		var err error
+		for {
+			// #nosec G204 -- port names are validated by config.SourceConfig
+			out, runErr := exec.CommandContext(ctx, jackConnectPath, src, dst).CombinedOutput()
+			err = runErr
+			if err == nil {
+				break
+			}
+			err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+			select {
+			case <-ctx.Done():
+			case <-time.After(200 * time.Millisecond):
+				continue
+			}
+			break
+		}
+		if m.cfg.Logger == nil {
+			continue
+		}
+		if err != nil {
+			m.cfg.Logger.Warn("failed to connect JACK port", "port", src, "input", dst, "error", err)
+		} else {
+			m.cfg.Logger.Info("connected JACK port", "port", src, "input", dst)
+		}
+	}
+}
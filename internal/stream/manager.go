@@ -35,35 +35,49 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
 	"github.com/tomtom215/lyrebirdaudio-go/internal/lock"
+	"github.com/tomtom215/lyrebirdaudio-go/internal/segment"
 )
 
 // ManagerConfig contains configuration for a stream manager.
 type ManagerConfig struct {
-	DeviceName    string // Sanitized device name (e.g., "blue_yeti")
-	ALSADevice    string // ALSA device identifier (e.g., "hw:0,0") or lavfi source
-	InputFormat   string // Input format: "alsa" or "lavfi" (default: "alsa")
-	RealtimeInput bool   // Pace a non-hardware input to real time with -re (e.g. a synthetic lavfi source); leave false for hardware ALSA capture, which is already real-time
-
-	StreamName      string                // Stream name for MediaMTX path
-	SampleRate      int                   // Sample rate in Hz
-	Channels        int                   // Number of channels
-	Bitrate         string                // Bitrate (e.g., "128k")
-	Codec           string                // Codec ("opus" or "aac")
-	ThreadQueue     int                   // FFmpeg thread queue size (optional)
-	RTSPURL         string                // Full RTSP URL or file path for output
-	OutputFormat    string                // Output format: "rtsp", "null", or empty for auto-detect (default: "rtsp")
-	LockDir         string                // Directory for lock files
-	FFmpegPath      string                // Path to ffmpeg binary
-	Backoff         *Backoff              // Backoff policy for restarts
-	Logger          *slog.Logger          // Optional structured logger (nil = no logging)
-	LogDir          string                // Directory for FFmpeg log files (empty = no logging)
-	MonitorInterval time.Duration         // Interval for resource monitoring (0 = disabled)
-	AlertCallback   func([]ResourceAlert) // Optional callback for resource alerts
-	StopTimeout     time.Duration         // Timeout for graceful FFmpeg stop before force-kill (default: 5s) (H-1 fix)
-	LocalRecordDir  string                // Directory for local audio recording segments (C-1 fix, empty = disabled)
-	SegmentDuration int                   // Duration in seconds for local recording segments (default: 3600 = 1 hour)
-	SegmentFormat   string                // Format for local recording segments: "wav", "flac", "ogg" (default: "wav")
+	DeviceName    string        // Sanitized device name (e.g., "blue_yeti")
+	ALSADevice    string        // ALSA device identifier (e.g., "hw:0,0"), lavfi source, pulse source name or JACK client name
+	InputFormat   string        // Input format: "alsa", "pulse", "jack" or "lavfi" (default: "alsa")
+	InputPorts    []string      // jack: ports connected to FFmpeg's input ports, in channel order, once it is running
+	Network       *NetworkInput // Pull from a URL instead of a local device (ALSADevice and InputFormat are then unused)
+	TestSignal    *TestSignal   // Generate a test signal instead of capturing (ALSADevice and InputFormat are then unused; implies -re)
+	Timecode      *Timecode     // Embed capture start time, clock sync state and IDs as metadata (nil = none)
+	RealtimeInput bool          // Pace a non-hardware input to real time with -re (e.g. a synthetic lavfi source); leave false for hardware ALSA capture, which is already real-time
+
+	StreamName           string                // Stream name for MediaMTX path
+	SampleRate           int                   // Sample rate in Hz
+	Channels             int                   // Number of channels
+	Bitrate              string                // Bitrate (e.g., "128k")
+	Codec                string                // Codec name from audio.CodecNames (e.g. "opus")
+	ThreadQueue          int                   // FFmpeg thread queue size (optional)
+	Opus                 audio.OpusSettings    // libopus encoder options, used when Codec is "opus" (zero = libopus defaults)
+	AudioFilter          string                // -af filter graph applied before encoding, rendered by config.FilterChain (empty = none)
+	RTSPURL              string                // Full RTSP URL or file path for output
+	Renditions           []Rendition           // Further encodings of the capture, each published to its own RTSP URL by the same FFmpeg (RTSP output only)
+	OutputFormat         string                // Output format: "rtsp", "null", or empty for auto-detect (default: "rtsp")
+	LockDir              string                // Directory for lock files
+	FFmpegPath           string                // Path to ffmpeg binary
+	Backoff              *Backoff              // Backoff policy for restarts
+	Logger               *slog.Logger          // Optional structured logger (nil = no logging)
+	LogDir               string                // Directory for FFmpeg log files (empty = no logging)
+	MonitorInterval      time.Duration         // Interval for resource monitoring (0 = disabled)
+	AlertCallback        func([]ResourceAlert) // Optional callback for resource alerts
+	StopTimeout          time.Duration         // Timeout for graceful FFmpeg stop before force-kill (default: 5s) (H-1 fix)
+	LocalRecordDir       string                // Directory for local audio recording segments (C-1 fix, empty = disabled)
+	SegmentDuration      int                   // Duration in seconds for local recording segments (default: 3600 = 1 hour)
+	SegmentFormat        string                // Format for local recording segments: "wav", "flac", "ogg" (default: "wav")
+	SegmentNaming        segment.Naming        // Segment file names and layout (default: <stream>_YYYYmmdd_HHMMSS, local time, flat)
+	SegmentAtClockTime   bool                  // Start segments at wall-clock multiples of SegmentDuration instead of relative to the FFmpeg start
+	RecordOnly           bool                  // Write local recording segments only and publish nothing to RTSPURL (requires LocalRecordDir)
+	Upstream             Upstream              // Optional readiness gate for the RTSP server; failures while it is down do not consume restart attempts (nil = always ready)
+	UpstreamResumeJitter time.Duration         // Max random delay before restarting after an upstream outage, to spread reconnects (0 = none)
 }
 
 // Manager manages a single audio stream's lifecycle.
@@ -244,6 +258,13 @@ func (m *Manager) Run(ctx context.Context) error {
 			return fmt.Errorf("max restart attempts (%d) exceeded", m.backoff.MaxAttempts())
 		}
 
+		// Hold the start while the RTSP server is down rather than launching
+		// an FFmpeg that can only fail against a dead port.
+		if err := m.waitUpstream(ctx); err != nil {
+			m.setState(StateStopped)
+			return err
+		}
+
 		// Start stream
 		m.setState(StateStarting)
 		m.attempts.Add(1)
@@ -264,6 +285,16 @@ func (m *Manager) Run(ctx context.Context) error {
 
 			m.failures.Add(1)
 			m.setState(StateFailed)
+			if m.upstreamDownAfterFailure(ctx) {
+				// The publish failed because the upstream went away. Do not
+				// consume a restart attempt; the next iteration waits for it.
+				m.logStructuredEvent("stream_failure_upstream_down",
+					"error", err.Error(),
+					"run_duration", runTime.String(),
+				)
+				m.logError("FFmpeg failed while upstream is down: %v (not counted toward restart attempts)", err)
+				continue
+			}
 			m.logStructuredEvent("stream_failure",
 				"error", err.Error(),
 				"attempt", m.attempts.Load(),
@@ -286,6 +317,13 @@ func (m *Manager) Run(ctx context.Context) error {
 		if runTime < successThreshold {
 			m.failures.Add(1)
 			m.setState(StateFailed)
+			if m.upstreamDownAfterFailure(ctx) {
+				m.logStructuredEvent("stream_failure_upstream_down",
+					"run_duration", runTime.String(),
+				)
+				m.logError("FFmpeg exited after %v while upstream is down (not counted toward restart attempts)", runTime)
+				continue
+			}
 			m.logStructuredEvent("stream_short_run_failure",
 				"run_duration", runTime.String(),
 				"threshold", successThreshold.String(),
@@ -0,0 +1,329 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUpstream is a controllable Upstream.
+type fakeUpstream struct {
+	mu      sync.Mutex
+	ready   bool
+	readyCh chan struct{}
+}
+
+func newFakeUpstream(ready bool) *fakeUpstream {
+	u := &fakeUpstream{readyCh: make(chan struct{})}
+	u.set(ready)
+	return u
+}
+
+func (u *fakeUpstream) set(ready bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if ready == u.ready {
+		return
+	}
+	u.ready = ready
+	if ready {
+		close(u.readyCh)
+	} else {
+		u.readyCh = make(chan struct{})
+	}
+}
+
+func (u *fakeUpstream) Ready() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.ready
+}
+
+func (u *fakeUpstream) WaitReady(ctx context.Context) error {
+	u.mu.Lock()
+	ch := u.readyCh
+	u.mu.Unlock()
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newUpstreamTestManager returns a manager running ffmpegPath against up
+// with a single-attempt backoff budget, so any counted failure ends Run.
+func newUpstreamTestManager(t *testing.T, ffmpegPath string, up Upstream, logBuf *bytes.Buffer) *Manager {
+	t.Helper()
+	mgr, err := NewManager(&ManagerConfig{
+		DeviceName:   "test",
+		ALSADevice:   "dummy",
+		StreamName:   "test",
+		SampleRate:   48000,
+		Channels:     2,
+		Bitrate:      "128k",
+		Codec:        "opus",
+		RTSPURL:      "/dev/null",
+		OutputFormat: "null",
+		LockDir:      t.TempDir(),
+		FFmpegPath:   ffmpegPath,
+		Backoff:      NewBackoff(10*time.Millisecond, 20*time.Millisecond, 1),
+		Upstream:     up,
+		Logger:       slog.New(slog.NewTextHandler(logBuf, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	return mgr
+}
+
+// TestManagerRunHoldsWhileUpstreamDown verifies FFmpeg is not started while
+// the upstream is down.
+func TestManagerRunHoldsWhileUpstreamDown(t *testing.T) {
+	var logBuf bytes.Buffer
+	mgr := newUpstreamTestManager(t, "/bin/false", newFakeUpstream(false), &logBuf)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- mgr.Run(ctx) }()
+
+	time.Sleep(200 * time.Millisecond)
+	if got := mgr.Attempts(); got != 0 {
+		t.Errorf("Attempts() while upstream down = %d, want 0", got)
+	}
+	if mgr.State() != StateFailed {
+		t.Errorf("State() while upstream down = %v, want StateFailed", mgr.State())
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run() = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	if mgr.State() != StateStopped {
+		t.Errorf("State() after cancel = %v, want StateStopped", mgr.State())
+	}
+	if !strings.Contains(logBuf.String(), "upstream_wait") {
+		t.Errorf("log missing upstream_wait event:\n%s", logBuf.String())
+	}
+}
+
+// TestManagerRunUpstreamFailureNotCounted verifies that an FFmpeg failure
+// observed while the upstream is down does not count toward
+// MaxRestartAttempts, and that the stream is restarted once it is back.
+func TestManagerRunUpstreamFailureNotCounted(t *testing.T) {
+	// The mock FFmpeg fails after a short run; the upstream is taken down
+	// while it is running, so the failure lands during the outage.
+	script := filepath.Join(t.TempDir(), "ffmpeg.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nsleep 0.3\nexit 1\n"), 0755); err != nil {
+		t.Fatalf("write mock ffmpeg: %v", err)
+	}
+
+	var logBuf bytes.Buffer
+	up := newFakeUpstream(true)
+	mgr := newUpstreamTestManager(t, script, up, &logBuf)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- mgr.Run(ctx) }()
+
+	time.Sleep(100 * time.Millisecond)
+	up.set(false)
+	time.Sleep(500 * time.Millisecond)
+
+	// With a one-attempt budget a counted failure would have ended Run.
+	select {
+	case err := <-errCh:
+		t.Fatalf("Run() returned %v during upstream outage", err)
+	default:
+	}
+	if got := mgr.Attempts(); got != 1 {
+		t.Errorf("Attempts() during outage = %d, want 1 (held, not retried)", got)
+	}
+
+	up.set(true)
+	deadline := time.Now().Add(5 * time.Second)
+	for mgr.Attempts() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := mgr.Attempts(); got < 2 {
+		t.Errorf("Attempts() after upstream returned = %d, want >= 2", got)
+	}
+
+	cancel()
+	select {
+	case <-errCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	if !strings.Contains(logBuf.String(), "stream_failure_upstream_down") {
+		t.Errorf("log missing stream_failure_upstream_down event:\n%s", logBuf.String())
+	}
+}
+
+// switchProbe is a probe whose result the test controls.
+type switchProbe struct {
+	mu sync.Mutex
+	up bool
+	n  int
+}
+
+func (p *switchProbe) set(up bool) {
+	p.mu.Lock()
+	p.up = up
+	p.mu.Unlock()
+}
+
+func (p *switchProbe) probe(context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.n++
+	if p.up {
+		return nil
+	}
+	return errors.New("connection refused")
+}
+
+func TestNewUpstreamMonitor(t *testing.T) {
+	if _, err := NewUpstreamMonitor(UpstreamMonitorConfig{}); err == nil {
+		t.Error("NewUpstreamMonitor() with nil probe should fail")
+	}
+	mon, err := NewUpstreamMonitor(UpstreamMonitorConfig{Probe: (&switchProbe{}).probe})
+	if err != nil {
+		t.Fatalf("NewUpstreamMonitor() error = %v", err)
+	}
+	if mon.Name() != "upstream-monitor" || mon.cfg.Interval != 5*time.Second {
+		t.Errorf("defaults not applied: name=%q interval=%v", mon.Name(), mon.cfg.Interval)
+	}
+	// Optimistic until the first probe says otherwise.
+	if !mon.Ready() {
+		t.Error("new monitor must start ready")
+	}
+	if err := mon.WaitReady(context.Background()); err != nil {
+		t.Errorf("WaitReady() on ready monitor = %v", err)
+	}
+}
+
+func TestUpstreamMonitorRunTracksProbe(t *testing.T) {
+	p := &switchProbe{}
+	mon, err := NewUpstreamMonitor(UpstreamMonitorConfig{Probe: p.probe, Interval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewUpstreamMonitor() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- mon.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for mon.Ready() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if mon.Ready() {
+		t.Fatal("monitor still ready after failing probes")
+	}
+
+	// Every waiter wakes once the probe succeeds.
+	const waiters = 5
+	woke := make(chan error, waiters)
+	for i := 0; i < waiters; i++ {
+		go func() {
+			wctx, wcancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer wcancel()
+			woke <- mon.WaitReady(wctx)
+		}()
+	}
+	p.set(true)
+	for i := 0; i < waiters; i++ {
+		if err := <-woke; err != nil {
+			t.Errorf("waiter %d: WaitReady() = %v", i, err)
+		}
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+}
+
+func TestUpstreamMonitorRecheck(t *testing.T) {
+	p := &switchProbe{}
+	mon, err := NewUpstreamMonitor(UpstreamMonitorConfig{Probe: p.probe})
+	if err != nil {
+		t.Fatalf("NewUpstreamMonitor() error = %v", err)
+	}
+	if mon.Recheck(context.Background()) || mon.Ready() {
+		t.Error("Recheck() with failing probe should report not ready")
+	}
+
+	// A cancelled caller must not flip the shared state.
+	p.set(true)
+	mon.set(nil)
+	p.set(false)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if !mon.Recheck(ctx) || !mon.Ready() {
+		t.Error("Recheck() with cancelled ctx must keep the previous state")
+	}
+}
+
+// TestManagerRunRechecksUpstreamOnFailure verifies that a failure against an
+// upstream the monitor still believes is up triggers a recheck, and is not
+// counted when the recheck finds it down.
+func TestManagerRunRechecksUpstreamOnFailure(t *testing.T) {
+	p := &switchProbe{}
+	mon, err := NewUpstreamMonitor(UpstreamMonitorConfig{Probe: p.probe, Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewUpstreamMonitor() error = %v", err)
+	}
+
+	var logBuf bytes.Buffer
+	mgr := newUpstreamTestManager(t, "/bin/false", mon, &logBuf)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- mgr.Run(ctx) }()
+
+	time.Sleep(200 * time.Millisecond)
+	select {
+	case err := <-errCh:
+		t.Fatalf("Run() returned %v; the failure should not have been counted", err)
+	default:
+	}
+	if mon.Ready() {
+		t.Error("monitor should be down after the post-failure recheck")
+	}
+	if got := mgr.Attempts(); got != 1 {
+		t.Errorf("Attempts() = %d, want 1 (held after the first failure)", got)
+	}
+
+	cancel()
+	<-errCh
+}
+
+func TestManagerResumeJitter(t *testing.T) {
+	mgr := &Manager{cfg: &ManagerConfig{UpstreamResumeJitter: time.Hour}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := mgr.resumeJitter(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("resumeJitter() = %v, want context.Canceled", err)
+	}
+
+	mgr.cfg.UpstreamResumeJitter = 0
+	if err := mgr.resumeJitter(context.Background()); err != nil {
+		t.Errorf("resumeJitter() with no jitter = %v", err)
+	}
+}
@@ -127,14 +127,14 @@ func TestValidateConfig(t *testing.T) {
 				SampleRate: 48000,
 				Channels:   2,
 				Bitrate:    "128k",
-				Codec:      "mp3",
+				Codec:      "vorbis",
 				RTSPURL:    "rtsp://localhost:8554/test",
 				LockDir:    "/tmp",
 				FFmpegPath: "/usr/bin/ffmpeg",
 				Backoff:    NewBackoff(1*time.Second, 10*time.Second, 5),
 			},
 			wantErr: true,
-			errMsg:  "codec must be opus or aac",
+			errMsg:  `codec must be one of opus, aac, flac, pcm, mp3, pcmu, pcma, speex (got "vorbis")`,
 		},
 		{
 			name: "missing backoff",
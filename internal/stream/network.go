@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultNetworkTimeout is how long a network input may deliver nothing
+// before FFmpeg gives up and the manager restarts it.
+const defaultNetworkTimeout = 10 * time.Second
+
+// NetworkInput pulls a stream's audio from a URL (an IP microphone or a
+// remote RTSP, HTTP or SRT source) instead of a local capture device.
+type NetworkInput struct {
+	URL               string        // rtsp://, rtsps://, http://, https:// or srt:// source
+	RTSPTransport     string        // rtsp: "tcp" (default) or "udp"
+	Timeout           time.Duration // I/O timeout; FFmpeg exits when it passes and the manager restarts it (0 = 10s)
+	ReconnectDelayMax time.Duration // http(s): reconnect within FFmpeg for up to this delay (0 = rely on restarts)
+	Copy              bool          // Republish the audio as received, without decoding or re-encoding
+}
+
+// inputArgs returns the FFmpeg input options for the URL, which precede -i.
+// The input format is left to FFmpeg's probing, since an HTTP or SRT source
+// may carry any container.
+//
+// A silent source must make FFmpeg exit rather than hang: a hung pull keeps
+// its stream "running" while nothing is published or recorded. The RTSP
+// demuxer takes its socket timeout as -timeout; other protocols take the
+// generic -rw_timeout. Both are in microseconds.
+func (n *NetworkInput) inputArgs() []string {
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = defaultNetworkTimeout
+	}
+	usec := fmt.Sprintf("%d", timeout.Microseconds())
+
+	scheme := ""
+	if u, err := url.Parse(n.URL); err == nil {
+		scheme = strings.ToLower(u.Scheme)
+	}
+	switch scheme {
+	case "rtsp", "rtsps":
+		transport := n.RTSPTransport
+		if transport == "" {
+			transport = "tcp"
+		}
+		return []string{"-rtsp_transport", transport, "-timeout", usec}
+	case "http", "https":
+		args := []string{"-rw_timeout", usec}
+		if n.ReconnectDelayMax > 0 {
+			args = append(args,
+				"-reconnect", "1",
+				"-reconnect_streamed", "1",
+				"-reconnect_on_network_error", "1",
+				"-reconnect_delay_max", fmt.Sprintf("%d", int(n.ReconnectDelayMax.Round(time.Second).Seconds())),
+			)
+		}
+		return args
+	default:
+		return []string{"-rw_timeout", usec}
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
 )
 
 // startFFmpeg starts the FFmpeg process and blocks until it exits.
@@ -18,9 +20,19 @@ func (m *Manager) startFFmpeg(ctx context.Context) error {
 		if err := os.MkdirAll(m.cfg.LocalRecordDir, 0750); err != nil {
 			return fmt.Errorf("failed to create recording directory %q: %w", m.cfg.LocalRecordDir, err)
 		}
+		if err := m.awaitFreeSegmentName(ctx); err != nil {
+			return err
+		}
+		if m.cfg.SegmentNaming.Daily {
+			m.makeDayDirs()
+		}
 	}
 
-	cmd := buildFFmpegCommand(ctx, m.cfg)
+	c := m.newCapture(ctx)
+	if m.cfg.LocalRecordDir != "" && m.cfg.SegmentNaming.Sequenced() {
+		c.seq = m.cfg.SegmentNaming.NextSeq(m.cfg.LocalRecordDir, m.cfg.StreamName)
+	}
+	cmd := buildCaptureCommand(ctx, m.cfg, c)
 
 	m.mu.Lock()
 	if m.logWriter != nil {
@@ -39,6 +51,16 @@ func (m *Manager) startFFmpeg(ctx context.Context) error {
 
 	m.setState(StateRunning)
 
+	if m.cfg.LocalRecordDir != "" && m.cfg.SegmentNaming.Daily {
+		dirCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go m.runDayDirs(dirCtx)
+	}
+
+	if m.cfg.InputFormat == "jack" && len(m.cfg.InputPorts) > 0 {
+		go m.connectJACKPorts(ctx)
+	}
+
 	if m.resourceMonitor != nil && cmd.Process != nil && m.cfg.MonitorInterval > 0 {
 		monitorCtx, cancel := context.WithCancel(ctx)
 		m.mu.Lock()
@@ -129,14 +151,42 @@ func (m *Manager) forceStop() error {
 	return fmt.Errorf("no process to kill")
 }
 
-// buildFFmpegCommand constructs the FFmpeg command line.
+// buildFFmpegCommand constructs the FFmpeg command line for a capture
+// starting now, without checking the clock.
 func buildFFmpegCommand(ctx context.Context, cfg *ManagerConfig) *exec.Cmd {
+	return buildCaptureCommand(ctx, cfg, capture{start: time.Now().UTC(), clock: ClockUnchecked})
+}
+
+// buildCaptureCommand constructs the FFmpeg command line for capture c.
+func buildCaptureCommand(ctx context.Context, cfg *ManagerConfig, c capture) *exec.Cmd {
 	inputFormat := cfg.InputFormat
 	if inputFormat == "" {
 		inputFormat = "alsa"
 	}
 
-	args := []string{"-f", inputFormat}
+	var args []string
+	input := cfg.ALSADevice
+	switch {
+	case cfg.Network != nil:
+		args = cfg.Network.inputArgs()
+		input = cfg.Network.URL
+	case cfg.TestSignal != nil:
+		// A generated signal; its DTMF ident reads the start time, so
+		// the graph is rebuilt on every (re)start.
+		args = []string{"-f", "lavfi"}
+		input = cfg.TestSignal.graph(cfg.SampleRate, c.start)
+	default:
+		args = []string{"-f", inputFormat}
+		// Sound-server inputs: pulse names the client so it is recognisable
+		// in pavucontrol or pw-top, and jack registers one input port per
+		// channel.
+		switch inputFormat {
+		case "pulse":
+			args = append(args, "-name", "lyrebird", "-stream_name", cfg.StreamName)
+		case "jack":
+			args = append(args, "-channels", fmt.Sprintf("%d", cfg.Channels))
+		}
+	}
 
 	// RealtimeInput paces a non-hardware source (e.g. a synthetic lavfi test
 	// tone) to real time with -re. Such a source otherwise generates frames as
@@ -145,28 +195,52 @@ func buildFFmpegCommand(ctx context.Context, cfg *ManagerConfig) *exec.Cmd {
 	// ALSA capture is already paced by the hardware clock and must NOT get -re
 	// (it would double-pace and drift), so this stays opt-in and defaults off.
 	// -re is an input option and must precede -i.
-	if cfg.RealtimeInput {
+	if cfg.RealtimeInput || cfg.TestSignal != nil {
 		args = append(args, "-re")
 	}
 
-	args = append(args,
-		"-i", cfg.ALSADevice,
-		"-ar", fmt.Sprintf("%d", cfg.SampleRate),
-		"-ac", fmt.Sprintf("%d", cfg.Channels),
-	)
+	// -ar sets the encoder rate; FFmpeg resamples the capture to it.
+	sampleRate := cfg.SampleRate
+	if codec, ok := audio.LookupCodec(cfg.Codec); ok {
+		sampleRate = codec.EncodeRate(sampleRate)
+	}
+	args = append(args, "-i", input)
+	if cfg.Network != nil {
+		// IP cameras send video alongside the audio; only audio is wanted.
+		args = append(args, "-vn")
+	}
+	copyAudio := cfg.Network != nil && cfg.Network.Copy
+	if !copyAudio {
+		args = append(args,
+			"-ar", fmt.Sprintf("%d", sampleRate),
+			"-ac", fmt.Sprintf("%d", cfg.Channels),
+		)
+	}
 
 	if cfg.ThreadQueue > 0 {
 		args = append(args, "-thread_queue_size", fmt.Sprintf("%d", cfg.ThreadQueue))
 	}
 
-	switch cfg.Codec {
-	case "opus":
-		args = append(args, "-c:a", "libopus")
-	case "aac":
-		args = append(args, "-c:a", "aac")
+	// The filter graph runs once, before the single encode, so the RTSP
+	// publish and the recorded segments carry identical processed audio.
+	// -ar/-ac above resample the filter output (loudnorm, for one, works
+	// internally at 192 kHz).
+	if cfg.AudioFilter != "" {
+		args = append(args, "-af", cfg.AudioFilter)
+	}
+
+	// A copied network stream is passed through as received.
+	if copyAudio {
+		args = append(args, "-c:a", "copy")
+	} else {
+		args = append(args, codecArgs(cfg.Codec, cfg.Bitrate, cfg.Opus)...)
 	}
 
-	args = append(args, "-b:a", cfg.Bitrate)
+	// Capture metadata applies to every output: the tee muxer copies it to
+	// both slaves. WAV segments also get a bext chunk, which the WAV muxer
+	// only writes on request.
+	bext := cfg.Timecode != nil && cfg.LocalRecordDir != "" && segmentFormat(cfg) == "wav"
+	args = append(args, c.metadataArgs(cfg, bext)...)
 
 	outputFormat := cfg.OutputFormat
 	if outputFormat == "" {
@@ -181,16 +255,20 @@ func buildFFmpegCommand(ctx context.Context, cfg *ManagerConfig) *exec.Cmd {
 		}
 	}
 
-	if cfg.LocalRecordDir != "" && outputFormat == "rtsp" {
-		segDuration := cfg.SegmentDuration
-		if segDuration <= 0 {
-			segDuration = 3600
-		}
-		segFormat := cfg.SegmentFormat
-		if segFormat == "" {
-			segFormat = "wav"
+	if cfg.RecordOnly && cfg.LocalRecordDir != "" {
+		// Record-only: the segment muxer is the sole output, so there is no
+		// tee and no RTSP publish for a recording failure to be isolated from.
+		segDuration, segPattern := segmentOutput(cfg)
+		args = append(args,
+			"-f", "segment",
+			"-segment_time", fmt.Sprintf("%d", segDuration),
+		)
+		for _, opt := range segmentOptions(cfg, c, bext) {
+			args = append(args, "-"+opt[0], opt[1])
 		}
-		segPattern := filepath.Join(cfg.LocalRecordDir, cfg.StreamName+"_%Y%m%d_%H%M%S."+segFormat)
+		args = append(args, segPattern)
+	} else if cfg.LocalRecordDir != "" && outputFormat == "rtsp" {
+		segDuration, segPattern := segmentOutput(cfg)
 
 		// onfail=ignore on the SEGMENT slave decouples local recording from the
 		// live stream: ffmpeg's tee muxer defaults to onfail=abort, so without
@@ -213,9 +291,13 @@ func buildFFmpegCommand(ctx context.Context, cfg *ManagerConfig) *exec.Cmd {
 		// the muxer's setup. Recovery for the tee's RTSP output is instead provided
 		// by the manager: the slave keeps ffmpeg's default onfail=abort, so a real
 		// publish failure exits ffmpeg and the backoff restart re-establishes it.
+		segOptions := ""
+		for _, opt := range segmentOptions(cfg, c, bext) {
+			segOptions += ":" + opt[0] + "=" + opt[1]
+		}
 		teeOutput := fmt.Sprintf(
-			"[f=rtsp:rtsp_transport=tcp]%s|[onfail=ignore:f=segment:segment_time=%d:strftime=1]%s",
-			cfg.RTSPURL, segDuration, segPattern,
+			"[f=rtsp:rtsp_transport=tcp]%s|[onfail=ignore:f=segment:segment_time=%d%s]%s",
+			cfg.RTSPURL, segDuration, segOptions, segPattern,
 		)
 		// The tee muxer does NOT perform ffmpeg's automatic stream selection the
 		// way a normal single output does, so without an explicit -map it maps no
@@ -241,6 +323,14 @@ func buildFFmpegCommand(ctx context.Context, cfg *ManagerConfig) *exec.Cmd {
 		args = append(args, cfg.RTSPURL)
 	}
 
+	// Renditions are further outputs of the same process, so the input is
+	// opened (and the card captured) once.
+	if outputFormat == "rtsp" && !cfg.RecordOnly {
+		for _, r := range cfg.Renditions {
+			args = append(args, r.outputArgs(cfg, c)...)
+		}
+	}
+
 	// Intentionally exec.Command, NOT exec.CommandContext(ctx): tying the
 	// process to the shutdown context makes os/exec send SIGKILL the instant the
 	// context is cancelled, which truncates the in-progress recording segment
@@ -254,18 +344,92 @@ func buildFFmpegCommand(ctx context.Context, cfg *ManagerConfig) *exec.Cmd {
 	_ = ctx
 	// #nosec G204 - FFmpegPath is from validated configuration, not user input
 	cmd := exec.Command(cfg.FFmpegPath, args...)
+	if cfg.LocalRecordDir != "" && cfg.SegmentNaming.UTC {
+		// strftime expands segment names (and segment_atclocktime aligns)
+		// in local time.
+		cmd.Env = append(os.Environ(), "TZ=UTC")
+	}
 
 	return cmd
 }
 
+// codecArgs returns the encoder options for codec name. PCM-family codecs
+// have a fixed bitrate, so -b:a is only passed to encoders that take one.
+func codecArgs(name, bitrate string, opus audio.OpusSettings) []string {
+	codec, ok := audio.LookupCodec(name)
+	if !ok {
+		return []string{"-b:a", bitrate}
+	}
+	args := []string{"-c:a", codec.Encoder}
+	if codec.UsesBitrate {
+		args = append(args, "-b:a", bitrate)
+	}
+	if codec.Name == "opus" {
+		args = append(args, opus.Args()...)
+	}
+	return args
+}
+
+// segmentOutput returns the segment length in seconds and the file pattern
+// for local recording, applying the defaults (1 hour, wav).
+func segmentOutput(cfg *ManagerConfig) (duration int, pattern string) {
+	duration = cfg.SegmentDuration
+	if duration <= 0 {
+		duration = 3600
+	}
+	pattern, _ = cfg.SegmentNaming.Pattern(cfg.StreamName, segmentFormat(cfg))
+	return duration, filepath.Join(cfg.LocalRecordDir, pattern)
+}
+
+// segmentOptions returns the segment muxer options that follow segment_time,
+// as name/value pairs: how the pattern is expanded (strftime, or a sequence
+// continuing from c.seq), clock alignment and, with bext, the WAV bext chunk.
+func segmentOptions(cfg *ManagerConfig, c capture, bext bool) [][2]string {
+	var opts [][2]string
+	if cfg.SegmentNaming.Sequenced() {
+		opts = append(opts, [2]string{"segment_start_number", fmt.Sprintf("%d", c.seq)})
+	} else {
+		opts = append(opts, [2]string{"strftime", "1"})
+	}
+	if cfg.SegmentAtClockTime {
+		opts = append(opts, [2]string{"segment_atclocktime", "1"})
+	}
+	if bext {
+		opts = append(opts, [2]string{"segment_format_options", "write_bext=1"})
+	}
+	return opts
+}
+
+// segmentFormat returns the recording segment container (default wav).
+func segmentFormat(cfg *ManagerConfig) string {
+	if cfg.SegmentFormat == "" {
+		return "wav"
+	}
+	return cfg.SegmentFormat
+}
+
 // validateConfig validates manager configuration.
 func validateConfig(cfg *ManagerConfig) error {
 	if cfg.DeviceName == "" {
 		return fmt.Errorf("device name cannot be empty")
 	}
-	if cfg.ALSADevice == "" {
+	if cfg.Network != nil {
+		if cfg.Network.URL == "" {
+			return fmt.Errorf("network input URL cannot be empty")
+		}
+		if cfg.AudioFilter != "" && cfg.Network.Copy {
+			return fmt.Errorf("an audio filter needs re-encoding; it cannot be used with a copied network input")
+		}
+	} else if cfg.TestSignal != nil {
+		if err := cfg.TestSignal.Validate(); err != nil {
+			return err
+		}
+	} else if cfg.ALSADevice == "" {
 		return fmt.Errorf("ALSA device cannot be empty")
 	}
+	if len(cfg.InputPorts) > 0 && cfg.InputFormat != "jack" {
+		return fmt.Errorf("input ports only apply to JACK input")
+	}
 	if cfg.StreamName == "" {
 		return fmt.Errorf("stream name cannot be empty")
 	}
@@ -275,13 +439,24 @@ func validateConfig(cfg *ManagerConfig) error {
 	if cfg.Channels <= 0 || cfg.Channels > 32 {
 		return fmt.Errorf("channels must be between 1 and 32")
 	}
-	if cfg.Bitrate == "" {
+	codec, ok := audio.LookupCodec(cfg.Codec)
+	if codec.UsesBitrate && cfg.Bitrate == "" {
 		return fmt.Errorf("bitrate cannot be empty")
 	}
-	if cfg.Codec != "opus" && cfg.Codec != "aac" {
-		return fmt.Errorf("codec must be opus or aac")
+	if !ok {
+		return audio.ValidateCodec(cfg.Codec)
 	}
-	if cfg.RTSPURL == "" {
+	if err := codec.CheckParams(cfg.SampleRate, cfg.Channels); err != nil {
+		return err
+	}
+	if !codec.RTSP && !cfg.RecordOnly {
+		return fmt.Errorf("codec %s cannot be published over RTSP; use a record-only stream", cfg.Codec)
+	}
+	if cfg.RecordOnly {
+		if cfg.LocalRecordDir == "" {
+			return fmt.Errorf("record-only stream needs a local recording directory")
+		}
+	} else if cfg.RTSPURL == "" {
 		return fmt.Errorf("RTSP URL cannot be empty")
 	}
 	if cfg.LockDir == "" {
@@ -293,5 +468,28 @@ func validateConfig(cfg *ManagerConfig) error {
 	if cfg.Backoff == nil {
 		return fmt.Errorf("backoff policy cannot be nil")
 	}
+	if cfg.LocalRecordDir != "" {
+		if err := cfg.SegmentNaming.Validate(); err != nil {
+			return fmt.Errorf("segment naming: %w", err)
+		}
+	}
+	if len(cfg.Renditions) > 0 {
+		if cfg.RecordOnly {
+			return fmt.Errorf("renditions need a published stream; a record-only stream has none")
+		}
+		if cfg.Network != nil && cfg.Network.Copy {
+			return fmt.Errorf("renditions need re-encoding; they cannot be used with a copied network input")
+		}
+		names := map[string]bool{}
+		for _, r := range cfg.Renditions {
+			if err := r.validate(cfg); err != nil {
+				return err
+			}
+			if names[r.StreamName] {
+				return fmt.Errorf("rendition %s is listed twice", r.StreamName)
+			}
+			names[r.StreamName] = true
+		}
+	}
 	return nil
 }
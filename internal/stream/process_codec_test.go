@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBuildFFmpegCommandCodecParams verifies the encoder, bitrate and sample rate
+// arguments for each codec.
+func TestBuildFFmpegCommandCodecParams(t *testing.T) {
+	tests := []struct {
+		codec       string
+		sampleRate  int
+		wantEncoder string
+		wantBitrate bool
+		wantRate    string
+	}{
+		{"opus", 48000, "libopus", true, "48000"},
+		{"opus", 44100, "libopus", true, "48000"}, // libopus cannot open at 44.1 kHz
+		{"aac", 44100, "aac", true, "44100"},
+		{"mp3", 44100, "libmp3lame", true, "44100"},
+		{"speex", 16000, "libspeex", true, "16000"},
+		{"pcm", 48000, "pcm_s16be", false, "48000"},
+		{"pcmu", 8000, "pcm_mulaw", false, "8000"},
+		{"pcma", 8000, "pcm_alaw", false, "8000"},
+		{"flac", 96000, "flac", false, "96000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.codec+"_"+tt.wantRate, func(t *testing.T) {
+			cfg := &ManagerConfig{
+				ALSADevice: "hw:0,0",
+				SampleRate: tt.sampleRate,
+				Channels:   1,
+				Bitrate:    "64k",
+				Codec:      tt.codec,
+				RTSPURL:    "rtsp://localhost:8554/test",
+			}
+			args := buildFFmpegCommand(context.Background(), cfg).Args
+
+			if i := slices.Index(args, "-c:a"); i == -1 || args[i+1] != tt.wantEncoder {
+				t.Errorf("expected -c:a %s, got: %v", tt.wantEncoder, args)
+			}
+			if got := slices.Contains(args, "-b:a"); got != tt.wantBitrate {
+				t.Errorf("-b:a present = %v, want %v: %v", got, tt.wantBitrate, args)
+			}
+			if i := slices.Index(args, "-ar"); i == -1 || args[i+1] != tt.wantRate {
+				t.Errorf("expected -ar %s, got: %v", tt.wantRate, args)
+			}
+		})
+	}
+}
+
+func TestValidateConfigCodecs(t *testing.T) {
+	base := ManagerConfig{
+		DeviceName: "test",
+		ALSADevice: "hw:0,0",
+		StreamName: "stream",
+		SampleRate: 8000,
+		Channels:   1,
+		RTSPURL:    "rtsp://localhost:8554/test",
+		LockDir:    "/tmp",
+		FFmpegPath: "/usr/bin/ffmpeg",
+		Backoff:    NewBackoff(time.Second, 10*time.Second, 5),
+	}
+
+	g711 := base
+	g711.Codec = "pcmu"
+	if err := validateConfig(&g711); err != nil {
+		t.Errorf("pcmu without bitrate: %v", err)
+	}
+
+	wide := g711
+	wide.SampleRate = 48000
+	if err := validateConfig(&wide); err == nil || !strings.Contains(err.Error(), "needs sample_rate 8000") {
+		t.Errorf("pcmu at 48 kHz: error = %v", err)
+	}
+
+	flac := base
+	flac.Codec = "flac"
+	if err := validateConfig(&flac); err == nil || !strings.Contains(err.Error(), "cannot be published over RTSP") {
+		t.Errorf("flac publishing: error = %v", err)
+	}
+	flac.RecordOnly = true
+	flac.LocalRecordDir = "/var/audio"
+	if err := validateConfig(&flac); err != nil {
+		t.Errorf("flac record-only: %v", err)
+	}
+
+	opus := base
+	opus.Codec = "opus"
+	if err := validateConfig(&opus); err == nil || err.Error() != "bitrate cannot be empty" {
+		t.Errorf("opus without bitrate: error = %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// TestBuildFFmpegCommandAudioFilter verifies that the filter graph is passed
+// once with -af, before the outputs, for every output layout.
+func TestBuildFFmpegCommandAudioFilter(t *testing.T) {
+	const graph = "highpass=f=120,volume=6dB"
+	tests := []struct {
+		name string
+		cfg  ManagerConfig
+	}{
+		{"rtsp only", ManagerConfig{}},
+		{"rtsp and recording", ManagerConfig{LocalRecordDir: "/var/audio"}},
+		{"record only", ManagerConfig{LocalRecordDir: "/var/audio", RecordOnly: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.ALSADevice = "hw:0,0"
+			cfg.StreamName = "blue_yeti"
+			cfg.SampleRate = 48000
+			cfg.Channels = 2
+			cfg.Bitrate = "128k"
+			cfg.Codec = "opus"
+			cfg.RTSPURL = "rtsp://localhost:8554/blue_yeti"
+			cfg.AudioFilter = graph
+
+			args := buildFFmpegCommand(context.Background(), &cfg).Args
+			af := slices.Index(args, "-af")
+			if af == -1 || af+1 >= len(args) || args[af+1] != graph {
+				t.Fatalf("expected -af %q, got: %v", graph, args)
+			}
+			if slices.Index(args[af+1:], "-af") != -1 {
+				t.Errorf("-af given more than once: %v", args)
+			}
+			if codec := slices.Index(args, "-c:a"); codec < af {
+				t.Errorf("-af must precede the encoder options: %v", args)
+			}
+		})
+	}
+
+	cfg := &ManagerConfig{ALSADevice: "hw:0,0", SampleRate: 48000, Channels: 1, Bitrate: "64k",
+		Codec: "opus", RTSPURL: "rtsp://localhost:8554/x"}
+	if args := buildFFmpegCommand(context.Background(), cfg).Args; slices.Contains(args, "-af") {
+		t.Errorf("no filters should mean no -af: %v", args)
+	}
+}
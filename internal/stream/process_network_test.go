@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func networkTestConfig(n *NetworkInput) *ManagerConfig {
+	return &ManagerConfig{
+		DeviceName: "gate_mic",
+		StreamName: "gate_mic",
+		SampleRate: 48000,
+		Channels:   1,
+		Bitrate:    "64k",
+		Codec:      "opus",
+		RTSPURL:    "rtsp://localhost:8554/gate_mic",
+		Network:    n,
+	}
+}
+
+// TestBuildFFmpegCommandNetworkInput verifies the per-protocol input options:
+// each precedes -i, and every protocol gets an I/O timeout so a silent
+// source makes FFmpeg exit instead of hanging.
+func TestBuildFFmpegCommandNetworkInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       NetworkInput
+		wantOpts []string
+	}{
+		{"rtsp default", NetworkInput{URL: "rtsp://cam/audio"},
+			[]string{"-rtsp_transport", "tcp", "-timeout", "10000000"}},
+		{"rtsp udp", NetworkInput{URL: "rtsp://cam/audio", RTSPTransport: "udp", Timeout: 3 * time.Second},
+			[]string{"-rtsp_transport", "udp", "-timeout", "3000000"}},
+		{"http reconnect", NetworkInput{URL: "https://radio/live.ogg", ReconnectDelayMax: 30 * time.Second},
+			[]string{"-rw_timeout", "10000000", "-reconnect", "1", "-reconnect_streamed", "1",
+				"-reconnect_on_network_error", "1", "-reconnect_delay_max", "30"}},
+		{"srt", NetworkInput{URL: "srt://relay:9000"}, []string{"-rw_timeout", "10000000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := buildFFmpegCommand(context.Background(), networkTestConfig(&tt.in)).Args[1:]
+			i := slices.Index(args, "-i")
+			if i == -1 || args[i+1] != tt.in.URL {
+				t.Fatalf("expected -i %s, got: %v", tt.in.URL, args)
+			}
+			if !slices.Equal(args[:i], tt.wantOpts) {
+				t.Errorf("input options = %v, want %v", args[:i], tt.wantOpts)
+			}
+			if args[i+2] != "-vn" {
+				t.Errorf("expected -vn after the input, got: %v", args)
+			}
+			if !slices.Contains(args, "libopus") || !slices.Contains(args, "-ar") {
+				t.Errorf("expected a re-encode, got: %v", args)
+			}
+		})
+	}
+}
+
+func TestBuildFFmpegCommandNetworkCopy(t *testing.T) {
+	cfg := networkTestConfig(&NetworkInput{URL: "rtsp://cam/audio", Copy: true})
+	cfg.LocalRecordDir = "/var/audio"
+	args := buildFFmpegCommand(context.Background(), cfg).Args
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "-c:a copy") {
+		t.Errorf("expected -c:a copy, got: %v", args)
+	}
+	for _, opt := range []string{"-ar", "-ac", "-b:a", "libopus"} {
+		if slices.Contains(args, opt) {
+			t.Errorf("copied stream should not get %s: %v", opt, args)
+		}
+	}
+	if !slices.Contains(args, "tee") {
+		t.Errorf("expected the tee output for publish plus recording, got: %v", args)
+	}
+}
+
+func TestValidateConfigNetworkInput(t *testing.T) {
+	cfg := networkTestConfig(&NetworkInput{URL: "rtsp://cam/audio", Copy: true})
+	cfg.AudioFilter = "volume=6dB"
+	if err := validateConfig(cfg); err == nil || !strings.Contains(err.Error(), "cannot be used with a copied network input") {
+		t.Errorf("validateConfig() = %v, want filter/copy error", err)
+	}
+	cfg.Network = &NetworkInput{}
+	if err := validateConfig(cfg); err == nil || !strings.Contains(err.Error(), "network input URL cannot be empty") {
+		t.Errorf("validateConfig() = %v, want empty URL error", err)
+	}
+	// A network input needs no ALSA device.
+	cfg.Network = &NetworkInput{URL: "rtsp://cam/audio"}
+	cfg.AudioFilter = ""
+	if err := validateConfig(cfg); err != nil && strings.Contains(err.Error(), "ALSA device") {
+		t.Errorf("validateConfig() = %v, want no ALSA device error", err)
+	}
+}
@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+)
+
+// TestBuildFFmpegCommandOpusSettings verifies that libopus options follow the
+// encoder and are dropped for other codecs.
+func TestBuildFFmpegCommandOpusSettings(t *testing.T) {
+	cfg := ManagerConfig{
+		ALSADevice: "hw:0,0",
+		StreamName: "radio_link",
+		SampleRate: 48000,
+		Channels:   1,
+		Bitrate:    "24k",
+		Codec:      "opus",
+		RTSPURL:    "rtsp://localhost:8554/radio_link",
+		Opus:       audio.OpusSettings{Application: "voip", PacketLoss: 15, FEC: true},
+	}
+
+	args := buildFFmpegCommand(context.Background(), &cfg).Args
+	enc := slices.Index(args, "libopus")
+	app := slices.Index(args, "-application")
+	if enc == -1 || app < enc || args[app+1] != "voip" {
+		t.Fatalf("expected -application voip after -c:a libopus, got: %v", args)
+	}
+	if i := slices.Index(args, "-fec"); i == -1 || args[i+1] != "1" {
+		t.Errorf("expected -fec 1, got: %v", args)
+	}
+	if i := slices.Index(args, "-packet_loss"); i == -1 || args[i+1] != "15" {
+		t.Errorf("expected -packet_loss 15, got: %v", args)
+	}
+
+	cfg.Codec = "aac"
+	if args := buildFFmpegCommand(context.Background(), &cfg).Args; slices.Contains(args, "-application") {
+		t.Errorf("opus options passed to aac: %v", args)
+	}
+}
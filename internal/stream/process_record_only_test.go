@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestBuildFFmpegCommandRecordOnly verifies a record-only stream writes
+// segments directly, with no tee and no RTSP output.
+func TestBuildFFmpegCommandRecordOnly(t *testing.T) {
+	cfg := &ManagerConfig{
+		ALSADevice:      "hw:0,0",
+		SampleRate:      48000,
+		Channels:        2,
+		Bitrate:         "128k",
+		Codec:           "opus",
+		RTSPURL:         "rtsp://localhost:8554/mic1",
+		LocalRecordDir:  "/var/audio",
+		StreamName:      "mic1",
+		SegmentDuration: 600,
+		SegmentFormat:   "ogg",
+		RecordOnly:      true,
+	}
+
+	args := strings.Join(buildFFmpegCommand(context.Background(), cfg).Args, " ")
+	want := "-f segment -segment_time 600 -strftime 1 /var/audio/mic1_%Y%m%d_%H%M%S.ogg"
+	if !strings.HasSuffix(args, want) {
+		t.Errorf("args = %s\nwant suffix %s", args, want)
+	}
+	for _, unwanted := range []string{"tee", "rtsp://", "-rtsp_transport"} {
+		if strings.Contains(args, unwanted) {
+			t.Errorf("record-only args contain %q: %s", unwanted, args)
+		}
+	}
+}
+
+func TestValidateConfigRecordOnly(t *testing.T) {
+	cfg := &ManagerConfig{
+		DeviceName: "mic1",
+		ALSADevice: "hw:0,0",
+		StreamName: "mic1",
+		SampleRate: 48000,
+		Channels:   2,
+		Bitrate:    "128k",
+		Codec:      "opus",
+		LockDir:    t.TempDir(),
+		FFmpegPath: "/usr/bin/ffmpeg",
+		Backoff:    NewBackoff(0, 0, 1),
+		RecordOnly: true,
+	}
+	if err := validateConfig(cfg); err == nil || !strings.Contains(err.Error(), "local recording directory") {
+		t.Errorf("validateConfig() = %v, want missing directory error", err)
+	}
+	cfg.LocalRecordDir = t.TempDir()
+	if err := validateConfig(cfg); err != nil {
+		t.Errorf("validateConfig() without RTSP URL = %v, want nil for record-only", err)
+	}
+}
+
+type downUpstream struct{}
+
+func (downUpstream) Ready() bool                         { return false }
+func (downUpstream) WaitReady(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }
+
+// TestRecordOnlyIgnoresUpstream verifies a record-only stream is not held
+// while the RTSP server is down.
+func TestRecordOnlyIgnoresUpstream(t *testing.T) {
+	m := &Manager{cfg: &ManagerConfig{Upstream: downUpstream{}, RecordOnly: true}}
+	if m.upstreamDown() || m.upstreamDownAfterFailure(context.Background()) {
+		t.Error("record-only stream treated the RTSP server as a dependency")
+	}
+	m.cfg.RecordOnly = false
+	if !m.upstreamDown() {
+		t.Error("publishing stream must wait for the RTSP server")
+	}
+}
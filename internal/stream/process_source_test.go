@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestBuildFFmpegCommandSoundServerInput verifies the input options for
+// pulse and JACK sources: they precede -i, and -i takes the source name.
+func TestBuildFFmpegCommandSoundServerInput(t *testing.T) {
+	tests := []struct {
+		format, device string
+		wantOpts       []string
+	}{
+		{"pulse", "alsa_input.usb-BLUE_Yeti-00.analog-stereo", []string{"-f", "pulse", "-name", "lyrebird", "-stream_name", "desk_mic"}},
+		{"jack", "lyrebird_desk_mic", []string{"-f", "jack", "-channels", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			cfg := ManagerConfig{
+				ALSADevice:  tt.device,
+				InputFormat: tt.format,
+				StreamName:  "desk_mic",
+				SampleRate:  48000,
+				Channels:    2,
+				Bitrate:     "128k",
+				Codec:       "opus",
+				RTSPURL:     "rtsp://localhost:8554/desk_mic",
+			}
+			args := buildFFmpegCommand(context.Background(), &cfg).Args[1:]
+			i := slices.Index(args, "-i")
+			if i == -1 || args[i+1] != tt.device {
+				t.Fatalf("expected -i %s, got: %v", tt.device, args)
+			}
+			if !slices.Equal(args[:len(tt.wantOpts)], tt.wantOpts) || i != len(tt.wantOpts) {
+				t.Errorf("input options = %v, want %v before -i", args[:i], tt.wantOpts)
+			}
+		})
+	}
+}
+
+func TestValidateConfigInputPorts(t *testing.T) {
+	cfg := &ManagerConfig{
+		DeviceName: "desk_mic",
+		ALSADevice: "hw:0,0",
+		StreamName: "desk_mic",
+		SampleRate: 48000,
+		Channels:   2,
+		Bitrate:    "128k",
+		Codec:      "opus",
+		InputPorts: []string{"system:capture_1"},
+	}
+	if err := validateConfig(cfg); err == nil || !strings.Contains(err.Error(), "input ports only apply to JACK") {
+		t.Errorf("validateConfig() = %v, want JACK-only error", err)
+	}
+}
+
+// TestConnectJACKPorts verifies that each configured port is connected to
+// FFmpeg's input port of the same position, retrying until FFmpeg has
+// registered its ports.
+func TestConnectJACKPorts(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "connections")
+	failOnce := filepath.Join(dir, "failed")
+	// The first call fails, as when FFmpeg has not registered its ports yet.
+	script := "#!/bin/sh\n" +
+		"if [ ! -e " + failOnce + " ]; then touch " + failOnce + "; echo 'cannot connect' >&2; exit 1; fi\n" +
+		"echo \"$1 $2\" >> " + logPath + "\n"
+	scriptPath := filepath.Join(dir, "jack_connect")
+	if err := os.WriteFile(scriptPath, []byte(script), 0755); err != nil {
+		t.Fatalf("write stub: %v", err)
+	}
+	orig := jackConnectPath
+	jackConnectPath = scriptPath
+	t.Cleanup(func() { jackConnectPath = orig })
+
+	m := &Manager{cfg: &ManagerConfig{
+		ALSADevice:  "lyrebird_bus",
+		InputFormat: "jack",
+		InputPorts:  []string{"system:capture_1", "system:capture_2"},
+		Logger:      slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})),
+	}}
+	m.connectJACKPorts(context.Background())
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("read connections: %v", err)
+	}
+	want := "system:capture_1 lyrebird_bus:input_1\nsystem:capture_2 lyrebird_bus:input_2\n"
+	if string(data) != want {
+		t.Errorf("connections = %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"fmt"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+)
+
+// Rendition is an additional encoding of a stream's capture, published to
+// its own RTSP URL by the same FFmpeg process. The input is opened once and
+// each rendition is a further FFmpeg output with its own encoder, so a
+// device can serve, say, 256 kbps stereo and 24 kbps mono without opening
+// the ALSA card twice.
+//
+// All outputs share one process: a rendition whose publish fails exits
+// FFmpeg like a failure of the main output does, and the manager's restart
+// brings every output back together.
+type Rendition struct {
+	StreamName string             // MediaMTX path (e.g. "blue_yeti_lo")
+	RTSPURL    string             // Full RTSP URL to publish to
+	SampleRate int                // Sample rate in Hz
+	Channels   int                // Number of channels
+	Bitrate    string             // Bitrate (e.g., "24k")
+	Codec      string             // Codec name from audio.CodecNames (e.g. "opus")
+	Opus       audio.OpusSettings // libopus encoder options, used when Codec is "opus"
+}
+
+// outputArgs returns the FFmpeg options and URL of the rendition's output,
+// which follow the main output. The audio filter and capture metadata of
+// the stream apply to every rendition.
+func (r Rendition) outputArgs(cfg *ManagerConfig, c capture) []string {
+	sampleRate := r.SampleRate
+	if codec, ok := audio.LookupCodec(r.Codec); ok {
+		sampleRate = codec.EncodeRate(sampleRate)
+	}
+	args := []string{"-map", "0:a"}
+	if cfg.AudioFilter != "" {
+		args = append(args, "-af", cfg.AudioFilter)
+	}
+	args = append(args,
+		"-ar", fmt.Sprintf("%d", sampleRate),
+		"-ac", fmt.Sprintf("%d", r.Channels),
+	)
+	args = append(args, codecArgs(r.Codec, r.Bitrate, r.Opus)...)
+
+	// The metadata title names the rendition's own path.
+	rc := *cfg
+	rc.StreamName = r.StreamName
+	args = append(args, c.metadataArgs(&rc, false)...)
+
+	// Publish over TCP for lossless, in-order RTP delivery to MediaMTX (see
+	// the tee branch of buildCaptureCommand).
+	return append(args, "-rtsp_transport", "tcp", "-f", "rtsp", r.RTSPURL)
+}
+
+// validate checks the rendition's own settings; cfg is the stream it
+// belongs to.
+func (r Rendition) validate(cfg *ManagerConfig) error {
+	if r.StreamName == "" || r.StreamName == cfg.StreamName {
+		return fmt.Errorf("rendition stream name must be set and differ from the stream's (%q)", cfg.StreamName)
+	}
+	if r.RTSPURL == "" {
+		return fmt.Errorf("rendition %s: RTSP URL cannot be empty", r.StreamName)
+	}
+	if r.SampleRate <= 0 {
+		return fmt.Errorf("rendition %s: sample rate must be positive", r.StreamName)
+	}
+	if r.Channels <= 0 || r.Channels > 32 {
+		return fmt.Errorf("rendition %s: channels must be between 1 and 32", r.StreamName)
+	}
+	codec, ok := audio.LookupCodec(r.Codec)
+	if !ok {
+		return fmt.Errorf("rendition %s: %w", r.StreamName, audio.ValidateCodec(r.Codec))
+	}
+	if codec.UsesBitrate && r.Bitrate == "" {
+		return fmt.Errorf("rendition %s: bitrate cannot be empty", r.StreamName)
+	}
+	if err := codec.CheckParams(r.SampleRate, r.Channels); err != nil {
+		return fmt.Errorf("rendition %s: %w", r.StreamName, err)
+	}
+	if !codec.RTSP {
+		return fmt.Errorf("rendition %s: codec %s cannot be published over RTSP", r.StreamName, r.Codec)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/audio"
+)
+
+func renditionTestConfig() *ManagerConfig {
+	cfg := timecodeTestConfig()
+	cfg.AudioFilter = "highpass=f=80"
+	cfg.Renditions = []Rendition{{
+		StreamName: "blue_yeti_lo",
+		RTSPURL:    "rtsp://localhost:8554/blue_yeti_lo",
+		SampleRate: 48000,
+		Channels:   1,
+		Bitrate:    "24k",
+		Codec:      "opus",
+		Opus:       audio.OpusSettings{Application: "voip"},
+	}}
+	return cfg
+}
+
+// TestBuildFFmpegCommandRenditions verifies that a rendition is a further
+// output of the same command, after the main one, with its own encoder.
+func TestBuildFFmpegCommandRenditions(t *testing.T) {
+	cfg := renditionTestConfig()
+	args := buildCaptureCommand(context.Background(), cfg, capture{start: time.Now().UTC(), clock: ClockUnchecked}).Args
+
+	if n := strings.Count(strings.Join(args, " "), " -i "); n != 1 {
+		t.Fatalf("expected one input, got %d: %v", n, args)
+	}
+	main := slices.Index(args, cfg.RTSPURL)
+	if main == -1 {
+		t.Fatalf("main output missing: %v", args)
+	}
+	lo := args[main+1:]
+	want := []string{
+		"-map", "0:a", "-af", "highpass=f=80", "-ar", "48000", "-ac", "1",
+		"-c:a", "libopus", "-b:a", "24k", "-application", "voip",
+	}
+	if !slices.Equal(lo[:len(want)], want) {
+		t.Errorf("rendition options = %v, want prefix %v", lo, want)
+	}
+	if !slices.Equal(lo[len(lo)-5:], []string{"-rtsp_transport", "tcp", "-f", "rtsp", "rtsp://localhost:8554/blue_yeti_lo"}) {
+		t.Errorf("rendition output = %v", lo)
+	}
+	if title := metadata(lo)["title"]; !strings.HasPrefix(title, "lyrebird blue_yeti_lo ") {
+		t.Errorf("rendition title = %q, want it to name the rendition", title)
+	}
+	if title := metadata(args[:main])["title"]; !strings.HasPrefix(title, "lyrebird blue_yeti ") {
+		t.Errorf("main title = %q", title)
+	}
+
+	// Alongside the tee, the rendition follows the tee output.
+	cfg.LocalRecordDir = "/var/audio"
+	args = buildFFmpegCommand(context.Background(), cfg).Args
+	if i := slices.Index(args, "tee"); i == -1 || args[i+2] != "-map" {
+		t.Errorf("expected the rendition right after the tee output: %v", args)
+	}
+
+	// A record-only stream publishes nothing, renditions included.
+	cfg.RecordOnly = true
+	args = buildFFmpegCommand(context.Background(), cfg).Args
+	if slices.Contains(args, "rtsp://localhost:8554/blue_yeti_lo") {
+		t.Errorf("record-only stream should not publish renditions: %v", args)
+	}
+}
+
+func TestValidateConfigRenditions(t *testing.T) {
+	valid := func() *ManagerConfig {
+		cfg := renditionTestConfig()
+		cfg.LockDir = "/tmp"
+		cfg.FFmpegPath = "/usr/bin/ffmpeg"
+		cfg.Backoff = NewBackoff(time.Second, 10*time.Second, 5)
+		return cfg
+	}
+	if err := validateConfig(valid()); err != nil {
+		t.Fatalf("validateConfig: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*ManagerConfig)
+		want   string
+	}{
+		{"same name as the stream", func(c *ManagerConfig) { c.Renditions[0].StreamName = c.StreamName }, "differ from the stream's"},
+		{"listed twice", func(c *ManagerConfig) { c.Renditions = append(c.Renditions, c.Renditions[0]) }, "listed twice"},
+		{"no URL", func(c *ManagerConfig) { c.Renditions[0].RTSPURL = "" }, "RTSP URL cannot be empty"},
+		{"no bitrate", func(c *ManagerConfig) { c.Renditions[0].Bitrate = "" }, "bitrate cannot be empty"},
+		{"bad rate", func(c *ManagerConfig) { c.Renditions[0].Codec = "pcmu" }, "needs sample_rate 8000"},
+		{"not RTSP", func(c *ManagerConfig) { c.Renditions[0].Codec = "flac" }, "cannot be published over RTSP"},
+		{"unknown codec", func(c *ManagerConfig) { c.Renditions[0].Codec = "vorbis" }, "codec must be one of"},
+		{"record-only", func(c *ManagerConfig) { c.RecordOnly, c.LocalRecordDir = true, "/var/audio" }, "need a published stream"},
+		{"copied network input", func(c *ManagerConfig) {
+			c.Network, c.AudioFilter = &NetworkInput{URL: "rtsp://cam/audio", Copy: true}, ""
+		}, "cannot be used with a copied network input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(cfg)
+			if err := validateConfig(cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("validateConfig = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
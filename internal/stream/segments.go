@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// segmentClock is the clock the first segment of a start is named from; a
+// variable so tests can stub it.
+var segmentClock = time.Now
+
+// maxSegmentNameWaits bounds awaitFreeSegmentName: one wait moves to a new
+// second, so more are only needed when the clock steps backwards.
+const maxSegmentNameWaits = 3
+
+// Per-day subdirectories are created ahead of time, since FFmpeg's segment
+// muxer does not create directories: every dayDirInterval, the directories
+// for now and dayDirLookahead from now are made.
+const (
+	dayDirInterval  = 10 * time.Minute
+	dayDirLookahead = time.Hour
+)
+
+// segmentName returns the file the segment muxer creates for a
+// strftime-named segment starting at t (see segmentOutput).
+func segmentName(cfg *ManagerConfig, t time.Time) string {
+	return filepath.Join(cfg.LocalRecordDir,
+		cfg.SegmentNaming.Path(cfg.StreamName, segmentFormat(cfg), t, 0))
+}
+
+// awaitFreeSegmentName delays a start whose first segment would be named
+// after a second that already has a segment. Segment names have one-second
+// resolution and the segment muxer truncates an existing file, so a restart
+// within the second the previous run started a segment in would otherwise
+// overwrite that segment. Sequenced names continue from the highest existing
+// number instead (see segment.Naming.NextSeq). It returns ctx.Err() if ctx
+// ends while waiting.
+func (m *Manager) awaitFreeSegmentName(ctx context.Context) error {
+	if m.cfg.SegmentNaming.Sequenced() {
+		return nil
+	}
+	for range maxSegmentNameWaits {
+		now := segmentClock()
+		name := segmentName(m.cfg, now)
+		if _, err := os.Stat(name); err != nil {
+			return nil
+		}
+		if m.cfg.Logger != nil {
+			m.cfg.Logger.Debug("segment for this second exists, delaying start", "segment", name)
+		}
+		timer := time.NewTimer(now.Truncate(time.Second).Add(time.Second).Sub(now))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// makeDayDirs creates the per-day subdirectories for now and for
+// dayDirLookahead from now.
+func (m *Manager) makeDayDirs() {
+	now := segmentClock()
+	for _, t := range []time.Time{now, now.Add(dayDirLookahead)} {
+		dir := filepath.Join(m.cfg.LocalRecordDir, m.cfg.SegmentNaming.DayDir(t))
+		if err := os.MkdirAll(dir, 0750); err != nil && m.cfg.Logger != nil {
+			m.cfg.Logger.Warn("failed to create day directory for recording segments", "dir", dir, "error", err)
+		}
+	}
+}
+
+// runDayDirs keeps the per-day subdirectories ahead of FFmpeg until ctx ends.
+func (m *Manager) runDayDirs(ctx context.Context) {
+	ticker := time.NewTicker(dayDirInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.makeDayDirs()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomtom215/lyrebirdaudio-go/internal/segment"
+)
+
+func TestSegmentName(t *testing.T) {
+	cfg := &ManagerConfig{StreamName: "blue_yeti", LocalRecordDir: "/var/audio", SegmentFormat: "ogg"}
+	at := time.Date(2026, 3, 1, 14, 5, 9, 500e6, time.Local)
+	if got, want := segmentName(cfg, at), "/var/audio/blue_yeti_20260301_140509.ogg"; got != want {
+		t.Errorf("segmentName = %q, want %q", got, want)
+	}
+}
+
+// TestAwaitFreeSegmentName verifies that a start in a second that already
+// has a segment waits for the next second instead of overwriting it.
+func TestAwaitFreeSegmentName(t *testing.T) {
+	orig := segmentClock
+	t.Cleanup(func() { segmentClock = orig })
+
+	dir := t.TempDir()
+	cfg := &ManagerConfig{StreamName: "blue_yeti", LocalRecordDir: dir}
+	m := &Manager{cfg: cfg}
+	taken := time.Date(2026, 3, 1, 14, 5, 9, 990e6, time.Local)
+	if err := os.WriteFile(segmentName(cfg, taken), []byte("audio"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls int
+	segmentClock = func() time.Time {
+		calls++
+		if calls == 1 {
+			return taken
+		}
+		return taken.Add(10 * time.Millisecond) // the next second
+	}
+	if err := m.awaitFreeSegmentName(context.Background()); err != nil {
+		t.Fatalf("awaitFreeSegmentName: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("clock read %d times, want 2 (one wait)", calls)
+	}
+
+	// A free second starts at once.
+	calls = 1
+	if err := m.awaitFreeSegmentName(context.Background()); err != nil || calls != 2 {
+		t.Errorf("free second: err = %v, clock reads = %d, want no wait", err, calls-1)
+	}
+
+	// A cancelled start does not wait.
+	segmentClock = func() time.Time { return taken.Add(-900 * time.Millisecond) }
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.awaitFreeSegmentName(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: err = %v, want context.Canceled", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 || filepath.Ext(entries[0].Name()) != ".wav" {
+		t.Errorf("recording directory = %v, want the one wav segment untouched", entries)
+	}
+}
+
+// TestSegmentNamingArgs verifies that the naming, sequence and clock
+// alignment reach both the tee and the record-only segment output.
+func TestSegmentNamingArgs(t *testing.T) {
+	cfg := timecodeTestConfig()
+	cfg.Timecode = nil
+	cfg.LocalRecordDir = "/var/audio"
+	cfg.SegmentFormat = "ogg"
+	cfg.SegmentDuration = 900
+	cfg.SegmentAtClockTime = true
+	cfg.SegmentNaming = segment.Naming{Template: "{station}_{stream}_{iso}", Station: "north", UTC: true, Daily: true}
+
+	cmd := buildCaptureCommand(context.Background(), cfg, capture{})
+	tee := cmd.Args[len(cmd.Args)-1]
+	want := "[onfail=ignore:f=segment:segment_time=900:strftime=1:segment_atclocktime=1]" +
+		"/var/audio/%Y-%m-%d/north_blue_yeti_%Y%m%dT%H%M%SZ.ogg"
+	if !strings.HasSuffix(tee, want) {
+		t.Errorf("tee output = %q, want suffix %q", tee, want)
+	}
+	if !slices.Contains(cmd.Env, "TZ=UTC") {
+		t.Error("a UTC naming should run FFmpeg with TZ=UTC")
+	}
+
+	cfg.RecordOnly = true
+	cfg.SegmentAtClockTime = false
+	cfg.SegmentNaming = segment.Naming{Template: "{stream}_{seq}"}
+	cmd = buildCaptureCommand(context.Background(), cfg, capture{seq: 12})
+	if i := slices.Index(cmd.Args, "-segment_start_number"); i == -1 || cmd.Args[i+1] != "12" {
+		t.Errorf("expected -segment_start_number 12, got: %v", cmd.Args)
+	}
+	if slices.Contains(cmd.Args, "-strftime") || slices.Contains(cmd.Args, "-segment_atclocktime") {
+		t.Errorf("sequenced, unaligned output should have neither -strftime nor -segment_atclocktime: %v", cmd.Args)
+	}
+	if got := cmd.Args[len(cmd.Args)-1]; got != "/var/audio/blue_yeti_%06d.ogg" {
+		t.Errorf("pattern = %q", got)
+	}
+	if cmd.Env != nil {
+		t.Error("a local-time naming should inherit the environment")
+	}
+}
+
+func TestMakeDayDirs(t *testing.T) {
+	orig := segmentClock
+	t.Cleanup(func() { segmentClock = orig })
+	segmentClock = func() time.Time { return time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC) }
+
+	dir := t.TempDir()
+	m := &Manager{cfg: &ManagerConfig{LocalRecordDir: dir, SegmentNaming: segment.Naming{UTC: true, Daily: true}}}
+	m.makeDayDirs()
+	for _, day := range []string{"2026-03-01", "2026-03-02"} {
+		if info, err := os.Stat(filepath.Join(dir, day)); err != nil || !info.IsDir() {
+			t.Errorf("day directory %s not created: %v", day, err)
+		}
+	}
+}
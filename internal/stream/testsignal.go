@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Test signal kinds (TestSignal.Kind).
+const (
+	TestSignalTone  = "tone"  // continuous sine
+	TestSignalSweep = "sweep" // linear chirp, repeated every period
+	TestSignalBeep  = "beep"  // half-second beep every period
+)
+
+// Test signal defaults for zero fields.
+const (
+	defaultTestFrequency    = 1000.0
+	defaultTestSweepStart   = 20.0
+	defaultTestSweepEnd     = 20000.0
+	defaultTestPeriod       = 10 * time.Second
+	defaultTestLevelDB      = -20.0
+	dtmfDigitSeconds        = 0.2  // one DTMF digit slot
+	dtmfToneSeconds         = 0.12 // tone within a slot; the rest is the inter-digit gap
+	dtmfIdentDigits         = 6    // HHMMSS
+	dtmfIdentGuardSeconds   = 0.2  // silence between the ident and the signal
+	testBeepDurationSeconds = 0.5
+)
+
+// TestSignal generates a stream's input with FFmpeg's lavfi aevalsrc instead
+// of capturing a device, so MediaMTX, the network path and local recording
+// can be verified on a box with no microphone attached.
+//
+// With Ident "dtmf", every period starts with the UTC time of day it began,
+// as six DTMF digits (HHMMSS; 120 ms tones, 80 ms gaps). A DTMF decoder run
+// on a recording or a client's playback gives the wall-clock time of that
+// point, which measures end-to-end latency and shows gaps.
+type TestSignal struct {
+	Kind         string        // tone (default), sweep or beep
+	Frequency    float64       // tone/beep: Hz (default 1000); sweep: start Hz (default 20)
+	EndFrequency float64       // sweep: end Hz (default 20000, capped below half the sample rate)
+	Period       time.Duration // sweep length, beep interval and ident interval (default 10s)
+	LevelDB      float64       // Peak level in dBFS (default -20)
+	Ident        string        // "dtmf" to start each period with the time as DTMF digits; "" for none
+}
+
+// Validate checks the signal kind, ident and that the numbers are usable.
+// Zero fields take their defaults.
+func (s *TestSignal) Validate() error {
+	switch s.Kind {
+	case "", TestSignalTone, TestSignalSweep, TestSignalBeep:
+	default:
+		return fmt.Errorf("test signal must be tone, sweep or beep (got %q)", s.Kind)
+	}
+	if s.Ident != "" && s.Ident != "dtmf" {
+		return fmt.Errorf("test signal ident must be dtmf or empty (got %q)", s.Ident)
+	}
+	if s.Frequency < 0 || s.EndFrequency < 0 {
+		return fmt.Errorf("test signal frequencies must not be negative")
+	}
+	if s.LevelDB > 0 {
+		return fmt.Errorf("test signal level must be at most 0 dBFS (got %g)", s.LevelDB)
+	}
+	if s.Period < 0 {
+		return fmt.Errorf("test signal period must not be negative (got %s)", s.Period)
+	}
+	if s.Ident == "dtmf" && s.Period > 0 && s.Period < 2*time.Second {
+		return fmt.Errorf("test signal period must be at least 2s to fit the DTMF ident (got %s)", s.Period)
+	}
+	return nil
+}
+
+// graph returns the lavfi filter graph generating the signal at sampleRate.
+// start is when FFmpeg starts; the ident is computed from it, so the graph
+// must be built afresh for every start.
+//
+// The whole signal is one aevalsrc expression, single-quoted so the graph
+// parser keeps its commas and semicolons. st()/ld() hold intermediate
+// values: 0 = time within the period, 1 = time since the signal part began,
+// and 2-5 = the ident's time of day, HHMMSS number, digit slot and digit.
+func (s *TestSignal) graph(sampleRate int, start time.Time) string {
+	period := s.Period
+	if period <= 0 {
+		period = defaultTestPeriod
+	}
+	p := period.Seconds()
+	level := s.LevelDB
+	if level == 0 {
+		level = defaultTestLevelDB
+	}
+	amp := math.Pow(10, level/20)
+
+	// The signal part starts after the ident and its guard.
+	offset := 0.0
+	if s.Ident == "dtmf" {
+		offset = dtmfIdentDigits*dtmfDigitSeconds + dtmfIdentGuardSeconds
+	}
+
+	var base string
+	switch s.Kind {
+	case TestSignalSweep:
+		f0 := s.Frequency
+		if f0 == 0 {
+			f0 = defaultTestSweepStart
+		}
+		f1 := s.EndFrequency
+		if f1 == 0 {
+			f1 = defaultTestSweepEnd
+		}
+		f1 = math.Min(f1, float64(sampleRate)*0.45)
+		// Linear chirp from f0 to f1 over the rest of the period.
+		d := p - offset
+		base = fmt.Sprintf("gte(ld(1),0)*sin(2*PI*(%s*ld(1)+%s*ld(1)*ld(1)))",
+			num(f0), num((f1-f0)/(2*d)))
+	case TestSignalBeep:
+		f := s.Frequency
+		if f == 0 {
+			f = defaultTestFrequency
+		}
+		base = fmt.Sprintf("between(ld(1),0,%s)*sin(2*PI*%s*t)", num(testBeepDurationSeconds), num(f))
+	default:
+		f := s.Frequency
+		if f == 0 {
+			f = defaultTestFrequency
+		}
+		base = fmt.Sprintf("gte(ld(1),0)*sin(2*PI*%s*t)", num(f))
+	}
+
+	expr := fmt.Sprintf("st(0,mod(t,%s));st(1,ld(0)-%s);", num(p), num(offset))
+	signal := base
+	if s.Ident == "dtmf" {
+		// Seconds of the UTC day at which the current period began.
+		utc := start.UTC()
+		midnight := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+		dayStart := utc.Sub(midnight).Seconds()
+		expr += fmt.Sprintf("st(2,mod(%s+t-ld(0),86400));", num(dayStart)) +
+			"st(3,floor(ld(2)/3600)*10000+floor(mod(ld(2),3600)/60)*100+floor(mod(ld(2),60)));" +
+			fmt.Sprintf("st(4,floor(ld(0)/%s));", num(dtmfDigitSeconds)) +
+			"st(5,mod(floor(ld(3)/pow(10,5-ld(4))),10));"
+		// Digit d plays its keypad row and column tones; 0 is row 4,
+		// column 2.
+		row := "if(eq(ld(5),0),941,if(lt(ld(5),4),697,if(lt(ld(5),7),770,852)))"
+		col := "if(eq(ld(5),0),1336,if(eq(mod(ld(5),3),1),1209,if(eq(mod(ld(5),3),2),1336,1477)))"
+		dtmf := fmt.Sprintf("lt(ld(4),%d)*lt(ld(0)-ld(4)*%s,%s)*0.5*(sin(2*PI*%s*t)+sin(2*PI*%s*t))",
+			dtmfIdentDigits, num(dtmfDigitSeconds), num(dtmfToneSeconds), row, col)
+		signal = base + "+" + dtmf
+	}
+	expr += fmt.Sprintf("%s*(%s)", num(amp), signal)
+
+	return fmt.Sprintf("aevalsrc=exprs='%s':s=%d", expr, sampleRate)
+}
+
+// num formats v for an FFmpeg expression: no exponent, at most six decimals.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+}
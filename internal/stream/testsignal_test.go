@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTestSignalGraph pins the generated aevalsrc expressions. The graph is
+// parsed twice by FFmpeg (filter chain, then filter options), so the
+// expression must be single-quoted and free of ':' and '|', which would
+// split the options or add channels.
+func TestTestSignalGraph(t *testing.T) {
+	start := time.Date(2026, 3, 1, 14, 5, 9, 0, time.UTC)
+	tests := []struct {
+		name string
+		sig  TestSignal
+		rate int
+		want []string
+	}{
+		{"tone defaults", TestSignal{}, 48000,
+			[]string{"st(0,mod(t,10));st(1,ld(0)-0);", "0.1*(gte(ld(1),0)*sin(2*PI*1000*t))"}},
+		{"beep", TestSignal{Kind: TestSignalBeep, Frequency: 440, Period: 5 * time.Second, LevelDB: -6}, 48000,
+			[]string{"mod(t,5)", "0.501187*(between(ld(1),0,0.5)*sin(2*PI*440*t))"}},
+		// 20 Hz to 20 kHz over 10s: f(t) = 20 + 2*999*t.
+		{"sweep", TestSignal{Kind: TestSignalSweep}, 48000,
+			[]string{"sin(2*PI*(20*ld(1)+999*ld(1)*ld(1)))"}},
+		// Capped at 0.45 of 16 kHz: (7200-20)/20 = 359.
+		{"sweep capped", TestSignal{Kind: TestSignalSweep, EndFrequency: 20000}, 16000,
+			[]string{"(20*ld(1)+359*ld(1)*ld(1))"}},
+		// 14:05:09 is 50709s into the day.
+		{"dtmf ident", TestSignal{Ident: "dtmf"}, 48000,
+			[]string{"st(1,ld(0)-1.4);", "st(2,mod(50709+t-ld(0),86400));", "lt(ld(4),6)*lt(ld(0)-ld(4)*0.2,0.12)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := tt.sig.graph(tt.rate, start)
+			prefix, suffix := "aevalsrc=exprs='", "':s="
+			if !strings.HasPrefix(g, prefix) || !strings.Contains(g, suffix) {
+				t.Fatalf("unexpected graph shape: %s", g)
+			}
+			expr := g[len(prefix):strings.Index(g, suffix)]
+			if strings.ContainsAny(expr, ":|' ") {
+				t.Errorf("expression contains a separator or quote: %s", expr)
+			}
+			if strings.Count(expr, "(") != strings.Count(expr, ")") {
+				t.Errorf("unbalanced parentheses: %s", expr)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(expr, w) {
+					t.Errorf("expression missing %q: %s", w, expr)
+				}
+			}
+		})
+	}
+}
+
+func TestTestSignalValidate(t *testing.T) {
+	valid := []TestSignal{{}, {Kind: TestSignalSweep, Ident: "dtmf"}, {Kind: TestSignalBeep, LevelDB: -3}}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("%+v: unexpected error: %v", s, err)
+		}
+	}
+	invalid := map[string]TestSignal{
+		"kind":         {Kind: "noise"},
+		"ident":        {Ident: "voice"},
+		"frequency":    {Frequency: -1},
+		"level":        {LevelDB: 3},
+		"period":       {Period: -time.Second},
+		"short period": {Ident: "dtmf", Period: time.Second},
+	}
+	for name, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("%s: expected an error for %+v", name, s)
+		}
+	}
+}
+
+func TestBuildFFmpegCommandTestSignal(t *testing.T) {
+	cfg := &ManagerConfig{
+		DeviceName: "lyrebird_test",
+		StreamName: "lyrebird_test",
+		SampleRate: 48000,
+		Channels:   2,
+		Bitrate:    "128k",
+		Codec:      "opus",
+		RTSPURL:    "rtsp://localhost:8554/lyrebird_test",
+		TestSignal: &TestSignal{Ident: "dtmf"},
+	}
+	args := buildFFmpegCommand(context.Background(), cfg).Args[1:]
+	i := slices.Index(args, "-i")
+	if i == -1 || !slices.Equal(args[:i], []string{"-f", "lavfi", "-re"}) {
+		t.Fatalf("expected -f lavfi -re before -i, got: %v", args)
+	}
+	if !strings.HasPrefix(args[i+1], "aevalsrc=") {
+		t.Errorf("expected an aevalsrc graph as input, got: %s", args[i+1])
+	}
+	if !slices.Contains(args, "-ac") {
+		t.Errorf("expected -ac to upmix the mono signal, got: %v", args)
+	}
+
+	// -re is passed once even when RealtimeInput is also set.
+	cfg.RealtimeInput = true
+	args = buildFFmpegCommand(context.Background(), cfg).Args
+	n := 0
+	for _, a := range args {
+		if a == "-re" {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("expected one -re, got %d: %v", n, args)
+	}
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Clock sync states recorded in the capture metadata.
+const (
+	ClockSynchronized   = "synchronized"   // NTP-synchronized when the capture started
+	ClockUnsynchronized = "unsynchronized" // Not synchronized: timestamps may be off by any amount
+	ClockUnknown        = "unknown"        // The sync state could not be read (no timedatectl)
+	ClockUnchecked      = "unchecked"      // Not checked (time source "system")
+)
+
+// clockProbeTimeout bounds the timedatectl call made before each start.
+const clockProbeTimeout = 3 * time.Second
+
+// clockSyncProbe reports whether the system clock is NTP-synchronized; a
+// variable so tests can stub it.
+var clockSyncProbe = timedatectlSynced
+
+// timedatectlSynced asks systemd-timesyncd/chrony (through timedatectl)
+// whether the kernel clock is synchronized.
+func timedatectlSynced(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, clockProbeTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "timedatectl", "show", "--property=NTPSynchronized", "--value").Output() // #nosec G204 -- fixed args
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == "yes", nil
+}
+
+// Timecode makes the manager embed capture metadata at every FFmpeg start:
+// the UTC start time, the clock sync state, and host and device IDs. It is
+// written as container metadata into every recording segment (Ogg/FLAC
+// comments, WAV INFO and bext) and as the session name (s=) of the SDP
+// announced to the RTSP server.
+type Timecode struct {
+	HostID    string // Station identifier
+	DeviceID  string // Device identifier, e.g. the USB ID (default: DeviceName)
+	CheckSync bool   // Check NTP sync before each start and warn when unsynchronized; false records "unchecked"
+}
+
+// capture describes one FFmpeg start.
+type capture struct {
+	start time.Time // UTC
+	clock string    // Clock* state
+	seq   int       // First segment number, for a sequenced segment naming
+}
+
+// newCapture returns the capture for an FFmpeg start now. With
+// Timecode.CheckSync it first probes the clock sync state and warns when a
+// capture starts on an unsynchronized clock, since its timestamps (segment
+// names and metadata alike) cannot then be trusted.
+func (m *Manager) newCapture(ctx context.Context) capture {
+	clock := ClockUnchecked
+	if tc := m.cfg.Timecode; tc != nil && tc.CheckSync {
+		synced, err := clockSyncProbe(ctx)
+		switch {
+		case err != nil:
+			clock = ClockUnknown
+		case synced:
+			clock = ClockSynchronized
+		default:
+			clock = ClockUnsynchronized
+			if m.cfg.Logger != nil {
+				m.cfg.Logger.Warn("capture starting before the system clock is synchronized; its timestamps may be wrong",
+					"stream", m.cfg.StreamName)
+			}
+		}
+	}
+	return capture{start: time.Now().UTC(), clock: clock}
+}
+
+// summary is the one-line description of the capture used for the title,
+// comment and bext description.
+func (c capture) summary(cfg *ManagerConfig) string {
+	return fmt.Sprintf("capture_start=%s clock=%s host=%s device=%s",
+		c.start.Format(captureTimeFormat), c.clock, cfg.Timecode.HostID, c.deviceID(cfg))
+}
+
+func (c capture) deviceID(cfg *ManagerConfig) string {
+	if cfg.Timecode.DeviceID != "" {
+		return cfg.Timecode.DeviceID
+	}
+	return cfg.DeviceName
+}
+
+// captureTimeFormat is ISO 8601 UTC with milliseconds.
+const captureTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// metadataArgs returns the -metadata output options for the capture, or nil
+// without Timecode. title becomes the SDP session name; the LYREBIRD_* keys
+// are kept as-is by Ogg, FLAC and Matroska, while WAV keeps title and
+// comment (INFO) and, when wav is true, the bext fields.
+func (c capture) metadataArgs(cfg *ManagerConfig, wav bool) []string {
+	if cfg.Timecode == nil {
+		return nil
+	}
+	summary := c.summary(cfg)
+	tags := []string{
+		"title=lyrebird " + cfg.StreamName + " " + summary,
+		"comment=" + summary,
+		"creation_time=" + c.start.Format(captureTimeFormat),
+		"LYREBIRD_CAPTURE_START=" + c.start.Format(captureTimeFormat),
+		"LYREBIRD_CLOCK=" + c.clock,
+		"LYREBIRD_HOST=" + cfg.Timecode.HostID,
+		"LYREBIRD_DEVICE=" + c.deviceID(cfg),
+	}
+	if wav {
+		// Broadcast WAV fields, truncated to their fixed sizes. These
+		// describe the capture run: every segment of one FFmpeg run carries
+		// the run's start, and the segment's own start is in its name.
+		tags = append(tags,
+			"description="+truncate(summary, 256),
+			"originator=LyreBirdAudio",
+			"originator_reference="+truncate(cfg.Timecode.HostID, 32),
+			"origination_date="+c.start.Format("2006-01-02"),
+			"origination_time="+c.start.Format("15:04:05"),
+		)
+	}
+	args := make([]string, 0, 2*len(tags))
+	for _, tag := range tags {
+		args = append(args, "-metadata", tag)
+	}
+	return args
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
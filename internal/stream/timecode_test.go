@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func timecodeTestConfig() *ManagerConfig {
+	return &ManagerConfig{
+		DeviceName: "blue_yeti",
+		ALSADevice: "hw:1,0",
+		StreamName: "blue_yeti",
+		SampleRate: 48000,
+		Channels:   2,
+		Bitrate:    "128k",
+		Codec:      "opus",
+		RTSPURL:    "rtsp://localhost:8554/blue_yeti",
+		Timecode:   &Timecode{HostID: "station-3", DeviceID: "usb:0d8c:0014/A1@1-1.4", CheckSync: true},
+	}
+}
+
+// metadata returns the -metadata values in args as key -> value.
+func metadata(args []string) map[string]string {
+	tags := make(map[string]string)
+	for i, a := range args {
+		if a == "-metadata" && i+1 < len(args) {
+			k, v, _ := strings.Cut(args[i+1], "=")
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+func TestCaptureMetadataArgs(t *testing.T) {
+	c := capture{start: time.Date(2026, 3, 1, 14, 5, 9, 250e6, time.UTC), clock: ClockSynchronized}
+	summary := "capture_start=2026-03-01T14:05:09.250Z clock=synchronized host=station-3 device=usb:0d8c:0014/A1@1-1.4"
+
+	cfg := timecodeTestConfig()
+	cfg.LocalRecordDir = "/var/audio"
+	cfg.SegmentFormat = "ogg"
+	args := buildCaptureCommand(context.Background(), cfg, c).Args
+	tags := metadata(args)
+	want := map[string]string{
+		"title":                  "lyrebird blue_yeti " + summary,
+		"comment":                summary,
+		"creation_time":          "2026-03-01T14:05:09.250Z",
+		"LYREBIRD_CAPTURE_START": "2026-03-01T14:05:09.250Z",
+		"LYREBIRD_CLOCK":         "synchronized",
+		"LYREBIRD_HOST":          "station-3",
+		"LYREBIRD_DEVICE":        "usb:0d8c:0014/A1@1-1.4",
+	}
+	for k, v := range want {
+		if tags[k] != v {
+			t.Errorf("metadata %s = %q, want %q", k, tags[k], v)
+		}
+	}
+	if _, ok := tags["origination_date"]; ok {
+		t.Error("bext fields should only be written for wav segments")
+	}
+	// The metadata is an output option: it must come before the tee output.
+	if m, tee := slices.Index(args, "-metadata"), slices.Index(args, "tee"); m == -1 || m > tee {
+		t.Errorf("expected -metadata before the tee output, got: %v", args)
+	}
+
+	// Without a device ID the stream's device name is used.
+	cfg.Timecode.DeviceID = ""
+	if got := metadata(buildCaptureCommand(context.Background(), cfg, c).Args)["LYREBIRD_DEVICE"]; got != "blue_yeti" {
+		t.Errorf("LYREBIRD_DEVICE = %q, want blue_yeti", got)
+	}
+
+	cfg.Timecode = nil
+	if args := buildCaptureCommand(context.Background(), cfg, c).Args; slices.Contains(args, "-metadata") {
+		t.Errorf("expected no metadata without Timecode, got: %v", args)
+	}
+}
+
+// TestCaptureMetadataWAV verifies the bext chunk is requested from the WAV
+// muxer inside the segment muxer, for both the tee and record-only outputs.
+func TestCaptureMetadataWAV(t *testing.T) {
+	c := capture{start: time.Date(2026, 3, 1, 14, 5, 9, 0, time.UTC), clock: ClockUnsynchronized}
+
+	cfg := timecodeTestConfig()
+	cfg.Codec = "pcm"
+	cfg.LocalRecordDir = "/var/audio"
+	cfg.SegmentFormat = "wav"
+	args := buildCaptureCommand(context.Background(), cfg, c).Args
+	tags := metadata(args)
+	for k, v := range map[string]string{
+		"originator":           "LyreBirdAudio",
+		"originator_reference": "station-3",
+		"origination_date":     "2026-03-01",
+		"origination_time":     "14:05:09",
+	} {
+		if tags[k] != v {
+			t.Errorf("metadata %s = %q, want %q", k, tags[k], v)
+		}
+	}
+	if !strings.HasPrefix(tags["description"], "capture_start=2026-03-01T14:05:09.000Z clock=unsynchronized") {
+		t.Errorf("description = %q", tags["description"])
+	}
+	if tee := args[len(args)-1]; !strings.Contains(tee, ":segment_format_options=write_bext=1]") {
+		t.Errorf("expected write_bext in the segment slave options, got: %s", tee)
+	}
+
+	cfg.RecordOnly = true
+	args = buildCaptureCommand(context.Background(), cfg, c).Args
+	if i := slices.Index(args, "-segment_format_options"); i == -1 || args[i+1] != "write_bext=1" {
+		t.Errorf("expected -segment_format_options write_bext=1, got: %v", args)
+	}
+}
+
+func TestNewCaptureClockState(t *testing.T) {
+	orig := clockSyncProbe
+	t.Cleanup(func() { clockSyncProbe = orig })
+
+	tests := []struct {
+		name      string
+		check     bool
+		synced    bool
+		err       error
+		wantClock string
+		wantWarn  bool
+	}{
+		{"synchronized", true, true, nil, ClockSynchronized, false},
+		{"unsynchronized", true, false, nil, ClockUnsynchronized, true},
+		{"probe failed", true, false, errors.New("timedatectl: not found"), ClockUnknown, false},
+		{"system time source", false, false, nil, ClockUnchecked, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probed := false
+			clockSyncProbe = func(context.Context) (bool, error) {
+				probed = true
+				return tt.synced, tt.err
+			}
+			var logBuf bytes.Buffer
+			cfg := timecodeTestConfig()
+			cfg.Timecode.CheckSync = tt.check
+			cfg.Logger = slog.New(slog.NewTextHandler(&logBuf, nil))
+			m := &Manager{cfg: cfg}
+
+			before := time.Now()
+			c := m.newCapture(context.Background())
+			if c.clock != tt.wantClock {
+				t.Errorf("clock = %q, want %q", c.clock, tt.wantClock)
+			}
+			if probed != tt.check {
+				t.Errorf("probed = %v, want %v", probed, tt.check)
+			}
+			if c.start.Before(before.Truncate(time.Millisecond)) || c.start.Location() != time.UTC {
+				t.Errorf("start = %v, want a UTC time after %v", c.start, before)
+			}
+			if warned := strings.Contains(logBuf.String(), "before the system clock is synchronized"); warned != tt.wantWarn {
+				t.Errorf("warned = %v, want %v; log: %s", warned, tt.wantWarn, logBuf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Upstream reports the readiness of the RTSP server a stream publishes to.
+//
+// When ManagerConfig.Upstream is set, the manager waits for the upstream
+// before every FFmpeg start and does not count a failure against
+// MaxRestartAttempts while the upstream is down: a dead MediaMTX is not the
+// stream's fault, and burning attempts against it would leave every stream
+// permanently failed after an outage that outlasts the backoff budget.
+//
+// supervisor.MediaMTXService implements Upstream for the managed-MediaMTX
+// mode; UpstreamMonitor implements it for an externally run server.
+type Upstream interface {
+	// Ready reports whether the upstream can currently accept a publisher.
+	Ready() bool
+
+	// WaitReady blocks until the upstream is ready or ctx is done.
+	WaitReady(ctx context.Context) error
+}
+
+// upstreamDown reports whether a configured upstream is currently not ready.
+// A nil upstream is always considered up.
+func (m *Manager) upstreamDown() bool {
+	// A record-only stream never talks to the RTSP server.
+	return m.cfg.Upstream != nil && !m.cfg.RecordOnly && !m.cfg.Upstream.Ready()
+}
+
+// waitUpstream blocks until the configured upstream is ready. It returns
+// immediately when no upstream is configured or it is already ready, and
+// returns ctx.Err() if ctx is cancelled while waiting.
+func (m *Manager) waitUpstream(ctx context.Context) error {
+	if !m.upstreamDown() {
+		return nil
+	}
+
+	m.setState(StateFailed)
+	m.logStructuredEvent("upstream_wait")
+	m.logf("Upstream not ready, holding FFmpeg start")
+	if err := m.cfg.Upstream.WaitReady(ctx); err != nil {
+		return err
+	}
+	if err := m.resumeJitter(ctx); err != nil {
+		return err
+	}
+	m.logStructuredEvent("upstream_resume")
+	m.logf("Upstream ready, starting FFmpeg")
+	return nil
+}
+
+// upstreamRechecker is implemented by upstreams that can re-probe on demand.
+// The manager rechecks after every FFmpeg failure: a publisher usually dies
+// within milliseconds of the server, long before a periodic probe notices,
+// and that failure must not be counted against the stream.
+type upstreamRechecker interface {
+	Recheck(ctx context.Context) bool
+}
+
+// upstreamDownAfterFailure reports whether the upstream is down right after
+// an FFmpeg failure, forcing a fresh probe when the upstream supports it.
+func (m *Manager) upstreamDownAfterFailure(ctx context.Context) bool {
+	if m.cfg.Upstream == nil || m.cfg.RecordOnly {
+		return false
+	}
+	if r, ok := m.cfg.Upstream.(upstreamRechecker); ok {
+		return !r.Recheck(ctx)
+	}
+	return !m.cfg.Upstream.Ready()
+}
+
+// resumeJitter sleeps for a random fraction of UpstreamResumeJitter after an
+// upstream outage so that every stream held on the same upstream does not
+// reconnect in the same instant. Returns ctx.Err() if cancelled.
+func (m *Manager) resumeJitter(ctx context.Context) error {
+	if m.cfg.UpstreamResumeJitter <= 0 {
+		return nil
+	}
+	// #nosec G404 -- decorrelation only, see jitteredDelay
+	d := time.Duration(rand.Int64N(int64(m.cfg.UpstreamResumeJitter)))
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// UpstreamMonitorConfig configures an UpstreamMonitor.
+type UpstreamMonitorConfig struct {
+	// Name is the service name when the monitor runs under a supervisor.
+	// Default: "upstream-monitor".
+	Name string
+
+	// Probe returns nil when the upstream can accept publishers. Required.
+	Probe func(ctx context.Context) error
+
+	// Interval is the probe period. It also bounds each probe. Default: 5s.
+	Interval time.Duration
+
+	// Logger is optional; nil disables logging.
+	Logger *slog.Logger
+}
+
+// UpstreamMonitor is an Upstream shared by every stream manager that
+// publishes to the same server. It probes the server periodically (and on
+// demand after a stream failure, see Recheck) and wakes all waiting managers
+// at once when the server comes back.
+//
+// The monitor starts optimistic (ready) so streams are not held before the
+// first probe; a stream that fails against a dead server triggers a recheck
+// that flips it to not-ready without consuming a restart attempt.
+//
+// It has Name and Run methods so it can be registered as a supervised
+// service.
+type UpstreamMonitor struct {
+	cfg UpstreamMonitorConfig
+
+	mu      sync.Mutex
+	ready   bool
+	readyCh chan struct{} // closed while ready; replaced when readiness is lost
+}
+
+// NewUpstreamMonitor validates cfg, applies defaults and returns a monitor in
+// the ready state.
+func NewUpstreamMonitor(cfg UpstreamMonitorConfig) (*UpstreamMonitor, error) {
+	if cfg.Probe == nil {
+		return nil, errors.New("upstream probe cannot be nil")
+	}
+	if cfg.Name == "" {
+		cfg.Name = "upstream-monitor"
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	readyCh := make(chan struct{})
+	close(readyCh)
+	return &UpstreamMonitor{cfg: cfg, ready: true, readyCh: readyCh}, nil
+}
+
+// Name returns the service name.
+func (u *UpstreamMonitor) Name() string {
+	return u.cfg.Name
+}
+
+// Ready implements Upstream.
+func (u *UpstreamMonitor) Ready() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.ready
+}
+
+// WaitReady implements Upstream.
+func (u *UpstreamMonitor) WaitReady(ctx context.Context) error {
+	u.mu.Lock()
+	ch := u.readyCh
+	u.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Recheck probes the upstream immediately, updates the shared state and
+// returns the new readiness.
+func (u *UpstreamMonitor) Recheck(ctx context.Context) bool {
+	probeCtx, cancel := context.WithTimeout(ctx, u.cfg.Interval)
+	defer cancel()
+	err := u.cfg.Probe(probeCtx)
+	if err != nil && ctx.Err() != nil {
+		// Our own cancellation says nothing about the upstream.
+		return u.Ready()
+	}
+	u.set(err)
+	return err == nil
+}
+
+// Run probes the upstream every Interval until ctx is cancelled.
+func (u *UpstreamMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(u.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		u.Recheck(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// set records a probe result, logging and signalling only on transitions.
+func (u *UpstreamMonitor) set(probeErr error) {
+	ready := probeErr == nil
+
+	u.mu.Lock()
+	changed := ready != u.ready
+	if changed {
+		u.ready = ready
+		if ready {
+			close(u.readyCh)
+		} else {
+			u.readyCh = make(chan struct{})
+		}
+	}
+	u.mu.Unlock()
+
+	if !changed || u.cfg.Logger == nil {
+		return
+	}
+	if ready {
+		u.cfg.Logger.Info("upstream is back, resuming held streams", "event", "upstream_up")
+	} else {
+		u.cfg.Logger.Warn("upstream is down, holding stream restarts", "event", "upstream_down", "error", probeErr)
+	}
+}
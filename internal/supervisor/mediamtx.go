@@ -0,0 +1,284 @@
+// SPDX-License-Identifier: MIT
+
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Pinger checks whether a server's control API is answering.
+// *mediamtx.Client satisfies it.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// MediaMTXConfig configures a MediaMTXService.
+type MediaMTXConfig struct {
+	// Name is the service name registered with the supervisor.
+	// Default: "mediamtx".
+	Name string
+
+	// BinaryPath is the mediamtx executable. Required.
+	BinaryPath string
+
+	// ConfigPath is the mediamtx.yml passed as the sole argument. Required.
+	ConfigPath string
+
+	// Pinger health-checks the running server. Required.
+	Pinger Pinger
+
+	// ReadyTimeout bounds how long a freshly started server may take to answer
+	// its first ping before it is killed and the start counted as a failure.
+	// Default: 30 seconds.
+	ReadyTimeout time.Duration
+
+	// HealthInterval is the ping period once the server is ready.
+	// Default: 5 seconds.
+	HealthInterval time.Duration
+
+	// MaxPingFailures is the number of consecutive failed pings, after the
+	// server was ready, that makes the service kill and restart it. This
+	// catches a wedged server whose process is still alive. Default: 3.
+	MaxPingFailures int
+
+	// StopTimeout is how long the server gets to exit after SIGTERM before it
+	// is killed. Default: 5 seconds.
+	StopTimeout time.Duration
+
+	// Output receives the server's stdout and stderr. Nil discards them.
+	Output io.Writer
+
+	// Logger is optional; nil disables logging.
+	Logger *slog.Logger
+}
+
+// MediaMTXService runs the mediamtx binary as a supervised Service and tracks
+// whether it is ready to accept publishers.
+//
+// Readiness is driven by the server's own API rather than by process
+// liveness: the service is ready only after a Ping succeeds, and becomes
+// not-ready the moment the process exits or MaxPingFailures consecutive pings
+// fail. Run returns an error in both cases so the supervisor restarts it.
+//
+// Stream managers consult Ready/WaitReady (see stream.Upstream) to hold their
+// restarts while the server is down, instead of burning restart attempts
+// publishing to a dead RTSP port.
+type MediaMTXService struct {
+	cfg MediaMTXConfig
+
+	mu      sync.Mutex
+	ready   bool
+	readyCh chan struct{} // closed while ready; replaced when readiness is lost
+}
+
+// mediamtxStartupPollInterval is the ping period while waiting for a
+// (re)started server to come up. Polling faster than HealthInterval keeps
+// held streams from waiting a full health period after the server is
+// actually listening.
+const mediamtxStartupPollInterval = 250 * time.Millisecond
+
+// NewMediaMTXService validates cfg, applies defaults and returns a service
+// ready to be registered with Supervisor.Add.
+func NewMediaMTXService(cfg MediaMTXConfig) (*MediaMTXService, error) {
+	if cfg.BinaryPath == "" {
+		return nil, errors.New("mediamtx binary path cannot be empty")
+	}
+	if cfg.ConfigPath == "" {
+		return nil, errors.New("mediamtx config path cannot be empty")
+	}
+	if cfg.Pinger == nil {
+		return nil, errors.New("mediamtx pinger cannot be nil")
+	}
+	if cfg.Name == "" {
+		cfg.Name = "mediamtx"
+	}
+	if cfg.ReadyTimeout <= 0 {
+		cfg.ReadyTimeout = 30 * time.Second
+	}
+	if cfg.HealthInterval <= 0 {
+		cfg.HealthInterval = 5 * time.Second
+	}
+	if cfg.MaxPingFailures <= 0 {
+		cfg.MaxPingFailures = 3
+	}
+	if cfg.StopTimeout <= 0 {
+		cfg.StopTimeout = 5 * time.Second
+	}
+	if cfg.Output == nil {
+		cfg.Output = io.Discard
+	}
+
+	return &MediaMTXService{
+		cfg:     cfg,
+		readyCh: make(chan struct{}),
+	}, nil
+}
+
+// Name implements Service.
+func (s *MediaMTXService) Name() string {
+	return s.cfg.Name
+}
+
+// Ready reports whether the server is running and answering its API.
+func (s *MediaMTXService) Ready() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ready
+}
+
+// WaitReady blocks until the server is ready or ctx is done.
+func (s *MediaMTXService) WaitReady(ctx context.Context) error {
+	s.mu.Lock()
+	ch := s.readyCh
+	s.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// setReady flips readiness, waking WaitReady callers on the false→true edge
+// and arming a fresh channel on the true→false edge.
+func (s *MediaMTXService) setReady(ready bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ready == s.ready {
+		return
+	}
+	s.ready = ready
+	if ready {
+		close(s.readyCh)
+	} else {
+		s.readyCh = make(chan struct{})
+	}
+}
+
+// Run implements Service. It starts the server, waits for it to answer its
+// API, then health-checks it until ctx is cancelled (clean stop, returns
+// ctx.Err()) or the server exits or stops answering (returns an error so the
+// supervisor restarts it).
+func (s *MediaMTXService) Run(ctx context.Context) error {
+	s.setReady(false)
+	defer s.setReady(false)
+
+	// A server already answering on our API address before we start means a
+	// second MediaMTX (typically the standalone mediamtx.service) owns the
+	// ports. Our child would fail to bind, and readiness would be reported
+	// from the wrong process, so make the conflict loud.
+	if s.ping(ctx) == nil {
+		s.logWarn("another MediaMTX is already answering the API before the managed server started; " +
+			"disable the standalone mediamtx service when mediamtx.managed is enabled")
+	}
+
+	// Intentionally exec.Command, not CommandContext: shutdown is owned by
+	// stop(), which lets the server exit gracefully before escalating.
+	// #nosec G204 -- BinaryPath and ConfigPath come from administrator configuration
+	cmd := exec.Command(s.cfg.BinaryPath, s.cfg.ConfigPath)
+	cmd.Stdout = s.cfg.Output
+	cmd.Stderr = s.cfg.Output
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start mediamtx: %w", err)
+	}
+	s.logInfo("mediamtx started", "pid", cmd.Process.Pid, "config", s.cfg.ConfigPath)
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	startDeadline := time.NewTimer(s.cfg.ReadyTimeout)
+	defer startDeadline.Stop()
+	ticker := time.NewTicker(mediamtxStartupPollInterval)
+	defer ticker.Stop()
+
+	ready := false
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			s.setReady(false)
+			s.stop(cmd, exited)
+			return ctx.Err()
+
+		case err := <-exited:
+			s.setReady(false)
+			if err == nil {
+				return errors.New("mediamtx exited unexpectedly")
+			}
+			return fmt.Errorf("mediamtx exited: %w", err)
+
+		case <-startDeadline.C:
+			if ready {
+				continue
+			}
+			s.stop(cmd, exited)
+			return fmt.Errorf("mediamtx did not answer its API within %v", s.cfg.ReadyTimeout)
+
+		case <-ticker.C:
+			if err := s.ping(ctx); err != nil {
+				if !ready {
+					continue
+				}
+				failures++
+				s.logWarn("mediamtx health check failed", "failures", failures, "error", err)
+				if failures >= s.cfg.MaxPingFailures {
+					s.setReady(false)
+					s.stop(cmd, exited)
+					return fmt.Errorf("mediamtx stopped answering its API after %d consecutive checks: %w", failures, err)
+				}
+				continue
+			}
+			failures = 0
+			if !ready {
+				ready = true
+				s.setReady(true)
+				ticker.Reset(s.cfg.HealthInterval)
+				s.logInfo("mediamtx ready")
+			}
+		}
+	}
+}
+
+// ping runs one health check bounded by HealthInterval so a wedged API
+// cannot hold the loop past its next tick.
+func (s *MediaMTXService) ping(ctx context.Context) error {
+	pingCtx, cancel := context.WithTimeout(ctx, s.cfg.HealthInterval)
+	defer cancel()
+	return s.cfg.Pinger.Ping(pingCtx)
+}
+
+// stop sends SIGTERM, waits up to StopTimeout for the process to exit, then
+// kills it. exited must be the channel fed by cmd.Wait.
+func (s *MediaMTXService) stop(cmd *exec.Cmd, exited <-chan error) {
+	_ = cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-exited:
+	case <-time.After(s.cfg.StopTimeout):
+		s.logWarn("mediamtx did not exit in time, killing", "timeout", s.cfg.StopTimeout)
+		_ = cmd.Process.Kill()
+		<-exited
+	}
+}
+
+func (s *MediaMTXService) logInfo(msg string, args ...any) {
+	if s.cfg.Logger != nil {
+		s.cfg.Logger.Info(msg, append([]any{"service", s.cfg.Name}, args...)...)
+	}
+}
+
+func (s *MediaMTXService) logWarn(msg string, args ...any) {
+	if s.cfg.Logger != nil {
+		s.cfg.Logger.Warn(msg, append([]any{"service", s.cfg.Name}, args...)...)
+	}
+}
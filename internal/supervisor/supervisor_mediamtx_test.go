@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakePinger fails until up is set.
+type fakePinger struct {
+	up    atomic.Bool
+	calls atomic.Int32
+}
+
+func (p *fakePinger) Ping(context.Context) error {
+	p.calls.Add(1)
+	if p.up.Load() {
+		return nil
+	}
+	return errors.New("connection refused")
+}
+
+// writeFakeMediaMTX writes a shell script standing in for the mediamtx binary.
+func writeFakeMediaMTX(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mediamtx")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatalf("write fake mediamtx: %v", err)
+	}
+	return path
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestNewMediaMTXService_Validation(t *testing.T) {
+	p := &fakePinger{}
+	tests := []struct {
+		name string
+		cfg  MediaMTXConfig
+		want string
+	}{
+		{"no binary", MediaMTXConfig{ConfigPath: "x", Pinger: p}, "binary path"},
+		{"no config", MediaMTXConfig{BinaryPath: "x", Pinger: p}, "config path"},
+		{"no pinger", MediaMTXConfig{BinaryPath: "x", ConfigPath: "x"}, "pinger"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMediaMTXService(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want mention of %q", err, tt.want)
+			}
+		})
+	}
+
+	svc, err := NewMediaMTXService(MediaMTXConfig{BinaryPath: "x", ConfigPath: "y", Pinger: p})
+	if err != nil {
+		t.Fatalf("NewMediaMTXService() error = %v", err)
+	}
+	if svc.Name() != "mediamtx" {
+		t.Errorf("Name() = %q, want mediamtx", svc.Name())
+	}
+	if svc.cfg.ReadyTimeout != 30*time.Second || svc.cfg.HealthInterval != 5*time.Second ||
+		svc.cfg.MaxPingFailures != 3 || svc.cfg.StopTimeout != 5*time.Second {
+		t.Errorf("defaults not applied: %+v", svc.cfg)
+	}
+	if svc.Ready() {
+		t.Error("new service must not be ready")
+	}
+}
+
+func TestMediaMTXService_ReadyThenCleanStop(t *testing.T) {
+	p := &fakePinger{}
+	svc, err := NewMediaMTXService(MediaMTXConfig{
+		BinaryPath:  writeFakeMediaMTX(t, "exec sleep 60"),
+		ConfigPath:  "/dev/null",
+		Pinger:      p,
+		StopTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewMediaMTXService() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- svc.Run(ctx) }()
+
+	// Not ready while the API does not answer.
+	waitFor(t, "startup pings", func() bool { return p.calls.Load() >= 2 })
+	if svc.Ready() {
+		t.Fatal("Ready() = true before the API answered")
+	}
+
+	p.up.Store(true)
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer waitCancel()
+	if err := svc.WaitReady(waitCtx); err != nil {
+		t.Fatalf("WaitReady() error = %v", err)
+	}
+	if !svc.Ready() {
+		t.Error("Ready() = false after WaitReady returned")
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run() = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	if svc.Ready() {
+		t.Error("Ready() = true after Run returned")
+	}
+}
+
+func TestMediaMTXService_ProcessExitIsError(t *testing.T) {
+	p := &fakePinger{}
+	p.up.Store(true)
+	svc, err := NewMediaMTXService(MediaMTXConfig{
+		BinaryPath: writeFakeMediaMTX(t, "sleep 0.5; exit 3"),
+		ConfigPath: "/dev/null",
+		Pinger:     p,
+	})
+	if err != nil {
+		t.Fatalf("NewMediaMTXService() error = %v", err)
+	}
+
+	err = svc.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "mediamtx exited") {
+		t.Errorf("Run() = %v, want mediamtx exited error", err)
+	}
+	if svc.Ready() {
+		t.Error("Ready() = true after the process exited")
+	}
+}
+
+func TestMediaMTXService_ReadyTimeout(t *testing.T) {
+	svc, err := NewMediaMTXService(MediaMTXConfig{
+		BinaryPath:   writeFakeMediaMTX(t, "exec sleep 60"),
+		ConfigPath:   "/dev/null",
+		Pinger:       &fakePinger{},
+		ReadyTimeout: 300 * time.Millisecond,
+		StopTimeout:  time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewMediaMTXService() error = %v", err)
+	}
+
+	err = svc.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "did not answer its API") {
+		t.Errorf("Run() = %v, want ready timeout error", err)
+	}
+}
+
+// TestMediaMTXService_WedgedServerRestarted verifies that a live process
+// whose API stops answering is killed after MaxPingFailures checks.
+func TestMediaMTXService_WedgedServerRestarted(t *testing.T) {
+	p := &fakePinger{}
+	p.up.Store(true)
+	svc, err := NewMediaMTXService(MediaMTXConfig{
+		BinaryPath:      writeFakeMediaMTX(t, "exec sleep 60"),
+		ConfigPath:      "/dev/null",
+		Pinger:          p,
+		HealthInterval:  20 * time.Millisecond,
+		MaxPingFailures: 2,
+		StopTimeout:     time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewMediaMTXService() error = %v", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- svc.Run(context.Background()) }()
+
+	waitFor(t, "ready", svc.Ready)
+	p.up.Store(false)
+
+	select {
+	case err := <-errCh:
+		if err == nil || !strings.Contains(err.Error(), "stopped answering") {
+			t.Errorf("Run() = %v, want stopped answering error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return for a wedged server")
+	}
+	if svc.Ready() {
+		t.Error("Ready() = true after health checks failed")
+	}
+}
+
+func TestMediaMTXService_WaitReadyCancelled(t *testing.T) {
+	svc, err := NewMediaMTXService(MediaMTXConfig{BinaryPath: "x", ConfigPath: "y", Pinger: &fakePinger{}})
+	if err != nil {
+		t.Fatalf("NewMediaMTXService() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := svc.WaitReady(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitReady() = %v, want DeadlineExceeded", err)
+	}
+}
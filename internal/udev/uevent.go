@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: MIT
+
+package udev
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Uevent is a kernel object event as delivered on the NETLINK_KOBJECT_UEVENT
+// socket.
+type Uevent struct {
+	Action    string            // "add", "remove", "change", "bind", ...
+	DevPath   string            // sysfs path below /sys, e.g. /devices/.../sound/card1
+	Subsystem string            // e.g. "sound", "usb"
+	Env       map[string]string // every KEY=value pair in the message
+}
+
+// UeventSource delivers uevents one at a time. Receive blocks until an event
+// arrives and returns an error once the source is closed. Close unblocks a
+// pending Receive.
+//
+// The netlink socket (OpenUeventSource) is the production implementation;
+// tests feed events through a fake.
+type UeventSource interface {
+	Receive() (Uevent, error)
+	Close() error
+}
+
+// ErrUeventOverflow reports that the kernel dropped uevents because the
+// socket's receive buffer was full. The source stays usable, but the caller
+// has missed events and must rescan to resynchronise.
+var ErrUeventOverflow = errors.New("uevent receive buffer overflow, events were lost")
+
+// ParseUevent decodes one kernel uevent message.
+//
+// The kernel format is a "ACTION@DEVPATH" header followed by NUL-separated
+// KEY=value pairs. Messages re-broadcast by udevd start with "libudev" and
+// carry a binary header; they are rejected because only kernel events are
+// consumed.
+func ParseUevent(msg []byte) (Uevent, error) {
+	fields := bytes.Split(msg, []byte{0})
+	if len(fields) == 0 || len(fields[0]) == 0 {
+		return Uevent{}, errors.New("empty uevent")
+	}
+	header := string(fields[0])
+	if strings.HasPrefix(header, "libudev") {
+		return Uevent{}, errors.New("udevd-format uevent not supported")
+	}
+	at := strings.IndexByte(header, '@')
+	if at <= 0 {
+		return Uevent{}, fmt.Errorf("malformed uevent header %q", header)
+	}
+
+	ev := Uevent{
+		Action:  header[:at],
+		DevPath: header[at+1:],
+		Env:     make(map[string]string, len(fields)-1),
+	}
+	for _, f := range fields[1:] {
+		k, v, ok := strings.Cut(string(f), "=")
+		if !ok || k == "" {
+			continue
+		}
+		ev.Env[k] = v
+	}
+	// The env copies are authoritative when present.
+	if a := ev.Env["ACTION"]; a != "" {
+		ev.Action = a
+	}
+	if p := ev.Env["DEVPATH"]; p != "" {
+		ev.DevPath = p
+	}
+	ev.Subsystem = ev.Env["SUBSYSTEM"]
+	return ev, nil
+}
+
+// SoundCard returns the ALSA card number if the event concerns a sound card
+// itself (DEVPATH ending in /sound/cardN), as opposed to one of its control,
+// PCM or MIDI nodes.
+func (e Uevent) SoundCard() (int, bool) {
+	if e.Subsystem != "sound" {
+		return 0, false
+	}
+	base := path.Base(e.DevPath)
+	if !strings.HasPrefix(base, "card") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(base, "card"))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+
+package udev
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// ueventGroupKernel is the multicast group carrying raw kernel uevents
+// (group 2 is udevd's re-broadcast, which needs libudev to decode).
+const ueventGroupKernel = 1
+
+// ueventReadBuffer is larger than the kernel's UEVENT_BUFFER_SIZE (2048) so
+// one read always holds a whole message.
+const ueventReadBuffer = 16 * 1024
+
+// ueventSocketBuffer enlarges the socket receive buffer so a hub with several
+// devices plugged at once does not overflow it.
+const ueventSocketBuffer = 1 << 20
+
+// netlinkSource is a UeventSource reading the kernel's NETLINK_KOBJECT_UEVENT
+// multicast group.
+type netlinkSource struct {
+	f   *os.File
+	buf []byte
+}
+
+// OpenUeventSource subscribes to kernel uevents over netlink. It needs no
+// libudev and no privileges: the kernel group is readable by any process.
+//
+// The socket is non-blocking and wrapped in an *os.File so reads park on the
+// runtime poller and Close reliably unblocks a pending Receive.
+func OpenUeventSource() (UeventSource, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK,
+		syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK,
+		syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uevent socket: %w", err)
+	}
+	// Best effort: the default buffer still works, just overflows sooner.
+	_ = syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_RCVBUF, ueventSocketBuffer)
+
+	addr := &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: ueventGroupKernel}
+	if err := syscall.Bind(fd, addr); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("failed to bind uevent socket: %w", err)
+	}
+	return &netlinkSource{
+		f:   os.NewFile(uintptr(fd), "netlink-uevent"),
+		buf: make([]byte, ueventReadBuffer),
+	}, nil
+}
+
+// Receive returns the next parseable kernel uevent. Malformed messages are
+// skipped. A kernel-side overflow is reported as ErrUeventOverflow.
+func (s *netlinkSource) Receive() (Uevent, error) {
+	for {
+		n, err := s.f.Read(s.buf)
+		if err != nil {
+			if errors.Is(err, syscall.ENOBUFS) {
+				return Uevent{}, ErrUeventOverflow
+			}
+			return Uevent{}, err
+		}
+		ev, err := ParseUevent(s.buf[:n])
+		if err != nil {
+			continue
+		}
+		return ev, nil
+	}
+}
+
+// Close closes the socket, unblocking any pending Receive.
+func (s *netlinkSource) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+
+package udev
+
+import (
+	"testing"
+	"time"
+)
+
+// TestOpenUeventSource_CloseUnblocksReceive verifies that Close wakes a
+// blocked Receive, which the daemon relies on for shutdown.
+func TestOpenUeventSource_CloseUnblocksReceive(t *testing.T) {
+	src, err := OpenUeventSource()
+	if err != nil {
+		t.Skipf("netlink uevent socket unavailable: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := src.Receive()
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if err := src.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Receive() after Close returned nil error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not unblock Receive")
+	}
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: MIT
+
+package udev
+
+import (
+	"strings"
+	"testing"
+)
+
+func ueventMsg(parts ...string) []byte {
+	return []byte(strings.Join(parts, "\x00") + "\x00")
+}
+
+func TestParseUevent(t *testing.T) {
+	msg := ueventMsg(
+		"add@/devices/pci0000:00/0000:00:14.0/usb1/1-1/1-1:1.0/sound/card1",
+		"ACTION=add",
+		"DEVPATH=/devices/pci0000:00/0000:00:14.0/usb1/1-1/1-1:1.0/sound/card1",
+		"SUBSYSTEM=sound",
+		"SEQNUM=4242",
+	)
+	ev, err := ParseUevent(msg)
+	if err != nil {
+		t.Fatalf("ParseUevent() error = %v", err)
+	}
+	if ev.Action != "add" || ev.Subsystem != "sound" || ev.Env["SEQNUM"] != "4242" {
+		t.Errorf("ParseUevent() = %+v", ev)
+	}
+	if !strings.HasSuffix(ev.DevPath, "/sound/card1") {
+		t.Errorf("DevPath = %q", ev.DevPath)
+	}
+	card, ok := ev.SoundCard()
+	if !ok || card != 1 {
+		t.Errorf("SoundCard() = %d, %v; want 1, true", card, ok)
+	}
+}
+
+func TestParseUevent_Errors(t *testing.T) {
+	for name, msg := range map[string][]byte{
+		"empty":     nil,
+		"no at":     ueventMsg("garbage", "ACTION=add"),
+		"libudev":   append([]byte("libudev\x00"), 0xfe, 0xed),
+		"empty hdr": ueventMsg("", "ACTION=add"),
+	} {
+		if _, err := ParseUevent(msg); err == nil {
+			t.Errorf("%s: ParseUevent() error = nil", name)
+		}
+	}
+}
+
+func TestUeventSoundCard(t *testing.T) {
+	tests := []struct {
+		ev   Uevent
+		card int
+		ok   bool
+	}{
+		{Uevent{Subsystem: "sound", DevPath: "/devices/x/sound/card12"}, 12, true},
+		{Uevent{Subsystem: "sound", DevPath: "/devices/x/sound/card1/controlC1"}, 0, false},
+		{Uevent{Subsystem: "sound", DevPath: "/devices/x/sound/card1/pcmC1D0c"}, 0, false},
+		{Uevent{Subsystem: "usb", DevPath: "/devices/x/card1"}, 0, false},
+		{Uevent{Subsystem: "sound", DevPath: "/devices/x/sound/cardX"}, 0, false},
+	}
+	for _, tt := range tests {
+		card, ok := tt.ev.SoundCard()
+		if card != tt.card || ok != tt.ok {
+			t.Errorf("SoundCard(%s) = %d, %v; want %d, %v", tt.ev.DevPath, card, ok, tt.card, tt.ok)
+		}
+	}
+}